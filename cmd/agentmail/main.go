@@ -6,8 +6,11 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"agentmail/internal/cli"
+	"agentmail/internal/config"
 	"agentmail/internal/mcp"
 
 	"github.com/peterbourgon/ff/v3/ffcli"
@@ -22,13 +25,31 @@ func main() {
 	var (
 		sendRecipient string
 		sendMessage   string
+		sendPriority  string
+		sendAt        string
+		sendIn        time.Duration
+		sendTTL       time.Duration
+		sendNotifyExp bool
+		sendReceipt   bool
+		sendKey       string
+		sendSubject   string
+		sendHeaders   stringList
 	)
 	// Long and short forms for recipient
-	sendFlagSet.StringVar(&sendRecipient, "recipient", "", "recipient tmux window name")
-	sendFlagSet.StringVar(&sendRecipient, "r", "", "recipient tmux window name (shorthand)")
+	sendFlagSet.StringVar(&sendRecipient, "recipient", "", "recipient tmux window name or @group")
+	sendFlagSet.StringVar(&sendRecipient, "r", "", "recipient tmux window name or @group (shorthand)")
 	// Long and short forms for message
 	sendFlagSet.StringVar(&sendMessage, "message", "", "message content")
 	sendFlagSet.StringVar(&sendMessage, "m", "", "message content (shorthand)")
+	sendFlagSet.StringVar(&sendPriority, "priority", "", "message priority: low, normal, high or urgent")
+	sendFlagSet.StringVar(&sendAt, "at", "", "deliver at this time (RFC3339, \"2006-01-02 15:04\" or \"15:04\")")
+	sendFlagSet.DurationVar(&sendIn, "in", 0, "deliver after this delay (e.g. 20m)")
+	sendFlagSet.DurationVar(&sendTTL, "ttl", 0, "discard the message if still unread this long after delivery (e.g. 10m)")
+	sendFlagSet.BoolVar(&sendNotifyExp, "notify-expired", false, "get a system message if the message expires unread")
+	sendFlagSet.BoolVar(&sendReceipt, "receipt", false, "get a system message when the message is read")
+	sendFlagSet.StringVar(&sendKey, "key", "", "idempotency key: a retry with the same key returns the original message ID")
+	sendFlagSet.StringVar(&sendSubject, "subject", "", "one-line message subject")
+	sendFlagSet.Var(&sendHeaders, "header", "message header as key=value (repeatable)")
 
 	sendCmd := &ffcli.Command{
 		Name:       "send",
@@ -41,8 +62,58 @@ or using flags. Flags take precedence over positional arguments.
 
 Message can also be piped via stdin.
 
+The recipient may be a window of the current session, a window of any
+session as session:window, a pane ID such as %12, or the mailbox of a
+renamed window (see "agentmail recipients --long").
+
+A pane of another session working in another git repository is only
+reached by its session:window or pane ID, never by a bare name. It gets
+the message in that repository's .agentmail store, where its mailman
+notifies it. The message is sent from your session:window, so replies
+come back to you.
+
+The recipient may be a group address instead of a window name:
+  @all     every window in the session except you and ignored windows
+  @<name>  a group defined in .agentmail/groups, one per line:
+           @reviewers: agent-2, agent-3
+Each member gets its own copy; all copies share one broadcast ID.
+
+Flags:
+  --subject   One-line subject shown before the body in receive output.
+  --header    A key=value header, e.g. task=123. Repeat for several
+              headers. Recipients can filter with "receive --header".
+  --priority  low, normal (default), high or urgent. Higher-priority
+              messages are received first. Urgent messages also wake
+              agents in "work" status.
+  --at        Deliver at a time: RFC3339, "2006-01-02 15:04", or "15:04"
+              (the next occurrence of that local time).
+  --in        Deliver after a delay, e.g. 20m or 2h.
+  --ttl       Expire the message if it is still unread this long after
+              delivery, e.g. 10m. Expired messages are never received;
+              they are moved to the dead-letter mailbox (reason "expired").
+  --notify-expired
+              With --ttl, send yourself a system message (from "agentmail")
+              when the message expires unread.
+  --receipt   Send yourself a system message (from "agentmail") when the
+              recipient first reads the message. See also "status-of".
+  --key       Idempotency key. Sending again with the same key within
+              idempotency_window (default 1h) prints the original message
+              ID instead of sending a duplicate, so a timed-out send can be
+              retried safely.
+Scheduled messages stay invisible to receive and the mailman until due.
+
 Examples:
   agentmail send agent2 "Hello"
+  agentmail send api:agent-1 "The schema changed"
+  agentmail send @all "Stop and rebase onto main"
+  agentmail send @reviewers "PR is ready"
+  agentmail send --priority urgent agent2 "Abort, main is broken"
+  agentmail send --subject "CI failure" --header task=123 agent2 "See log"
+  agentmail send --in 20m agent2 "Check CI"
+  agentmail send --at 14:00 @all "Start phase 2"
+  agentmail send --ttl 10m --notify-expired agent2 "Hold off on merging"
+  agentmail send --receipt agent2 "Please review PR #42"
+  agentmail send --key deploy-42 agent2 "Deploy finished"
   agentmail send -r agent2 -m "Hello"
   agentmail send --recipient agent2 --message "Hello"
   echo "Hello" | agentmail send agent2
@@ -71,7 +142,150 @@ Examples:
 				finalArgs = append(finalArgs, message)
 			}
 
-			exitCode := cli.Send(finalArgs, os.Stdin, os.Stdout, os.Stderr, cli.SendOptions{})
+			exitCode := cli.Send(finalArgs, os.Stdin, os.Stdout, os.Stderr, cli.SendOptions{
+				Priority:      sendPriority,
+				DeliverAt:     sendAt,
+				DeliverIn:     sendIn,
+				TTL:           sendTTL,
+				NotifyExpired: sendNotifyExp,
+				Receipt:       sendReceipt,
+				Key:           sendKey,
+				Subject:       sendSubject,
+				Headers:       sendHeaders,
+			})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Reply command flags
+	replyFlagSet := flag.NewFlagSet("agentmail reply", flag.ContinueOnError)
+	var replyMessage string
+	replyFlagSet.StringVar(&replyMessage, "message", "", "reply content")
+	replyFlagSet.StringVar(&replyMessage, "m", "", "reply content (shorthand)")
+
+	replyCmd := &ffcli.Command{
+		Name:       "reply",
+		ShortUsage: "agentmail reply [flags] <message-id> [<message>]",
+		ShortHelp:  "Reply to a message in your mailbox",
+		LongHelp: `Reply to a message that was delivered to your mailbox.
+
+The reply is sent back to the original sender and joins the same
+conversation thread, so "agentmail thread <id>" shows the whole exchange.
+
+Message can also be piped via stdin.
+
+Examples:
+  agentmail reply xK7mN2pQ "Done, tests pass"
+  agentmail reply -m "Done" xK7mN2pQ
+  echo "Done" | agentmail reply xK7mN2pQ`,
+		FlagSet: replyFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			// Message: flag takes precedence over second positional arg
+			finalArgs := args
+			if replyMessage != "" && len(args) > 0 {
+				finalArgs = []string{args[0], replyMessage}
+			}
+
+			exitCode := cli.Reply(finalArgs, os.Stdin, os.Stdout, os.Stderr, cli.SendOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Thread command (no flags)
+	threadFlagSet := flag.NewFlagSet("agentmail thread", flag.ContinueOnError)
+
+	threadCmd := &ffcli.Command{
+		Name:       "thread",
+		ShortUsage: "agentmail thread <message-id>",
+		ShortHelp:  "Show the conversation containing a message",
+		LongHelp: `Show every message in the conversation containing the given message.
+
+Messages are collected from all mailboxes and printed oldest first,
+so both sides of an exchange appear in order.
+
+Examples:
+  agentmail thread xK7mN2pQ`,
+		FlagSet: threadFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.Thread(args, os.Stdout, os.Stderr, cli.ThreadOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Status-of command (no flags)
+	statusOfFlagSet := flag.NewFlagSet("agentmail status-of", flag.ContinueOnError)
+
+	statusOfCmd := &ffcli.Command{
+		Name:       "status-of",
+		ShortUsage: "agentmail status-of <message-id>",
+		ShortHelp:  "Show the delivery status of a message",
+		LongHelp: `Show where a message is and what happened to it so far.
+
+The state is unread, read, leased, expired, scheduled, dead-lettered,
+or removed (deleted by cleanup). It is followed by the message's
+delivery events, oldest first:
+
+  queued    stored in the recipient's mailbox
+  notified  the mailman notified the recipient
+  read      the recipient received, read or leased it (CLI or MCP)
+  acked     the recipient acknowledged it
+
+Send with --receipt to also get a system message when it is read.
+
+Examples:
+  agentmail status-of xK7mN2pQ`,
+		FlagSet: statusOfFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.StatusOf(args, os.Stdout, os.Stderr, cli.StatusOfOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Ask command flags
+	askFlagSet := flag.NewFlagSet("agentmail ask", flag.ContinueOnError)
+	var askTimeout time.Duration
+	askFlagSet.DurationVar(&askTimeout, "timeout", cli.DefaultAskTimeout, "maximum time to wait for the reply")
+
+	askCmd := &ffcli.Command{
+		Name:       "ask",
+		ShortUsage: "agentmail ask [--timeout <duration>] <recipient> [<message>]",
+		ShortHelp:  "Send a message and wait for the reply",
+		LongHelp: `Send a message to another agent and block until it replies.
+
+The message is marked as expecting a reply. When the recipient answers
+with "agentmail reply <id>", the reply is printed in receive format and
+marked as read. Other mail in your mailbox is left untouched.
+
+Message can also be piped via stdin.
+
+Flags:
+  --timeout  Maximum time to wait for the reply (default: 5m)
+
+Exit codes:
+  0  Reply received
+  1  Error, or no reply within the timeout
+  2  Not running inside tmux
+
+Examples:
+  agentmail ask agent2 "Is the migration finished?"
+  agentmail ask --timeout 30s agent2 "Ready to deploy?"`,
+		FlagSet: askFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.Ask(args, os.Stdin, os.Stdout, os.Stderr, cli.AskOptions{
+				Timeout: askTimeout,
+			})
 			if exitCode != 0 {
 				os.Exit(exitCode)
 			}
@@ -81,12 +295,22 @@ Examples:
 
 	// Receive command flags
 	receiveFlagSet := flag.NewFlagSet("agentmail receive", flag.ContinueOnError)
-	var hookMode bool
+	var (
+		hookMode     bool
+		waitMode     bool
+		waitTimeout  time.Duration
+		leaseTimeout time.Duration
+		recvHeaders  stringList
+	)
 	receiveFlagSet.BoolVar(&hookMode, "hook", false, "enable hook mode for Claude Code integration")
+	receiveFlagSet.BoolVar(&waitMode, "wait", false, "block until a message arrives")
+	receiveFlagSet.DurationVar(&waitTimeout, "timeout", 0, "maximum time to wait with --wait (0 = no limit)")
+	receiveFlagSet.DurationVar(&leaseTimeout, "lease", 0, "keep the message in-flight until acked, redelivering after this long")
+	receiveFlagSet.Var(&recvHeaders, "header", "only receive messages with this key=value header (repeatable)")
 
 	receiveCmd := &ffcli.Command{
 		Name:       "receive",
-		ShortUsage: "agentmail receive [--hook]",
+		ShortUsage: "agentmail receive [--hook] [--wait [--timeout <duration>]] [--lease <duration>] [--header <key=value>]...",
 		ShortHelp:  "Read the oldest unread message",
 		LongHelp: `Read the oldest unread message from your mailbox.
 
@@ -97,14 +321,268 @@ Flags:
             - Exit code 2 indicates new message available
             - Exit code 0 for no messages, not in tmux, or errors
             - Silent operation (no output on exit code 0)
+  --wait    Block until a message arrives, then deliver it.
+            Uses the same file watching as the mailman daemon.
+  --timeout Maximum time to wait with --wait, e.g. 30s or 5m.
+            On timeout, behaves as if the mailbox were empty.
+  --lease   Instead of marking the message read, keep it in-flight
+            for this long (e.g. 10m). Complete it with
+            "agentmail ack <id>"; if the lease expires first, the
+            message returns to the queue and is delivered again.
+            The output includes the delivery attempt number.
+  --header  Only receive messages carrying this key=value header,
+            e.g. task=123. Repeat to require several headers.
+            Other messages stay unread.
 
 Examples:
   agentmail receive
-  agentmail receive --hook`,
+  agentmail receive --hook
+  agentmail receive --wait --timeout 5m
+  agentmail receive --lease 10m
+  agentmail receive --header task=123`,
 		FlagSet: receiveFlagSet,
 		Exec: func(ctx context.Context, args []string) error {
 			exitCode := cli.Receive(os.Stdout, os.Stderr, cli.ReceiveOptions{
-				HookMode: hookMode,
+				HookMode:    hookMode,
+				Wait:        waitMode,
+				WaitTimeout: waitTimeout,
+				Lease:       leaseTimeout,
+				Headers:     recvHeaders,
+			})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Ack command (no flags)
+	ackFlagSet := flag.NewFlagSet("agentmail ack", flag.ContinueOnError)
+
+	ackCmd := &ffcli.Command{
+		Name:       "ack",
+		ShortUsage: "agentmail ack <message-id>",
+		ShortHelp:  "Acknowledge a leased message",
+		LongHelp: `Acknowledge a message received with "receive --lease".
+
+The message is marked as read and will not be delivered again.
+Ack once you have finished acting on the message.
+
+Examples:
+  agentmail receive --lease 10m
+  agentmail ack xK7mN2pQ`,
+		FlagSet: ackFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.Ack(args, os.Stdout, os.Stderr, cli.AckOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Inbox command flags
+	inboxFlagSet := flag.NewFlagSet("agentmail inbox", flag.ContinueOnError)
+	var (
+		inboxAll    bool
+		inboxUnread bool
+		inboxFrom   string
+		inboxSince  time.Duration
+		inboxLimit  int
+	)
+	inboxFlagSet.BoolVar(&inboxAll, "all", false, "list read and unread messages (default)")
+	inboxFlagSet.BoolVar(&inboxUnread, "unread", false, "only list messages that are not read yet")
+	inboxFlagSet.StringVar(&inboxFrom, "from", "", "only list messages from this sender")
+	inboxFlagSet.DurationVar(&inboxSince, "since", 0, "only list messages delivered within this long (e.g. 1h)")
+	inboxFlagSet.IntVar(&inboxLimit, "limit", 0, "list at most this many messages (0 = no limit)")
+
+	inboxCmd := &ffcli.Command{
+		Name:       "inbox",
+		ShortUsage: "agentmail inbox [--all|--unread] [--from <sender>] [--since <duration>] [--limit <n>]",
+		ShortHelp:  "List the messages in your mailbox",
+		LongHelp: `List the messages in your mailbox, newest first, one per line:
+
+  <id>  <from>  <age>  <state>  <subject, or first line of the message>
+
+The state is unread, read, leased (received with --lease, not acked)
+or expired. Nothing is marked as read. Scheduled messages are listed
+once they are due.
+
+Flags:
+  --all     List read and unread messages (default)
+  --unread  Only list messages that are not read yet
+  --from    Only list messages from this sender
+  --since   Only list messages delivered within this long, e.g. 1h
+  --limit   List at most this many messages
+
+Examples:
+  agentmail inbox
+  agentmail inbox --unread
+  agentmail inbox --from agent-1 --since 1h --limit 10`,
+		FlagSet: inboxFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.Inbox(os.Stdout, os.Stderr, cli.InboxOptions{
+				All:    inboxAll,
+				Unread: inboxUnread,
+				From:   inboxFrom,
+				Since:  inboxSince,
+				Limit:  inboxLimit,
+			})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Read command (no flags)
+	readFlagSet := flag.NewFlagSet("agentmail read", flag.ContinueOnError)
+
+	readCmd := &ffcli.Command{
+		Name:       "read",
+		ShortUsage: "agentmail read <message-id>",
+		ShortHelp:  "Show a message from your mailbox",
+		LongHelp: `Show any message in your mailbox by ID, read or not.
+
+The output adds the recipient, delivery date and state to the
+receive format. The message is not changed: an unread message stays
+unread and a leased message stays leased.
+
+Examples:
+  agentmail inbox
+  agentmail read xK7mN2pQ`,
+		FlagSet: readFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.Read(args, os.Stdout, os.Stderr, cli.MailboxOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Peek command (no flags)
+	peekFlagSet := flag.NewFlagSet("agentmail peek", flag.ContinueOnError)
+
+	peekCmd := &ffcli.Command{
+		Name:       "peek",
+		ShortUsage: "agentmail peek",
+		ShortHelp:  "Show the next message without marking it read",
+		LongHelp: `Show the message "agentmail receive" would return next,
+in the same format, without marking it as read or leasing it.
+
+Examples:
+  agentmail peek`,
+		FlagSet: peekFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.Peek(os.Stdout, os.Stderr, cli.MailboxOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Search command flags
+	searchFlagSet := flag.NewFlagSet("agentmail search", flag.ContinueOnError)
+	var (
+		searchFrom  string
+		searchTo    string
+		searchSince time.Duration
+		searchRegex bool
+		searchJSON  bool
+	)
+	searchFlagSet.StringVar(&searchFrom, "from", "", "only search messages from this sender")
+	searchFlagSet.StringVar(&searchTo, "to", "", "only search this recipient's mailbox")
+	searchFlagSet.DurationVar(&searchSince, "since", 0, "only search messages sent within this long (e.g. 1h)")
+	searchFlagSet.BoolVar(&searchRegex, "regex", false, "treat the query as a regular expression")
+	searchFlagSet.BoolVar(&searchJSON, "json", false, "print one JSON object per match")
+
+	searchCmd := &ffcli.Command{
+		Name:       "search",
+		ShortUsage: "agentmail search <query> [--from <sender>] [--to <recipient>] [--since <duration>] [--regex] [--json]",
+		ShortHelp:  "Search messages in every mailbox",
+		LongHelp: `Search the subject, body and header values of every message in
+every mailbox, read or not. Matches are printed oldest first:
+
+  <id>  <from> -> <to>  <time>  <matching line>
+
+The query is a case-insensitive substring unless --regex is given.
+
+Flags:
+  --from   Only search messages from this sender
+  --to     Only search this recipient's mailbox
+  --since  Only search messages sent within this long, e.g. 1h
+  --regex  Treat the query as a Go regular expression
+           (case-sensitive; prefix it with (?i) to ignore case)
+  --json   Print each match as one JSON object per line: the stored
+           message plus a "match" field
+
+Examples:
+  agentmail search "schema change"
+  agentmail search deploy --from agent-1 --since 2h
+  agentmail search --regex 'PR #[0-9]+' --json`,
+		FlagSet: searchFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			// Allow flags after the query ("search <query> --from <sender>")
+			if len(args) > 1 {
+				if err := searchFlagSet.Parse(args[1:]); err != nil {
+					return err
+				}
+				args = append(args[:1], searchFlagSet.Args()...)
+			}
+			exitCode := cli.Search(args, os.Stdout, os.Stderr, cli.SearchOptions{
+				From:  searchFrom,
+				To:    searchTo,
+				Since: searchSince,
+				Regex: searchRegex,
+				JSON:  searchJSON,
+			})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Export command flags
+	exportFlagSet := flag.NewFlagSet("agentmail export", flag.ContinueOnError)
+	var (
+		exportFormat string
+		exportThread string
+		exportSince  time.Duration
+	)
+	exportFlagSet.StringVar(&exportFormat, "format", "json", "output format: mbox, json or markdown")
+	exportFlagSet.StringVar(&exportThread, "thread", "", "only export the conversation containing this message ID")
+	exportFlagSet.DurationVar(&exportSince, "since", 0, "only export messages sent within this long (e.g. 24h)")
+
+	exportCmd := &ffcli.Command{
+		Name:       "export",
+		ShortUsage: "agentmail export [--format mbox|json|markdown] [--thread <message-id>] [--since <duration>]",
+		ShortHelp:  "Write all mail as one transcript",
+		LongHelp: `Write every mailbox, merged into one chronological transcript, to stdout.
+
+Formats:
+  json      A document "agentmail import" can load back (default)
+  mbox      An mboxrd file for mail clients; window names become
+            <window>@agentmail addresses
+  markdown  A readable transcript for reviews and archives
+
+Flags:
+  --format  Output format: mbox, json or markdown
+  --thread  Only export the conversation containing this message
+  --since   Only export messages sent within this long, e.g. 24h
+
+Examples:
+  agentmail export > mail.json
+  agentmail export --format markdown > docs/agents/feature-x.md
+  agentmail export --format mbox --thread xK7mN2pQ > thread.mbox`,
+		FlagSet: exportFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.Export(os.Stdout, os.Stderr, cli.ExportOptions{
+				Format: exportFormat,
+				Thread: exportThread,
+				Since:  exportSince,
 			})
 			if exitCode != 0 {
 				os.Exit(exitCode)
@@ -113,23 +591,64 @@ Examples:
 		},
 	}
 
-	// Recipients command (no flags)
+	// Import command (no flags)
+	importFlagSet := flag.NewFlagSet("agentmail import", flag.ContinueOnError)
+
+	importCmd := &ffcli.Command{
+		Name:       "import",
+		ShortUsage: "agentmail import [<file>|-]",
+		ShortHelp:  "Load a JSON export into the store",
+		LongHelp: `Load a JSON export (from "agentmail export") into the mail store.
+
+Messages keep their IDs, timestamps and read state. A message whose
+ID is already in its recipient's mailbox is skipped, so importing the
+same file twice is harmless. Reads stdin when no file (or "-") is given.
+
+Unread messages are imported unread: with the mailman running, their
+recipients are notified.
+
+Examples:
+  agentmail import mail.json
+  agentmail export | (cd ../other-repo && agentmail import)`,
+		FlagSet: importFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.Import(args, os.Stdout, os.Stderr, cli.ImportOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Recipients command
 	recipientsFlagSet := flag.NewFlagSet("agentmail recipients", flag.ContinueOnError)
+	var recipientsLong bool
+	recipientsFlagSet.BoolVar(&recipientsLong, "long", false, "also show the pane and session:window reading each mailbox")
 
 	recipientsCmd := &ffcli.Command{
 		Name:       "recipients",
-		ShortUsage: "agentmail recipients",
+		ShortUsage: "agentmail recipients [--long]",
 		ShortHelp:  "List available message recipients",
-		LongHelp: `List all tmux windows in the current session that can receive messages.
+		LongHelp: `List the mailboxes of all tmux windows in the current session.
 
 The current window is marked with [you].
 Windows in .agentmailignore are excluded from the list.
 
+A mailbox is named after the window its agent first used agentmail in
+and keeps that name when the window is renamed. A window named like a
+window of another session gets a session:window mailbox instead.
+.agentmail/aliases.jsonl records which pane reads each mailbox.
+
+Flags:
+  --long  Also show the pane ID and the session:window each mailbox is
+          read in, e.g. "agent-1  %3  web:reviewer".
+
 Examples:
-  agentmail recipients`,
+  agentmail recipients
+  agentmail recipients --long`,
 		FlagSet: recipientsFlagSet,
 		Exec: func(ctx context.Context, args []string) error {
-			exitCode := cli.Recipients(os.Stdout, os.Stderr, cli.RecipientsOptions{})
+			exitCode := cli.Recipients(os.Stdout, os.Stderr, cli.RecipientsOptions{Long: recipientsLong})
 			if exitCode != 0 {
 				os.Exit(exitCode)
 			}
@@ -139,10 +658,16 @@ Examples:
 
 	// Status command (no flags)
 	statusFlagSet := flag.NewFlagSet("agentmail status", flag.ContinueOnError)
+	var (
+		statusNotify     string
+		statusNotifyText string
+	)
+	statusFlagSet.StringVar(&statusNotify, "notify", "", "how the mailman notifies you: keys, display, bell, command or default")
+	statusFlagSet.StringVar(&statusNotifyText, "notify-text", "", "notification text; {sender}, {count}, {subject} and {recipient} are filled in")
 
 	statusCmd := &ffcli.Command{
 		Name:       "status",
-		ShortUsage: "agentmail status <ready|work|offline>",
+		ShortUsage: "agentmail status <ready|work|offline> [--notify <strategy>] [--notify-text <template>]",
 		ShortHelp:  "Set agent availability status",
 		LongHelp: `Set the agent's availability status for hooks integration.
 
@@ -159,13 +684,37 @@ to false, allowing future notifications when returning to 'ready'.
 
 Outside of a tmux session, this command is a silent no-op (exit 0).
 
+Flags:
+  --notify <strategy>       How the mailman notifies this window,
+                            overriding notify_strategy in the config:
+                              keys     type the text and press Enter
+                              display  show the text in the status line
+                              bell     ring the terminal bell
+                              command  run notify_command
+                              default  clear this window's overrides
+  --notify-text <template>  Notification text, overriding notify_template;
+                            {sender}, {count}, {subject} and {recipient}
+                            are filled in
+
 Examples:
   agentmail status ready
   agentmail status work
-  agentmail status offline`,
+  agentmail status offline
+  agentmail status ready --notify display
+  agentmail status ready --notify-text "/mail {count} from {sender}"`,
 		FlagSet: statusFlagSet,
 		Exec: func(ctx context.Context, args []string) error {
-			exitCode := cli.Status(args, os.Stdout, os.Stderr, cli.StatusOptions{})
+			// Allow flags after the status ("status ready --notify bell")
+			if len(args) > 1 {
+				if err := statusFlagSet.Parse(args[1:]); err != nil {
+					return err
+				}
+				args = append(args[:1], statusFlagSet.Args()...)
+			}
+			exitCode := cli.Status(args, os.Stdout, os.Stderr, cli.StatusOptions{
+				Notify:     statusNotify,
+				NotifyText: statusNotifyText,
+			})
 			if exitCode != 0 {
 				os.Exit(exitCode)
 			}
@@ -250,11 +799,18 @@ Examples:
 		ShortHelp:  "Start MCP server (STDIO transport)",
 		LongHelp: `Start the Model Context Protocol (MCP) server for AI agent integration.
 
-The MCP server exposes AgentMail functionality through four tools:
-  send            Send a message to another agent
-  receive         Receive the oldest unread message
-  status          Set agent availability status
-  list-recipients List available agents in the session
+The MCP server exposes AgentMail functionality through these tools:
+  send             Send a message to another agent (optionally as a reply)
+  ask              Send a message and wait for the reply
+  receive          Receive the oldest unread message
+  wait-for-message Block until a message arrives, then receive it
+  status           Set agent availability status
+  list-recipients  List available agents in the session
+  inbox            List the messages in your mailbox
+  read             Show a message from your mailbox by ID
+  peek             Show the next message without marking it read
+  search           Search messages in every mailbox
+  message-status   Show the delivery status of a message
 
 The server uses STDIO transport and communicates via JSON-RPC 2.0.
 It must be run inside a tmux session.
@@ -290,12 +846,16 @@ Examples:
 	// Cleanup command flags
 	cleanupFlagSet := flag.NewFlagSet("agentmail cleanup", flag.ContinueOnError)
 	var (
-		staleHours     int
-		deliveredHours int
-		dryRun         bool
+		staleHours         int
+		deliveredHours     int
+		undeliverableHours int
+		maxNotifications   int
+		dryRun             bool
 	)
-	cleanupFlagSet.IntVar(&staleHours, "stale-hours", 48, "hours threshold for stale recipients")
-	cleanupFlagSet.IntVar(&deliveredHours, "delivered-hours", 2, "hours threshold for delivered messages")
+	cleanupFlagSet.IntVar(&staleHours, "stale-hours", config.DefaultCleanupStaleHours, "hours threshold for stale recipients")
+	cleanupFlagSet.IntVar(&deliveredHours, "delivered-hours", config.DefaultCleanupDeliveredHours, "hours threshold for delivered messages")
+	cleanupFlagSet.IntVar(&undeliverableHours, "undeliverable-hours", config.DefaultUndeliverableHours, "hours unread mail for a missing window waits before dead-lettering (0 = disabled)")
+	cleanupFlagSet.IntVar(&maxNotifications, "max-notifications", config.DefaultMaxNotifications, "notifications an unread message may trigger before dead-lettering (0 = disabled)")
 	cleanupFlagSet.BoolVar(&dryRun, "dry-run", false, "report what would be cleaned without deleting")
 
 	cleanupCmd := &ffcli.Command{
@@ -309,11 +869,28 @@ This command cleans up:
 - Stale recipients (not updated within threshold)
 - Old delivered messages (read messages older than threshold)
 - Empty mailbox files
+- Delivery events of removed messages (once older than --stale-hours)
+
+Mailbox logs are compacted: read and update records are folded back
+into one line per message.
+
+Undeliverable unread messages are moved to the dead-letter mailbox
+(see "agentmail deadletter"): mail whose --ttl ran out (counted
+separately as expired), mail for windows that no longer exist, and mail
+the mailman has notified about too many times.
 
 Flags:
-  --stale-hours      Hours threshold for stale recipients (default: 48)
-  --delivered-hours  Hours threshold for delivered messages (default: 2)
-  --dry-run          Report what would be cleaned without deleting
+  --stale-hours          Hours threshold for stale recipients (default: 48)
+  --delivered-hours      Hours threshold for delivered messages (default: 2)
+  --undeliverable-hours  Hours unread mail for a missing window waits
+                         before dead-lettering (default: 24, 0 = disabled)
+  --max-notifications    Notifications an unread message may trigger
+                         before dead-lettering (default: 10, 0 = disabled)
+  --dry-run              Report what would be cleaned without deleting
+
+Thresholds not given as flags come from .agentmail/config.toml
+(cleanup_stale_hours, cleanup_delivered_hours, undeliverable_hours and
+max_notifications; see "agentmail config").
 
 Examples:
   agentmail cleanup
@@ -321,10 +898,33 @@ Examples:
   agentmail cleanup --stale-hours 24 --delivered-hours 1`,
 		FlagSet: cleanupFlagSet,
 		Exec: func(ctx context.Context, args []string) error {
+			// Thresholds not given as flags come from the config file
+			cfg, err := config.Load(".")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			given := make(map[string]bool)
+			cleanupFlagSet.Visit(func(f *flag.Flag) { given[f.Name] = true })
+			if !given["stale-hours"] {
+				staleHours = cfg.CleanupStaleHours
+			}
+			if !given["delivered-hours"] {
+				deliveredHours = cfg.CleanupDeliveredHours
+			}
+			if !given["undeliverable-hours"] {
+				undeliverableHours = cfg.UndeliverableHours
+			}
+			if !given["max-notifications"] {
+				maxNotifications = cfg.MaxNotifications
+			}
+
 			exitCode := cli.Cleanup(os.Stdout, os.Stderr, cli.CleanupOptions{
-				StaleHours:     staleHours,
-				DeliveredHours: deliveredHours,
-				DryRun:         dryRun,
+				StaleHours:         staleHours,
+				DeliveredHours:     deliveredHours,
+				UndeliverableHours: undeliverableHours,
+				MaxNotifications:   maxNotifications,
+				DryRun:             dryRun,
 			})
 			if exitCode != 0 {
 				os.Exit(exitCode)
@@ -333,6 +933,284 @@ Examples:
 		},
 	}
 
+	// Doctor command flags
+	doctorFlagSet := flag.NewFlagSet("agentmail doctor", flag.ContinueOnError)
+	var doctorRepair bool
+	doctorFlagSet.BoolVar(&doctorRepair, "repair", false, "quarantine corrupt records and rewrite the clean ones")
+
+	doctorCmd := &ffcli.Command{
+		Name:       "doctor",
+		ShortUsage: "agentmail doctor [--repair]",
+		ShortHelp:  "Check the mail store for corrupt records",
+		LongHelp: `Check the mail store for corrupt records.
+
+Every mailbox, the dead-letter mailbox and the recipients file are
+scanned for records that cannot be decoded, such as a line torn by a
+crash. Each one is reported with its file, byte offset and reason.
+
+Other commands skip corrupt records, so the rest of a mailbox stays
+readable. With --repair, corrupt records are moved to
+.agentmail/quarantine/<mailbox>.bad and the clean records are rewritten.
+
+Flags:
+  --repair  Quarantine corrupt records and rewrite the clean ones
+
+Exit codes:
+  0  No corruption found, or corruption repaired
+  1  Corruption found without --repair, or the check failed
+
+Examples:
+  agentmail doctor
+  agentmail doctor --repair`,
+		FlagSet: doctorFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.Doctor(os.Stdout, os.Stderr, cli.DoctorOptions{Repair: doctorRepair})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Migrate command flags
+	migrateFlagSet := flag.NewFlagSet("agentmail migrate", flag.ContinueOnError)
+	var migrateDryRun bool
+	migrateFlagSet.BoolVar(&migrateDryRun, "dry-run", false, "print the planned changes without writing")
+
+	migrateCmd := &ffcli.Command{
+		Name:       "migrate",
+		ShortUsage: "agentmail migrate [--dry-run]",
+		ShortHelp:  "Upgrade the mail store to the current format",
+		LongHelp: `Upgrade the mail store to the current format.
+
+The store format version is recorded in .agentmail/VERSION. Commands
+refuse to write to a store with a newer version than they understand.
+Stores left in the pre-.agentmail/ layout (.git/mail/) are version 0.
+
+Before changing anything, migrate copies .agentmail/ and any legacy
+data to .agentmail/backups/v<version>-<timestamp>/. Stop the mailman
+daemon before migrating.
+
+Flags:
+  --dry-run  Print the planned changes without writing
+
+Examples:
+  agentmail migrate --dry-run
+  agentmail migrate`,
+		FlagSet: migrateFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.Migrate(os.Stdout, os.Stderr, cli.MigrateOptions{DryRun: migrateDryRun})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	// Deadletter subcommands
+	deadletterListCmd := &ffcli.Command{
+		Name:       "list",
+		ShortUsage: "agentmail deadletter list",
+		ShortHelp:  "List dead-lettered messages",
+		FlagSet:    flag.NewFlagSet("agentmail deadletter list", flag.ContinueOnError),
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.DeadLetterList(os.Stdout, os.Stderr, cli.DeadLetterOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	requeueFlagSet := flag.NewFlagSet("agentmail deadletter requeue", flag.ContinueOnError)
+	var requeueTo string
+	requeueFlagSet.StringVar(&requeueTo, "to", "", "window to deliver the message to (default: original recipient)")
+
+	deadletterRequeueCmd := &ffcli.Command{
+		Name:       "requeue",
+		ShortUsage: "agentmail deadletter requeue <message-id> [--to <window>]",
+		ShortHelp:  "Deliver a dead-lettered message to a live window",
+		FlagSet:    requeueFlagSet,
+		Exec: func(ctx context.Context, args []string) error {
+			// Allow flags after the message ID ("requeue <id> --to <window>")
+			if len(args) > 1 {
+				if err := requeueFlagSet.Parse(args[1:]); err != nil {
+					return err
+				}
+				args = append(args[:1], requeueFlagSet.Args()...)
+			}
+			exitCode := cli.DeadLetterRequeue(args, requeueTo, os.Stdout, os.Stderr, cli.DeadLetterOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	deadletterPurgeCmd := &ffcli.Command{
+		Name:       "purge",
+		ShortUsage: "agentmail deadletter purge",
+		ShortHelp:  "Permanently delete all dead-lettered messages",
+		FlagSet:    flag.NewFlagSet("agentmail deadletter purge", flag.ContinueOnError),
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.DeadLetterPurge(os.Stdout, os.Stderr, cli.DeadLetterOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	deadletterHelp := `Manage messages that could not be delivered.
+
+Unread messages are moved to .agentmail/deadletter/ by the mailman
+daemon and by cleanup when their recipient window has been gone for
+too long, or when the recipient was notified about them too many times.
+
+Subcommands:
+  list                                  List dead-lettered messages
+  requeue <message-id> [--to <window>]  Deliver a message to a live window
+                                        (default: its original recipient)
+  purge                                 Permanently delete all dead letters
+
+Examples:
+  agentmail deadletter list
+  agentmail deadletter requeue xK7mN2pQ --to agent-2
+  agentmail deadletter purge`
+
+	deadletterCmd := &ffcli.Command{
+		Name:        "deadletter",
+		ShortUsage:  "agentmail deadletter <list|requeue|purge>",
+		ShortHelp:   "Manage undeliverable messages",
+		LongHelp:    deadletterHelp,
+		FlagSet:     flag.NewFlagSet("agentmail deadletter", flag.ContinueOnError),
+		Subcommands: []*ffcli.Command{deadletterListCmd, deadletterRequeueCmd, deadletterPurgeCmd},
+		Exec: func(ctx context.Context, args []string) error {
+			// No subcommand provided, show help
+			fmt.Fprintln(os.Stderr, deadletterHelp)
+			os.Exit(1)
+			return nil
+		},
+	}
+
+	// Config subcommands
+	configListCmd := &ffcli.Command{
+		Name:       "list",
+		ShortUsage: "agentmail config list",
+		ShortHelp:  "List every setting and where its value comes from",
+		FlagSet:    flag.NewFlagSet("agentmail config list", flag.ContinueOnError),
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.ConfigList(os.Stdout, os.Stderr, cli.ConfigOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	configGetCmd := &ffcli.Command{
+		Name:       "get",
+		ShortUsage: "agentmail config get <key>",
+		ShortHelp:  "Print the effective value of a setting",
+		FlagSet:    flag.NewFlagSet("agentmail config get", flag.ContinueOnError),
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.ConfigGet(args, os.Stdout, os.Stderr, cli.ConfigOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	configSetCmd := &ffcli.Command{
+		Name:       "set",
+		ShortUsage: "agentmail config set <key> <value>",
+		ShortHelp:  "Write a setting to .agentmail/config.toml",
+		FlagSet:    flag.NewFlagSet("agentmail config set", flag.ContinueOnError),
+		Exec: func(ctx context.Context, args []string) error {
+			exitCode := cli.ConfigSet(args, os.Stdout, os.Stderr, cli.ConfigOptions{})
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	configHelp := `Show and change the repository's AgentMail settings.
+
+Settings are read from .agentmail/config.toml by the CLI, the MCP server
+and the mailman daemon. Each key can be overridden with an environment
+variable named AGENTMAIL_<KEY>, e.g. AGENTMAIL_NOTIFY_DEBOUNCE=30s.
+
+Subcommands:
+  list               List every setting and where its value comes from
+  get <key>          Print the effective value of a setting
+  set <key> <value>  Write a setting to .agentmail/config.toml
+
+Keys:
+  store                      Storage backend: jsonl or bolt (default jsonl)
+  notify_debounce            Minimum time between notifications of a
+                             ready agent (default 1m)
+  work_protection            No notifications for this long after an agent
+                             switches to work or offline (default 1h)
+  stateless_notify_interval  Minimum time between notifications of an
+                             agent without status (default 1m)
+  debounce_window            How long the mailman lets file events settle
+                             before checking (default 500ms)
+  fallback_interval          How often the mailman checks when no file
+                             event arrives (default 1m)
+  stale_threshold            Age at which the mailman drops recipient
+                             states (default 1h)
+  max_message_size           Largest message the MCP send and ask tools
+                             accept, in bytes (default 65536)
+  idempotency_window         How long a retry with the same --key returns
+                             the original send (default 1h)
+  cleanup_stale_hours        Default of cleanup --stale-hours (48)
+  cleanup_delivered_hours    Default of cleanup --delivered-hours (2)
+  undeliverable_hours        Default of cleanup --undeliverable-hours and
+                             the mailman's dead-letter sweep (24)
+  max_notifications          Default of cleanup --max-notifications and
+                             the mailman's dead-letter sweep (10)
+  notify_strategy            How the mailman notifies agents: keys, display,
+                             bell or command (default keys)
+  notify_template            Notification text; {sender}, {count},
+                             {subject} and {recipient} are filled in
+                             (default "Check your agentmail")
+  notify_command             Shell command run by the command strategy,
+                             with AGENTMAIL_RECIPIENT, AGENTMAIL_SENDER,
+                             AGENTMAIL_COUNT, AGENTMAIL_SUBJECT and
+                             AGENTMAIL_TEXT set
+  idle_quiet                 How long a pane must be unchanged before
+                             keys are typed into it (default 2s; 0
+                             only checks idle_prompt)
+  idle_prompt                Regular expression the pane must match
+                             before keys are typed into it (default any)
+
+Durations are written like 90s, 5m or 2h. The mailman reads
+debounce_window, fallback_interval and stateless_notify_interval when
+it starts, and config set warns that a running mailman must be
+restarted for them; other settings take effect on its next check.
+
+Examples:
+  agentmail config list
+  agentmail config get notify_debounce
+  agentmail config set work_protection 30m`
+
+	configCmd := &ffcli.Command{
+		Name:        "config",
+		ShortUsage:  "agentmail config <list|get|set>",
+		ShortHelp:   "Show and change repository settings",
+		LongHelp:    configHelp,
+		FlagSet:     flag.NewFlagSet("agentmail config", flag.ContinueOnError),
+		Subcommands: []*ffcli.Command{configListCmd, configGetCmd, configSetCmd},
+		Exec: func(ctx context.Context, args []string) error {
+			// No subcommand provided, show help
+			fmt.Fprintln(os.Stderr, configHelp)
+			os.Exit(1)
+			return nil
+		},
+	}
+
 	// Root command help text
 	rootHelp := `agentmail - Inter-agent communication for tmux sessions
 
@@ -341,13 +1219,28 @@ through a simple file-based mail system stored in .agentmail/.
 
 Commands:
   send        Send a message to a tmux window
+  ask         Send a message and wait for the reply
   receive     Read the oldest unread message
+  ack         Acknowledge a leased message
+  inbox       List the messages in your mailbox
+  read        Show a message from your mailbox
+  peek        Show the next message without marking it read
+  reply       Reply to a message in your mailbox
+  thread      Show the conversation containing a message
+  status-of   Show the delivery status of a message
+  search      Search messages in every mailbox
+  export      Write all mail as one transcript
+  import      Load a JSON export into the store
   recipients  List available message recipients
   status      Set agent availability status
   mailman     Start the mailman daemon
   onboard     Output agent onboarding context
   mcp         Start MCP server (STDIO transport)
   cleanup     Remove stale data from AgentMail
+  deadletter  Manage undeliverable messages
+  doctor      Check the mail store for corrupt records
+  migrate     Upgrade the mail store to the current format
+  config      Show and change repository settings
 
 Use "agentmail <command> --help" for more information about a command.`
 
@@ -357,7 +1250,7 @@ Use "agentmail <command> --help" for more information about a command.`
 		ShortHelp:   "Inter-agent communication for tmux sessions",
 		LongHelp:    rootHelp,
 		FlagSet:     rootFlagSet,
-		Subcommands: []*ffcli.Command{sendCmd, receiveCmd, recipientsCmd, statusCmd, mailmanCmd, onboardCmd, mcpCmd, cleanupCmd},
+		Subcommands: []*ffcli.Command{sendCmd, askCmd, receiveCmd, ackCmd, inboxCmd, readCmd, peekCmd, replyCmd, threadCmd, statusOfCmd, searchCmd, exportCmd, importCmd, recipientsCmd, statusCmd, mailmanCmd, onboardCmd, mcpCmd, cleanupCmd, deadletterCmd, doctorCmd, migrateCmd, configCmd},
 		Exec: func(ctx context.Context, args []string) error {
 			// No subcommand provided, show help
 			fmt.Fprintln(os.Stderr, rootHelp)
@@ -373,3 +1266,15 @@ Use "agentmail <command> --help" for more information about a command.`
 		os.Exit(1)
 	}
 }
+
+// stringList is a flag.Value that collects every occurrence of a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
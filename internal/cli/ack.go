@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"agentmail/internal/mail"
+	"agentmail/internal/tmux"
+)
+
+// AckOptions configures the Ack command behavior.
+// Used for testing to mock tmux and file system operations.
+type AckOptions struct {
+	SkipTmuxCheck bool   // Skip tmux environment check
+	MockReceiver  string // Mock receiver window name
+	RepoRoot      string // Repository root (defaults to finding git root)
+}
+
+// Ack implements the agentmail ack command.
+// It completes a message received with receive --lease: the message is marked
+// as read and will not be redelivered when the lease expires.
+//
+// Exit Codes:
+// - 0: Message acknowledged
+// - 1: Missing argument, unknown message, or write failure
+// - 2: Not running inside tmux
+func Ack(args []string, stdout, stderr io.Writer, opts AckOptions) int {
+	if !opts.SkipTmuxCheck {
+		if !tmux.InTmux() {
+			fmt.Fprintln(stderr, "error: agentmail must run inside a tmux session")
+			return 2
+		}
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "error: missing required argument: message-id")
+		fmt.Fprintln(stderr, "usage: agentmail ack <message-id>")
+		return 1
+	}
+
+	// Get receiver identity (only messages in your own mailbox can be acked)
+	var receiver string
+	if opts.MockReceiver != "" {
+		receiver = opts.MockReceiver
+	} else {
+		var err error
+		receiver, err = mail.CurrentMailbox(opts.RepoRoot)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to get current window: %v\n", err)
+			return 1
+		}
+	}
+
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return 1
+		}
+	}
+
+	if err := mail.Ack(repoRoot, receiver, args[0]); err != nil {
+		if errors.Is(err, mail.ErrMessageNotFound) {
+			fmt.Fprintf(stderr, "error: message #%s not found in your mailbox\n", args[0])
+			return 1
+		}
+		fmt.Fprintf(stderr, "error: failed to acknowledge message: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Message #%s acknowledged\n", args[0])
+	return 0
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"agentmail/internal/mail"
+)
+
+func TestAckCommand_MissingMessageID(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Ack([]string{}, &stdout, &stderr, AckOptions{SkipTmuxCheck: true})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "missing required argument: message-id") {
+		t.Errorf("Expected missing argument error, got: %q", stderr.String())
+	}
+}
+
+func TestAckCommand_NotInTmux(t *testing.T) {
+	t.Setenv("TMUX", "")
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Ack([]string{"msg00001"}, &stdout, &stderr, AckOptions{})
+
+	if exitCode != 2 {
+		t.Errorf("Expected exit code 2 when not in tmux, got %d", exitCode)
+	}
+}
+
+func TestAckCommand_UnknownMessage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Ack([]string{"missing1"}, &stdout, &stderr, AckOptions{
+		SkipTmuxCheck: true,
+		MockReceiver:  "agent-2",
+		RepoRoot:      t.TempDir(),
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "message #missing1 not found in your mailbox") {
+		t.Errorf("Expected not found error, got: %q", stderr.String())
+	}
+}
+
+func TestAckCommand_CompletesLeasedMessage(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := mail.Append(tmpDir, mail.Message{ID: "lease001", From: "agent-1", To: "agent-2", Message: "build it"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Receive(&stdout, &stderr, ReceiveOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+		Lease:         time.Millisecond,
+	})
+	if exitCode != 0 {
+		t.Fatalf("Receive failed with exit code %d: %s", exitCode, stderr.String())
+	}
+
+	stdout.Reset()
+	exitCode = Ack([]string{"lease001"}, &stdout, &stderr, AckOptions{
+		SkipTmuxCheck: true,
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+	})
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if stdout.String() != "Message #lease001 acknowledged\n" {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+
+	// The lease has expired, but the acked message must not be redelivered
+	time.Sleep(10 * time.Millisecond)
+	unread, err := mail.FindUnread(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("FindUnread failed: %v", err)
+	}
+	if len(unread) != 0 {
+		t.Errorf("Expected no unread messages after ack, got %d", len(unread))
+	}
+}
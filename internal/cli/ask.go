@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"agentmail/internal/daemon"
+	"agentmail/internal/mail"
+	"agentmail/internal/tmux"
+)
+
+// DefaultAskTimeout is how long ask waits for a reply when no timeout is given.
+const DefaultAskTimeout = 5 * time.Minute
+
+// AskOptions configures the Ask command behavior.
+type AskOptions struct {
+	Send    SendOptions   // Options for the outgoing question (mocks, repo root)
+	Timeout time.Duration // Maximum time to wait for the reply (0 = DefaultAskTimeout)
+}
+
+// Ask implements the agentmail ask command.
+// It sends a message marked as expecting a reply, then blocks until a message
+// replying to it (In-Reply-To = its ID) arrives in the caller's mailbox.
+// The reply is marked as read and printed in receive format.
+//
+// Exit Codes:
+// - 0: Reply received
+// - 1: Send failed, wait failed, or no reply within the timeout
+// - 2: Not running inside tmux
+func Ask(args []string, stdin io.Reader, stdout, stderr io.Writer, opts AskOptions) int {
+	if !opts.Send.SkipTmuxCheck {
+		if !tmux.InTmux() {
+			fmt.Fprintln(stderr, "error: agentmail must run inside a tmux session")
+			return 2
+		}
+	}
+
+	// A single correlation ID cannot match replies to several copies
+	if len(args) > 0 && mail.IsGroupAddress(args[0]) {
+		fmt.Fprintln(stderr, "error: ask requires a single recipient, not a group address")
+		return 1
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultAskTimeout
+	}
+
+	// Pre-generate the ID so the reply can be correlated with the question
+	id, err := mail.GenerateID()
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to generate message ID: %v\n", err)
+		return 1
+	}
+
+	sendOpts := opts.Send
+	sendOpts.MessageID = id
+	sendOpts.ExpectsReply = true
+	// Send's confirmation is suppressed so stdout carries only the reply
+	if exitCode := Send(args, stdin, io.Discard, stderr, sendOpts); exitCode != 0 {
+		return exitCode
+	}
+
+	// Get asker identity (where the reply will be delivered)
+	var asker string
+	if opts.Send.MockSender != "" {
+		asker = opts.Send.MockSender
+	} else {
+		asker, err = mail.CurrentMailbox(opts.Send.RepoRoot)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to get current window: %v\n", err)
+			return 1
+		}
+	}
+
+	// Determine repository root
+	repoRoot := opts.Send.RepoRoot
+	if repoRoot == "" {
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return 1
+		}
+	}
+
+	found, err := daemon.WaitFor(context.Background(), repoRoot, timeout, func() bool {
+		_, ok, _ := mail.FindReply(repoRoot, asker, id)
+		return ok
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to wait for reply: %v\n", err)
+		return 1
+	}
+	if !found {
+		fmt.Fprintf(stderr, "error: no reply to #%s within %v\n", id, timeout)
+		return 1
+	}
+
+	reply, _, err := mail.FindReply(repoRoot, asker, id)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to read reply: %v\n", err)
+		return 1
+	}
+
+	if err := mail.MarkAsRead(repoRoot, asker, reply.ID); err != nil {
+		fmt.Fprintf(stderr, "error: failed to mark message as read: %v\n", err)
+		return 1
+	}
+
+	// Same format as receive
+	writeMessage(stdout, reply)
+
+	return 0
+}
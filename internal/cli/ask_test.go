@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"agentmail/internal/mail"
+)
+
+// answerQuestion waits for the first unread message in recipient's mailbox and
+// appends a reply to it from recipient, as the asked agent would.
+func answerQuestion(repoRoot, recipient, answer string) {
+	for i := 0; i < 100; i++ {
+		unread, err := mail.FindUnread(repoRoot, recipient)
+		if err == nil && len(unread) > 0 {
+			question := unread[0]
+			_ = mail.Append(repoRoot, mail.Message{
+				ID:        "answ0001",
+				From:      recipient,
+				To:        question.From,
+				Message:   answer,
+				InReplyTo: question.ID,
+				ThreadID:  question.ID,
+			})
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestAskCommand_ReturnsReply(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	go answerQuestion(tmpDir, "agent-2", "use the v2 schema")
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Ask([]string{"agent-2", "which schema?"}, nil, &stdout, &stderr, AskOptions{
+		Send: SendOptions{
+			SkipTmuxCheck: true,
+			MockWindows:   []string{"agent-1", "agent-2"},
+			MockSender:    "agent-1",
+			RepoRoot:      tmpDir,
+		},
+		Timeout: 10 * time.Second,
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+
+	questions, err := mail.ReadAll(tmpDir, "agent-2")
+	if err != nil || len(questions) != 1 {
+		t.Fatalf("Expected 1 question in agent-2 mailbox, got %d (err: %v)", len(questions), err)
+	}
+	if !questions[0].ExpectsReply {
+		t.Error("Question should be marked as expecting a reply")
+	}
+
+	expected := "From: agent-2\nID: answ0001\nIn-Reply-To: " + questions[0].ID + "\n\nuse the v2 schema"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	// The reply is consumed so a later receive does not return it again
+	unread, err := mail.FindUnread(tmpDir, "agent-1")
+	if err != nil {
+		t.Fatalf("FindUnread failed: %v", err)
+	}
+	if len(unread) != 0 {
+		t.Errorf("Expected reply to be marked as read, got %d unread", len(unread))
+	}
+}
+
+func TestAskCommand_IgnoresUnrelatedMessages(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// An unrelated unread message must not satisfy the wait
+	if err := mail.Append(tmpDir, mail.Message{ID: "other001", From: "agent-3", To: "agent-1", Message: "fyi"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Ask([]string{"agent-2", "anyone there?"}, nil, &stdout, &stderr, AskOptions{
+		Send: SendOptions{
+			SkipTmuxCheck: true,
+			MockWindows:   []string{"agent-1", "agent-2", "agent-3"},
+			MockSender:    "agent-1",
+			RepoRoot:      tmpDir,
+		},
+		Timeout: 200 * time.Millisecond,
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1 on timeout, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "no reply to #") {
+		t.Errorf("Expected timeout error, got: %q", stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no stdout on timeout, got %q", stdout.String())
+	}
+}
+
+func TestAskCommand_RejectsGroupAddress(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Ask([]string{"@all", "who is free?"}, nil, &stdout, &stderr, AskOptions{
+		Send: SendOptions{
+			SkipTmuxCheck: true,
+			MockWindows:   []string{"agent-1", "agent-2"},
+			MockSender:    "agent-1",
+			RepoRoot:      t.TempDir(),
+		},
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "not a group address") {
+		t.Errorf("Expected group address error, got: %q", stderr.String())
+	}
+}
+
+func TestAskCommand_NotInTmux(t *testing.T) {
+	t.Setenv("TMUX", "")
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Ask([]string{"agent-2", "hi"}, nil, &stdout, &stderr, AskOptions{})
+
+	if exitCode != 2 {
+		t.Errorf("Expected exit code 2 when not in tmux, got %d", exitCode)
+	}
+}
@@ -17,13 +17,19 @@ func formatSummary(stdout io.Writer, result CleanupResult, dryRun bool) {
 		fmt.Fprintf(stdout, "  Recipients to remove: %d (%d offline, %d stale)\n",
 			result.RecipientsRemoved, result.OfflineRemoved, result.StaleRemoved)
 		fmt.Fprintf(stdout, "  Messages to remove: %d\n", result.MessagesRemoved)
+		fmt.Fprintf(stdout, "  Messages to expire: %d\n", result.Expired)
+		fmt.Fprintf(stdout, "  Messages to dead-letter: %d\n", result.DeadLettered)
 		fmt.Fprintf(stdout, "  Mailboxes to remove: %d\n", result.MailboxesRemoved)
+		fmt.Fprintf(stdout, "  Delivery events to remove: %d\n", result.EventsRemoved)
 	} else {
 		fmt.Fprintln(stdout, "Cleanup complete:")
 		fmt.Fprintf(stdout, "  Recipients removed: %d (%d offline, %d stale)\n",
 			result.RecipientsRemoved, result.OfflineRemoved, result.StaleRemoved)
 		fmt.Fprintf(stdout, "  Messages removed: %d\n", result.MessagesRemoved)
+		fmt.Fprintf(stdout, "  Messages expired: %d\n", result.Expired)
+		fmt.Fprintf(stdout, "  Messages dead-lettered: %d\n", result.DeadLettered)
 		fmt.Fprintf(stdout, "  Mailboxes removed: %d\n", result.MailboxesRemoved)
+		fmt.Fprintf(stdout, "  Delivery events removed: %d\n", result.EventsRemoved)
 	}
 }
 
@@ -40,6 +46,9 @@ type CleanupOptions struct {
 	DeliveredHours int  // Hours threshold for delivered messages (default: 2)
 	DryRun         bool // If true, report what would be cleaned without deleting
 
+	UndeliverableHours int // Hours unread mail for a missing window waits before dead-lettering (0 = disabled)
+	MaxNotifications   int // Notifications an unread message may trigger before dead-lettering (0 = disabled)
+
 	// Testing options
 	RepoRoot      string   // Repository root (defaults to "." if empty)
 	SkipTmuxCheck bool     // Skip real tmux check (for testing)
@@ -54,11 +63,15 @@ type CleanupResult struct {
 	StaleRemoved      int // Recipients removed because updated_at expired
 	MessagesRemoved   int // Messages removed (read + old)
 	MailboxesRemoved  int // Empty mailbox files removed
+	EventsRemoved     int // Delivery events of removed messages
+	Expired           int // Unread messages whose TTL ran out (moved to the dead-letter mailbox)
+	DeadLettered      int // Messages moved to the dead-letter mailbox
 	FilesSkipped      int // Files skipped due to lock contention
 }
 
 // Cleanup removes stale data from the AgentMail system.
-// It removes offline recipients, stale recipients, old delivered messages, and empty mailboxes.
+// It removes offline recipients, stale recipients, old delivered messages, empty mailboxes
+// and the delivery events of removed messages, moves expired and undeliverable messages to the dead-letter mailbox, and compacts mailbox logs.
 //
 // FR-001: Compare each recipient in recipients.jsonl against current tmux window names
 // FR-002: Remove recipients whose names don't match any current tmux window
@@ -90,14 +103,15 @@ func Cleanup(stdout, stderr io.Writer, opts CleanupOptions) int {
 	}
 
 	// Phase 1: Clean offline recipients (US1)
+	// windows stays nil outside tmux, which also disables undeliverable dead-lettering
+	var windows []string
 	if inTmux {
 		// Get list of valid tmux windows
-		var windows []string
 		if isMocking {
 			windows = opts.MockWindows
 		} else {
 			var err error
-			windows, err = tmux.ListWindows()
+			windows, err = mail.LiveMailboxes(repoRoot)
 			if err != nil {
 				fmt.Fprintf(stderr, "Warning: failed to list tmux windows: %v\n", err)
 				// Continue without offline cleanup
@@ -155,7 +169,49 @@ func Cleanup(stdout, stderr io.Writer, opts CleanupOptions) int {
 		result.RecipientsRemoved += staleRemoved
 	}
 
-	// Phase 3: Clean old delivered messages (US3)
+	// Phase 3: Move expired and undeliverable messages to the dead-letter mailbox
+	// This runs BEFORE message cleanup so that mailboxes emptied here are removed in Phase 5
+	// Expiry runs first so expired messages are counted as expired, not undeliverable
+	if opts.DryRun {
+		count, err := mail.CountExpired(repoRoot)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error counting expired messages: %v\n", err)
+			return 1
+		}
+		result.Expired = count
+	} else {
+		expired, err := mail.ExpireMessages(repoRoot)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error expiring messages: %v\n", err)
+			return 1
+		}
+		result.Expired = expired
+	}
+
+	deadWindows := windows
+	if opts.UndeliverableHours <= 0 {
+		deadWindows = nil
+	}
+	if deadWindows != nil || opts.MaxNotifications > 0 {
+		undeliverableThreshold := time.Duration(opts.UndeliverableHours) * time.Hour
+		if opts.DryRun {
+			count, err := mail.CountDeadLetterCandidates(repoRoot, deadWindows, undeliverableThreshold, opts.MaxNotifications)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error counting undeliverable messages: %v\n", err)
+				return 1
+			}
+			result.DeadLettered = count
+		} else {
+			moved, err := mail.DeadLetterSweep(repoRoot, deadWindows, undeliverableThreshold, opts.MaxNotifications)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error moving messages to dead-letter mailbox: %v\n", err)
+				return 1
+			}
+			result.DeadLettered = moved
+		}
+	}
+
+	// Phase 4: Clean old delivered messages (US3)
 	// Remove read messages older than DeliveredHours threshold
 	deliveredThreshold := time.Duration(opts.DeliveredHours) * time.Hour
 
@@ -191,10 +247,16 @@ func Cleanup(stdout, stderr io.Writer, opts CleanupOptions) int {
 			}
 			result.MessagesRemoved += removed
 		}
+
+		// Fold read/update records of the remaining mailbox logs back into one line per message
+		if _, err := mail.CompactMailboxes(repoRoot, 1); err != nil {
+			fmt.Fprintf(stderr, "Error compacting mailboxes: %v\n", err)
+			return 1
+		}
 	}
 
-	// Phase 4: Remove empty mailboxes (US4)
-	// This runs AFTER message cleanup so that mailboxes emptied by Phase 3 are also removed
+	// Phase 5: Remove empty mailboxes (US4)
+	// This runs AFTER message cleanup so that mailboxes emptied by Phases 3 and 4 are also removed
 	if opts.DryRun {
 		// Dry-run mode: just count
 		count, err := mail.CountEmptyMailboxes(repoRoot)
@@ -213,7 +275,25 @@ func Cleanup(stdout, stderr io.Writer, opts CleanupOptions) int {
 		result.MailboxesRemoved = mailboxesRemoved
 	}
 
-	// Phase 5: Output summary (FR-014) and warnings
+	// Phase 6: Remove delivery events of messages that are gone
+	// This runs AFTER message cleanup; events are kept for StaleHours so status-of can still report removed messages
+	if opts.DryRun {
+		count, err := mail.CountOldEvents(repoRoot, staleThreshold)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error counting delivery events: %v\n", err)
+			return 1
+		}
+		result.EventsRemoved = count
+	} else {
+		eventsRemoved, err := mail.CleanOldEvents(repoRoot, staleThreshold)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error removing delivery events: %v\n", err)
+			return 1
+		}
+		result.EventsRemoved = eventsRemoved
+	}
+
+	// Phase 7: Output summary (FR-014) and warnings
 	formatSummary(stdout, result, opts.DryRun)
 	formatSkippedWarning(stderr, result.FilesSkipped)
 
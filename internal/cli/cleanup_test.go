@@ -1543,3 +1543,94 @@ func TestCleanup_WarningOnSkippedFiles(t *testing.T) {
 	// The expected format when files are skipped is:
 	// "Warning: Skipped N locked file(s)"
 }
+
+// =============================================================================
+// Dead-letter: undeliverable messages move out of mailboxes of missing windows
+// =============================================================================
+
+func TestCleanup_DeadLettersUndeliverableMessages(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	old := time.Now().Add(-48 * time.Hour)
+	if err := mail.WriteAll(tmpDir, "agent-gone", []mail.Message{
+		{ID: "orphan01", From: "agent-1", To: "agent-gone", Message: "pick this up", CreatedAt: old},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	opts := CleanupOptions{
+		StaleHours:         48,
+		DeliveredHours:     2,
+		UndeliverableHours: 24,
+		DryRun:             true,
+		RepoRoot:           tmpDir,
+		SkipTmuxCheck:      true,
+		MockInTmux:         true,
+		MockWindows:        []string{"agent-1"},
+	}
+
+	var stdout, stderr bytes.Buffer
+	if exitCode := Cleanup(&stdout, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Messages to dead-letter: 1") {
+		t.Errorf("Expected dry-run dead-letter count, got: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	opts.DryRun = false
+	if exitCode := Cleanup(&stdout, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Messages dead-lettered: 1") {
+		t.Errorf("Expected dead-letter count, got: %s", stdout.String())
+	}
+
+	dead, err := mail.ListDeadLetters(tmpDir)
+	if err != nil {
+		t.Fatalf("ListDeadLetters failed: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != "orphan01" || dead[0].DeadReason != mail.ReasonUndeliverable {
+		t.Errorf("Unexpected dead letters: %+v", dead)
+	}
+
+	// The emptied mailbox is removed in the same run
+	if _, err := os.Stat(filepath.Join(tmpDir, ".agentmail", "mailboxes", "agent-gone.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("Expected emptied mailbox to be removed, stat err: %v", err)
+	}
+}
+
+func TestCleanup_CountsExpiredSeparately(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := mail.WriteAll(tmpDir, "agent-2", []mail.Message{
+		{ID: "stale001", From: "agent-1", To: "agent-2", Message: "hold off", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(-time.Minute)},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	opts := CleanupOptions{
+		StaleHours:     48,
+		DeliveredHours: 2,
+		DryRun:         true,
+		RepoRoot:       tmpDir,
+		SkipTmuxCheck:  true,
+	}
+
+	var stdout, stderr bytes.Buffer
+	if exitCode := Cleanup(&stdout, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Messages to expire: 1") {
+		t.Errorf("Expected dry-run expired count, got: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	opts.DryRun = false
+	if exitCode := Cleanup(&stdout, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Messages expired: 1") || !strings.Contains(stdout.String(), "Messages dead-lettered: 0") {
+		t.Errorf("Expected expired message counted separately, got: %s", stdout.String())
+	}
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"agentmail/internal/config"
+	"agentmail/internal/daemon"
+	"agentmail/internal/mail"
+)
+
+// ConfigOptions configures the config commands.
+type ConfigOptions struct {
+	RepoRoot string // Repository root (defaults to finding git root)
+}
+
+// resolveRepoRoot returns opts.RepoRoot, or the git root if it is empty.
+func (opts ConfigOptions) resolveRepoRoot(stderr io.Writer) (string, bool) {
+	if opts.RepoRoot != "" {
+		return opts.RepoRoot, true
+	}
+	repoRoot, err := mail.FindGitRoot()
+	if err != nil {
+		fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+		return "", false
+	}
+	return repoRoot, true
+}
+
+// ConfigList implements the agentmail config list command.
+// It prints every setting as a config file line, followed by where its value
+// comes from (default, file, or the overriding environment variable):
+//
+//	notify_debounce = "1m"  # default
+//
+// Problems with the file or an environment override are printed as warnings;
+// the keys they affect are listed with their defaults.
+//
+// Exit Codes:
+// - 0: Settings listed
+// - 1: Invalid store setting or unreadable config file
+func ConfigList(stdout, stderr io.Writer, opts ConfigOptions) int {
+	repoRoot, ok := opts.resolveRepoRoot(stderr)
+	if !ok {
+		return 1
+	}
+
+	settings, err := config.Describe(repoRoot)
+	printConfigProblems(stderr, repoRoot, err)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
+	width := 0
+	for _, s := range settings {
+		width = max(width, len(s.Line()))
+	}
+	for _, s := range settings {
+		fmt.Fprintf(stdout, "%-*s  # %s\n", width, s.Line(), s.Source)
+	}
+	return 0
+}
+
+// printConfigProblems prints the problems of the config that Load works around
+// as warnings. loadErr, the error that stopped loading, is reported by the caller.
+func printConfigProblems(stderr io.Writer, repoRoot string, loadErr error) {
+	for _, problem := range config.Check(repoRoot) {
+		if loadErr == nil || problem.Error() != loadErr.Error() {
+			fmt.Fprintf(stderr, "Warning: %v\n", problem)
+		}
+	}
+}
+
+// ConfigGet implements the agentmail config get command.
+// It prints the effective value of one setting, without quotes.
+//
+// Exit Codes:
+// - 0: Value printed
+// - 1: Missing argument, unknown key, invalid store setting or unreadable config file
+func ConfigGet(args []string, stdout, stderr io.Writer, opts ConfigOptions) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "error: missing required argument: key")
+		fmt.Fprintln(stderr, "usage: agentmail config get <key>")
+		return 1
+	}
+
+	repoRoot, ok := opts.resolveRepoRoot(stderr)
+	if !ok {
+		return 1
+	}
+
+	settings, err := config.Describe(repoRoot)
+	printConfigProblems(stderr, repoRoot, err)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	var value string
+	found := false
+	for _, s := range settings {
+		if s.Key == args[0] {
+			value, found = s.Value, true
+		}
+	}
+	if !found {
+		fmt.Fprintf(stderr, "error: unknown key %q\n", args[0])
+		return 1
+	}
+
+	fmt.Fprintln(stdout, value)
+	return 0
+}
+
+// ConfigSet implements the agentmail config set command.
+// It validates the value and writes it to .agentmail/config.toml. A warning
+// is printed if an environment variable overrides the key, or if a running
+// mailman reads the key only when it starts.
+//
+// Exit Codes:
+// - 0: Value written
+// - 1: Missing argument, unknown key, invalid value, or write failure
+func ConfigSet(args []string, stdout, stderr io.Writer, opts ConfigOptions) int {
+	if len(args) < 2 {
+		fmt.Fprintln(stderr, "error: missing required arguments: key and value")
+		fmt.Fprintln(stderr, "usage: agentmail config set <key> <value>")
+		return 1
+	}
+	key, value := args[0], args[1]
+
+	repoRoot, ok := opts.resolveRepoRoot(stderr)
+	if !ok {
+		return 1
+	}
+
+	// A config file of a newer store may hold settings this build doesn't know
+	if err := mail.CheckWritable(repoRoot); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	if err := config.Set(repoRoot, key, value); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Set %s in %s\n", key, config.File)
+	if env := config.Env(key); os.Getenv(env) != "" {
+		fmt.Fprintf(stderr, "Warning: %s is set and overrides %s\n", env, key)
+	}
+	if config.ReadAtStart(key) {
+		if status, _, err := daemon.CheckExistingDaemon(repoRoot); err == nil && status == daemon.DaemonRunning {
+			fmt.Fprintf(stderr, "Warning: the running mailman reads %s only when it starts; restart it for the change to take effect\n", key)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agentmail/internal/config"
+	"agentmail/internal/daemon"
+	"agentmail/internal/mail"
+)
+
+// hasConfigLine reports whether config list output has the setting line with the given source.
+func hasConfigLine(output, setting, source string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		value, comment, _ := strings.Cut(line, "  # ")
+		if strings.TrimSpace(value) == setting && comment == source {
+			return true
+		}
+	}
+	return false
+}
+
+func TestConfigSetGetList(t *testing.T) {
+	t.Setenv(config.Env("notify_debounce"), "")
+	t.Setenv(config.Env("max_message_size"), "")
+	tmpDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+
+	if exitCode := ConfigSet([]string{"notify_debounce", "90s"}, &stdout, &stderr, ConfigOptions{RepoRoot: tmpDir}); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", exitCode, stderr.String())
+	}
+	if stdout.String() != "Set notify_debounce in .agentmail/config.toml\n" {
+		t.Errorf("Unexpected set output: %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if exitCode := ConfigGet([]string{"notify_debounce"}, &stdout, &stderr, ConfigOptions{RepoRoot: tmpDir}); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", exitCode, stderr.String())
+	}
+	if stdout.String() != "1m30s\n" {
+		t.Errorf("Expected 1m30s, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if exitCode := ConfigList(&stdout, &stderr, ConfigOptions{RepoRoot: tmpDir}); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", exitCode, stderr.String())
+	}
+	output := stdout.String()
+	if !hasConfigLine(output, `notify_debounce = "1m30s"`, "file") {
+		t.Errorf("Expected notify_debounce from file, got:\n%s", output)
+	}
+	if !hasConfigLine(output, "max_message_size = 65536", "default") {
+		t.Errorf("Expected default max_message_size, got:\n%s", output)
+	}
+	if lines := strings.Count(output, "\n"); lines != len(config.Keys()) {
+		t.Errorf("Expected %d lines, got %d", len(config.Keys()), lines)
+	}
+}
+
+func TestConfigSet_WarnsAboutEnvOverride(t *testing.T) {
+	t.Setenv(config.Env("work_protection"), "2h")
+	var stdout, stderr bytes.Buffer
+
+	exitCode := ConfigSet([]string{"work_protection", "30m"}, &stdout, &stderr, ConfigOptions{RepoRoot: t.TempDir()})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", exitCode, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "AGENTMAIL_WORK_PROTECTION is set and overrides work_protection") {
+		t.Errorf("Expected override warning, got %q", stderr.String())
+	}
+}
+
+func TestConfigSet_WarnsAboutRestart(t *testing.T) {
+	t.Setenv(config.Env("fallback_interval"), "")
+	t.Setenv(config.Env("idle_quiet"), "")
+	tmpDir := t.TempDir()
+
+	// Without a running mailman there is nothing to restart
+	var stdout, stderr bytes.Buffer
+	if exitCode := ConfigSet([]string{"fallback_interval", "30s"}, &stdout, &stderr, ConfigOptions{RepoRoot: tmpDir}); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", exitCode, stderr.String())
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected no warning without a mailman, got %q", stderr.String())
+	}
+
+	if err := daemon.WritePID(tmpDir, os.Getpid()); err != nil {
+		t.Fatalf("WritePID failed: %v", err)
+	}
+	stderr.Reset()
+	if exitCode := ConfigSet([]string{"fallback_interval", "20s"}, &stdout, &stderr, ConfigOptions{RepoRoot: tmpDir}); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", exitCode, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "reads fallback_interval only when it starts") {
+		t.Errorf("Expected a restart warning, got %q", stderr.String())
+	}
+
+	// The mailman picks up idle settings on its next check
+	stderr.Reset()
+	if exitCode := ConfigSet([]string{"idle_quiet", "2s"}, &stdout, &stderr, ConfigOptions{RepoRoot: tmpDir}); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", exitCode, stderr.String())
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected no restart warning for idle_quiet, got %q", stderr.String())
+	}
+}
+
+func TestConfig_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name string
+		run  func(stdout, stderr *bytes.Buffer) int
+		want string
+	}{
+		{"get without key", func(stdout, stderr *bytes.Buffer) int {
+			return ConfigGet(nil, stdout, stderr, ConfigOptions{RepoRoot: tmpDir})
+		}, "missing required argument: key"},
+		{"get unknown key", func(stdout, stderr *bytes.Buffer) int {
+			return ConfigGet([]string{"stroe"}, stdout, stderr, ConfigOptions{RepoRoot: tmpDir})
+		}, `unknown key "stroe"`},
+		{"set without value", func(stdout, stderr *bytes.Buffer) int {
+			return ConfigSet([]string{"store"}, stdout, stderr, ConfigOptions{RepoRoot: tmpDir})
+		}, "missing required arguments"},
+		{"set invalid value", func(stdout, stderr *bytes.Buffer) int {
+			return ConfigSet([]string{"max_message_size", "big"}, stdout, stderr, ConfigOptions{RepoRoot: tmpDir})
+		}, "invalid max_message_size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if exitCode := tt.run(&stdout, &stderr); exitCode != 1 {
+				t.Errorf("Expected exit code 1, got %d", exitCode)
+			}
+			if !strings.Contains(stderr.String(), tt.want) {
+				t.Errorf("Expected error containing %q, got %q", tt.want, stderr.String())
+			}
+		})
+	}
+}
+
+func TestConfigSet_RefusesNewerStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".agentmail"), 0750); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, mail.VersionFile), []byte("99\n"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	var stdout, stderr bytes.Buffer
+
+	if exitCode := ConfigSet([]string{"notify_debounce", "90s"}, &stdout, &stderr, ConfigOptions{RepoRoot: tmpDir}); exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "newer agentmail") {
+		t.Errorf("Expected the store version error, got %q", stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, config.File)); !os.IsNotExist(err) {
+		t.Errorf("Expected no config file to be written, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"agentmail/internal/mail"
+	"agentmail/internal/tmux"
+)
+
+// DeadLetterOptions configures the deadletter commands.
+// Used for testing to mock tmux and file system operations.
+type DeadLetterOptions struct {
+	SkipTmuxCheck bool     // Skip tmux environment check (requeue only)
+	MockWindows   []string // Mock list of tmux windows (requeue only)
+	RepoRoot      string   // Repository root (defaults to finding git root)
+}
+
+// resolveRepoRoot returns opts.RepoRoot, or the git root if it is empty.
+func (opts DeadLetterOptions) resolveRepoRoot(stderr io.Writer) (string, bool) {
+	if opts.RepoRoot != "" {
+		return opts.RepoRoot, true
+	}
+	repoRoot, err := mail.FindGitRoot()
+	if err != nil {
+		fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+		return "", false
+	}
+	return repoRoot, true
+}
+
+// DeadLetterList implements the agentmail deadletter list command.
+// It prints every dead-lettered message, oldest first, one per line:
+//
+//	<id>  <from> -> <to>  <reason>  <preview>
+//
+// Exit Codes:
+// - 0: Success (messages listed or none)
+// - 1: Read failure
+func DeadLetterList(stdout, stderr io.Writer, opts DeadLetterOptions) int {
+	repoRoot, ok := opts.resolveRepoRoot(stderr)
+	if !ok {
+		return 1
+	}
+
+	messages, err := mail.ListDeadLetters(repoRoot)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to read dead letters: %v\n", err)
+		return 1
+	}
+
+	if len(messages) == 0 {
+		fmt.Fprintln(stdout, "No dead letters")
+		return 0
+	}
+
+	for _, msg := range messages {
+		fmt.Fprintf(stdout, "%s  %s -> %s  %s  %s\n", msg.ID, msg.From, msg.To, msg.DeadReason, mail.Preview(msg.Message))
+	}
+	return 0
+}
+
+// DeadLetterRequeue implements the agentmail deadletter requeue command.
+// It moves a dead-lettered message into the mailbox of a live window. The
+// target defaults to the original recipient when to is empty.
+//
+// Exit Codes:
+// - 0: Message requeued
+// - 1: Missing argument, unknown message, missing window, or write failure
+// - 2: Not running inside tmux
+func DeadLetterRequeue(args []string, to string, stdout, stderr io.Writer, opts DeadLetterOptions) int {
+	if !opts.SkipTmuxCheck {
+		if !tmux.InTmux() {
+			fmt.Fprintln(stderr, "error: agentmail must run inside a tmux session")
+			return 2
+		}
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "error: missing required argument: message-id")
+		fmt.Fprintln(stderr, "usage: agentmail deadletter requeue <message-id> [--to <window>]")
+		return 1
+	}
+	messageID := args[0]
+
+	repoRoot, ok := opts.resolveRepoRoot(stderr)
+	if !ok {
+		return 1
+	}
+
+	if to == "" {
+		messages, err := mail.ListDeadLetters(repoRoot)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to read dead letters: %v\n", err)
+			return 1
+		}
+		for _, msg := range messages {
+			if msg.ID == messageID {
+				to = msg.To
+				break
+			}
+		}
+		if to == "" {
+			fmt.Fprintf(stderr, "error: message #%s not found in dead letters\n", messageID)
+			return 1
+		}
+	}
+
+	// The target must be a live window, otherwise the message would just die again
+	var exists bool
+	if opts.MockWindows != nil {
+		for _, w := range opts.MockWindows {
+			if w == to {
+				exists = true
+				break
+			}
+		}
+	} else {
+		var err error
+		_, exists, err = mail.LocateMailbox(repoRoot, to)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to check window: %v\n", err)
+			return 1
+		}
+	}
+	if !exists {
+		fmt.Fprintf(stderr, "error: window '%s' not found in tmux session\n", to)
+		return 1
+	}
+
+	if _, err := mail.Requeue(repoRoot, messageID, to); err != nil {
+		if errors.Is(err, mail.ErrMessageNotFound) {
+			fmt.Fprintf(stderr, "error: message #%s not found in dead letters\n", messageID)
+			return 1
+		}
+		fmt.Fprintf(stderr, "error: failed to requeue message: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Message #%s requeued to %s\n", messageID, to)
+	return 0
+}
+
+// DeadLetterPurge implements the agentmail deadletter purge command.
+// It permanently deletes every dead-lettered message.
+//
+// Exit Codes:
+// - 0: Success
+// - 1: Write failure
+func DeadLetterPurge(stdout, stderr io.Writer, opts DeadLetterOptions) int {
+	repoRoot, ok := opts.resolveRepoRoot(stderr)
+	if !ok {
+		return 1
+	}
+
+	purged, err := mail.PurgeDeadLetters(repoRoot)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to purge dead letters: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Purged %d dead letter(s)\n", purged)
+	return 0
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"agentmail/internal/mail"
+)
+
+// createDeadLetter puts a message for a missing window into the dead-letter mailbox.
+func createDeadLetter(t *testing.T, repoRoot string, msg mail.Message) {
+	t.Helper()
+	msg.CreatedAt = time.Now().Add(-48 * time.Hour)
+	if err := mail.WriteAll(repoRoot, msg.To, []mail.Message{msg}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+	if _, err := mail.DeadLetterSweep(repoRoot, []string{}, time.Hour, 0); err != nil {
+		t.Fatalf("DeadLetterSweep failed: %v", err)
+	}
+}
+
+func TestDeadLetterList_Empty(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := DeadLetterList(&stdout, &stderr, DeadLetterOptions{RepoRoot: t.TempDir()})
+
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d", exitCode)
+	}
+	if stdout.String() != "No dead letters\n" {
+		t.Errorf("Expected 'No dead letters', got %q", stdout.String())
+	}
+}
+
+func TestDeadLetterList_ShowsMessages(t *testing.T) {
+	tmpDir := t.TempDir()
+	createDeadLetter(t, tmpDir, mail.Message{ID: "orphan01", From: "agent-1", To: "agent-gone", Message: "run the migration\nthen report back"})
+
+	var stdout, stderr bytes.Buffer
+	exitCode := DeadLetterList(&stdout, &stderr, DeadLetterOptions{RepoRoot: tmpDir})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	expected := "orphan01  agent-1 -> agent-gone  undeliverable  run the migration\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestDeadLetterRequeue_ToLiveWindow(t *testing.T) {
+	tmpDir := t.TempDir()
+	createDeadLetter(t, tmpDir, mail.Message{ID: "orphan01", From: "agent-1", To: "agent-gone", Message: "task"})
+
+	var stdout, stderr bytes.Buffer
+	exitCode := DeadLetterRequeue([]string{"orphan01"}, "agent-2", &stdout, &stderr, DeadLetterOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		RepoRoot:      tmpDir,
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if stdout.String() != "Message #orphan01 requeued to agent-2\n" {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+
+	unread, err := mail.FindUnread(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("FindUnread failed: %v", err)
+	}
+	if len(unread) != 1 || unread[0].ID != "orphan01" {
+		t.Errorf("Expected requeued message in agent-2 mailbox, got %+v", unread)
+	}
+}
+
+func TestDeadLetterRequeue_DefaultsToMissingOriginalRecipient(t *testing.T) {
+	tmpDir := t.TempDir()
+	createDeadLetter(t, tmpDir, mail.Message{ID: "orphan01", From: "agent-1", To: "agent-gone", Message: "task"})
+
+	var stdout, stderr bytes.Buffer
+	exitCode := DeadLetterRequeue([]string{"orphan01"}, "", &stdout, &stderr, DeadLetterOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		RepoRoot:      tmpDir,
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "window 'agent-gone' not found") {
+		t.Errorf("Expected missing window error, got: %q", stderr.String())
+	}
+
+	// The message stays dead-lettered
+	dead, _ := mail.ListDeadLetters(tmpDir)
+	if len(dead) != 1 {
+		t.Errorf("Expected message to remain dead-lettered, got %d", len(dead))
+	}
+}
+
+func TestDeadLetterRequeue_UnknownMessage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := DeadLetterRequeue([]string{"missing1"}, "agent-2", &stdout, &stderr, DeadLetterOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-2"},
+		RepoRoot:      t.TempDir(),
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "message #missing1 not found in dead letters") {
+		t.Errorf("Expected not found error, got: %q", stderr.String())
+	}
+}
+
+func TestDeadLetterPurge(t *testing.T) {
+	tmpDir := t.TempDir()
+	createDeadLetter(t, tmpDir, mail.Message{ID: "orphan01", From: "agent-1", To: "agent-gone", Message: "task"})
+
+	var stdout, stderr bytes.Buffer
+	exitCode := DeadLetterPurge(&stdout, &stderr, DeadLetterOptions{RepoRoot: tmpDir})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if stdout.String() != "Purged 1 dead letter(s)\n" {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"agentmail/internal/mail"
+)
+
+// DoctorOptions configures the Doctor command.
+type DoctorOptions struct {
+	Repair   bool   // Quarantine bad records and rewrite the clean ones
+	RepoRoot string // Repository root (defaults to finding git root)
+}
+
+// Doctor implements the agentmail doctor command.
+// It scans every mailbox, the dead-letter mailbox and the recipient state for
+// records that cannot be decoded and prints one line per record:
+//
+//	<file>:<offset>: <reason>
+//
+// With Repair set, the bad records are moved to .agentmail/quarantine/ and the
+// clean records rewritten.
+//
+// Exit Codes:
+// - 0: No corruption found, or corruption repaired
+// - 1: Corruption found without --repair, or scan/repair failure
+func Doctor(stdout, stderr io.Writer, opts DoctorOptions) int {
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return 1
+		}
+	}
+
+	bad, err := mail.RepairStore(repoRoot, opts.Repair)
+	for _, record := range bad {
+		fmt.Fprintf(stdout, "%s:%d: %s\n", record.File, record.Offset, record.Reason)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to check store: %v\n", err)
+		return 1
+	}
+
+	if len(bad) == 0 {
+		fmt.Fprintln(stdout, "No corruption found")
+		return 0
+	}
+	if opts.Repair {
+		fmt.Fprintf(stdout, "Quarantined %d corrupt record(s) to %s/\n", len(bad), mail.QuarantineDir)
+		return 0
+	}
+	fmt.Fprintf(stdout, "Found %d corrupt record(s); run \"agentmail doctor --repair\" to quarantine them\n", len(bad))
+	return 1
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agentmail/internal/mail"
+)
+
+func TestDoctor_Clean(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = mail.Append(tmpDir, mail.Message{ID: "a", From: "agent-1", To: "agent-2", Message: "hi"})
+
+	var stdout, stderr bytes.Buffer
+	exitCode := Doctor(&stdout, &stderr, DoctorOptions{RepoRoot: tmpDir})
+
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if stdout.String() != "No corruption found\n" {
+		t.Errorf("Expected 'No corruption found', got %q", stdout.String())
+	}
+}
+
+func TestDoctor_ReportsAndRepairs(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = mail.Append(tmpDir, mail.Message{ID: "a", From: "agent-1", To: "agent-2", Message: "hi"})
+	filePath := filepath.Join(tmpDir, mail.MailDir, "agent-2.jsonl")
+	data, _ := os.ReadFile(filePath)
+	if err := os.WriteFile(filePath, append(data, []byte("{torn\n")...), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	location := fmt.Sprintf("%s:%d: ", filepath.Join(mail.MailDir, "agent-2.jsonl"), len(data))
+
+	var stdout, stderr bytes.Buffer
+	exitCode := Doctor(&stdout, &stderr, DoctorOptions{RepoRoot: tmpDir})
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1 for unrepaired corruption, got %d", exitCode)
+	}
+	if !strings.HasPrefix(stdout.String(), location) || !strings.Contains(stdout.String(), "Found 1 corrupt record(s)") {
+		t.Errorf("Unexpected report: %q", stdout.String())
+	}
+
+	stdout.Reset()
+	exitCode = Doctor(&stdout, &stderr, DoctorOptions{RepoRoot: tmpDir, Repair: true})
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0 after repair, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Quarantined 1 corrupt record(s) to .agentmail/quarantine/") {
+		t.Errorf("Unexpected repair output: %q", stdout.String())
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, mail.QuarantineDir, "agent-2.bad")); err != nil {
+		t.Errorf("Expected quarantine file: %v", err)
+	}
+
+	stdout.Reset()
+	if exitCode := Doctor(&stdout, &stderr, DoctorOptions{RepoRoot: tmpDir}); exitCode != 0 {
+		t.Errorf("Expected a clean store after repair, got exit code %d: %s", exitCode, stdout.String())
+	}
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"agentmail/internal/mail"
+)
+
+// ExportOptions configures the Export command behavior.
+type ExportOptions struct {
+	RepoRoot string        // Repository root (defaults to finding git root)
+	Format   string        // mbox, json or markdown (--format, default json)
+	Thread   string        // Only export the conversation containing this message ID (--thread)
+	Since    time.Duration // Only export messages sent within this long (--since, 0 = no limit)
+}
+
+// Export implements the agentmail export command.
+// It writes every mailbox, merged into one chronological transcript, to stdout
+// as an mbox file, a JSON document (readable by import) or Markdown.
+//
+// Exit Codes:
+// - 0: Transcript written
+// - 1: Unknown format, unknown thread, or read/write failure
+func Export(stdout, stderr io.Writer, opts ExportOptions) int {
+	format := opts.Format
+	if format == "" {
+		format = mail.FormatJSON
+	}
+	if format != mail.FormatJSON && format != mail.FormatMbox && format != mail.FormatMarkdown {
+		fmt.Fprintf(stderr, "error: %v\n", mail.ErrUnknownFormat)
+		return 1
+	}
+
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return 1
+		}
+	}
+
+	filter := mail.TranscriptFilter{Thread: opts.Thread}
+	if opts.Since > 0 {
+		filter.Since = time.Now().Add(-opts.Since)
+	}
+
+	messages, err := mail.Transcript(repoRoot, filter)
+	if err != nil {
+		if errors.Is(err, mail.ErrMessageNotFound) {
+			fmt.Fprintf(stderr, "error: message #%s not found\n", opts.Thread)
+			return 1
+		}
+		fmt.Fprintf(stderr, "error: failed to read messages: %v\n", err)
+		return 1
+	}
+
+	if err := mail.WriteExport(stdout, format, messages); err != nil {
+		fmt.Fprintf(stderr, "error: failed to write export: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// ImportOptions configures the Import command behavior.
+type ImportOptions struct {
+	RepoRoot string    // Repository root (defaults to finding git root)
+	Stdin    io.Reader // Input when no file (or "-") is given (defaults to os.Stdin)
+}
+
+// Import implements the agentmail import command.
+// It loads a JSON export from the file in args[0] (or stdin) into the store,
+// preserving message IDs and skipping messages already in their mailbox.
+//
+// Exit Codes:
+// - 0: Export imported
+// - 1: Unreadable or invalid export, or write failure
+func Import(args []string, stdout, stderr io.Writer, opts ImportOptions) int {
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return 1
+		}
+	}
+
+	input := opts.Stdin
+	if input == nil {
+		input = os.Stdin
+	}
+	if len(args) > 0 && args[0] != "-" {
+		file, err := os.Open(args[0]) // #nosec G304 - the user names the file to import
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to open export: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		input = file
+	}
+
+	export, err := mail.ReadExport(input)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
+	imported, skipped, err := mail.Import(repoRoot, export.Messages)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to import messages (%d imported before the failure): %v\n", imported, err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Imported %d message(s), skipped %d already present\n", imported, skipped)
+	return 0
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"agentmail/internal/mail"
+)
+
+func TestExportImport(t *testing.T) {
+	srcDir := t.TempDir()
+	created := time.Date(2026, 1, 14, 9, 0, 0, 0, time.UTC)
+	_ = mail.WriteAll(srcDir, "agent-2", []mail.Message{
+		{ID: "q1", From: "agent-1", To: "agent-2", Message: "Ready?", CreatedAt: created},
+	})
+
+	var stdout, stderr bytes.Buffer
+	if exitCode := Export(&stdout, &stderr, ExportOptions{RepoRoot: srcDir}); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"agentmail_export": 1`) {
+		t.Errorf("Expected a JSON export by default, got:\n%s", stdout.String())
+	}
+
+	exportPath := filepath.Join(t.TempDir(), "mail.json")
+	if err := os.WriteFile(exportPath, stdout.Bytes(), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	dstDir := t.TempDir()
+	stdout.Reset()
+	if exitCode := Import([]string{exportPath}, &stdout, &stderr, ImportOptions{RepoRoot: dstDir}); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if stdout.String() != "Imported 1 message(s), skipped 0 already present\n" {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+
+	// Re-importing from stdin skips the duplicate
+	data, _ := os.ReadFile(exportPath)
+	stdout.Reset()
+	if exitCode := Import(nil, &stdout, &stderr, ImportOptions{RepoRoot: dstDir, Stdin: bytes.NewReader(data)}); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if stdout.String() != "Imported 0 message(s), skipped 1 already present\n" {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+	if messages, _ := mail.ReadAll(dstDir, "agent-2"); len(messages) != 1 || messages[0].ID != "q1" {
+		t.Errorf("Expected q1 imported once, got %+v", messages)
+	}
+}
+
+func TestExport_Formats(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = mail.WriteAll(tmpDir, "agent-2", []mail.Message{
+		{ID: "q1", From: "agent-1", To: "agent-2", Message: "Ready?", CreatedAt: time.Now()},
+	})
+
+	var stdout, stderr bytes.Buffer
+	if exitCode := Export(&stdout, &stderr, ExportOptions{RepoRoot: tmpDir, Format: "mbox"}); exitCode != 0 || !strings.HasPrefix(stdout.String(), "From agent-1@agentmail ") {
+		t.Errorf("Expected mbox output, got %d:\n%s", exitCode, stdout.String())
+	}
+
+	stdout.Reset()
+	if exitCode := Export(&stdout, &stderr, ExportOptions{RepoRoot: tmpDir, Format: "markdown", Since: time.Hour}); exitCode != 0 || !strings.Contains(stdout.String(), "## agent-1 → agent-2") {
+		t.Errorf("Expected markdown output, got %d:\n%s", exitCode, stdout.String())
+	}
+
+	if exitCode := Export(&stdout, &stderr, ExportOptions{RepoRoot: tmpDir, Format: "pdf"}); exitCode != 1 || !strings.Contains(stderr.String(), "unknown export format") {
+		t.Errorf("Expected unknown format error, got %d: %q", exitCode, stderr.String())
+	}
+
+	stderr.Reset()
+	if exitCode := Export(&stdout, &stderr, ExportOptions{RepoRoot: tmpDir, Thread: "missing"}); exitCode != 1 || !strings.Contains(stderr.String(), "message #missing not found") {
+		t.Errorf("Expected thread not found error, got %d: %q", exitCode, stderr.String())
+	}
+}
+
+func TestImport_InvalidFile(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := Import(nil, &stdout, &stderr, ImportOptions{RepoRoot: t.TempDir(), Stdin: strings.NewReader("garbage")})
+	if exitCode != 1 || !strings.Contains(stderr.String(), "error: invalid JSON export") {
+		t.Errorf("Expected invalid export error, got %d: %q", exitCode, stderr.String())
+	}
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"agentmail/internal/mail"
+	"agentmail/internal/tmux"
+)
+
+// InboxOptions configures the Inbox command behavior.
+// Used for testing to mock tmux and file system operations.
+type InboxOptions struct {
+	SkipTmuxCheck bool          // Skip tmux environment check
+	MockReceiver  string        // Mock receiver window name
+	RepoRoot      string        // Repository root (defaults to finding git root)
+	All           bool          // List read and unread messages (--all, the default)
+	Unread        bool          // Only list messages that are not read yet (--unread)
+	From          string        // Only list messages from this sender (--from)
+	Since         time.Duration // Only list messages delivered within this long (--since, 0 = no limit)
+	Limit         int           // List at most this many messages (--limit, 0 = no limit)
+}
+
+// MailboxOptions configures the read and peek commands.
+// Used for testing to mock tmux and file system operations.
+type MailboxOptions struct {
+	SkipTmuxCheck bool   // Skip tmux environment check
+	MockReceiver  string // Mock receiver window name
+	RepoRoot      string // Repository root (defaults to finding git root)
+}
+
+// resolveMailbox returns the caller's window and repository root, printing an
+// error and returning a non-zero exit code if either cannot be determined.
+func resolveMailbox(stderr io.Writer, opts MailboxOptions) (string, string, int) {
+	if !opts.SkipTmuxCheck {
+		if !tmux.InTmux() {
+			fmt.Fprintln(stderr, "error: agentmail must run inside a tmux session")
+			return "", "", 2
+		}
+	}
+
+	receiver := opts.MockReceiver
+	if receiver == "" {
+		var err error
+		receiver, err = mail.CurrentMailbox(opts.RepoRoot)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to get current window: %v\n", err)
+			return "", "", 1
+		}
+	}
+
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return "", "", 1
+		}
+	}
+	return receiver, repoRoot, 0
+}
+
+// Inbox implements the agentmail inbox command.
+// It lists the messages in your mailbox, newest first, one per line,
+// without marking anything as read:
+//
+//	<id>  <from>  <age>  <state>  <subject, or first line of the message>
+//
+// The state is unread, read, leased or expired.
+//
+// Exit Codes:
+// - 0: Messages listed (or none)
+// - 1: Conflicting flags or read failure
+// - 2: Not running inside tmux
+func Inbox(stdout, stderr io.Writer, opts InboxOptions) int {
+	if opts.All && opts.Unread {
+		fmt.Fprintln(stderr, "error: --all and --unread cannot be combined")
+		return 1
+	}
+	if opts.Limit < 0 {
+		fmt.Fprintln(stderr, "error: --limit must not be negative")
+		return 1
+	}
+
+	receiver, repoRoot, exitCode := resolveMailbox(stderr, MailboxOptions{
+		SkipTmuxCheck: opts.SkipTmuxCheck,
+		MockReceiver:  opts.MockReceiver,
+		RepoRoot:      opts.RepoRoot,
+	})
+	if exitCode != 0 {
+		return exitCode
+	}
+
+	now := time.Now()
+	filter := mail.InboxFilter{
+		UnreadOnly: opts.Unread,
+		From:       opts.From,
+		Limit:      opts.Limit,
+	}
+	if opts.Since > 0 {
+		filter.Since = now.Add(-opts.Since)
+	}
+
+	messages, err := mail.ListInbox(repoRoot, receiver, filter)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to read messages: %v\n", err)
+		return 1
+	}
+
+	if len(messages) == 0 {
+		if opts.Unread {
+			fmt.Fprintln(stdout, "No unread messages")
+		} else {
+			fmt.Fprintln(stdout, "No messages")
+		}
+		return 0
+	}
+
+	for _, msg := range messages {
+		fmt.Fprintf(stdout, "%s  %s  %s  %s  %s\n", msg.ID, msg.From, formatAge(now.Sub(msg.DeliveredAt())), msg.State(now), msg.Summary())
+	}
+	return 0
+}
+
+// formatAge formats how long ago a message was delivered in its largest whole unit: 45s, 12m, 3h, 2d.
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	}
+}
+
+// Read implements the agentmail read command.
+// It prints any message in your mailbox by ID, read or not, without changing it:
+// an unread message stays unread and a leased message stays leased.
+//
+// Format:
+//
+//	From: <sender>
+//	To: <recipient>
+//	Date: <RFC 3339 delivery time>
+//	State: <unread|read|leased|expired>
+//	ID: <id>
+//	Subject: <subject> ... (the remaining receive format lines)
+//
+//	<message>
+//
+// Exit Codes:
+// - 0: Message printed
+// - 1: Missing argument, unknown message, or read failure
+// - 2: Not running inside tmux
+func Read(args []string, stdout, stderr io.Writer, opts MailboxOptions) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "error: missing required argument: message-id")
+		fmt.Fprintln(stderr, "usage: agentmail read <message-id>")
+		return 1
+	}
+
+	receiver, repoRoot, exitCode := resolveMailbox(stderr, opts)
+	if exitCode != 0 {
+		return exitCode
+	}
+
+	msg, err := mail.FindInMailbox(repoRoot, receiver, args[0])
+	if err != nil {
+		if errors.Is(err, mail.ErrMessageNotFound) {
+			fmt.Fprintf(stderr, "error: message #%s not found in your mailbox\n", args[0])
+			return 1
+		}
+		fmt.Fprintf(stderr, "error: failed to read messages: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "From: %s\n", msg.From)
+	fmt.Fprintf(stdout, "To: %s\n", msg.To)
+	fmt.Fprintf(stdout, "Date: %s\n", msg.DeliveredAt().Format(time.RFC3339))
+	fmt.Fprintf(stdout, "State: %s\n", msg.State(time.Now()))
+	writeMessageBody(stdout, msg)
+	return 0
+}
+
+// Peek implements the agentmail peek command.
+// It prints the message receive would return next, in receive format,
+// without marking it as read or leasing it.
+//
+// Exit Codes:
+// - 0: Message printed, or "No unread messages"
+// - 1: Read failure
+// - 2: Not running inside tmux
+func Peek(stdout, stderr io.Writer, opts MailboxOptions) int {
+	receiver, repoRoot, exitCode := resolveMailbox(stderr, opts)
+	if exitCode != 0 {
+		return exitCode
+	}
+
+	unread, err := mail.FindUnread(repoRoot, receiver)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to read messages: %v\n", err)
+		return 1
+	}
+	if len(unread) == 0 {
+		fmt.Fprintln(stdout, "No unread messages")
+		return 0
+	}
+
+	writeMessage(stdout, unread[0])
+	return 0
+}
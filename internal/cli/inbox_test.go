@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"agentmail/internal/mail"
+)
+
+func writeInboxMessages(t *testing.T, repoRoot string) {
+	t.Helper()
+	now := time.Now()
+	messages := []mail.Message{
+		{ID: "old00001", From: "agent-1", To: "agent-2", Message: "deploy done\ndetails", ReadFlag: true, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "new00001", From: "agent-3", To: "agent-2", Subject: "Review", Message: "please review", CreatedAt: now.Add(-5 * time.Minute)},
+	}
+	if err := mail.WriteAll(repoRoot, "agent-2", messages); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+}
+
+func TestInbox_ListsNewestFirst(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeInboxMessages(t, tmpDir)
+
+	var stdout, stderr bytes.Buffer
+	exitCode := Inbox(&stdout, &stderr, InboxOptions{SkipTmuxCheck: true, MockReceiver: "agent-2", RepoRoot: tmpDir})
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	expected := "new00001  agent-3  5m  unread  Review\n" +
+		"old00001  agent-1  3h  read  deploy done\n"
+	if stdout.String() != expected {
+		t.Errorf("Unexpected output:\n%s", stdout.String())
+	}
+
+	// Listing does not consume mail
+	if unread, _ := mail.FindUnread(tmpDir, "agent-2"); len(unread) != 1 {
+		t.Errorf("Expected the message still unread, got %+v", unread)
+	}
+}
+
+func TestInbox_Filters(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeInboxMessages(t, tmpDir)
+
+	tests := []struct {
+		name string
+		opts InboxOptions
+		want string
+	}{
+		{"unread", InboxOptions{Unread: true}, "new00001"},
+		{"from", InboxOptions{From: "agent-1"}, "old00001"},
+		{"since", InboxOptions{Since: time.Hour}, "new00001"},
+		{"limit", InboxOptions{Limit: 1}, "new00001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.SkipTmuxCheck = true
+			tt.opts.MockReceiver = "agent-2"
+			tt.opts.RepoRoot = tmpDir
+
+			var stdout, stderr bytes.Buffer
+			if exitCode := Inbox(&stdout, &stderr, tt.opts); exitCode != 0 {
+				t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+			}
+			lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+			if len(lines) != 1 || !strings.HasPrefix(lines[0], tt.want+"  ") {
+				t.Errorf("Expected only %s, got:\n%s", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestInbox_EmptyAndConflictingFlags(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	exitCode := Inbox(&stdout, &stderr, InboxOptions{SkipTmuxCheck: true, MockReceiver: "agent-2", RepoRoot: tmpDir, Unread: true})
+	if exitCode != 0 || stdout.String() != "No unread messages\n" {
+		t.Errorf("Expected 'No unread messages', got %d: %q", exitCode, stdout.String())
+	}
+
+	stdout.Reset()
+	exitCode = Inbox(&stdout, &stderr, InboxOptions{SkipTmuxCheck: true, MockReceiver: "agent-2", RepoRoot: tmpDir, All: true, Unread: true})
+	if exitCode != 1 || !strings.Contains(stderr.String(), "--all and --unread cannot be combined") {
+		t.Errorf("Expected conflicting flags error, got %d: %q", exitCode, stderr.String())
+	}
+}
+
+func TestRead_ShowsWithoutMarkingRead(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeInboxMessages(t, tmpDir)
+	opts := MailboxOptions{SkipTmuxCheck: true, MockReceiver: "agent-2", RepoRoot: tmpDir}
+
+	var stdout, stderr bytes.Buffer
+	exitCode := Read([]string{"new00001"}, &stdout, &stderr, opts)
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.HasPrefix(out, "From: agent-3\nTo: agent-2\nDate: ") ||
+		!strings.Contains(out, "State: unread\nID: new00001\nSubject: Review\n\nplease review") {
+		t.Errorf("Unexpected output:\n%s", out)
+	}
+	if unread, _ := mail.FindUnread(tmpDir, "agent-2"); len(unread) != 1 || unread[0].ID != "new00001" {
+		t.Errorf("Expected the message to stay unread, got %+v", unread)
+	}
+
+	// Already read messages can be shown again
+	stdout.Reset()
+	if exitCode := Read([]string{"old00001"}, &stdout, &stderr, opts); exitCode != 0 || !strings.Contains(stdout.String(), "State: read\n") {
+		t.Errorf("Expected read message shown, got %d:\n%s", exitCode, stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if exitCode := Read([]string{"missing1"}, &stdout, &stderr, opts); exitCode != 1 || !strings.Contains(stderr.String(), "message #missing1 not found in your mailbox") {
+		t.Errorf("Expected not found error, got %d: %q", exitCode, stderr.String())
+	}
+
+	stderr.Reset()
+	if exitCode := Read(nil, &stdout, &stderr, opts); exitCode != 1 || !strings.Contains(stderr.String(), "missing required argument") {
+		t.Errorf("Expected missing argument error, got %d: %q", exitCode, stderr.String())
+	}
+}
+
+func TestPeek_DoesNotMarkRead(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeInboxMessages(t, tmpDir)
+	opts := MailboxOptions{SkipTmuxCheck: true, MockReceiver: "agent-2", RepoRoot: tmpDir}
+
+	for i := 0; i < 2; i++ {
+		var stdout, stderr bytes.Buffer
+		if exitCode := Peek(&stdout, &stderr, opts); exitCode != 0 {
+			t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+		}
+		if stdout.String() != "From: agent-3\nID: new00001\nSubject: Review\n\nplease review" {
+			t.Errorf("Peek %d: unexpected output %q", i, stdout.String())
+		}
+	}
+
+	_ = mail.MarkAsRead(tmpDir, "agent-2", "new00001")
+	var stdout, stderr bytes.Buffer
+	if exitCode := Peek(&stdout, &stderr, opts); exitCode != 0 || stdout.String() != "No unread messages\n" {
+		t.Errorf("Expected 'No unread messages', got %d: %q", exitCode, stdout.String())
+	}
+}
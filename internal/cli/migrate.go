@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"agentmail/internal/mail"
+)
+
+// MigrateOptions configures the Migrate command.
+type MigrateOptions struct {
+	DryRun   bool   // Print the planned changes without writing
+	RepoRoot string // Repository root (defaults to finding git root)
+}
+
+// Migrate implements the agentmail migrate command.
+// It upgrades the store to the format version of this build, after copying it to
+// .agentmail/backups/. With DryRun set it only prints the planned changes.
+//
+// Exit Codes:
+// - 0: Store migrated, already up to date, or dry run printed
+// - 1: Store newer than this build, backup or migration failure
+func Migrate(stdout, stderr io.Writer, opts MigrateOptions) int {
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return 1
+		}
+	}
+
+	result, err := mail.Migrate(repoRoot, opts.DryRun)
+	if result.Backup != "" {
+		fmt.Fprintf(stdout, "Backup written to %s\n", result.Backup)
+	}
+	verb := "Migrated"
+	if opts.DryRun {
+		verb = "Would migrate"
+	}
+	for i, step := range result.Steps {
+		if err != nil && i == len(result.Steps)-1 {
+			verb = "Failed to migrate" // Changes listed below were made before the failure
+		}
+		fmt.Fprintf(stdout, "%s %d -> %d: %s\n", verb, step.From, step.From+1, step.Description)
+		for _, change := range step.Changes {
+			fmt.Fprintf(stdout, "  %s\n", change)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
+	switch {
+	case len(result.Steps) == 0:
+		fmt.Fprintf(stdout, "Store is up to date (version %d)\n", result.To)
+	case opts.DryRun:
+		fmt.Fprintln(stdout, "Dry run: nothing was changed")
+	default:
+		fmt.Fprintf(stdout, "Store is now at version %d\n", result.To)
+	}
+	return 0
+}
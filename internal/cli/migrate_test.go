@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agentmail/internal/mail"
+)
+
+func TestMigrate_UpToDate(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = mail.Append(tmpDir, mail.Message{ID: "a", From: "agent-1", To: "agent-2", Message: "hi"})
+
+	var stdout, stderr bytes.Buffer
+	exitCode := Migrate(&stdout, &stderr, MigrateOptions{RepoRoot: tmpDir})
+
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if stdout.String() != "Store is up to date (version 1)\n" {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+}
+
+func TestMigrate_LegacyStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	legacyDir := filepath.Join(tmpDir, ".git", "mail")
+	_ = os.MkdirAll(legacyDir, 0750)
+	if err := os.WriteFile(filepath.Join(legacyDir, "agent-2.jsonl"), []byte(`{"id":"a","from":"agent-1","message":"hi"}`+"\n"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	exitCode := Migrate(&stdout, &stderr, MigrateOptions{RepoRoot: tmpDir, DryRun: true})
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	expected := "Would migrate 0 -> 1: move mailboxes and recipient state from .git/ to .agentmail/\n" +
+		"  .git/mail/agent-2.jsonl: 1 message(s) -> mailbox agent-2\n" +
+		"Dry run: nothing was changed\n"
+	if stdout.String() != expected {
+		t.Errorf("Unexpected dry run output:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	exitCode = Migrate(&stdout, &stderr, MigrateOptions{RepoRoot: tmpDir})
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.HasPrefix(out, "Backup written to .agentmail/backups/v0-") ||
+		!strings.Contains(out, "Migrated 0 -> 1:") || !strings.HasSuffix(out, "Store is now at version 1\n") {
+		t.Errorf("Unexpected output:\n%s", out)
+	}
+	if messages, _ := mail.ReadAll(tmpDir, "agent-2"); len(messages) != 1 {
+		t.Errorf("Expected the legacy message migrated, got %+v", messages)
+	}
+}
+
+func TestMigrate_NewerStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = mail.Append(tmpDir, mail.Message{ID: "a", From: "agent-1", To: "agent-2", Message: "hi"})
+	if err := os.WriteFile(filepath.Join(tmpDir, mail.VersionFile), []byte("99\n"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	exitCode := Migrate(&stdout, &stderr, MigrateOptions{RepoRoot: tmpDir})
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "error: store was written by a newer agentmail") {
+		t.Errorf("Unexpected stderr: %q", stderr.String())
+	}
+}
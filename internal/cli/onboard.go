@@ -53,6 +53,16 @@ func Onboard(stdout, stderr io.Writer, opts OnboardOptions) int {
 	fmt.Fprintln(stdout, "agentmail receive")
 	fmt.Fprintln(stdout, "```")
 	fmt.Fprintln(stdout, "Returns \"No unread messages\" if mailbox is empty.")
+	fmt.Fprintln(stdout, "Use `agentmail peek` to look at the next message without marking it read, and `agentmail inbox` to review earlier messages.")
+	fmt.Fprintln(stdout)
+
+	// Reply command
+	fmt.Fprintln(stdout, "**reply** - Answer a message; the reply goes back to its sender in the same thread")
+	fmt.Fprintln(stdout, "```")
+	fmt.Fprintln(stdout, "agentmail reply <message-id> \"<message>\"")
+	fmt.Fprintln(stdout, "```")
+	fmt.Fprintln(stdout, "Use `agentmail thread <message-id>` to see the whole conversation.")
+	fmt.Fprintln(stdout, "Always reply to messages marked `Expects-Reply: yes`; the sender is waiting.")
 	fmt.Fprintln(stdout)
 
 	// Recipients command
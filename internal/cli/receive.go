@@ -1,10 +1,12 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
 
+	"agentmail/internal/daemon"
 	"agentmail/internal/mail"
 	"agentmail/internal/tmux"
 )
@@ -12,11 +14,15 @@ import (
 // ReceiveOptions configures the Receive command behavior.
 // Used for testing to mock tmux and file system operations.
 type ReceiveOptions struct {
-	SkipTmuxCheck bool     // Skip tmux environment check
-	MockWindows   []string // Mock list of tmux windows
-	MockReceiver  string   // Mock receiver window name
-	RepoRoot      string   // Repository root (defaults to current directory)
-	HookMode      bool     // Enable hook mode for Claude Code integration
+	SkipTmuxCheck bool          // Skip tmux environment check
+	MockWindows   []string      // Mock list of tmux windows
+	MockReceiver  string        // Mock receiver window name
+	RepoRoot      string        // Repository root (defaults to current directory)
+	HookMode      bool          // Enable hook mode for Claude Code integration
+	Wait          bool          // Block until a message arrives (--wait)
+	WaitTimeout   time.Duration // Maximum time to wait (0 = wait indefinitely)
+	Lease         time.Duration // Lease the message instead of marking it read (0 = mark read)
+	Headers       []string      // Only receive messages carrying all of these "key=value" headers
 }
 
 // Receive implements the agentmail receive command.
@@ -50,7 +56,7 @@ func Receive(stdout, stderr io.Writer, opts ReceiveOptions) int {
 		receiver = opts.MockReceiver
 	} else {
 		var err error
-		receiver, err = tmux.GetCurrentWindow()
+		receiver, err = mail.CurrentMailbox(opts.RepoRoot)
 		if err != nil {
 			// FR-004a: Hook mode exits silently on errors
 			if opts.HookMode {
@@ -72,7 +78,7 @@ func Receive(stdout, stderr io.Writer, opts ReceiveOptions) int {
 		}
 	} else {
 		var err error
-		receiverExists, err = tmux.WindowExists(receiver)
+		_, receiverExists, err = mail.LocateMailbox(opts.RepoRoot, receiver)
 		if err != nil {
 			// FR-004a: Hook mode exits silently on errors
 			if opts.HookMode {
@@ -107,6 +113,30 @@ func Receive(stdout, stderr io.Writer, opts ReceiveOptions) int {
 		}
 	}
 
+	filter, err := mail.ParseHeaders(opts.Headers)
+	if err != nil {
+		if opts.HookMode {
+			return 0
+		}
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
+	// Block until matching mail arrives; on timeout fall through to the normal empty-mailbox path
+	if opts.Wait {
+		hasMail := func() bool {
+			unread, err := mail.FindUnread(repoRoot, receiver)
+			return err == nil && len(mail.FilterByHeaders(unread, filter)) > 0
+		}
+		if _, err := daemon.WaitFor(context.Background(), repoRoot, opts.WaitTimeout, hasMail); err != nil {
+			if opts.HookMode {
+				return 0
+			}
+			fmt.Fprintf(stderr, "error: failed to wait for messages: %v\n", err)
+			return 1
+		}
+	}
+
 	// T036: Find unread messages for receiver
 	unread, err := mail.FindUnread(repoRoot, receiver)
 	if err != nil {
@@ -117,6 +147,7 @@ func Receive(stdout, stderr io.Writer, opts ReceiveOptions) int {
 		fmt.Fprintf(stderr, "error: failed to read messages: %v\n", err)
 		return 1
 	}
+	unread = mail.FilterByHeaders(unread, filter)
 
 	// T037: Handle no unread messages
 	if len(unread) == 0 {
@@ -131,8 +162,18 @@ func Receive(stdout, stderr io.Writer, opts ReceiveOptions) int {
 	// Get oldest unread message (FIFO - first in list)
 	msg := unread[0]
 
-	// FR-001c: Mark as read
-	if err := mail.MarkAsRead(repoRoot, receiver, msg.ID); err != nil {
+	if opts.Lease > 0 {
+		// Keep the message in-flight until it is acked; it is redelivered if the lease expires
+		msg, err = mail.Lease(repoRoot, receiver, msg.ID, opts.Lease)
+		if err != nil {
+			if opts.HookMode {
+				return 0
+			}
+			fmt.Fprintf(stderr, "error: failed to lease message: %v\n", err)
+			return 1
+		}
+	} else if err := mail.MarkAsRead(repoRoot, receiver, msg.ID); err != nil {
+		// FR-001c: Mark as read
 		// FR-004a: Hook mode exits silently on errors
 		if opts.HookMode {
 			return 0
@@ -153,24 +194,59 @@ func Receive(stdout, stderr io.Writer, opts ReceiveOptions) int {
 	// FR-001a: Hook mode prefixes with "You got new mail\n"
 	if opts.HookMode {
 		fmt.Fprintln(stderr, "You got new mail")
-		fmt.Fprintf(stderr, "From: %s\n", msg.From)
-		fmt.Fprintf(stderr, "ID: %s\n", msg.ID)
-		fmt.Fprintln(stderr)
-		fmt.Fprint(stderr, msg.Message)
+		writeMessage(stderr, msg)
 		// FR-001b: Hook mode exits with code 2 when messages exist
 		return 2
 	}
 
 	// Normal mode: Display message to stdout
-	// Format:
-	// From: <sender>
-	// ID: <id>
-	//
-	// <message>
-	fmt.Fprintf(stdout, "From: %s\n", msg.From)
-	fmt.Fprintf(stdout, "ID: %s\n", msg.ID)
-	fmt.Fprintln(stdout)
-	fmt.Fprint(stdout, msg.Message)
+	writeMessage(stdout, msg)
 
 	return 0
 }
+
+// writeMessage prints a received message in receive format:
+//
+//	From: <sender>
+//	ID: <id>
+//	Subject: <subject> (if set)
+//	In-Reply-To: <id> (replies only)
+//	Priority: <level> (non-normal only)
+//	Expires: <RFC 3339 time> (messages with a TTL only)
+//	Expects-Reply: yes (ask only)
+//	Attempt: <n> (leased only)
+//	Header: <key>=<value> (one per header, sorted by key)
+//
+//	<message>
+func writeMessage(w io.Writer, msg mail.Message) {
+	fmt.Fprintf(w, "From: %s\n", msg.From)
+	writeMessageBody(w, msg)
+}
+
+// writeMessageBody prints a message in receive format from the ID line on.
+func writeMessageBody(w io.Writer, msg mail.Message) {
+	fmt.Fprintf(w, "ID: %s\n", msg.ID)
+	if msg.Subject != "" {
+		fmt.Fprintf(w, "Subject: %s\n", msg.Subject)
+	}
+	if msg.InReplyTo != "" {
+		fmt.Fprintf(w, "In-Reply-To: %s\n", msg.InReplyTo)
+	}
+	if msg.Priority != "" && msg.Priority != mail.PriorityNormal {
+		fmt.Fprintf(w, "Priority: %s\n", msg.Priority)
+	}
+	if !msg.ExpiresAt.IsZero() {
+		fmt.Fprintf(w, "Expires: %s\n", msg.ExpiresAt.Format(time.RFC3339))
+	}
+	if msg.ExpectsReply {
+		fmt.Fprintln(w, "Expects-Reply: yes")
+	}
+	if msg.Attempts > 0 {
+		fmt.Fprintf(w, "Attempt: %d\n", msg.Attempts)
+	}
+	for _, key := range msg.HeaderKeys() {
+		fmt.Fprintf(w, "Header: %s=%s\n", key, msg.Headers[key])
+	}
+	fmt.Fprintln(w)
+	fmt.Fprint(w, msg.Message)
+}
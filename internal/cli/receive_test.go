@@ -6,6 +6,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"agentmail/internal/mail"
 )
 
 // T028: Tests for receive command no-messages case
@@ -130,11 +133,11 @@ func TestReceiveCommand_Success(t *testing.T) {
 	}
 
 	// Verify message was marked as read
-	fileContent, err := os.ReadFile(filePath)
+	messages, err := mail.ReadAll(tmpDir, "agent-2")
 	if err != nil {
-		t.Fatalf("Failed to read file: %v", err)
+		t.Fatalf("Failed to read mailbox: %v", err)
 	}
-	if !strings.Contains(string(fileContent), `"read_flag":true`) {
+	if len(messages) != 1 || !messages[0].ReadFlag {
 		t.Errorf("Message should be marked as read after receive")
 	}
 }
@@ -291,11 +294,11 @@ func TestReceiveCommand_HookMode_WithMessages(t *testing.T) {
 	}
 
 	// FR-001c: Message should be marked as read
-	fileContent, err := os.ReadFile(filePath)
+	messages, err := mail.ReadAll(tmpDir, "agent-2")
 	if err != nil {
-		t.Fatalf("Failed to read file: %v", err)
+		t.Fatalf("Failed to read mailbox: %v", err)
 	}
-	if !strings.Contains(string(fileContent), `"read_flag":true`) {
+	if len(messages) != 1 || !messages[0].ReadFlag {
 		t.Errorf("Hook mode should mark message as read")
 	}
 }
@@ -488,3 +491,197 @@ func TestReceiveCommand_NormalMode_Unchanged(t *testing.T) {
 		t.Errorf("Normal mode should not have hook prefix")
 	}
 }
+
+// Tests for receive --wait
+
+func TestReceiveCommand_WaitDeliversArrivingMessage(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = mail.Append(tmpDir, mail.Message{ID: "wait0001", From: "agent-1", To: "agent-2", Message: "work is ready"})
+	}()
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Receive(&stdout, &stderr, ReceiveOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+		Wait:          true,
+		WaitTimeout:   10 * time.Second,
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	expected := "From: agent-1\nID: wait0001\n\nwork is ready"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestReceiveCommand_WaitTimeout(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Receive(&stdout, &stderr, ReceiveOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockReceiver:  "agent-2",
+		RepoRoot:      t.TempDir(),
+		Wait:          true,
+		WaitTimeout:   200 * time.Millisecond,
+	})
+
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d", exitCode)
+	}
+	if stdout.String() != "No unread messages\n" {
+		t.Errorf("Expected 'No unread messages', got %q", stdout.String())
+	}
+}
+
+func TestReceiveCommand_LeaseKeepsMessageInFlight(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := mail.Append(tmpDir, mail.Message{ID: "lease001", From: "agent-1", To: "agent-2", Message: "build it"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	opts := ReceiveOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+		Lease:         50 * time.Millisecond,
+	}
+
+	var stdout, stderr bytes.Buffer
+	if exitCode := Receive(&stdout, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	expected := "From: agent-1\nID: lease001\nAttempt: 1\n\nbuild it"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	// While leased, the message is not delivered again
+	stdout.Reset()
+	Receive(&stdout, &stderr, opts)
+	if stdout.String() != "No unread messages\n" {
+		t.Errorf("Expected in-flight message to be hidden, got %q", stdout.String())
+	}
+
+	// After the lease expires it is redelivered with the next attempt number
+	time.Sleep(100 * time.Millisecond)
+	stdout.Reset()
+	Receive(&stdout, &stderr, opts)
+	expected = "From: agent-1\nID: lease001\nAttempt: 2\n\nbuild it"
+	if stdout.String() != expected {
+		t.Errorf("Expected redelivery %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestReceiveCommand_UrgentFirstWithPriorityLine(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := mail.WriteAll(tmpDir, "agent-2", []mail.Message{
+		{ID: "status01", From: "agent-1", To: "agent-2", Message: "routine update"},
+		{ID: "urgent01", From: "agent-3", To: "agent-2", Message: "abort, main is broken", Priority: mail.PriorityUrgent},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Receive(&stdout, &stderr, ReceiveOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2", "agent-3"},
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	expected := "From: agent-3\nID: urgent01\nPriority: urgent\n\nabort, main is broken"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestReceiveCommand_ShowsExpiry(t *testing.T) {
+	tmpDir := t.TempDir()
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := mail.WriteAll(tmpDir, "agent-2", []mail.Message{
+		{ID: "ttl00001", From: "agent-1", To: "agent-2", Message: "hold off on merging", ExpiresAt: expires},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Receive(&stdout, &stderr, ReceiveOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Expires: "+expires.Format(time.RFC3339)+"\n") {
+		t.Errorf("Expected Expires line, got %q", stdout.String())
+	}
+}
+
+func TestReceiveCommand_HeaderFilterAndOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := mail.WriteAll(tmpDir, "agent-2", []mail.Message{
+		{ID: "other001", From: "agent-1", To: "agent-2", Message: "unrelated", Headers: map[string]string{"task": "456"}},
+		{ID: "task0001", From: "agent-3", To: "agent-2", Message: "see the log", Subject: "CI failure",
+			Headers: map[string]string{"task": "123", "branch": "main"}},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Receive(&stdout, &stderr, ReceiveOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2", "agent-3"},
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+		Headers:       []string{"task=123"},
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	expected := "From: agent-3\nID: task0001\nSubject: CI failure\nHeader: branch=main\nHeader: task=123\n\nsee the log"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	// The non-matching message stays unread
+	unread, err := mail.FindUnread(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("FindUnread failed: %v", err)
+	}
+	if len(unread) != 1 || unread[0].ID != "other001" {
+		t.Errorf("Expected other001 to remain unread, got %+v", unread)
+	}
+
+	// No further matches
+	stdout.Reset()
+	Receive(&stdout, &stderr, ReceiveOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2", "agent-3"},
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+		Headers:       []string{"task=123"},
+	})
+	if stdout.String() != "No unread messages\n" {
+		t.Errorf("Expected no matching messages, got %q", stdout.String())
+	}
+}
@@ -12,13 +12,19 @@ import (
 type RecipientsOptions struct {
 	SkipTmuxCheck  bool            // Skip tmux environment check
 	MockWindows    []string        // Mock list of tmux windows
+	MockMailboxes  []mail.Alias    // Mock mailboxes with their panes (overrides MockWindows)
 	MockCurrent    string          // Mock current window name
 	MockIgnoreList map[string]bool // Mock ignore list (nil = load from file)
 	MockGitRoot    string          // Mock git root (for testing)
+	Long           bool            // Also show the pane and session:window reading each mailbox
 }
 
 // Recipients implements the agentmail recipients command.
-// It lists all tmux windows with the current window marked "[you]".
+// It lists the mailbox of every window in the session with the current one marked "[you]".
+// A mailbox keeps the name of the window it was created for when the window is renamed;
+// with Long set, each line also shows the pane reading it and where that pane is now:
+//
+//	agent-1  %3  web:reviewer
 func Recipients(stdout, stderr io.Writer, opts RecipientsOptions) int {
 	// Validate running inside tmux
 	if !opts.SkipTmuxCheck {
@@ -28,13 +34,16 @@ func Recipients(stdout, stderr io.Writer, opts RecipientsOptions) int {
 		}
 	}
 
-	// Get list of windows
-	var windows []string
-	if opts.MockWindows != nil {
-		windows = opts.MockWindows
-	} else {
+	// Get the mailbox of every window
+	mailboxes := opts.MockMailboxes
+	mocking := opts.MockMailboxes != nil || opts.MockWindows != nil
+	if mailboxes == nil && opts.MockWindows != nil {
+		for _, window := range opts.MockWindows {
+			mailboxes = append(mailboxes, mail.Alias{Mailbox: window, Window: window})
+		}
+	} else if !mocking {
 		var err error
-		windows, err = tmux.ListWindows()
+		mailboxes, err = mail.SessionMailboxes(opts.MockGitRoot)
 		if err != nil {
 			fmt.Fprintf(stderr, "error: failed to list windows: %v\n", err)
 			return 1
@@ -44,11 +53,11 @@ func Recipients(stdout, stderr io.Writer, opts RecipientsOptions) int {
 	// Get current window
 	// In mock mode (MockWindows is set), use MockCurrent even if empty
 	var currentWindow string
-	if opts.MockWindows != nil {
+	if mocking {
 		currentWindow = opts.MockCurrent
 	} else {
 		var err error
-		currentWindow, err = tmux.GetCurrentWindow()
+		currentWindow, err = mail.CurrentMailbox(opts.MockGitRoot)
 		if err != nil {
 			fmt.Fprintf(stderr, "error: failed to get current window: %v\n", err)
 			return 1
@@ -74,15 +83,29 @@ func Recipients(stdout, stderr io.Writer, opts RecipientsOptions) int {
 		}
 	}
 
-	// Output windows with current marked, filtering ignored windows
-	for _, window := range windows {
-		// Current window is always shown (per FR-004), even if in ignore list
-		if window == currentWindow {
-			fmt.Fprintf(stdout, "%s [you]\n", window)
-		} else if ignoreList == nil || !ignoreList[window] {
-			// Only show non-current windows if they're not in the ignore list
-			fmt.Fprintf(stdout, "%s\n", window)
+	// Current window is always shown (per FR-004), even if in ignore list;
+	// other windows only if they're not in the ignore list
+	var shown []mail.Alias
+	for _, mailbox := range mailboxes {
+		if mailbox.Mailbox == currentWindow || ignoreList == nil || !ignoreList[mailbox.Mailbox] {
+			shown = append(shown, mailbox)
+		}
+	}
+
+	nameWidth, paneWidth := 0, 0
+	for _, mailbox := range shown {
+		nameWidth = max(nameWidth, len(mailbox.Mailbox))
+		paneWidth = max(paneWidth, len(mailbox.Pane))
+	}
+	for _, mailbox := range shown {
+		line := mailbox.Mailbox
+		if opts.Long {
+			line = fmt.Sprintf("%-*s  %-*s  %s", nameWidth, mailbox.Mailbox, paneWidth, mailbox.Pane, mailbox.Address())
+		}
+		if mailbox.Mailbox == currentWindow {
+			line += " [you]"
 		}
+		fmt.Fprintln(stdout, line)
 	}
 
 	return 0
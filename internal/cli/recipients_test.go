@@ -5,6 +5,8 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"agentmail/internal/mail"
 )
 
 // T008: Unit tests for Recipients() in internal/cli/recipients_test.go
@@ -434,3 +436,26 @@ func TestRecipientsCommand_CurrentWindowShownEvenIfIgnored(t *testing.T) {
 		t.Errorf("Expected 2 lines (main and agent1 [you]), got %d: %v", len(lines), lines)
 	}
 }
+
+func TestRecipientsCommand_LongShowsPaneMapping(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Recipients(&stdout, &stderr, RecipientsOptions{
+		SkipTmuxCheck: true,
+		MockMailboxes: []mail.Alias{
+			{Mailbox: "agent-1", Pane: "%3", Session: "web", Window: "reviewer"},
+			{Mailbox: "lead", Pane: "%12", Session: "web", Window: "lead"},
+		},
+		MockCurrent:    "lead",
+		MockIgnoreList: map[string]bool{},
+		Long:           true,
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	want := "agent-1  %3   web:reviewer\nlead     %12  web:lead [you]\n"
+	if stdout.String() != want {
+		t.Errorf("Expected:\n%s\nGot:\n%s", want, stdout.String())
+	}
+}
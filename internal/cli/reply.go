@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"agentmail/internal/mail"
+	"agentmail/internal/tmux"
+)
+
+// Reply implements the agentmail reply command.
+// It looks up a message in the caller's mailbox and sends the reply back to
+// its sender. The reply inherits the original message's thread.
+//
+// Arguments:
+// - args[0]: ID of the message being replied to
+// - args[1]: reply text (optional if piped via stdin)
+//
+// Exit Codes:
+// - 0: Reply sent
+// - 1: Missing arguments, unknown message, or send failure
+// - 2: Not running inside tmux
+func Reply(args []string, stdin io.Reader, stdout, stderr io.Writer, opts SendOptions) int {
+	if !opts.SkipTmuxCheck {
+		if !tmux.InTmux() {
+			fmt.Fprintln(stderr, "error: agentmail must run inside a tmux session")
+			return 2
+		}
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "error: missing required argument: message-id")
+		fmt.Fprintln(stderr, "usage: agentmail reply <message-id> <message>")
+		return 1
+	}
+
+	messageID := args[0]
+
+	// Get caller identity (the recipient of the original message)
+	var caller string
+	if opts.MockSender != "" {
+		caller = opts.MockSender
+	} else {
+		var err error
+		caller, err = mail.CurrentMailbox(opts.RepoRoot)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to get current window: %v\n", err)
+			return 1
+		}
+	}
+
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return 1
+		}
+	}
+
+	// Only messages delivered to the caller can be replied to
+	messages, err := mail.ReadAll(repoRoot, caller)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to read messages: %v\n", err)
+		return 1
+	}
+
+	var original *mail.Message
+	for i := range messages {
+		if messages[i].ID == messageID {
+			original = &messages[i]
+			break
+		}
+	}
+
+	if original == nil {
+		fmt.Fprintf(stderr, "error: message #%s not found in your mailbox\n", messageID)
+		return 1
+	}
+
+	// Route the reply back to the original sender
+	sendArgs := append([]string{original.From}, args[1:]...)
+	opts.ReplyTo = original.ID
+	opts.RepoRoot = repoRoot
+	return Send(sendArgs, stdin, stdout, stderr, opts)
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"agentmail/internal/mail"
+)
+
+func TestReplyCommand_MissingMessageID(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Reply([]string{}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "missing required argument: message-id") {
+		t.Errorf("Expected missing argument error, got: %q", stderr.String())
+	}
+}
+
+func TestReplyCommand_NotInTmux(t *testing.T) {
+	t.Setenv("TMUX", "")
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Reply([]string{"msg00001", "hi"}, nil, &stdout, &stderr, SendOptions{})
+
+	if exitCode != 2 {
+		t.Errorf("Expected exit code 2 when not in tmux, got %d", exitCode)
+	}
+}
+
+func TestReplyCommand_MessageNotInMailbox(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Message exists, but in another agent's mailbox
+	if err := mail.Append(tmpDir, mail.Message{ID: "msg00001", From: "agent-1", To: "agent-3", Message: "hi"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Reply([]string{"msg00001", "hello"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2", "agent-3"},
+		MockSender:    "agent-2",
+		RepoRoot:      tmpDir,
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if stderr.String() != "error: message #msg00001 not found in your mailbox\n" {
+		t.Errorf("Unexpected stderr: %q", stderr.String())
+	}
+}
+
+func TestReplyCommand_RoutesToSenderAndInheritsThread(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// agent-1 asked agent-2 a question that is itself part of an existing thread
+	question := mail.Message{ID: "msg00002", From: "agent-1", To: "agent-2", Message: "question", InReplyTo: "msg00001", ThreadID: "msg00001"}
+	if err := mail.Append(tmpDir, question); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Reply([]string{"msg00002", "answer"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockSender:    "agent-2",
+		RepoRoot:      tmpDir,
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+
+	messages, err := mail.ReadAll(tmpDir, "agent-1")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 reply in agent-1 mailbox, got %d", len(messages))
+	}
+
+	reply := messages[0]
+	if reply.From != "agent-2" || reply.Message != "answer" {
+		t.Errorf("Unexpected reply: %+v", reply)
+	}
+	if reply.InReplyTo != "msg00002" {
+		t.Errorf("Expected InReplyTo 'msg00002', got %q", reply.InReplyTo)
+	}
+	if reply.ThreadID != "msg00001" {
+		t.Errorf("Expected ThreadID 'msg00001', got %q", reply.ThreadID)
+	}
+	if !strings.HasPrefix(stdout.String(), "Message #"+reply.ID+" sent") {
+		t.Errorf("Unexpected stdout: %q", stdout.String())
+	}
+}
+
+func TestSendCommand_ReplyToUnknownMessage(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"agent-2", "hello"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockSender:    "agent-1",
+		RepoRoot:      tmpDir,
+		ReplyTo:       "missing1",
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "failed to find message #missing1") {
+		t.Errorf("Unexpected stderr: %q", stderr.String())
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"agentmail/internal/mail"
+)
+
+// SearchOptions configures the Search command behavior.
+type SearchOptions struct {
+	RepoRoot string        // Repository root (defaults to finding git root)
+	From     string        // Only search messages from this sender (--from)
+	To       string        // Only search this recipient's mailbox (--to)
+	Since    time.Duration // Only search messages sent within this long (--since, 0 = no limit)
+	Regex    bool          // Treat the query as a regular expression (--regex)
+	JSON     bool          // Print one JSON object per match (--json)
+}
+
+// Search implements the agentmail search command.
+// It searches the subject, body and header values of every message in every
+// mailbox, read or not, and prints the matches oldest first, one per line:
+//
+//	<id>  <from> -> <to>  <RFC 3339 time>  <matching line>
+//
+// With JSON set each match is printed as the stored message plus a "match" field.
+//
+// Exit Codes:
+// - 0: Matches printed (or "No matches")
+// - 1: Missing argument, invalid regular expression, or read failure
+func Search(args []string, stdout, stderr io.Writer, opts SearchOptions) int {
+	if len(args) == 0 || args[0] == "" {
+		fmt.Fprintln(stderr, "error: missing required argument: query")
+		fmt.Fprintln(stderr, "usage: agentmail search <query> [--from <sender>] [--to <recipient>] [--since <duration>] [--regex] [--json]")
+		return 1
+	}
+
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return 1
+		}
+	}
+
+	query := mail.SearchQuery{
+		Text:  args[0],
+		Regex: opts.Regex,
+		From:  opts.From,
+		To:    opts.To,
+	}
+	if opts.Since > 0 {
+		query.Since = time.Now().Add(-opts.Since)
+	}
+
+	matches, err := mail.Search(repoRoot, query)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if opts.JSON {
+		encoder := json.NewEncoder(stdout)
+		for _, match := range matches {
+			if err := encoder.Encode(match); err != nil {
+				fmt.Fprintf(stderr, "error: failed to encode match: %v\n", err)
+				return 1
+			}
+		}
+		return 0
+	}
+
+	if len(matches) == 0 {
+		fmt.Fprintln(stdout, "No matches")
+		return 0
+	}
+	for _, match := range matches {
+		fmt.Fprintf(stdout, "%s  %s -> %s  %s  %s\n", match.ID, match.From, match.To, match.CreatedAt.Format(time.RFC3339), match.Match)
+	}
+	return 0
+}
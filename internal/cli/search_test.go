@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"agentmail/internal/mail"
+)
+
+func TestSearch_PrintsMatches(t *testing.T) {
+	tmpDir := t.TempDir()
+	created := time.Date(2026, 1, 14, 9, 30, 0, 0, time.UTC)
+	_ = mail.WriteAll(tmpDir, "agent-2", []mail.Message{
+		{ID: "m1", From: "agent-1", To: "agent-2", Message: "Plan:\nuse the new schema", CreatedAt: created},
+		{ID: "m2", From: "agent-1", To: "agent-2", Message: "lunch?", CreatedAt: created},
+	})
+
+	var stdout, stderr bytes.Buffer
+	exitCode := Search([]string{"SCHEMA"}, &stdout, &stderr, SearchOptions{RepoRoot: tmpDir})
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	expected := "m1  agent-1 -> agent-2  " + created.Format(time.RFC3339) + "  use the new schema\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	if exitCode := Search([]string{"schema"}, &stdout, &stderr, SearchOptions{RepoRoot: tmpDir, JSON: true}); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d", exitCode)
+	}
+	var match mail.SearchMatch
+	if err := json.Unmarshal(stdout.Bytes(), &match); err != nil {
+		t.Fatalf("Expected one JSON object, got %q: %v", stdout.String(), err)
+	}
+	if match.ID != "m1" || match.Match != "use the new schema" || match.Message.Message != "Plan:\nuse the new schema" {
+		t.Errorf("Unexpected JSON match: %+v", match)
+	}
+
+	stdout.Reset()
+	if exitCode := Search([]string{"schema"}, &stdout, &stderr, SearchOptions{RepoRoot: tmpDir, From: "agent-3"}); exitCode != 0 || stdout.String() != "No matches\n" {
+		t.Errorf("Expected 'No matches', got %d: %q", exitCode, stdout.String())
+	}
+}
+
+func TestSearch_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	if exitCode := Search(nil, &stdout, &stderr, SearchOptions{RepoRoot: tmpDir}); exitCode != 1 || !strings.Contains(stderr.String(), "missing required argument: query") {
+		t.Errorf("Expected missing argument error, got %d: %q", exitCode, stderr.String())
+	}
+
+	stderr.Reset()
+	if exitCode := Search([]string{"("}, &stdout, &stderr, SearchOptions{RepoRoot: tmpDir, Regex: true}); exitCode != 1 || !strings.Contains(stderr.String(), "error: invalid regular expression") {
+		t.Errorf("Expected regex error, got %d: %q", exitCode, stderr.String())
+	}
+}
@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
+	"agentmail/internal/daemon"
 	"agentmail/internal/mail"
 	"agentmail/internal/tmux"
 )
@@ -20,6 +23,18 @@ type SendOptions struct {
 	MockGitRoot    string          // Mock git root (for testing)
 	StdinContent   string          // Mock stdin content (empty = no stdin)
 	StdinIsPipe    bool            // Mock whether stdin is a pipe
+	ReplyTo        string          // ID of the message being replied to (empty = new thread)
+	MessageID      string          // Pre-generated message ID (empty = generate one)
+	ExpectsReply   bool            // Mark the message as awaiting a reply (used by ask)
+	Priority       string          // low, normal, high or urgent (empty = normal)
+	DeliverAt      string          // Absolute delivery time (empty = immediately)
+	DeliverIn      time.Duration   // Delivery delay (zero = immediately)
+	TTL            time.Duration   // Time to live once delivered (zero = never expires)
+	NotifyExpired  bool            // Send the sender a system message if it expires unread
+	Receipt        bool            // Send the sender a system message when it is read
+	Key            string          // Idempotency key: a retry with the same key reports the original send
+	Subject        string          // Optional one-line subject
+	Headers        []string        // Optional "key=value" headers
 }
 
 // Send implements the agentmail send command.
@@ -85,21 +100,65 @@ func Send(args []string, stdin io.Reader, stdout, stderr io.Writer, opts SendOpt
 		return 1
 	}
 
+	if err := mail.ValidatePriority(opts.Priority); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if err := mail.ValidateSubject(opts.Subject); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	if err := mail.ValidateIdempotencyKey(opts.Key); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	headers, err := mail.ParseHeaders(opts.Headers)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
+	now := time.Now()
+	deliverAfter, err := mail.DeliveryTime(opts.DeliverAt, opts.DeliverIn, now)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	expiresAt, err := mail.ExpiryTime(opts.TTL, deliverAfter, now)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
 	// Get sender identity
 	var sender string
 	if opts.MockSender != "" {
 		sender = opts.MockSender
 	} else {
 		var err error
-		sender, err = tmux.GetCurrentWindow()
+		sender, err = mail.CurrentMailbox(opts.RepoRoot)
 		if err != nil {
 			fmt.Fprintf(stderr, "error: failed to get current window: %v\n", err)
 			return 1
 		}
 	}
 
+	// A retry with the same idempotency key reports the original send instead of sending again
+	if opts.Key != "" {
+		if exitCode, done := reportDuplicate(sender, stdout, stderr, opts); done {
+			return exitCode
+		}
+	}
+
+	// Group addresses (@all, @name) fan out to multiple mailboxes
+	if mail.IsGroupAddress(recipient) {
+		return sendGroup(recipient, message, sender, headers, deliverAfter, expiresAt, stdout, stderr, opts)
+	}
+
 	// T022: Validate recipient exists
 	var recipientExists bool
+	var target mail.Target
 	if opts.MockWindows != nil {
 		for _, w := range opts.MockWindows {
 			if w == recipient {
@@ -108,9 +167,12 @@ func Send(args []string, stdin io.Reader, stdout, stderr io.Writer, opts SendOpt
 			}
 		}
 	} else {
-		var err error
-		recipientExists, err = tmux.WindowExists(recipient)
-		if err != nil {
+		// Pane IDs, session:window addresses and renamed windows all resolve to a mailbox
+		target, err = mail.Resolve(opts.RepoRoot, recipient)
+		switch {
+		case err == nil:
+			recipient, recipientExists = target.Mailbox, true
+		case !errors.Is(err, mail.ErrRecipientNotFound):
 			fmt.Fprintf(stderr, "error: failed to check recipient: %v\n", err)
 			return 1
 		}
@@ -121,6 +183,12 @@ func Send(args []string, stdin io.Reader, stdout, stderr io.Writer, opts SendOpt
 		return 1
 	}
 
+	// A pane of another session working in another repository reads that repository's
+	// store: the message goes there, from an address that resolves back to the sender
+	if target.RepoRoot != "" {
+		return sendElsewhere(target, message, headers, deliverAfter, expiresAt, stdout, stderr, opts)
+	}
+
 	// T029: Check if recipient is the sender (self-send not allowed)
 	if recipient == sender {
 		fmt.Fprintln(stderr, "error: recipient not found")
@@ -128,23 +196,7 @@ func Send(args []string, stdin io.Reader, stdout, stderr io.Writer, opts SendOpt
 	}
 
 	// T029: Load and check ignore list
-	var ignoreList map[string]bool
-	if opts.MockIgnoreList != nil {
-		ignoreList = opts.MockIgnoreList
-	} else {
-		// Load from .agentmailignore file
-		var gitRoot string
-		if opts.MockGitRoot != "" {
-			gitRoot = opts.MockGitRoot
-		} else {
-			gitRoot, _ = mail.FindGitRoot()
-			// Errors from FindGitRoot mean not in a git repo - proceed without ignore list
-		}
-		if gitRoot != "" {
-			ignoreList, _ = mail.LoadIgnoreList(gitRoot)
-			// Errors from LoadIgnoreList are treated as no ignore file
-		}
-	}
+	ignoreList := loadSendIgnoreList(opts)
 
 	// T030: Check if recipient is in ignore list
 	if ignoreList != nil && ignoreList[recipient] {
@@ -152,11 +204,14 @@ func Send(args []string, stdin io.Reader, stdout, stderr io.Writer, opts SendOpt
 		return 1
 	}
 
-	// Generate message ID
-	id, err := mail.GenerateID()
-	if err != nil {
-		fmt.Fprintf(stderr, "error: failed to generate message ID: %v\n", err)
-		return 1
+	// Generate message ID unless the caller already chose one
+	id := opts.MessageID
+	if id == "" {
+		id, err = mail.GenerateID()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to generate message ID: %v\n", err)
+			return 1
+		}
 	}
 
 	// Determine repository root (find git root, not current directory)
@@ -171,19 +226,291 @@ func Send(args []string, stdin io.Reader, stdout, stderr io.Writer, opts SendOpt
 
 	// T023: Store message
 	msg := mail.Message{
-		ID:       id,
-		From:     sender,
-		To:       recipient,
-		Message:  message,
-		ReadFlag: false,
+		ID:             id,
+		From:           sender,
+		To:             recipient,
+		Message:        message,
+		Subject:        opts.Subject,
+		Headers:        headers,
+		ReadFlag:       false,
+		ExpectsReply:   opts.ExpectsReply,
+		Priority:       opts.Priority,
+		DeliverAfter:   deliverAfter,
+		ExpiresAt:      expiresAt,
+		NotifyExpired:  opts.NotifyExpired,
+		Receipt:        opts.Receipt,
+		IdempotencyKey: opts.Key,
+	}
+
+	// Replies inherit the thread of the message they answer
+	if opts.ReplyTo != "" {
+		original, err := mail.FindMessage(repoRoot, opts.ReplyTo)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to find message #%s: %v\n", opts.ReplyTo, err)
+			return 1
+		}
+		msg.InReplyTo = original.ID
+		msg.ThreadID = original.ThreadRoot()
 	}
 
 	if err := mail.Append(repoRoot, msg); err != nil {
-		fmt.Fprintf(stderr, "error: failed to write message: %v\n", err)
-		return 1
+		return reportWriteError(stdout, stderr, err)
 	}
 
 	// Output message confirmation
 	fmt.Fprintf(stdout, "Message #%s sent\n", id)
+	printSchedule(stdout, deliverAfter)
+	return 0
+}
+
+// sendElsewhere delivers a message into the store of another repository, whose
+// mailman notifies the recipient. The sender is named by its session-qualified
+// window there, and the repository is checked for a running mailman. The message
+// records the sender's repository and mailbox, where receipts and expiry notices go.
+func sendElsewhere(target mail.Target, message string, headers map[string]string, deliverAfter, expiresAt time.Time, stdout, stderr io.Writer, opts SendOptions) int {
+	sender, mailbox := opts.MockSender, opts.MockSender
+	if sender == "" {
+		var err error
+		sender, err = mail.CurrentAddress()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to get current window: %v\n", err)
+			return 1
+		}
+		if mailbox, err = mail.CurrentMailbox(opts.RepoRoot); err != nil {
+			fmt.Fprintf(stderr, "error: failed to get current window: %v\n", err)
+			return 1
+		}
+	}
+
+	// The same checks as a send in this repository: not to self, and not to a window
+	// ignored there, or ignored here by its session-qualified address
+	remote := opts
+	remote.RepoRoot = target.RepoRoot
+	remote.MockGitRoot = target.RepoRoot
+	if target.Pane.Address() == sender || mail.Ignores(loadSendIgnoreList(remote), target) || loadSendIgnoreList(opts)[target.Pane.Address()] {
+		fmt.Fprintln(stderr, "error: recipient not found")
+		return 1
+	}
+
+	// Retries are looked up where the original send went
+	if opts.Key != "" {
+		if exitCode, done := reportDuplicate(sender, stdout, stderr, remote); done {
+			return exitCode
+		}
+	}
+
+	id := opts.MessageID
+	if id == "" {
+		var err error
+		id, err = mail.GenerateID()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to generate message ID: %v\n", err)
+			return 1
+		}
+	}
+
+	msg := mail.Message{
+		ID:             id,
+		From:           sender,
+		To:             target.Mailbox,
+		Message:        message,
+		Subject:        opts.Subject,
+		Headers:        headers,
+		ReadFlag:       false,
+		ExpectsReply:   opts.ExpectsReply,
+		Priority:       opts.Priority,
+		DeliverAfter:   deliverAfter,
+		ExpiresAt:      expiresAt,
+		NotifyExpired:  opts.NotifyExpired,
+		Receipt:        opts.Receipt,
+		IdempotencyKey: opts.Key,
+	}
+
+	// Receipts and expiry notices come back to this repository, and a reply
+	// answers a message of its store
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		repoRoot, _ = mail.FindGitRoot() // G104: FindMessage reports the missing store
+	}
+	if repoRoot != "" {
+		msg.OriginRepo, msg.OriginMailbox = repoRoot, mailbox
+	}
+	if opts.ReplyTo != "" {
+		original, err := mail.FindMessage(repoRoot, opts.ReplyTo)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to find message #%s: %v\n", opts.ReplyTo, err)
+			return 1
+		}
+		msg.InReplyTo = original.ID
+		msg.ThreadID = original.ThreadRoot()
+	}
+
+	if err := mail.Append(target.RepoRoot, msg); err != nil {
+		return reportWriteError(stdout, stderr, err)
+	}
+
+	fmt.Fprintf(stdout, "Message #%s sent\n", id)
+	fmt.Fprintf(stdout, "Delivered to %s in %s\n", target.Mailbox, target.RepoRoot)
+	printSchedule(stdout, deliverAfter)
+	if status, _, err := daemon.CheckExistingDaemon(target.RepoRoot); err == nil && status != daemon.DaemonRunning {
+		fmt.Fprintf(stderr, "Warning: no mailman running in %s; %s will not be notified until one starts\n", target.RepoRoot, target.Mailbox)
+	}
 	return 0
 }
+
+// loadSendIgnoreList returns the ignore list for a send, from mocks or .agentmailignore.
+func loadSendIgnoreList(opts SendOptions) map[string]bool {
+	if opts.MockIgnoreList != nil {
+		return opts.MockIgnoreList
+	}
+
+	// Load from .agentmailignore file
+	var gitRoot string
+	if opts.MockGitRoot != "" {
+		gitRoot = opts.MockGitRoot
+	} else {
+		gitRoot, _ = mail.FindGitRoot()
+		// Errors from FindGitRoot mean not in a git repo - proceed without ignore list
+	}
+	if gitRoot == "" {
+		return nil
+	}
+	ignoreList, _ := mail.LoadIgnoreList(gitRoot)
+	// Errors from LoadIgnoreList are treated as no ignore file
+	return ignoreList
+}
+
+// sendGroup delivers one copy of the message to every member of a group address.
+// The sender and ignored windows are excluded; all copies share one broadcast ID.
+func sendGroup(address, message, sender string, headers map[string]string, deliverAfter, expiresAt time.Time, stdout, stderr io.Writer, opts SendOptions) int {
+	// Get list of windows in the session
+	var windows []string
+	if opts.MockWindows != nil {
+		windows = opts.MockWindows
+	} else {
+		mailboxes, err := mail.SessionMailboxes(opts.RepoRoot)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to list windows: %v\n", err)
+			return 1
+		}
+		windows = mail.MailboxNames(mailboxes)
+	}
+
+	// Determine repository root (find git root, not current directory)
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return 1
+		}
+	}
+
+	groups, err := mail.LoadGroups(repoRoot)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to read groups: %v\n", err)
+		return 1
+	}
+
+	recipients, err := mail.ExpandAddress(address, windows, groups, sender, loadSendIgnoreList(opts))
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %s: %v\n", address, err)
+		return 1
+	}
+
+	msg := mail.Message{
+		From:           sender,
+		Message:        message,
+		Subject:        opts.Subject,
+		Headers:        headers,
+		ReadFlag:       false,
+		Priority:       opts.Priority,
+		DeliverAfter:   deliverAfter,
+		ExpiresAt:      expiresAt,
+		NotifyExpired:  opts.NotifyExpired,
+		Receipt:        opts.Receipt,
+		IdempotencyKey: opts.Key,
+	}
+
+	// Replies inherit the thread of the message they answer
+	if opts.ReplyTo != "" {
+		original, err := mail.FindMessage(repoRoot, opts.ReplyTo)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: failed to find message #%s: %v\n", opts.ReplyTo, err)
+			return 1
+		}
+		msg.InReplyTo = original.ID
+		msg.ThreadID = original.ThreadRoot()
+	}
+
+	broadcastID, err := mail.Broadcast(repoRoot, msg, recipients)
+	if err != nil {
+		return reportWriteError(stdout, stderr, err)
+	}
+
+	fmt.Fprintf(stdout, "Broadcast #%s sent to %d recipient(s): %s\n", broadcastID, len(recipients), strings.Join(recipients, ", "))
+	printSchedule(stdout, deliverAfter)
+	return 0
+}
+
+// reportDuplicate looks for a message the sender already sent with opts.Key and,
+// if there is one, prints the confirmation of that send again. done is false if
+// there is no such message and the send should go ahead.
+func reportDuplicate(sender string, stdout, stderr io.Writer, opts SendOptions) (exitCode int, done bool) {
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return 1, true
+		}
+	}
+
+	copies, err := mail.FindByIdempotencyKey(repoRoot, sender, opts.Key)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: failed to check idempotency key: %v\n", err)
+		return 1, true
+	}
+	if len(copies) == 0 {
+		return 0, false
+	}
+	printOriginal(stdout, copies)
+	return 0, true
+}
+
+// reportWriteError reports a failed send. A concurrent send with the same
+// idempotency key that got there first is not a failure: its confirmation is
+// printed instead.
+func reportWriteError(stdout, stderr io.Writer, err error) int {
+	var duplicate *mail.DuplicateError
+	if errors.As(err, &duplicate) {
+		printOriginal(stdout, duplicate.Copies)
+		return 0
+	}
+	fmt.Fprintf(stderr, "error: failed to write message: %v\n", err)
+	return 1
+}
+
+// printOriginal prints the confirmation of an earlier send again.
+func printOriginal(stdout io.Writer, copies []mail.Message) {
+	original := copies[0]
+	if original.BroadcastID != "" {
+		recipients := make([]string, len(copies))
+		for i, msg := range copies {
+			recipients[i] = msg.To
+		}
+		fmt.Fprintf(stdout, "Broadcast #%s sent to %d recipient(s): %s\n", original.BroadcastID, len(recipients), strings.Join(recipients, ", "))
+	} else {
+		fmt.Fprintf(stdout, "Message #%s sent\n", original.ID)
+	}
+	printSchedule(stdout, original.DeliverAfter)
+}
+
+// printSchedule tells the sender when a scheduled message will be delivered.
+func printSchedule(stdout io.Writer, deliverAfter time.Time) {
+	if !deliverAfter.IsZero() {
+		fmt.Fprintf(stdout, "Delivery scheduled for %s\n", deliverAfter.Format(time.RFC3339))
+	}
+}
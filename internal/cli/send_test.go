@@ -6,6 +6,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"agentmail/internal/mail"
 )
 
 // T015: Tests for send command argument validation
@@ -550,3 +553,523 @@ func TestSendCommand_NoMessageProvided_Regression(t *testing.T) {
 		t.Errorf("FR-011 Regression: Expected empty stdout, got: %s", stdout.String())
 	}
 }
+
+// Tests for group addressing (@all, @name)
+
+func TestSendCommand_BroadcastAll(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"@all", "stop and rebase"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck:  true,
+		MockWindows:    []string{"agent-1", "agent-2", "agent-3", "monitor"},
+		MockSender:     "agent-1",
+		MockIgnoreList: map[string]bool{"monitor": true},
+		RepoRoot:       tmpDir,
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+
+	output := stdout.String()
+	if !strings.HasPrefix(output, "Broadcast #") || !strings.HasSuffix(output, "sent to 2 recipient(s): agent-2, agent-3\n") {
+		t.Errorf("Unexpected output: %q", output)
+	}
+
+	mailDir := filepath.Join(tmpDir, ".agentmail", "mailboxes")
+	for _, name := range []string{"agent-2", "agent-3"} {
+		if _, err := os.Stat(filepath.Join(mailDir, name+".jsonl")); err != nil {
+			t.Errorf("Expected mailbox for %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"agent-1", "monitor"} {
+		if _, err := os.Stat(filepath.Join(mailDir, name+".jsonl")); !os.IsNotExist(err) {
+			t.Errorf("Sender and ignored windows should not receive a copy (%s)", name)
+		}
+	}
+}
+
+func TestSendCommand_NamedGroup(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".agentmail"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	groups := "@reviewers: agent-2, agent-3\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".agentmail", "groups"), []byte(groups), 0644); err != nil {
+		t.Fatalf("Failed to write groups file: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"@reviewers", "please review"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck:  true,
+		MockWindows:    []string{"agent-1", "agent-2", "agent-3", "agent-4"},
+		MockSender:     "agent-1",
+		MockIgnoreList: map[string]bool{},
+		RepoRoot:       tmpDir,
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if !strings.HasSuffix(stdout.String(), "sent to 2 recipient(s): agent-2, agent-3\n") {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, ".agentmail", "mailboxes", "agent-4.jsonl")); !os.IsNotExist(err) {
+		t.Error("Non-members should not receive a copy")
+	}
+}
+
+func TestSendCommand_UnknownGroup(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"@nobody", "hello"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck:  true,
+		MockWindows:    []string{"agent-1", "agent-2"},
+		MockSender:     "agent-1",
+		MockIgnoreList: map[string]bool{},
+		RepoRoot:       t.TempDir(),
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if stderr.String() != "error: @nobody: unknown group\n" {
+		t.Errorf("Unexpected stderr: %q", stderr.String())
+	}
+}
+
+func TestSendCommand_PriorityStored(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"agent-2", "Abort, main is broken"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockSender:    "agent-1",
+		RepoRoot:      tmpDir,
+		Priority:      "urgent",
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".agentmail", "mailboxes", "agent-2.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to read mailbox: %v", err)
+	}
+	if !strings.Contains(string(data), `"priority":"urgent"`) {
+		t.Errorf("Expected priority to be stored, got: %s", data)
+	}
+}
+
+func TestSendCommand_InvalidPriority(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"agent-2", "Hello"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockSender:    "agent-1",
+		RepoRoot:      t.TempDir(),
+		Priority:      "critical",
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "invalid priority") {
+		t.Errorf("Expected invalid priority error, got: %q", stderr.String())
+	}
+}
+
+func TestSendCommand_DeliverInSchedulesMessage(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"agent-2", "Check CI"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockSender:    "agent-1",
+		RepoRoot:      tmpDir,
+		DeliverIn:     20 * time.Minute,
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Delivery scheduled for ") {
+		t.Errorf("Expected schedule confirmation, got: %q", stdout.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".agentmail", "mailboxes", "agent-2.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to read mailbox: %v", err)
+	}
+	if !strings.Contains(string(data), `"deliver_after":`) {
+		t.Errorf("Expected deliver_after to be stored, got: %s", data)
+	}
+}
+
+func TestSendCommand_DeliverAtAndInConflict(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"agent-2", "Hello"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockSender:    "agent-1",
+		RepoRoot:      t.TempDir(),
+		DeliverAt:     "14:00",
+		DeliverIn:     time.Minute,
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "cannot combine") {
+		t.Errorf("Expected conflict error, got: %q", stderr.String())
+	}
+}
+
+func TestSendCommand_TTLSetsExpiry(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"agent-2", "Hold off on merging"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockSender:    "agent-1",
+		RepoRoot:      tmpDir,
+		TTL:           10 * time.Minute,
+		NotifyExpired: true,
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".agentmail", "mailboxes", "agent-2.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to read mailbox: %v", err)
+	}
+	if !strings.Contains(string(data), `"expires_at":`) || !strings.Contains(string(data), `"notify_expired":true`) {
+		t.Errorf("Expected expiry to be stored, got: %s", data)
+	}
+}
+
+func TestSendCommand_ReceiptIsStored(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"agent-2", "Please review"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockSender:    "agent-1",
+		RepoRoot:      tmpDir,
+		Receipt:       true,
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".agentmail", "mailboxes", "agent-2.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to read mailbox: %v", err)
+	}
+	if !strings.Contains(string(data), `"receipt":true`) {
+		t.Errorf("Expected receipt request to be stored, got: %s", data)
+	}
+}
+
+func TestSendCommand_IdempotencyKeyReturnsOriginal(t *testing.T) {
+	tmpDir := t.TempDir()
+	opts := SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockSender:    "agent-1",
+		RepoRoot:      tmpDir,
+		Key:           "deploy-42",
+	}
+
+	var first, second, stderr bytes.Buffer
+	if exitCode := Send([]string{"agent-2", "Deploy finished"}, nil, &first, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if exitCode := Send([]string{"agent-2", "Deploy finished"}, nil, &second, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0 for the retry, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+
+	if first.String() != second.String() {
+		t.Errorf("Expected the retry to report the original send %q, got %q", first.String(), second.String())
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".agentmail", "mailboxes", "agent-2.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to read mailbox: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Errorf("Expected one stored message, got %d: %s", lines, data)
+	}
+}
+
+func TestSendCommand_IdempotencyKeyGroupSend(t *testing.T) {
+	tmpDir := t.TempDir()
+	opts := SendOptions{
+		SkipTmuxCheck:  true,
+		MockWindows:    []string{"agent-1", "agent-2", "agent-3"},
+		MockSender:     "agent-1",
+		MockIgnoreList: map[string]bool{},
+		RepoRoot:       tmpDir,
+		Key:            "rebase-1",
+	}
+
+	var first, second, stderr bytes.Buffer
+	if exitCode := Send([]string{"@all", "Rebase onto main"}, nil, &first, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if exitCode := Send([]string{"@all", "Rebase onto main"}, nil, &second, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0 for the retry, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if first.String() != second.String() {
+		t.Errorf("Expected the retry to report the original broadcast %q, got %q", first.String(), second.String())
+	}
+}
+
+func TestSendCommand_IdempotencyKeyInvalid(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"agent-2", "Hello"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockSender:    "agent-1",
+		RepoRoot:      t.TempDir(),
+		Key:           "two\nlines",
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "error: idempotency key must be a single line") {
+		t.Errorf("Unexpected stderr: %q", stderr.String())
+	}
+}
+
+func TestSendCommand_SubjectAndHeaders(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"agent-2", "See the log"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockSender:    "agent-1",
+		RepoRoot:      tmpDir,
+		Subject:       "CI failure",
+		Headers:       []string{"task=123"},
+	})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".agentmail", "mailboxes", "agent-2.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to read mailbox: %v", err)
+	}
+	if !strings.Contains(string(data), `"subject":"CI failure"`) || !strings.Contains(string(data), `"headers":{"task":"123"}`) {
+		t.Errorf("Expected subject and headers to be stored, got: %s", data)
+	}
+}
+
+func TestSendCommand_InvalidHeader(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Send([]string{"agent-2", "Hello"}, nil, &stdout, &stderr, SendOptions{
+		SkipTmuxCheck: true,
+		MockWindows:   []string{"agent-1", "agent-2"},
+		MockSender:    "agent-1",
+		RepoRoot:      t.TempDir(),
+		Headers:       []string{"task"},
+	})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "invalid header") {
+		t.Errorf("Expected invalid header error, got: %q", stderr.String())
+	}
+}
+
+// fakeSessions puts a fake tmux on PATH with two projects, each with its own session
+// and repository: lead (%1) and reviewer (%3) in "web", agent-1 (%5) in "api".
+// Commands run in lead's pane.
+func fakeSessions(t *testing.T) (webRepo, apiRepo string) {
+	t.Helper()
+	webRepo, apiRepo = t.TempDir(), t.TempDir()
+	for _, repo := range []string{webRepo, apiRepo} {
+		if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+			t.Fatalf("Mkdir failed: %v", err)
+		}
+	}
+	lead := "100\\t%%1\\tweb\\t" + webRepo + "\\tlead\\n"
+	reviewer := "100\\t%%3\\tweb\\t" + webRepo + "\\treviewer\\n"
+	agent := "100\\t%%5\\tapi\\t" + apiRepo + "/cmd\\tagent-1\\n"
+	script := "#!/bin/sh\ncase \"$1 $3\" in\n" +
+		"'display-message %1') printf '" + lead + "' ;;\n" +
+		"'display-message %5') printf '" + agent + "' ;;\n" +
+		"list-panes*) printf '" + lead + reviewer + agent + "' ;;\n" +
+		"esac\n"
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tmux"), []byte(script), 0700); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TMUX", "/tmp/tmux-fake/default,1,0")
+	t.Setenv("TMUX_PANE", "%1")
+	return webRepo, apiRepo
+}
+
+func TestSendCommand_SessionWindowInAnotherRepository(t *testing.T) {
+	webRepo, apiRepo := fakeSessions(t)
+
+	var stdout, stderr bytes.Buffer
+	opts := SendOptions{SkipTmuxCheck: true, RepoRoot: webRepo, MockIgnoreList: map[string]bool{}}
+	if exitCode := Send([]string{"api:agent-1", "Schema changed"}, nil, &stdout, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Delivered to agent-1 in "+apiRepo) {
+		t.Errorf("Expected the target repository in the output, got: %s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "no mailman running in "+apiRepo) {
+		t.Errorf("Expected a warning about the missing mailman, got: %s", stderr.String())
+	}
+
+	messages, err := mail.ReadAll(apiRepo, "agent-1")
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("Expected the message in the api repository, got %+v, %v", messages, err)
+	}
+	if messages[0].From != "web:lead" {
+		t.Errorf("Expected the sender to be addressed as web:lead, got %q", messages[0].From)
+	}
+	if messages, _ := mail.ReadAll(webRepo, "agent-1"); len(messages) != 0 {
+		t.Errorf("Expected nothing in the web repository, got %+v", messages)
+	}
+
+	// The reply from the api session finds its way back to lead's repository
+	t.Setenv("TMUX_PANE", "%5")
+	stdout.Reset()
+	stderr.Reset()
+	opts = SendOptions{SkipTmuxCheck: true, RepoRoot: apiRepo, MockIgnoreList: map[string]bool{}, ReplyTo: messages[0].ID}
+	if exitCode := Send([]string{messages[0].From, "Thanks"}, nil, &stdout, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0 for the reply, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	replies, err := mail.ReadAll(webRepo, "lead")
+	if err != nil || len(replies) != 1 {
+		t.Fatalf("Expected the reply in lead's mailbox, got %+v, %v", replies, err)
+	}
+	if replies[0].From != "api:agent-1" || replies[0].InReplyTo != messages[0].ID {
+		t.Errorf("Expected a reply from api:agent-1 to #%s, got %+v", messages[0].ID, replies[0])
+	}
+}
+
+func TestSendCommand_ResolvesPaneOfThisRepository(t *testing.T) {
+	webRepo, _ := fakeSessions(t)
+
+	var stdout, stderr bytes.Buffer
+	opts := SendOptions{SkipTmuxCheck: true, RepoRoot: webRepo, MockIgnoreList: map[string]bool{}}
+	if exitCode := Send([]string{"%3", "Please review"}, nil, &stdout, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "Delivered to") {
+		t.Errorf("Expected a local delivery, got: %s", stdout.String())
+	}
+	messages, err := mail.ReadAll(webRepo, "reviewer")
+	if err != nil || len(messages) != 1 || messages[0].From != "lead" {
+		t.Errorf("Expected the message from lead in reviewer's mailbox, got %+v, %v", messages, err)
+	}
+
+	// The sender's own pane is not a recipient
+	stderr.Reset()
+	if exitCode := Send([]string{"%1", "Hello me"}, nil, &stdout, &stderr, opts); exitCode != 1 {
+		t.Errorf("Expected exit code 1 for a send to self, got %d", exitCode)
+	}
+}
+
+func TestSendCommand_AnotherRepositoryChecksSelfAndIgnoreList(t *testing.T) {
+	webRepo, apiRepo := fakeSessions(t)
+
+	// agent-1 is ignored by its own repository
+	if err := os.WriteFile(filepath.Join(apiRepo, ".agentmailignore"), []byte("agent-1\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	var stdout, stderr bytes.Buffer
+	opts := SendOptions{SkipTmuxCheck: true, RepoRoot: webRepo}
+	if exitCode := Send([]string{"api:agent-1", "Schema changed"}, nil, &stdout, &stderr, opts); exitCode != 1 {
+		t.Errorf("Expected exit code 1 for an ignored window, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "recipient not found") {
+		t.Errorf("Expected recipient not found, got: %s", stderr.String())
+	}
+
+	// ... or by the sender's repository under its session-qualified address
+	if err := os.Remove(filepath.Join(apiRepo, ".agentmailignore")); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(webRepo, ".agentmailignore"), []byte("api:agent-1\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	stderr.Reset()
+	opts.MockGitRoot = webRepo
+	if exitCode := Send([]string{"%5", "Schema changed"}, nil, &stdout, &stderr, opts); exitCode != 1 {
+		t.Errorf("Expected exit code 1 for a window ignored here, got %d", exitCode)
+	}
+
+	// A send to the sender's own address is a send to self
+	stderr.Reset()
+	opts = SendOptions{SkipTmuxCheck: true, RepoRoot: webRepo, MockIgnoreList: map[string]bool{}, MockSender: "api:agent-1"}
+	if exitCode := Send([]string{"api:agent-1", "Hello me"}, nil, &stdout, &stderr, opts); exitCode != 1 {
+		t.Errorf("Expected exit code 1 for a send to self, got %d", exitCode)
+	}
+
+	if messages, _ := mail.ReadAll(apiRepo, "agent-1"); len(messages) != 0 {
+		t.Errorf("Expected nothing delivered, got %+v", messages)
+	}
+}
+
+func TestSendCommand_ReceiptFromAnotherRepository(t *testing.T) {
+	webRepo, apiRepo := fakeSessions(t)
+
+	var stdout, stderr bytes.Buffer
+	opts := SendOptions{SkipTmuxCheck: true, RepoRoot: webRepo, MockIgnoreList: map[string]bool{}, Receipt: true}
+	if exitCode := Send([]string{"api:agent-1", "Schema changed"}, nil, &stdout, &stderr, opts); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+
+	// agent-1 reads it in its own repository
+	t.Setenv("TMUX_PANE", "%5")
+	stdout.Reset()
+	if exitCode := Receive(&stdout, &stderr, ReceiveOptions{SkipTmuxCheck: true, RepoRoot: apiRepo}); exitCode != 0 {
+		t.Fatalf("Expected exit code 0 for receive, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Schema changed") {
+		t.Fatalf("Expected agent-1 to receive the message, got: %s", stdout.String())
+	}
+
+	receipts, err := mail.ReadAll(webRepo, "lead")
+	if err != nil || len(receipts) != 1 || receipts[0].From != mail.SystemSender {
+		t.Fatalf("Expected the receipt in lead's mailbox, got %+v, %v", receipts, err)
+	}
+	if !strings.Contains(receipts[0].Message, "to agent-1 was read") {
+		t.Errorf("Unexpected receipt: %+v", receipts[0])
+	}
+	if stray, _ := mail.ReadAll(apiRepo, "web:lead"); len(stray) != 0 {
+		t.Errorf("Expected no receipt in the api repository, got %+v", stray)
+	}
+}
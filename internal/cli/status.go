@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 
+	"agentmail/internal/config"
 	"agentmail/internal/mail"
 	"agentmail/internal/tmux"
 )
@@ -14,6 +15,8 @@ type StatusOptions struct {
 	SkipTmuxCheck bool   // Skip tmux environment check
 	MockWindow    string // Mock current window name
 	RepoRoot      string // Repository root (defaults to finding git root)
+	Notify        string // Notification strategy for this agent (--notify; "default" clears the overrides)
+	NotifyText    string // Notification text template for this agent (--notify-text)
 }
 
 // ValidateStatus checks if the provided status is a valid status value.
@@ -37,6 +40,10 @@ func ValidateStatus(status string) bool {
 // Arguments:
 // - STATUS: One of `ready`, `work`, `offline`
 //
+// Flags:
+// - --notify: How the mailman notifies this agent: keys, display, bell, command or default
+// - --notify-text: Notification text, with {sender}, {count}, {subject} and {recipient} placeholders
+//
 // Exit Codes:
 // - 0: Status updated (or no-op outside tmux)
 // - 1: Invalid status name
@@ -46,6 +53,9 @@ func ValidateStatus(status string) bool {
 // Stderr (invalid status):
 // Invalid status: foo. Valid: ready, work, offline
 //
+// Stderr (invalid notification strategy):
+// error: invalid notify strategy "foo" (must be keys, display, bell or command)
+//
 // Behavior:
 // 1. Check if running inside tmux ($TMUX env var)
 // 2. If not in tmux: exit 0 silently (no-op for non-tmux environments)
@@ -53,7 +63,8 @@ func ValidateStatus(status string) bool {
 // 4. Parse status argument - if not ready/work/offline: print error to stderr, exit 1
 // 5. Update .agentmail/recipients.jsonl
 // 6. If transitioning to `work` or `offline`: reset `notified` to false
-// 7. Exit 0
+// 7. Store the notification overrides, if given
+// 8. Exit 0
 func Status(args []string, stdout, stderr io.Writer, opts StatusOptions) int {
 	// T040: Handle non-tmux case (silent exit 0)
 	if !opts.SkipTmuxCheck {
@@ -77,6 +88,12 @@ func Status(args []string, stdout, stderr io.Writer, opts StatusOptions) int {
 		fmt.Fprintf(stderr, "Invalid status: %s. Valid: ready, work, offline\n", status)
 		return 1
 	}
+	if opts.Notify != "" && opts.Notify != mail.NotifyDefault {
+		if err := config.ValidateNotifyStrategy(opts.Notify); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
 
 	// Get current window name
 	var window string
@@ -84,7 +101,7 @@ func Status(args []string, stdout, stderr io.Writer, opts StatusOptions) int {
 		window = opts.MockWindow
 	} else {
 		var err error
-		window, err = tmux.GetCurrentWindow()
+		window, err = mail.CurrentMailbox(opts.RepoRoot)
 		if err != nil {
 			fmt.Fprintf(stderr, "error: failed to get current window: %v\n", err)
 			return 1
@@ -111,6 +128,12 @@ func Status(args []string, stdout, stderr io.Writer, opts StatusOptions) int {
 		fmt.Fprintf(stderr, "error: failed to update status: %v\n", err)
 		return 1
 	}
+	if opts.Notify != "" || opts.NotifyText != "" {
+		if err := mail.SetNotifyPreference(repoRoot, window, opts.Notify, opts.NotifyText); err != nil {
+			fmt.Fprintf(stderr, "error: failed to update notification settings: %v\n", err)
+			return 1
+		}
+	}
 
 	// Silent success - no output
 	return 0
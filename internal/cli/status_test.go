@@ -601,3 +601,38 @@ func TestStatusCommand_MultipleAgents(t *testing.T) {
 		t.Errorf("Expected agent-2 status 'ready' (unchanged), got %s", agent2.Status)
 	}
 }
+
+func TestStatusCommand_NotifyOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Status([]string{"ready"}, &stdout, &stderr, StatusOptions{
+		SkipTmuxCheck: true,
+		MockWindow:    "agent-1",
+		RepoRoot:      tmpDir,
+		Notify:        "bell",
+		NotifyText:    "/mail {count}",
+	})
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	recipients, _ := mail.ReadAllRecipients(tmpDir)
+	if len(recipients) != 1 || recipients[0].Notify != "bell" || recipients[0].NotifyTemplate != "/mail {count}" {
+		t.Errorf("Expected bell with template stored, got %+v", recipients)
+	}
+
+	// An invalid strategy leaves the status untouched
+	exitCode = Status([]string{"work"}, &stdout, &stderr, StatusOptions{
+		SkipTmuxCheck: true,
+		MockWindow:    "agent-1",
+		RepoRoot:      tmpDir,
+		Notify:        "pager",
+	})
+	if exitCode != 1 || !strings.Contains(stderr.String(), `invalid notify strategy "pager"`) {
+		t.Errorf("Expected exit 1 with invalid strategy error, got %d: %s", exitCode, stderr.String())
+	}
+	recipients, _ = mail.ReadAllRecipients(tmpDir)
+	if recipients[0].Status != mail.StatusReady {
+		t.Errorf("Expected status to stay ready, got %s", recipients[0].Status)
+	}
+}
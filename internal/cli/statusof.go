@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"agentmail/internal/mail"
+)
+
+// StatusOfOptions configures the StatusOf command behavior.
+type StatusOfOptions struct {
+	RepoRoot string // Repository root (defaults to finding git root)
+}
+
+// StatusOf implements the agentmail status-of command.
+// It prints where a message is and its delivery events, so a sender can see
+// whether a message was queued, notified, read and acked.
+//
+// Exit Codes:
+// - 0: Status printed
+// - 1: Missing argument, unknown message, or read failure
+func StatusOf(args []string, stdout, stderr io.Writer, opts StatusOfOptions) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "error: missing required argument: message-id")
+		fmt.Fprintln(stderr, "usage: agentmail status-of <message-id>")
+		return 1
+	}
+
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return 1
+		}
+	}
+
+	status, err := mail.MessageStatus(repoRoot, args[0])
+	if err != nil {
+		if errors.Is(err, mail.ErrMessageNotFound) {
+			fmt.Fprintf(stderr, "error: message #%s not found\n", args[0])
+			return 1
+		}
+		fmt.Fprintf(stderr, "error: failed to read message status: %v\n", err)
+		return 1
+	}
+
+	// Format:
+	// Message #<id>
+	// From: <sender> (unless removed)
+	// To: <recipient> (unless removed)
+	// State: <state>
+	//
+	// <time>  <event>
+	msg := status.Message
+	fmt.Fprintf(stdout, "Message #%s\n", msg.ID)
+	if msg.From != "" {
+		fmt.Fprintf(stdout, "From: %s\n", msg.From)
+		fmt.Fprintf(stdout, "To: %s\n", msg.To)
+	}
+	fmt.Fprintf(stdout, "State: %s\n", status.State)
+	if msg.Receipt {
+		fmt.Fprintln(stdout, "Receipt: requested")
+	}
+	if len(status.Events) == 0 {
+		return 0
+	}
+	fmt.Fprintln(stdout)
+	for _, event := range status.Events {
+		fmt.Fprintf(stdout, "%s  %s\n", event.At.Format(time.RFC3339), event.Event)
+	}
+	return 0
+}
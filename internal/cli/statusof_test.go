@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatusOfCommand_MissingMessageID(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := StatusOf([]string{}, &stdout, &stderr, StatusOfOptions{RepoRoot: t.TempDir()})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+}
+
+func TestStatusOfCommand_NotFound(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := StatusOf([]string{"missing1"}, &stdout, &stderr, StatusOfOptions{RepoRoot: t.TempDir()})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if stderr.String() != "error: message #missing1 not found\n" {
+		t.Errorf("Unexpected stderr: %q", stderr.String())
+	}
+}
+
+func TestStatusOfCommand_PrintsStateAndEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	mailDir := filepath.Join(tmpDir, ".agentmail", "mailboxes")
+	if err := os.MkdirAll(mailDir, 0755); err != nil {
+		t.Fatalf("Failed to create mail dir: %v", err)
+	}
+
+	mailbox := `{"id":"xK7mN2pQ","from":"agent-1","to":"agent-2","message":"Review please","read_flag":true,"created_at":"2026-01-14T09:30:00Z","receipt":true}
+`
+	events := `{"id":"xK7mN2pQ","event":"queued","at":"2026-01-14T09:30:00Z","recipient":"agent-2"}
+{"id":"other001","event":"queued","at":"2026-01-14T09:30:01Z","recipient":"agent-3"}
+{"id":"xK7mN2pQ","event":"notified","at":"2026-01-14T09:30:05Z","recipient":"agent-2"}
+{"id":"xK7mN2pQ","event":"read","at":"2026-01-14T09:31:12Z","recipient":"agent-2"}
+`
+	if err := os.WriteFile(filepath.Join(mailDir, "agent-2.jsonl"), []byte(mailbox), 0644); err != nil {
+		t.Fatalf("Failed to write mailbox: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".agentmail", "events.jsonl"), []byte(events), 0644); err != nil {
+		t.Fatalf("Failed to write events: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := StatusOf([]string{"xK7mN2pQ"}, &stdout, &stderr, StatusOfOptions{RepoRoot: tmpDir})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+
+	expected := `Message #xK7mN2pQ
+From: agent-1
+To: agent-2
+State: read
+Receipt: requested
+
+2026-01-14T09:30:00Z  queued
+2026-01-14T09:30:05Z  notified
+2026-01-14T09:31:12Z  read
+`
+	if stdout.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nExpected:\n%s", stdout.String(), expected)
+	}
+}
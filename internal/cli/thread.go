@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"agentmail/internal/mail"
+)
+
+// ThreadOptions configures the Thread command behavior.
+type ThreadOptions struct {
+	RepoRoot string // Repository root (defaults to finding git root)
+}
+
+// Thread implements the agentmail thread command.
+// It prints every message in the conversation containing the given message ID,
+// collected from all mailboxes and ordered oldest first.
+//
+// Exit Codes:
+// - 0: Thread printed
+// - 1: Missing argument, unknown message, or read failure
+func Thread(args []string, stdout, stderr io.Writer, opts ThreadOptions) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "error: missing required argument: message-id")
+		fmt.Fprintln(stderr, "usage: agentmail thread <message-id>")
+		return 1
+	}
+
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: not in a git repository: %v\n", err)
+			return 1
+		}
+	}
+
+	thread, err := mail.FindThread(repoRoot, args[0])
+	if err != nil {
+		if errors.Is(err, mail.ErrMessageNotFound) {
+			fmt.Fprintf(stderr, "error: message #%s not found\n", args[0])
+			return 1
+		}
+		fmt.Fprintf(stderr, "error: failed to read messages: %v\n", err)
+		return 1
+	}
+
+	// Format:
+	// Thread #<root> (<n> messages)
+	//
+	// From: <sender>
+	// To: <recipient>
+	// ID: <id>
+	// In-Reply-To: <id> (replies only)
+	//
+	// <message>
+	fmt.Fprintf(stdout, "Thread #%s (%d messages)\n", thread[0].ThreadRoot(), len(thread))
+	for _, msg := range thread {
+		fmt.Fprintln(stdout)
+		fmt.Fprintf(stdout, "From: %s\n", msg.From)
+		fmt.Fprintf(stdout, "To: %s\n", msg.To)
+		fmt.Fprintf(stdout, "ID: %s\n", msg.ID)
+		if msg.InReplyTo != "" {
+			fmt.Fprintf(stdout, "In-Reply-To: %s\n", msg.InReplyTo)
+		}
+		fmt.Fprintln(stdout)
+		fmt.Fprintln(stdout, msg.Message)
+	}
+
+	return 0
+}
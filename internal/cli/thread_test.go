@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestThreadCommand_MissingMessageID(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Thread([]string{}, &stdout, &stderr, ThreadOptions{RepoRoot: t.TempDir()})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+}
+
+func TestThreadCommand_NotFound(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Thread([]string{"missing1"}, &stdout, &stderr, ThreadOptions{RepoRoot: t.TempDir()})
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if stderr.String() != "error: message #missing1 not found\n" {
+		t.Errorf("Unexpected stderr: %q", stderr.String())
+	}
+}
+
+func TestThreadCommand_PrintsConversation(t *testing.T) {
+	tmpDir := t.TempDir()
+	mailDir := filepath.Join(tmpDir, ".agentmail", "mailboxes")
+	if err := os.MkdirAll(mailDir, 0755); err != nil {
+		t.Fatalf("Failed to create mail dir: %v", err)
+	}
+
+	agent2 := `{"id":"q0000001","from":"agent-1","to":"agent-2","message":"Ready to merge?","read_flag":true,"created_at":"2026-01-01T10:00:00Z"}
+`
+	agent1 := `{"id":"a0000001","from":"agent-2","to":"agent-1","message":"Yes","read_flag":false,"created_at":"2026-01-01T10:01:00Z","in_reply_to":"q0000001","thread_id":"q0000001"}
+`
+	if err := os.WriteFile(filepath.Join(mailDir, "agent-2.jsonl"), []byte(agent2), 0644); err != nil {
+		t.Fatalf("Failed to write mailbox: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(mailDir, "agent-1.jsonl"), []byte(agent1), 0644); err != nil {
+		t.Fatalf("Failed to write mailbox: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	exitCode := Thread([]string{"a0000001"}, &stdout, &stderr, ThreadOptions{RepoRoot: tmpDir})
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+
+	expected := `Thread #q0000001 (2 messages)
+
+From: agent-1
+To: agent-2
+ID: q0000001
+
+Ready to merge?
+
+From: agent-2
+To: agent-1
+ID: a0000001
+In-Reply-To: q0000001
+
+Yes
+`
+	if stdout.String() != expected {
+		t.Errorf("Unexpected output.\nExpected:\n%s\nGot:\n%s", expected, stdout.String())
+	}
+}
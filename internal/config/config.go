@@ -0,0 +1,460 @@
+// Package config loads AgentMail settings from .agentmail/config.toml.
+//
+// The file uses a small subset of TOML: one "key = value" pair per line,
+// "#" comments and blank lines. Environment variables override the file.
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// File is the configuration file, relative to the repository root
+const File = ".agentmail/config.toml"
+
+// Storage backends
+const (
+	StoreJSONL = "jsonl" // One JSONL file per mailbox (default)
+	StoreBolt  = "bolt"  // Single-file embedded database (.agentmail/agentmail.db)
+)
+
+// Notification strategies of the mailman
+const (
+	NotifyKeys    = "keys"    // Type the notification text into the window and press Enter (default)
+	NotifyDisplay = "display" // Show the text in the tmux status line without touching the prompt
+	NotifyBell    = "bell"    // Ring the terminal bell in the window
+	NotifyCommand = "command" // Run notify_command
+)
+
+// EnvStore overrides the store setting.
+const EnvStore = "AGENTMAIL_STORE"
+
+// EnvPrefix starts the environment variable that overrides each key:
+// notify_debounce is overridden by AGENTMAIL_NOTIFY_DEBOUNCE.
+const EnvPrefix = "AGENTMAIL_"
+
+// Defaults of the tunable settings
+const (
+	DefaultNotifyDebounce          = 60 * time.Second
+	DefaultWorkProtection          = time.Hour
+	DefaultStatelessNotifyInterval = 60 * time.Second
+	DefaultDebounceWindow          = 500 * time.Millisecond
+	DefaultFallbackInterval        = 60 * time.Second
+	DefaultStaleThreshold          = time.Hour
+	DefaultMaxMessageSize          = 65536
+	DefaultIdempotencyWindow       = time.Hour
+	DefaultCleanupStaleHours       = 48
+	DefaultCleanupDeliveredHours   = 2
+	DefaultUndeliverableHours      = 24
+	DefaultMaxNotifications        = 10
+	DefaultNotifyTemplate          = "Check your agentmail"
+	DefaultIdleQuiet               = 2 * time.Second
+)
+
+// Config holds the AgentMail settings for a repository.
+type Config struct {
+	Store string // Storage backend: StoreJSONL or StoreBolt
+
+	NotifyDebounce          time.Duration // Minimum time between notifications of a ready agent
+	WorkProtection          time.Duration // No notifications for this long after an agent switches to work or offline
+	StatelessNotifyInterval time.Duration // Minimum time between notifications of an agent without recipient state
+	DebounceWindow          time.Duration // How long the mailman lets file events settle before checking
+	FallbackInterval        time.Duration // How often the mailman checks when no file event arrives
+	StaleThreshold          time.Duration // Age at which the mailman drops recipient states
+	MaxMessageSize          int           // Largest message the MCP send and ask tools accept, in bytes
+	IdempotencyWindow       time.Duration // How long a sender's idempotency key is remembered
+
+	CleanupStaleHours     int // Default of cleanup --stale-hours
+	CleanupDeliveredHours int // Default of cleanup --delivered-hours
+	UndeliverableHours    int // Hours unread mail for a missing window waits before dead-lettering (0 = disabled)
+	MaxNotifications      int // Notifications an unread message may trigger before dead-lettering (0 = disabled)
+
+	NotifyStrategy string // How the mailman notifies agents: NotifyKeys, NotifyDisplay, NotifyBell or NotifyCommand
+	NotifyTemplate string // Notification text; {sender}, {count}, {subject} and {recipient} are filled in
+	NotifyCommand  string // Shell command run by the NotifyCommand strategy
+
+	IdleQuiet  time.Duration // How long a pane must be unchanged before the keys strategy types into it (0 = only check IdlePrompt)
+	IdlePrompt string        // Regular expression the pane content must match before keys are typed (empty = any)
+}
+
+// Default returns the settings used when nothing is configured.
+func Default() Config {
+	return Config{
+		Store:                   StoreJSONL,
+		NotifyDebounce:          DefaultNotifyDebounce,
+		WorkProtection:          DefaultWorkProtection,
+		StatelessNotifyInterval: DefaultStatelessNotifyInterval,
+		DebounceWindow:          DefaultDebounceWindow,
+		FallbackInterval:        DefaultFallbackInterval,
+		StaleThreshold:          DefaultStaleThreshold,
+		MaxMessageSize:          DefaultMaxMessageSize,
+		IdempotencyWindow:       DefaultIdempotencyWindow,
+		CleanupStaleHours:       DefaultCleanupStaleHours,
+		CleanupDeliveredHours:   DefaultCleanupDeliveredHours,
+		UndeliverableHours:      DefaultUndeliverableHours,
+		MaxNotifications:        DefaultMaxNotifications,
+		NotifyStrategy:          NotifyKeys,
+		NotifyTemplate:          DefaultNotifyTemplate,
+		IdleQuiet:               DefaultIdleQuiet,
+	}
+}
+
+// Warn reports a problem Load worked around. It prints each problem to stderr
+// once per process, so long-running commands don't repeat it on every load.
+var Warn = func(problem error) {
+	warnedMu.Lock()
+	defer warnedMu.Unlock()
+	if !warned[problem.Error()] {
+		warned[problem.Error()] = true
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", problem)
+	}
+}
+
+var (
+	warned   = make(map[string]bool)
+	warnedMu sync.Mutex
+)
+
+// Load reads the repository's config file and applies environment overrides.
+// A missing file yields the defaults. Malformed lines, unknown keys and invalid
+// values are reported through Warn and leave their keys at the defaults, so a
+// typo doesn't stop mail. Only an invalid store fails: it decides where mail is.
+func Load(repoRoot string) (Config, error) {
+	cfg, _, problems, err := load(repoRoot)
+	for _, problem := range problems {
+		Warn(problem)
+	}
+	return cfg, err
+}
+
+// LoadStore returns the storage backend of the repository, the one setting
+// every mail operation needs. Problems with other keys are not reported.
+func LoadStore(repoRoot string) (string, error) {
+	store := StoreJSONL
+	values, problems, err := readFile(filepath.Join(repoRoot, File))
+	if err != nil {
+		return "", err
+	}
+	if err := storeLineError(problems); err != nil {
+		return "", err
+	}
+	if value, ok := values["store"]; ok {
+		store = value
+	}
+	if value := os.Getenv(EnvStore); value != "" {
+		store = value
+	}
+	if err := ValidateStore(store); err != nil {
+		return "", err
+	}
+	return store, nil
+}
+
+// Check returns the problems Load works around: malformed lines, unknown keys
+// and invalid values, including an invalid store.
+func Check(repoRoot string) []error {
+	_, _, problems, err := load(repoRoot)
+	if err != nil {
+		problems = append(problems, err)
+	}
+	return problems
+}
+
+// load is Load that also reports which keys were set and by what (SourceFile
+// or the name of the overriding environment variable), and the problems it
+// worked around.
+func load(repoRoot string) (Config, map[string]string, []error, error) {
+	cfg := Default()
+	sources := make(map[string]string)
+
+	values, problems, err := readFile(filepath.Join(repoRoot, File))
+	if err != nil {
+		return Config{}, nil, nil, err
+	}
+	if err := storeLineError(problems); err != nil {
+		return Config{}, nil, problems, err
+	}
+	for key, value := range values {
+		s, ok := lookup(key)
+		if !ok {
+			problems = append(problems, fmt.Errorf("%s: unknown key %q", File, key))
+			continue
+		}
+		if err := s.set(&cfg, value); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", File, err))
+			continue
+		}
+		sources[key] = SourceFile
+	}
+
+	for _, s := range settings {
+		env := Env(s.key)
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		if err := s.set(&cfg, value); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", env, err))
+			continue
+		}
+		sources[s.key] = env
+	}
+
+	if err := ValidateStore(cfg.Store); err != nil {
+		return Config{}, nil, problems, err
+	}
+	return cfg, sources, append(problems, cfg.repair()...), nil
+}
+
+// Validate checks that every setting has an allowed value.
+func (c Config) Validate() error {
+	if err := ValidateStore(c.Store); err != nil {
+		return err
+	}
+	if problems := c.repair(); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// repair resets the settings other than the store that Validate rejects to
+// their defaults and returns why.
+func (c *Config) repair() []error {
+	defaults := Default()
+	var problems []error
+	if err := ValidateNotifyStrategy(c.NotifyStrategy); err != nil {
+		problems = append(problems, err)
+		c.NotifyStrategy = defaults.NotifyStrategy
+	}
+	if c.NotifyTemplate == "" {
+		problems = append(problems, fmt.Errorf("notify_template must not be empty"))
+		c.NotifyTemplate = defaults.NotifyTemplate
+	}
+	if c.NotifyStrategy == NotifyCommand && c.NotifyCommand == "" {
+		problems = append(problems, fmt.Errorf("notify_strategy %q requires notify_command", NotifyCommand))
+		c.NotifyStrategy = defaults.NotifyStrategy
+	}
+	if _, err := regexp.Compile(c.IdlePrompt); err != nil {
+		problems = append(problems, fmt.Errorf("invalid idle_prompt: %v", err))
+		c.IdlePrompt = defaults.IdlePrompt
+	}
+	return problems
+}
+
+// ValidateStore checks that store is one of the storage backends.
+func ValidateStore(store string) error {
+	switch store {
+	case StoreJSONL, StoreBolt:
+		return nil
+	default:
+		return fmt.Errorf("invalid store %q (must be %s or %s)", store, StoreJSONL, StoreBolt)
+	}
+}
+
+// ValidateNotifyStrategy checks that strategy is one of the notification strategies.
+func ValidateNotifyStrategy(strategy string) error {
+	switch strategy {
+	case NotifyKeys, NotifyDisplay, NotifyBell, NotifyCommand:
+		return nil
+	default:
+		return fmt.Errorf("invalid notify strategy %q (must be %s, %s, %s or %s)", strategy, NotifyKeys, NotifyDisplay, NotifyBell, NotifyCommand)
+	}
+}
+
+// Set validates value and writes it to the repository's config file, replacing
+// the key's line or appending one. Comments and other keys are kept.
+// The file is replaced atomically, so a concurrent Load never sees a partial write.
+// Callers check first that the store accepts writes (mail.CheckWritable).
+func Set(repoRoot, key, value string) error {
+	s, ok := lookup(key)
+	if !ok {
+		return fmt.Errorf("unknown key %q", key)
+	}
+
+	// Validate the new value together with the rest of the file, since some
+	// keys depend on each other (notify_strategy "command" needs notify_command)
+	path := filepath.Join(repoRoot, File)
+	values, problems, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return problems[0] // Don't rewrite a file that doesn't parse
+	}
+	cfg := Default()
+	for name, existing := range values {
+		if other, ok := lookup(name); ok && name != key {
+			_ = other.set(&cfg, existing) // G104: an invalid value elsewhere is reported by Validate or Load
+		}
+	}
+	if err := s.set(&cfg, value); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 - path is constructed from constant
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	line := formatLine(key, s.get(cfg), s.quoted)
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	replaced := false
+	for i, existing := range lines {
+		trimmed := strings.TrimSpace(existing)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if name, _, _ := strings.Cut(trimmed, "="); strings.TrimSpace(name) == key {
+			lines[i] = line
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, line)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	return writeFile(path, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// writeFile replaces path with data through a synced temp file and a rename,
+// so a crash leaves either the old or the new file, never a torn one.
+func writeFile(path string, data []byte) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()           // G104: may already be closed
+			_ = os.Remove(tmp.Name()) // G104: best-effort cleanup of the temp file
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lineError is a line of the config file that doesn't parse.
+type lineError struct {
+	line int
+	key  string // Key of the line, if it has one
+	err  error
+}
+
+func (e *lineError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", File, e.line, e.err)
+}
+
+// storeLineError returns the problem with the store line, if it doesn't parse:
+// the backend can't be guessed, so unlike other keys it isn't left at the default.
+func storeLineError(problems []error) error {
+	for _, problem := range problems {
+		var lineErr *lineError
+		if errors.As(problem, &lineErr) && lineErr.key == "store" {
+			return problem
+		}
+	}
+	return nil
+}
+
+// readFile parses the config file into raw key/value pairs, skipping lines
+// that don't parse and returning why. Returns an empty map if the file doesn't exist.
+func readFile(path string) (map[string]string, []error, error) {
+	file, err := os.Open(path) // #nosec G304 - path is constructed from constant
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil, nil
+		}
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	var problems []error
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			problems = append(problems, &lineError{line: lineNo, err: errors.New("expected key = value")})
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			problems = append(problems, &lineError{line: lineNo, key: key, err: err})
+			continue
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return values, problems, nil
+}
+
+// parseValue decodes a TOML scalar: a quoted string, or a bare number or boolean.
+// A trailing "# comment" after the value is ignored.
+func parseValue(raw string) (string, error) {
+	if strings.HasPrefix(raw, `"`) {
+		end := -1
+		for i := 1; i < len(raw); i++ {
+			if raw[i] == '\\' {
+				i++ // Skip the escaped character
+				continue
+			}
+			if raw[i] == '"' {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		quoted := raw[:end+1]
+		if rest := strings.TrimSpace(raw[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected text after value: %q", rest)
+		}
+		return strconv.Unquote(quoted)
+	}
+
+	if i := strings.Index(raw, "#"); i >= 0 {
+		raw = strings.TrimSpace(raw[:i])
+	}
+	if raw == "" {
+		return "", fmt.Errorf("missing value")
+	}
+	return raw, nil
+}
@@ -0,0 +1,373 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, repoRoot, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(repoRoot, ".agentmail"), 0750); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoRoot, File), []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+// loadWarnings runs Load and returns the problems it warned about.
+func loadWarnings(t *testing.T, repoRoot string) (Config, []string, error) {
+	t.Helper()
+	var warnings []string
+	saved := Warn
+	Warn = func(problem error) { warnings = append(warnings, problem.Error()) }
+	defer func() { Warn = saved }()
+	cfg, err := Load(repoRoot)
+	return cfg, warnings, err
+}
+
+// expectWarning checks that warnings holds one containing want.
+func expectWarning(t *testing.T, warnings []string, want string) {
+	t.Helper()
+	for _, w := range warnings {
+		if strings.Contains(w, want) {
+			return
+		}
+	}
+	t.Errorf("Expected a warning containing %q, got %q", want, warnings)
+}
+
+func TestLoad_MissingFileUsesDefaults(t *testing.T) {
+	t.Setenv(EnvStore, "")
+
+	cfg, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg != Default() {
+		t.Errorf("Expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoad_ReadsFile(t *testing.T) {
+	t.Setenv(EnvStore, "")
+	repoRoot := t.TempDir()
+	writeConfig(t, repoRoot, "# storage\n\nstore = \"bolt\" # single file\n")
+
+	cfg, err := Load(repoRoot)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Store != StoreBolt {
+		t.Errorf("Expected store %q, got %q", StoreBolt, cfg.Store)
+	}
+}
+
+func TestLoad_EnvOverridesFile(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeConfig(t, repoRoot, "store = \"bolt\"\n")
+	t.Setenv(EnvStore, StoreJSONL)
+
+	cfg, err := Load(repoRoot)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Store != StoreJSONL {
+		t.Errorf("Expected env override %q, got %q", StoreJSONL, cfg.Store)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Setenv(EnvStore, "")
+
+	// The store decides where mail is: a bad store setting fails
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"invalid store", "store = \"sqlite\"\n", "invalid store"},
+		{"unterminated string", "store = \"bolt\n", "unterminated string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoRoot := t.TempDir()
+			writeConfig(t, repoRoot, tt.content)
+
+			_, err := Load(repoRoot)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+			if _, err := LoadStore(repoRoot); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected LoadStore error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoad_WarnsAboutOtherProblems(t *testing.T) {
+	t.Setenv(EnvStore, "")
+	t.Setenv(Env("notify_debounce"), "")
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown key", "stroe = \"bolt\"\n", "unknown key"},
+		{"missing equals", "notify_debounce\n", "expected key = value"},
+		{"unterminated string", "notify_template = \"mail\n", "unterminated string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoRoot := t.TempDir()
+			writeConfig(t, repoRoot, tt.content+"store = \"bolt\"\n")
+
+			cfg, warnings, err := loadWarnings(t, repoRoot)
+			if err != nil {
+				t.Fatalf("Expected Load to work around the problem, got %v", err)
+			}
+			expectWarning(t, warnings, tt.want)
+			if cfg.Store != StoreBolt || cfg.NotifyTemplate != DefaultNotifyTemplate {
+				t.Errorf("Expected valid keys to apply and the others to keep defaults, got %+v", cfg)
+			}
+			if problems := Check(repoRoot); len(problems) != 1 || !strings.Contains(problems[0].Error(), tt.want) {
+				t.Errorf("Expected Check to report %q, got %v", tt.want, problems)
+			}
+		})
+	}
+}
+
+func TestLoad_TunableSettings(t *testing.T) {
+	t.Setenv(EnvStore, "")
+	t.Setenv(Env("work_protection"), "")
+	t.Setenv(Env("max_message_size"), "")
+	repoRoot := t.TempDir()
+	writeConfig(t, repoRoot, "notify_debounce = \"90s\"\nwork_protection = \"30m\"\nmax_message_size = 1024\ncleanup_stale_hours = 0\n")
+
+	cfg, err := Load(repoRoot)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.NotifyDebounce != 90*time.Second || cfg.WorkProtection != 30*time.Minute {
+		t.Errorf("Expected 90s and 30m, got %v and %v", cfg.NotifyDebounce, cfg.WorkProtection)
+	}
+	if cfg.MaxMessageSize != 1024 || cfg.CleanupStaleHours != 0 {
+		t.Errorf("Expected 1024 and 0, got %d and %d", cfg.MaxMessageSize, cfg.CleanupStaleHours)
+	}
+	if cfg.FallbackInterval != DefaultFallbackInterval {
+		t.Errorf("Expected unset keys to keep defaults, got %v", cfg.FallbackInterval)
+	}
+
+	// Every key can be overridden from the environment
+	t.Setenv(Env("work_protection"), "2h")
+	cfg, err = Load(repoRoot)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.WorkProtection != 2*time.Hour {
+		t.Errorf("Expected env override 2h, got %v", cfg.WorkProtection)
+	}
+}
+
+func TestLoad_InvalidTunableSettings(t *testing.T) {
+	t.Setenv(EnvStore, "")
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"not a duration", "notify_debounce = \"soon\"\n", "invalid notify_debounce"},
+		{"zero duration", "fallback_interval = \"0s\"\n", "invalid fallback_interval"},
+		{"zero size", "max_message_size = 0\n", "invalid max_message_size"},
+		{"zero window", "idempotency_window = \"0s\"\n", "invalid idempotency_window"},
+		{"negative hours", "cleanup_delivered_hours = -1\n", "invalid cleanup_delivered_hours"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoRoot := t.TempDir()
+			writeConfig(t, repoRoot, tt.content)
+
+			cfg, warnings, err := loadWarnings(t, repoRoot)
+			if err != nil {
+				t.Fatalf("Expected Load to work around the invalid value, got %v", err)
+			}
+			expectWarning(t, warnings, tt.want)
+			if cfg != Default() {
+				t.Errorf("Expected the invalid value to be replaced by its default, got %+v", cfg)
+			}
+		})
+	}
+
+	t.Run("invalid env override", func(t *testing.T) {
+		t.Setenv(Env("max_notifications"), "many")
+		cfg, warnings, _ := loadWarnings(t, t.TempDir())
+		expectWarning(t, warnings, "AGENTMAIL_MAX_NOTIFICATIONS")
+		if cfg.MaxNotifications != DefaultMaxNotifications {
+			t.Errorf("Expected the default, got %d", cfg.MaxNotifications)
+		}
+	})
+}
+
+func TestSet_KeepsCommentsAndOtherKeys(t *testing.T) {
+	t.Setenv(EnvStore, "")
+	t.Setenv(Env("notify_debounce"), "")
+	repoRoot := t.TempDir()
+	writeConfig(t, repoRoot, "# team settings\nstore = \"bolt\"\nnotify_debounce = \"90s\"\n")
+
+	if err := Set(repoRoot, "notify_debounce", "2m"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := Set(repoRoot, "max_notifications", "3"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(repoRoot, File))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	want := "# team settings\nstore = \"bolt\"\nnotify_debounce = \"2m\"\nmax_notifications = 3\n"
+	if string(data) != want {
+		t.Errorf("Expected file:\n%s\ngot:\n%s", want, data)
+	}
+
+	cfg, err := Load(repoRoot)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Store != StoreBolt || cfg.NotifyDebounce != 2*time.Minute || cfg.MaxNotifications != 3 {
+		t.Errorf("Unexpected settings after Set: %+v", cfg)
+	}
+}
+
+func TestSet_RejectsInvalidValues(t *testing.T) {
+	repoRoot := t.TempDir()
+
+	for _, kv := range [][2]string{{"store", "sqlite"}, {"debounce_window", "fast"}, {"stroe", "bolt"}} {
+		if err := Set(repoRoot, kv[0], kv[1]); err == nil {
+			t.Errorf("Expected Set(%q, %q) to fail", kv[0], kv[1])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, File)); !os.IsNotExist(err) {
+		t.Errorf("Expected no config file after rejected values, got %v", err)
+	}
+}
+
+func TestDescribe_ReportsSources(t *testing.T) {
+	t.Setenv(EnvStore, "")
+	t.Setenv(Env("work_protection"), "")
+	repoRoot := t.TempDir()
+	writeConfig(t, repoRoot, "work_protection = \"30m\"\n")
+	t.Setenv(Env("debounce_window"), "1s")
+
+	settings, err := Describe(repoRoot)
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	if len(settings) != len(Keys()) {
+		t.Fatalf("Expected %d settings, got %d", len(Keys()), len(settings))
+	}
+	sources := make(map[string]Setting)
+	for _, s := range settings {
+		sources[s.Key] = s
+	}
+	if s := sources["work_protection"]; s.Source != SourceFile || s.Line() != `work_protection = "30m"` {
+		t.Errorf("Unexpected work_protection: %+v", s)
+	}
+	if s := sources["debounce_window"]; s.Source != "AGENTMAIL_DEBOUNCE_WINDOW" || s.Value != "1s" {
+		t.Errorf("Unexpected debounce_window: %+v", s)
+	}
+	if s := sources["max_message_size"]; s.Source != SourceDefault || s.Line() != "max_message_size = 65536" {
+		t.Errorf("Unexpected max_message_size: %+v", s)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Hour:               "1h",
+		90 * time.Minute:        "1h30m",
+		60 * time.Second:        "1m",
+		90 * time.Second:        "1m30s",
+		500 * time.Millisecond:  "500ms",
+		time.Hour + time.Second: "1h0m1s",
+	}
+	for d, want := range tests {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestLoad_NotifySettings(t *testing.T) {
+	t.Setenv(EnvStore, "")
+	for _, key := range []string{"notify_strategy", "notify_template", "notify_command"} {
+		t.Setenv(Env(key), "")
+	}
+
+	repoRoot := t.TempDir()
+	writeConfig(t, repoRoot, "notify_strategy = \"command\"\n")
+	cfg, warnings, _ := loadWarnings(t, repoRoot)
+	expectWarning(t, warnings, "requires notify_command")
+	if cfg.NotifyStrategy != NotifyKeys {
+		t.Errorf("Expected command strategy without notify_command to fall back to keys, got %q", cfg.NotifyStrategy)
+	}
+	writeConfig(t, repoRoot, "notify_strategy = \"pager\"\n")
+	cfg, warnings, _ = loadWarnings(t, repoRoot)
+	expectWarning(t, warnings, "invalid notify strategy")
+	if cfg.NotifyStrategy != NotifyKeys {
+		t.Errorf("Expected unknown strategy to fall back to keys, got %q", cfg.NotifyStrategy)
+	}
+	if err := Set(repoRoot, "notify_strategy", "pager"); err == nil {
+		t.Error("Expected Set to reject an unknown strategy")
+	}
+
+	// Set checks the new value against the rest of the file
+	writeConfig(t, repoRoot, "notify_command = \"notify-send agentmail \\\"$AGENTMAIL_TEXT\\\"\"\n")
+	if err := Set(repoRoot, "notify_strategy", NotifyCommand); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	cfg, err := Load(repoRoot)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.NotifyStrategy != NotifyCommand || cfg.NotifyCommand != `notify-send agentmail "$AGENTMAIL_TEXT"` {
+		t.Errorf("Unexpected notify settings: %+v", cfg)
+	}
+	if cfg.NotifyTemplate != DefaultNotifyTemplate {
+		t.Errorf("Expected default template, got %q", cfg.NotifyTemplate)
+	}
+}
+
+func TestLoad_IdleSettings(t *testing.T) {
+	t.Setenv(EnvStore, "")
+	for _, key := range []string{"idle_quiet", "idle_prompt"} {
+		t.Setenv(Env(key), "")
+	}
+
+	repoRoot := t.TempDir()
+	writeConfig(t, repoRoot, "idle_quiet = \"0\"\nidle_prompt = \"(?m)^> $\"\n")
+	cfg, err := Load(repoRoot)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.IdleQuiet != 0 || cfg.IdlePrompt != "(?m)^> $" {
+		t.Errorf("Unexpected idle settings: %+v", cfg)
+	}
+	if err := Set(repoRoot, "idle_quiet", "-1s"); err == nil {
+		t.Error("Expected a negative idle_quiet to be rejected")
+	}
+
+	writeConfig(t, repoRoot, "idle_prompt = \"([\"\n")
+	cfg, warnings, _ := loadWarnings(t, repoRoot)
+	expectWarning(t, warnings, "invalid idle_prompt")
+	if cfg.IdlePrompt != "" {
+		t.Errorf("Expected an invalid pattern to be dropped, got %q", cfg.IdlePrompt)
+	}
+}
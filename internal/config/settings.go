@@ -0,0 +1,210 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources of a setting's value other than an environment variable
+const (
+	SourceDefault = "default" // Not set anywhere
+	SourceFile    = "file"    // Set in the config file
+)
+
+// setting is one key of the config file: how to parse it into a Config and print it back.
+type setting struct {
+	key    string
+	help   string
+	quoted bool // Written as a TOML string
+	get    func(c Config) string
+	set    func(c *Config, value string) error
+}
+
+// settings lists every config key in documentation order.
+var settings = []setting{
+	stringSetting("store", "storage backend: jsonl or bolt",
+		func(c *Config) *string { return &c.Store }),
+	durationSetting("notify_debounce", "minimum time between notifications of a ready agent", false,
+		func(c *Config) *time.Duration { return &c.NotifyDebounce }),
+	durationSetting("work_protection", "no notifications for this long after an agent switches to work or offline", false,
+		func(c *Config) *time.Duration { return &c.WorkProtection }),
+	durationSetting("stateless_notify_interval", "minimum time between notifications of an agent without status", false,
+		func(c *Config) *time.Duration { return &c.StatelessNotifyInterval }),
+	durationSetting("debounce_window", "how long the mailman lets file events settle before checking", false,
+		func(c *Config) *time.Duration { return &c.DebounceWindow }),
+	durationSetting("fallback_interval", "how often the mailman checks when no file event arrives", false,
+		func(c *Config) *time.Duration { return &c.FallbackInterval }),
+	durationSetting("stale_threshold", "age at which the mailman drops recipient states", false,
+		func(c *Config) *time.Duration { return &c.StaleThreshold }),
+	intSetting("max_message_size", "largest message the MCP send and ask tools accept, in bytes", 1,
+		func(c *Config) *int { return &c.MaxMessageSize }),
+	durationSetting("idempotency_window", "how long a retry with the same --idempotency-key returns the original send", false,
+		func(c *Config) *time.Duration { return &c.IdempotencyWindow }),
+	intSetting("cleanup_stale_hours", "default of cleanup --stale-hours", 0,
+		func(c *Config) *int { return &c.CleanupStaleHours }),
+	intSetting("cleanup_delivered_hours", "default of cleanup --delivered-hours", 0,
+		func(c *Config) *int { return &c.CleanupDeliveredHours }),
+	intSetting("undeliverable_hours", "hours unread mail for a missing window waits before dead-lettering (0 = disabled)", 0,
+		func(c *Config) *int { return &c.UndeliverableHours }),
+	intSetting("max_notifications", "notifications an unread message may trigger before dead-lettering (0 = disabled)", 0,
+		func(c *Config) *int { return &c.MaxNotifications }),
+	stringSetting("notify_strategy", "how the mailman notifies agents: keys, display, bell or command",
+		func(c *Config) *string { return &c.NotifyStrategy }),
+	stringSetting("notify_template", "notification text; {sender}, {count}, {subject} and {recipient} are filled in",
+		func(c *Config) *string { return &c.NotifyTemplate }),
+	stringSetting("notify_command", "shell command run by the command strategy",
+		func(c *Config) *string { return &c.NotifyCommand }),
+	durationSetting("idle_quiet", "how long a pane must be unchanged before keys are typed into it (0 = only check idle_prompt)", true,
+		func(c *Config) *time.Duration { return &c.IdleQuiet }),
+	stringSetting("idle_prompt", "regular expression the pane must match before keys are typed into it (empty = any)",
+		func(c *Config) *string { return &c.IdlePrompt }),
+}
+
+// stringSetting is a key holding a string, checked by Validate.
+func stringSetting(key, help string, field func(c *Config) *string) setting {
+	return setting{
+		key:    key,
+		help:   help,
+		quoted: true,
+		get:    func(c Config) string { return *field(&c) },
+		set:    func(c *Config, value string) error { *field(c) = value; return nil },
+	}
+}
+
+// durationSetting is a key holding a positive duration such as "90s" or "2h",
+// or also zero if allowZero is set.
+func durationSetting(key, help string, allowZero bool, field func(c *Config) *time.Duration) setting {
+	return setting{
+		key:    key,
+		help:   help,
+		quoted: true,
+		get:    func(c Config) string { return FormatDuration(*field(&c)) },
+		set: func(c *Config, value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 || (d == 0 && !allowZero) {
+				if allowZero {
+					return fmt.Errorf("invalid %s %q (must be a duration such as 2s, or 0 to disable)", key, value)
+				}
+				return fmt.Errorf("invalid %s %q (must be a positive duration such as 90s or 2h)", key, value)
+			}
+			*field(c) = d
+			return nil
+		},
+	}
+}
+
+// intSetting is a key holding a whole number of at least min.
+func intSetting(key, help string, min int, field func(c *Config) *int) setting {
+	return setting{
+		key:  key,
+		help: help,
+		get:  func(c Config) string { return strconv.Itoa(*field(&c)) },
+		set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < min {
+				return fmt.Errorf("invalid %s %q (must be a whole number of at least %d)", key, value, min)
+			}
+			*field(c) = n
+			return nil
+		},
+	}
+}
+
+// ReadAtStart reports whether the mailman reads key only when it starts, so a
+// running mailman has to be restarted for a change to take effect. It reads the
+// other keys again on every check.
+func ReadAtStart(key string) bool {
+	switch key {
+	case "debounce_window", "fallback_interval", "stateless_notify_interval":
+		return true
+	}
+	return false
+}
+
+// lookup finds the setting for key.
+func lookup(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// Env returns the environment variable that overrides key.
+func Env(key string) string {
+	return EnvPrefix + strings.ToUpper(key)
+}
+
+// Keys returns every config key in documentation order.
+func Keys() []string {
+	keys := make([]string, len(settings))
+	for i, s := range settings {
+		keys[i] = s.key
+	}
+	return keys
+}
+
+// Get returns the value of key, without TOML quotes.
+func (c Config) Get(key string) (string, error) {
+	s, ok := lookup(key)
+	if !ok {
+		return "", fmt.Errorf("unknown key %q", key)
+	}
+	return s.get(c), nil
+}
+
+// Setting is a key's effective value, as listed by "agentmail config list".
+type Setting struct {
+	Key    string // Key in the config file
+	Value  string // Effective value, without TOML quotes
+	Source string // SourceDefault, SourceFile or the environment variable that set it
+	Help   string // One-line description
+	quoted bool
+}
+
+// Line returns the setting as a config file line.
+func (s Setting) Line() string {
+	return formatLine(s.Key, s.Value, s.quoted)
+}
+
+// Describe returns every key's effective value and where it came from.
+// Like Load it works around problems; Check reports them.
+func Describe(repoRoot string) ([]Setting, error) {
+	cfg, sources, _, err := load(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	described := make([]Setting, len(settings))
+	for i, s := range settings {
+		source, ok := sources[s.key]
+		if !ok {
+			source = SourceDefault
+		}
+		described[i] = Setting{Key: s.key, Value: s.get(cfg), Source: source, Help: s.help, quoted: s.quoted}
+	}
+	return described, nil
+}
+
+// FormatDuration prints d the way it is written in the config file,
+// dropping zero minutes and seconds: "1h" rather than "1h0m0s".
+func FormatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// formatLine formats a key and value as a config file line.
+func formatLine(key, value string, quoted bool) string {
+	if quoted {
+		value = strconv.Quote(value)
+	}
+	return key + " = " + value
+}
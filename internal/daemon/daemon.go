@@ -11,8 +11,11 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
+	"agentmail/internal/config"
 	"agentmail/internal/mail"
+	"agentmail/internal/tmux"
 )
 
 // PIDFile is the filename for the mailman daemon PID file within .agentmail/
@@ -185,6 +188,13 @@ func StartDaemon(repoRoot string, daemonize bool, stdout, stderr io.Writer) int
 func runForeground(repoRoot string, stdout, stderr io.Writer) int {
 	currentPID := os.Getpid()
 
+	// Refuse to start with a config file that doesn't parse
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
 	// Write PID file
 	if err := WritePID(repoRoot, currentPID); err != nil {
 		fmt.Fprintf(stderr, "error: %v\n", err)
@@ -199,7 +209,7 @@ func runForeground(repoRoot string, stdout, stderr io.Writer) int {
 	fmt.Fprintf(stdout, "Mailman daemon started (PID: %d)\n", currentPID)
 
 	// T025: Initialize StatelessTracker for stateless agent notifications (FR-010, FR-012)
-	tracker := NewStatelessTracker(StatelessNotifyInterval)
+	tracker := NewStatelessTracker(cfg.StatelessNotifyInterval)
 
 	// Create options for notification checks
 	opts := LoopOptions{
@@ -207,6 +217,8 @@ func runForeground(repoRoot string, stdout, stderr io.Writer) int {
 		SkipTmuxCheck:    false,   // Production mode: use real tmux
 		StatelessTracker: tracker, // Enable stateless agent notifications
 		Logger:           stdout,  // Log all actions in foreground mode
+		// Reconfigured from idle_quiet and idle_prompt before each notification
+		IdleDetector: NewIdleDetector(cfg.IdleQuiet, idlePrompt(cfg.IdlePrompt), tmux.CapturePane),
 	}
 
 	// Initialize file watcher for event-driven notifications (required)
@@ -219,6 +231,9 @@ func runForeground(repoRoot string, stdout, stderr io.Writer) int {
 	}
 
 	fileWatcher.SetLogger(stdout)
+	fileWatcher.SetNextDue(func() time.Time {
+		return earliest(nextDue(repoRoot), opts.IdleDetector.NextCheck())
+	})
 	if err := fileWatcher.AddWatches(); err != nil {
 		fmt.Fprintf(stderr, "error: failed to add file watches: %v\n", err)
 		_ = fileWatcher.Close() // G104: best-effort cleanup
@@ -229,11 +244,14 @@ func runForeground(repoRoot string, stdout, stderr io.Writer) int {
 	fmt.Fprintf(stdout, "[mailman] File watching enabled\n")
 
 	go func() {
-		// Create process function that wraps CheckAndNotify AND cleanStaleStates
-		// This ensures stale cleanup runs on events and fallback timer
+		// Create process function that wraps CheckAndNotify, cleanStaleStates, expireMessages, sweepDeadLetters and compactMailboxes
+		// This ensures stale cleanup, expiry, dead-lettering and compaction run on events and fallback timer
 		processFunc := func() {
 			_ = CheckAndNotify(opts) // G104: errors are logged but don't stop the watcher
 			cleanStaleStates(repoRoot, stdout)
+			expireMessages(repoRoot, stdout)
+			sweepDeadLetters(repoRoot, stdout)
+			compactMailboxes(repoRoot, stdout)
 		}
 
 		// Run initial check immediately (includes stale cleanup)
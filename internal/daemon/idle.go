@@ -0,0 +1,143 @@
+package daemon
+
+import (
+	"errors"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ErrPaneBusy is returned by a notifier that deferred typing into a pane that is not idle.
+// The agent is notified on a later check, once its pane has been quiet.
+var ErrPaneBusy = errors.New("pane is busy")
+
+// CaptureFunc is the function signature for reading a window's visible pane content.
+type CaptureFunc func(window string) (string, error)
+
+// IdleDetector tells whether a pane is idle enough to type a notification into.
+// A pane is idle when its content has not changed for the quiet period, so the agent
+// is not generating output and nobody is typing, and, if a prompt pattern is set,
+// the content matches it. Snapshots are kept between checks in memory.
+// A zero quiet period only checks the prompt; without a prompt either, every
+// pane is idle.
+type IdleDetector struct {
+	quiet   time.Duration
+	prompt  *regexp.Regexp
+	capture CaptureFunc
+	now     func() time.Time
+
+	mu    sync.Mutex
+	panes map[string]paneSnapshot // Window name → last snapshot
+}
+
+// paneSnapshot is the last content seen in a pane.
+type paneSnapshot struct {
+	content string
+	changed time.Time // When the content was first seen
+	waiting bool      // The last check deferred a notification until the pane is quiet
+}
+
+// NewIdleDetector creates a detector that requires panes to be unchanged for quiet and,
+// if prompt is non-nil, to match it. capture reads a pane, e.g. tmux.CapturePane.
+func NewIdleDetector(quiet time.Duration, prompt *regexp.Regexp, capture CaptureFunc) *IdleDetector {
+	return &IdleDetector{
+		quiet:   quiet,
+		prompt:  prompt,
+		capture: capture,
+		now:     time.Now,
+		panes:   make(map[string]paneSnapshot),
+	}
+}
+
+// Configure changes the quiet period and prompt pattern. The mailman calls it with
+// the current config before each notification, so "agentmail config set" applies
+// without a restart. A zero quiet period forgets every snapshot.
+func (d *IdleDetector) Configure(quiet time.Duration, prompt *regexp.Regexp) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.quiet = quiet
+	d.prompt = prompt
+	if quiet == 0 {
+		d.panes = make(map[string]paneSnapshot)
+	}
+}
+
+// Idle captures the window's pane and reports whether it has been quiet and shows
+// the prompt. A pane is quiet once two checks at least the quiet period apart saw
+// the same content, so a pane seen for the first time is busy.
+func (d *IdleDetector) Idle(window string) (bool, error) {
+	d.mu.Lock()
+	disabled := d.quiet == 0 && d.prompt == nil
+	d.mu.Unlock()
+	if disabled {
+		return true, nil
+	}
+
+	content, err := d.capture(window)
+	if err != nil {
+		return false, err
+	}
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.quiet > 0 {
+		snapshot, seen := d.panes[window]
+		if !seen || snapshot.content != content {
+			snapshot = paneSnapshot{content: content, changed: now}
+		}
+		quiet := now.Sub(snapshot.changed) >= d.quiet
+		snapshot.waiting = !quiet
+		d.panes[window] = snapshot
+		if !quiet {
+			return false, nil
+		}
+	}
+	if d.prompt != nil && !d.prompt.MatchString(content) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// NextCheck returns when the earliest deferred pane will have been quiet long enough,
+// if it doesn't change in the meantime, or the zero time if no notification is waiting.
+// The mailman wakes up then instead of waiting for its fallback timer.
+func (d *IdleDetector) NextCheck() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var next time.Time
+	for _, snapshot := range d.panes {
+		if !snapshot.waiting {
+			continue
+		}
+		at := snapshot.changed.Add(d.quiet)
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
+
+// idlePrompt compiles the idle_prompt setting. Load resets an invalid pattern to
+// the default, so the empty pattern (any content) is the only other result.
+func idlePrompt(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	prompt, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil
+	}
+	return prompt
+}
+
+// earliest returns the earlier of two times, ignoring zero times.
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
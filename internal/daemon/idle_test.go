@@ -0,0 +1,241 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"agentmail/internal/config"
+	"agentmail/internal/mail"
+	"agentmail/internal/tmux"
+)
+
+// fakePane puts a fake tmux on PATH whose capture-pane prints the returned file,
+// so tests can change what the pane shows by rewriting it.
+func fakePane(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	pane := filepath.Join(dir, "pane")
+	script := "#!/bin/sh\ncat \"" + pane + "\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "tmux"), []byte(script), 0700); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TMUX", "/tmp/tmux-fake/default,1,0")
+	setPane(t, pane, content)
+	return pane
+}
+
+// setPane changes what the fake pane shows.
+func setPane(t *testing.T, pane, content string) {
+	t.Helper()
+	if err := os.WriteFile(pane, []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+// fakeClock makes the detector read the time from the returned pointer.
+func fakeClock(d *IdleDetector) *time.Time {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	return &now
+}
+
+func TestIdleDetector_WaitsForQuietPane(t *testing.T) {
+	pane := fakePane(t, "Thinking...\n")
+	d := NewIdleDetector(2*time.Second, nil, tmux.CapturePane)
+	now := fakeClock(d)
+
+	idle, err := d.Idle("agent-1")
+	if err != nil {
+		t.Fatalf("Idle() failed: %v", err)
+	}
+	if idle {
+		t.Error("Expected a pane seen for the first time to be busy")
+	}
+
+	// Output arrives: the quiet period starts over
+	setPane(t, pane, "Thinking...\nEditing main.go\n")
+	if idle, _ := d.Idle("agent-1"); idle {
+		t.Error("Expected a pane that just changed to be busy")
+	}
+	if want := now.Add(2 * time.Second); !d.NextCheck().Equal(want) {
+		t.Errorf("Expected next check at %v, got %v", want, d.NextCheck())
+	}
+
+	// Output keeps arriving
+	*now = now.Add(time.Second)
+	setPane(t, pane, "Thinking...\nEditing main.go\nEditing loop.go\n")
+	if idle, _ := d.Idle("agent-1"); idle {
+		t.Error("Expected a pane that changed again to be busy")
+	}
+
+	*now = now.Add(time.Second)
+	if idle, _ := d.Idle("agent-1"); idle {
+		t.Error("Expected a pane quiet for less than idle_quiet to be busy")
+	}
+
+	*now = now.Add(time.Second)
+	if idle, _ := d.Idle("agent-1"); !idle {
+		t.Error("Expected a pane quiet for idle_quiet to be idle")
+	}
+	if !d.NextCheck().IsZero() {
+		t.Errorf("Expected no pending check once idle, got %v", d.NextCheck())
+	}
+}
+
+func TestIdleDetector_PromptPattern(t *testing.T) {
+	pane := fakePane(t, "Running tests...\n")
+	d := NewIdleDetector(time.Second, regexp.MustCompile(`(?m)^> ?$`), tmux.CapturePane)
+	now := fakeClock(d)
+
+	_, _ = d.Idle("agent-1")
+	*now = now.Add(time.Minute)
+	if idle, _ := d.Idle("agent-1"); idle {
+		t.Error("Expected a quiet pane without the prompt to be busy")
+	}
+
+	setPane(t, pane, "Tests passed\n> \n\n")
+	_, _ = d.Idle("agent-1")
+	*now = now.Add(time.Second)
+	if idle, _ := d.Idle("agent-1"); !idle {
+		t.Error("Expected a quiet pane showing the prompt to be idle")
+	}
+}
+
+func TestIdleDetector_Configure(t *testing.T) {
+	pane := fakePane(t, "Compiling...\n")
+	d := NewIdleDetector(time.Hour, nil, tmux.CapturePane)
+	fakeClock(d)
+
+	_, _ = d.Idle("agent-1")
+	setPane(t, pane, "Compiling...\nLinking...\n")
+	if idle, _ := d.Idle("agent-1"); idle {
+		t.Fatal("Expected a pane that just changed to be busy")
+	}
+
+	// Disabling detection makes every pane idle and drops pending checks
+	d.Configure(0, nil)
+	if idle, _ := d.Idle("agent-1"); !idle {
+		t.Error("Expected every pane to be idle with detection disabled")
+	}
+	if !d.NextCheck().IsZero() {
+		t.Errorf("Expected no pending check with detection disabled, got %v", d.NextCheck())
+	}
+
+	// Without a quiet period the prompt alone decides
+	d.Configure(0, regexp.MustCompile(`(?m)^> ?$`))
+	if idle, _ := d.Idle("agent-1"); idle {
+		t.Error("Expected a pane without the prompt to be busy")
+	}
+	setPane(t, pane, "Done\n> \n")
+	if idle, _ := d.Idle("agent-1"); !idle {
+		t.Error("Expected a pane showing the prompt to be idle")
+	}
+}
+
+func TestIdleDetector_CaptureError(t *testing.T) {
+	t.Setenv("TMUX", "")
+	d := NewIdleDetector(time.Second, nil, tmux.CapturePane)
+	if _, err := d.Idle("agent-1"); err == nil {
+		t.Error("Expected an error when the pane cannot be captured")
+	}
+}
+
+func TestCheckAndNotify_DefersBusyPane(t *testing.T) {
+	t.Setenv(config.Env("notify_strategy"), "")
+	t.Setenv(config.Env("idle_quiet"), "2s")
+	got := fakeStrategies(t)
+	pane := fakePane(t, "Compiling...\n")
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".agentmail", "mailboxes"), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	_ = mail.Append(tmpDir, mail.Message{ID: "idle0001", From: "agent-1", To: "agent-2", Message: "hi"})
+	_ = mail.Append(tmpDir, mail.Message{ID: "idle0002", From: "agent-1", To: "agent-3", Message: "hi"})
+	if err := mail.UpdateRecipientState(tmpDir, "agent-2", mail.StatusReady, false); err != nil {
+		t.Fatalf("UpdateRecipientState failed: %v", err)
+	}
+
+	d := NewIdleDetector(2*time.Second, nil, tmux.CapturePane)
+	now := fakeClock(d)
+	_, _ = d.Idle("agent-2")
+	_, _ = d.Idle("agent-3")
+	setPane(t, pane, "Compiling...\nLinking...\n")
+	opts := LoopOptions{RepoRoot: tmpDir, StatelessTracker: NewStatelessTracker(time.Hour), IdleDetector: d}
+	windowExists := func(string) (bool, error) { return true, nil }
+
+	if err := CheckAndNotifyWithNotifier(opts, RecipientNotifier(tmpDir, d), windowExists); err != nil {
+		t.Fatalf("CheckAndNotifyWithNotifier failed: %v", err)
+	}
+	if len(*got) != 0 {
+		t.Fatalf("Expected no keys typed into a busy pane, got %+v", *got)
+	}
+	recipients, _ := mail.ReadAllRecipients(tmpDir)
+	if len(recipients) != 1 || !recipients[0].NotifiedAt.IsZero() {
+		t.Errorf("Expected the stated agent to stay unnotified, got %+v", recipients)
+	}
+	if !opts.StatelessTracker.ShouldNotify("agent-3") {
+		t.Error("Expected the stateless agent to stay due")
+	}
+
+	*now = now.Add(2 * time.Second)
+	if err := CheckAndNotifyWithNotifier(opts, RecipientNotifier(tmpDir, d), windowExists); err != nil {
+		t.Fatalf("CheckAndNotifyWithNotifier failed: %v", err)
+	}
+	if len(*got) != 2 || (*got)[0].window != "agent-2" || (*got)[1].window != "agent-3" {
+		t.Errorf("Expected both agents notified once the pane was quiet, got %+v", *got)
+	}
+}
+
+func TestRecipientNotifier_OnlyKeysWaitForIdle(t *testing.T) {
+	t.Setenv(config.Env("notify_strategy"), config.NotifyBell)
+	t.Setenv(config.Env("idle_quiet"), "1h")
+	got := fakeStrategies(t)
+	pane := fakePane(t, "Compiling...\n")
+	tmpDir := t.TempDir()
+	_ = mail.Append(tmpDir, mail.Message{ID: "idle0003", From: "agent-1", To: "agent-2", Message: "hi"})
+
+	d := NewIdleDetector(time.Hour, nil, tmux.CapturePane)
+	_, _ = d.Idle("agent-2")
+	setPane(t, pane, "Compiling...\nLinking...\n")
+	if err := RecipientNotifier(tmpDir, d)("agent-2"); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+	if len(*got) != 1 || (*got)[0].strategy != config.NotifyBell {
+		t.Errorf("Expected the bell regardless of pane activity, got %+v", *got)
+	}
+
+	t.Setenv(config.Env("notify_strategy"), config.NotifyKeys)
+	if err := RecipientNotifier(tmpDir, d)("agent-2"); !errors.Is(err, ErrPaneBusy) {
+		t.Errorf("Expected ErrPaneBusy for keys into a busy pane, got %v", err)
+	}
+
+	// The running mailman picks up a config change on the next notification
+	t.Setenv(config.Env("idle_quiet"), "0")
+	if err := RecipientNotifier(tmpDir, d)("agent-2"); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+	if len(*got) != 2 || (*got)[1].strategy != config.NotifyKeys {
+		t.Errorf("Expected keys typed once idle detection was disabled, got %+v", *got)
+	}
+}
+
+func TestEarliest(t *testing.T) {
+	a := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := a.Add(time.Minute)
+	for _, tc := range []struct{ x, y, want time.Time }{
+		{a, b, a},
+		{b, a, a},
+		{time.Time{}, b, b},
+		{a, time.Time{}, a},
+		{time.Time{}, time.Time{}, time.Time{}},
+	} {
+		if got := earliest(tc.x, tc.y); !got.Equal(tc.want) {
+			t.Errorf("earliest(%v, %v) = %v, want %v", tc.x, tc.y, got, tc.want)
+		}
+	}
+}
@@ -3,20 +3,42 @@
 package daemon
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"sync"
 	"time"
 
+	"agentmail/internal/config"
 	"agentmail/internal/mail"
-	"agentmail/internal/tmux"
 )
 
-// DefaultStaleThreshold is the default threshold for cleaning stale states.
-const DefaultStaleThreshold = time.Hour
+// DefaultStaleThreshold is the default threshold for cleaning stale states (stale_threshold in the config file).
+const DefaultStaleThreshold = config.DefaultStaleThreshold
 
-// StatelessNotifyInterval is the interval between notifications for stateless agents (T001).
-const StatelessNotifyInterval = 60 * time.Second
+// DefaultUndeliverableThreshold is how long unread mail for a missing window waits before it is dead-lettered
+// by default (undeliverable_hours in the config file).
+const DefaultUndeliverableThreshold = config.DefaultUndeliverableHours * time.Hour
+
+// DefaultMaxNotifications is how many notifications an unread message may trigger before it is dead-lettered
+// by default (max_notifications in the config file).
+const DefaultMaxNotifications = config.DefaultMaxNotifications
+
+// StatelessNotifyInterval is the default interval between notifications for stateless agents (T001)
+// (stateless_notify_interval in the config file).
+const StatelessNotifyInterval = config.DefaultStatelessNotifyInterval
+
+// loadConfig returns the repository's settings. Notification checks read the config
+// file on every cycle, so their settings apply without restarting the daemon.
+// While the file cannot be read, the defaults are used so that a bad edit does not
+// stop notifications.
+func loadConfig(repoRoot string) config.Config {
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return config.Default()
+	}
+	return cfg
+}
 
 // StatelessTracker tracks notification timestamps for stateless agents (T002).
 // It uses in-memory storage that resets on daemon restart.
@@ -81,6 +103,7 @@ type LoopOptions struct {
 	SkipTmuxCheck    bool              // Skip tmux check (for testing)
 	StatelessTracker *StatelessTracker // Tracker for stateless agents (T003)
 	Logger           io.Writer         // Logger for foreground mode (nil = no logging)
+	IdleDetector     *IdleDetector     // Defers typed notifications until the pane is idle (nil = type immediately)
 }
 
 // log writes a formatted message to the logger if configured.
@@ -96,26 +119,23 @@ type NotifyFunc func(window string) error
 // WindowCheckerFunc is the function signature for checking if a window exists.
 type WindowCheckerFunc func(window string) (bool, error)
 
-// NotifyAgent sends a notification to an agent's tmux window.
+// MailboxChecker returns the WindowCheckerFunc the mailman uses: a mailbox exists
+// while a pane in any session reads it, also after its window was renamed.
+func MailboxChecker(repoRoot string) WindowCheckerFunc {
+	return func(mailbox string) (bool, error) {
+		_, ok, err := mail.LocateMailbox(repoRoot, mailbox)
+		return ok, err
+	}
+}
+
+// NotifyAgent sends the default notification to an agent's tmux window.
 // Notification protocol:
 // 1. tmux send-keys -t <window> "Check your agentmail"
 // 2. time.Sleep(1 * time.Second)
 // 3. tmux send-keys -t <window> Enter
+// The mailman itself uses RecipientNotifier, which applies the configured strategy and text.
 func NotifyAgent(window string) error {
-	// Send the notification message
-	if err := tmux.SendKeys(window, "Check your agentmail"); err != nil {
-		return err
-	}
-
-	// Wait 1 second before sending Enter
-	time.Sleep(1 * time.Second)
-
-	// Send Enter to execute the command
-	if err := tmux.SendEnter(window); err != nil {
-		return err
-	}
-
-	return nil
+	return typeNotification(window, config.DefaultNotifyTemplate)
 }
 
 // CheckAndNotify performs a single notification cycle.
@@ -127,7 +147,7 @@ func CheckAndNotify(opts LoopOptions) error {
 		// In test mode, skip actual notifications but still update flags
 		return CheckAndNotifyWithNotifier(opts, nil, nil)
 	}
-	return CheckAndNotifyWithNotifier(opts, NotifyAgent, tmux.WindowExists)
+	return CheckAndNotifyWithNotifier(opts, RecipientNotifier(opts.RepoRoot, opts.IdleDetector), MailboxChecker(opts.RepoRoot))
 }
 
 // CheckAndNotifyWithNotifier performs a single notification cycle with a custom notifier.
@@ -139,6 +159,7 @@ func CheckAndNotify(opts LoopOptions) error {
 // - Phase 2: Stateless agents (mailbox but no recipient state)
 func CheckAndNotifyWithNotifier(opts LoopOptions, notify NotifyFunc, windowChecker WindowCheckerFunc) error {
 	opts.log("Starting notification cycle")
+	cfg := loadConfig(opts.RepoRoot)
 
 	// =========================================================================
 	// Phase 1: Stated agents (existing logic)
@@ -161,18 +182,22 @@ func CheckAndNotifyWithNotifier(opts LoopOptions, notify NotifyFunc, windowCheck
 
 	// Check each recipient
 	for _, recipient := range recipients {
-		// Skip non-ready agents (work/offline have 1 hour protection)
+		// Skip non-ready agents (work/offline have 1 hour protection),
+		// except that urgent mail reaches agents in work status
 		if recipient.Status != mail.StatusReady {
-			if !recipient.ShouldNotify() {
-				opts.log("Skipping stated agent %q: status=%s, protected for 1h", recipient.Recipient, recipient.Status)
+			if recipient.Status == mail.StatusWork && hasUrgentMail(opts.RepoRoot, recipient, cfg.NotifyDebounce) {
+				opts.log("Stated agent %q: status=work, notifying for urgent mail", recipient.Recipient)
 			} else {
-				opts.log("Skipping stated agent %q: status=%s (not ready)", recipient.Recipient, recipient.Status)
+				if !recipient.ShouldNotify(cfg) {
+					opts.log("Skipping stated agent %q: status=%s, protected for %s", recipient.Recipient, recipient.Status, config.FormatDuration(cfg.WorkProtection))
+				} else {
+					opts.log("Skipping stated agent %q: status=%s (not ready)", recipient.Recipient, recipient.Status)
+				}
+				continue
 			}
-			continue
-		}
-		// Ready agents: check 60s debounce
-		if !recipient.ShouldNotify() {
-			opts.log("Skipping stated agent %q: notified within last 60s", recipient.Recipient)
+		} else if !recipient.ShouldNotify(cfg) {
+			// Ready agents: check the notification debounce
+			opts.log("Skipping stated agent %q: notified within last %gs", recipient.Recipient, cfg.NotifyDebounce.Seconds())
 			continue
 		}
 
@@ -194,7 +219,11 @@ func CheckAndNotifyWithNotifier(opts LoopOptions, notify NotifyFunc, windowCheck
 		if notify != nil {
 			opts.log("Notifying stated agent %q", recipient.Recipient)
 			if err := notify(recipient.Recipient); err != nil {
-				opts.log("Notification failed for stated agent %q: %v", recipient.Recipient, err)
+				if errors.Is(err, ErrPaneBusy) {
+					opts.log("Deferring notification of stated agent %q: pane busy", recipient.Recipient)
+				} else {
+					opts.log("Notification failed for stated agent %q: %v", recipient.Recipient, err)
+				}
 				continue
 			}
 			opts.log("Notification sent to stated agent %q", recipient.Recipient)
@@ -206,6 +235,11 @@ func CheckAndNotifyWithNotifier(opts LoopOptions, notify NotifyFunc, windowCheck
 			continue
 		}
 		opts.log("Marked stated agent %q as notified", recipient.Recipient)
+
+		// Count the notification against each waiting message (dead-letter limit)
+		if err := mail.RecordNotification(opts.RepoRoot, recipient.Recipient); err != nil {
+			opts.log("Error recording notification for %q: %v", recipient.Recipient, err)
+		}
 	}
 
 	// =========================================================================
@@ -274,6 +308,11 @@ func CheckAndNotifyWithNotifier(opts LoopOptions, notify NotifyFunc, windowCheck
 		if notify != nil {
 			opts.log("Notifying stateless agent %q", mailboxRecipient)
 			if err := notify(mailboxRecipient); err != nil {
+				if errors.Is(err, ErrPaneBusy) {
+					// Not marked, so the agent is notified as soon as the pane is idle
+					opts.log("Deferring notification of stateless agent %q: pane busy", mailboxRecipient)
+					continue
+				}
 				opts.log("Notification failed for stateless agent %q: %v", mailboxRecipient, err)
 				// Mark as notified even on failure to rate-limit retries
 				opts.StatelessTracker.MarkNotified(mailboxRecipient)
@@ -286,6 +325,11 @@ func CheckAndNotifyWithNotifier(opts LoopOptions, notify NotifyFunc, windowCheck
 		// T023: Mark as notified
 		opts.StatelessTracker.MarkNotified(mailboxRecipient)
 		opts.log("Marked stateless agent %q in tracker", mailboxRecipient)
+
+		// Count the notification against each waiting message (dead-letter limit)
+		if err := mail.RecordNotification(opts.RepoRoot, mailboxRecipient); err != nil {
+			opts.log("Error recording notification for %q: %v", mailboxRecipient, err)
+		}
 	}
 
 	opts.log("Found %d stateless agents", statelessCount)
@@ -298,10 +342,62 @@ func CheckAndNotifyWithNotifier(opts LoopOptions, notify NotifyFunc, windowCheck
 	return nil
 }
 
+// hasUrgentMail reports whether a working agent should be interrupted for urgent mail.
+// It bypasses the work protection interval but still applies the notification debounce.
+func hasUrgentMail(repoRoot string, recipient mail.RecipientState, debounce time.Duration) bool {
+	if !recipient.NotifiedAt.IsZero() && time.Since(recipient.NotifiedAt) < debounce {
+		return false
+	}
+	unread, err := mail.FindUnread(repoRoot, recipient.Recipient)
+	if err != nil {
+		return false
+	}
+	// FindUnread sorts by priority, so urgent mail comes first
+	return len(unread) > 0 && unread[0].IsUrgent()
+}
+
 // cleanStaleStates removes recipient states older than the threshold.
 func cleanStaleStates(repoRoot string, logger io.Writer) {
+	threshold := loadConfig(repoRoot).StaleThreshold
 	if logger != nil {
-		fmt.Fprintf(logger, "[mailman] Cleaning stale recipient states (threshold: %v)\n", DefaultStaleThreshold)
+		fmt.Fprintf(logger, "[mailman] Cleaning stale recipient states (threshold: %v)\n", threshold)
+	}
+	_, _ = mail.CleanStaleStates(repoRoot, threshold) // G104: best-effort cleanup, errors don't stop the daemon
+}
+
+// sweepDeadLetters moves undeliverable and over-notified messages to the dead-letter mailbox.
+// If tmux panes cannot be listed, only the notification limit is applied.
+func sweepDeadLetters(repoRoot string, logger io.Writer) {
+	cfg := loadConfig(repoRoot)
+	windows, err := mail.LiveMailboxes(repoRoot)
+	if err != nil || cfg.UndeliverableHours == 0 {
+		windows = nil // Without a window list, missing recipients cannot be detected
+	}
+	undeliverableAfter := time.Duration(cfg.UndeliverableHours) * time.Hour
+	moved, _ := mail.DeadLetterSweep(repoRoot, windows, undeliverableAfter, cfg.MaxNotifications) // G104: best-effort, errors don't stop the daemon
+	if logger != nil && moved > 0 {
+		fmt.Fprintf(logger, "[mailman] Moved %d message(s) to dead-letter mailbox\n", moved)
+	}
+}
+
+// expireMessages moves expired unread messages to the dead-letter mailbox and notifies their senders.
+func expireMessages(repoRoot string, logger io.Writer) {
+	expired, _ := mail.ExpireMessages(repoRoot) // G104: best-effort, errors don't stop the daemon
+	if logger != nil && expired > 0 {
+		fmt.Fprintf(logger, "[mailman] Expired %d unread message(s)\n", expired)
 	}
-	_, _ = mail.CleanStaleStates(repoRoot, DefaultStaleThreshold) // G104: best-effort cleanup, errors don't stop the daemon
+}
+
+// compactMailboxes compacts mailbox logs that have accumulated mail.CompactThreshold read/update records.
+func compactMailboxes(repoRoot string, logger io.Writer) {
+	compacted, _ := mail.CompactMailboxes(repoRoot, mail.CompactThreshold) // G104: best-effort, errors don't stop the daemon
+	if logger != nil && compacted > 0 {
+		fmt.Fprintf(logger, "[mailman] Compacted %d mailbox(es)\n", compacted)
+	}
+}
+
+// nextDue returns when the next scheduled message becomes deliverable, or the zero time if none is scheduled.
+func nextDue(repoRoot string) time.Time {
+	due, _, _ := mail.NextDue(repoRoot) // G104: best-effort, the fallback timer covers read errors
+	return due
 }
@@ -1682,3 +1682,97 @@ func TestLogging_SkipMessages(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckAndNotify_RecordsNotificationOnMessages(t *testing.T) {
+	repoRoot := createTestMailDir(t)
+
+	createRecipientState(t, repoRoot, "agent-1", mail.StatusReady, false, time.Now())
+	createUnreadMessage(t, repoRoot, "agent-1", "sender", "Hello!")
+
+	opts := LoopOptions{
+		RepoRoot:      repoRoot,
+		SkipTmuxCheck: true,
+	}
+
+	mockNotify := func(window string) error { return nil }
+	if err := CheckAndNotifyWithNotifier(opts, mockNotify, nil); err != nil {
+		t.Fatalf("CheckAndNotify failed: %v", err)
+	}
+
+	messages, err := mail.ReadAll(repoRoot, "agent-1")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if messages[0].Notified != 1 {
+		t.Errorf("Expected notification count 1, got %d", messages[0].Notified)
+	}
+}
+
+func TestCheckAndNotify_UrgentMailNotifiesWorkAgent(t *testing.T) {
+	repoRoot := createTestMailDir(t)
+
+	// agent-1 switched to work just now, so it is inside WorkProtectionInterval
+	createRecipientState(t, repoRoot, "agent-1", mail.StatusWork, false, time.Now())
+	createRecipientState(t, repoRoot, "agent-2", mail.StatusWork, false, time.Now())
+	createUnreadMessage(t, repoRoot, "agent-2", "sender", "routine update")
+	if err := mail.Append(repoRoot, mail.Message{ID: "urgent01", From: "sender", To: "agent-1", Message: "abort", Priority: mail.PriorityUrgent}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	var notifiedAgents []string
+	mockNotify := func(window string) error {
+		notifiedAgents = append(notifiedAgents, window)
+		return nil
+	}
+
+	opts := LoopOptions{
+		RepoRoot:      repoRoot,
+		SkipTmuxCheck: true,
+	}
+
+	if err := CheckAndNotifyWithNotifier(opts, mockNotify, nil); err != nil {
+		t.Fatalf("CheckAndNotify failed: %v", err)
+	}
+
+	// Only the agent with urgent mail is interrupted
+	if len(notifiedAgents) != 1 || notifiedAgents[0] != "agent-1" {
+		t.Fatalf("Expected only agent-1 to be notified, got %v", notifiedAgents)
+	}
+
+	// The 60s debounce still applies to urgent notifications
+	notifiedAgents = nil
+	if err := CheckAndNotifyWithNotifier(opts, mockNotify, nil); err != nil {
+		t.Fatalf("CheckAndNotify failed: %v", err)
+	}
+	if len(notifiedAgents) != 0 {
+		t.Errorf("Expected no repeat notification within debounce, got %v", notifiedAgents)
+	}
+}
+
+func TestCheckAndNotify_IgnoresScheduledMailUntilDue(t *testing.T) {
+	repoRoot := createTestMailDir(t)
+
+	createRecipientState(t, repoRoot, "agent-1", mail.StatusReady, false, time.Now())
+	if err := mail.Append(repoRoot, mail.Message{ID: "later001", From: "sender", To: "agent-1", Message: "check CI", DeliverAfter: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	var notifiedAgents []string
+	mockNotify := func(window string) error {
+		notifiedAgents = append(notifiedAgents, window)
+		return nil
+	}
+
+	opts := LoopOptions{
+		RepoRoot:      repoRoot,
+		SkipTmuxCheck: true,
+	}
+
+	if err := CheckAndNotifyWithNotifier(opts, mockNotify, nil); err != nil {
+		t.Fatalf("CheckAndNotify failed: %v", err)
+	}
+
+	if len(notifiedAgents) != 0 {
+		t.Errorf("Expected no notification for mail that is not yet due, got %v", notifiedAgents)
+	}
+}
@@ -0,0 +1,183 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"agentmail/internal/config"
+	"agentmail/internal/mail"
+	"agentmail/internal/tmux"
+)
+
+// NotifyCommandTimeout is how long a notify_command may run before it is killed.
+const NotifyCommandTimeout = 10 * time.Second
+
+// Notification delivery, replaced by tests to observe notifications without tmux.
+var (
+	typeKeys    = typeNotification // Strategy config.NotifyKeys
+	showMessage = tmux.DisplayMessage
+	ringBell    = tmux.Bell
+	runCommand  = runNotifyCommand
+)
+
+// Notification describes an agent's unread mail for the notification text.
+type Notification struct {
+	Recipient string // Mailbox being notified
+	Target    string // tmux target of the recipient: the pane reading the mailbox (empty = Recipient)
+	Sender    string // Sender of the first unread message
+	Count     int    // Number of unread messages
+	Subject   string // Subject of the first unread message, or the start of its body
+}
+
+// newNotification summarizes the unread messages of recipient, which FindUnread sorts
+// so that the message received next comes first.
+func newNotification(recipient string, unread []mail.Message) Notification {
+	n := Notification{Recipient: recipient, Count: len(unread)}
+	if len(unread) > 0 {
+		n.Sender = unread[0].From
+		n.Subject = unread[0].Subject
+		if n.Subject == "" {
+			n.Subject = mail.Preview(unread[0].Message)
+		}
+	}
+	return n
+}
+
+// Text fills in the {sender}, {count}, {subject} and {recipient} placeholders of template.
+// Line breaks are replaced by spaces, so typing the text never submits it early.
+func (n Notification) Text(template string) string {
+	text := strings.NewReplacer(
+		"{sender}", n.Sender,
+		"{count}", strconv.Itoa(n.Count),
+		"{subject}", n.Subject,
+		"{recipient}", n.Recipient,
+	).Replace(template)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// env returns the variables describing the notification to a notify_command.
+func (n Notification) env(text string) []string {
+	return []string{
+		"AGENTMAIL_RECIPIENT=" + n.Recipient,
+		"AGENTMAIL_SENDER=" + n.Sender,
+		"AGENTMAIL_COUNT=" + strconv.Itoa(n.Count),
+		"AGENTMAIL_SUBJECT=" + n.Subject,
+		"AGENTMAIL_TEXT=" + text,
+	}
+}
+
+// RecipientNotifier returns the NotifyFunc the mailman uses: it notifies each window
+// with the strategy and template from its recipient state (set with
+// "agentmail status --notify"), falling back to the config file.
+// Notifications go to the pane reading the mailbox, which may be in a renamed window.
+// When idle is non-nil, keys are only typed into a pane it reports idle;
+// otherwise the notifier returns ErrPaneBusy. idle is configured from idle_quiet
+// and idle_prompt on every call.
+func RecipientNotifier(repoRoot string, idle *IdleDetector) NotifyFunc {
+	return func(window string) error {
+		target := window
+		if alias, ok, err := mail.LocateMailbox(repoRoot, window); err == nil && ok {
+			target = alias.Pane
+		}
+
+		cfg := loadConfig(repoRoot)
+		strategy, template := cfg.NotifyStrategy, cfg.NotifyTemplate
+		if idle != nil {
+			idle.Configure(cfg.IdleQuiet, idlePrompt(cfg.IdlePrompt))
+		}
+
+		recipients, err := mail.ReadAllRecipients(repoRoot)
+		if err != nil {
+			return err
+		}
+		for _, r := range recipients {
+			if r.Recipient != window {
+				continue
+			}
+			if r.Notify != "" {
+				strategy = r.Notify
+			}
+			if r.NotifyTemplate != "" {
+				template = r.NotifyTemplate
+			}
+		}
+
+		if strategy == config.NotifyKeys && idle != nil {
+			ready, err := idle.Idle(target)
+			if err != nil {
+				return err
+			}
+			if !ready {
+				return ErrPaneBusy
+			}
+		}
+
+		unread, err := mail.FindUnread(repoRoot, window)
+		if err != nil {
+			return err
+		}
+		n := newNotification(window, unread)
+		n.Target = target
+		return deliver(strategy, template, cfg.NotifyCommand, n)
+	}
+}
+
+// deliver sends a notification with one strategy.
+func deliver(strategy, template, command string, n Notification) error {
+	text := n.Text(template)
+	if n.Target == "" {
+		n.Target = n.Recipient
+	}
+	switch strategy {
+	case config.NotifyKeys:
+		return typeKeys(n.Target, text)
+	case config.NotifyDisplay:
+		return showMessage(n.Target, text)
+	case config.NotifyBell:
+		return ringBell(n.Target)
+	case config.NotifyCommand:
+		if command == "" {
+			return fmt.Errorf("notify strategy %q requires notify_command", config.NotifyCommand)
+		}
+		return runCommand(command, n.env(text))
+	default:
+		return config.ValidateNotifyStrategy(strategy)
+	}
+}
+
+// typeNotification types text into the window and presses Enter:
+// 1. tmux send-keys -t <window> "<text>"
+// 2. time.Sleep(1 * time.Second)
+// 3. tmux send-keys -t <window> Enter
+func typeNotification(window, text string) error {
+	if err := tmux.SendKeys(window, text); err != nil {
+		return err
+	}
+
+	// Wait 1 second before sending Enter
+	time.Sleep(1 * time.Second)
+
+	return tmux.SendEnter(window)
+}
+
+// runNotifyCommand runs command with sh -c, adding env to the daemon's environment.
+// The command is killed after NotifyCommandTimeout so that it cannot stall the mailman.
+func runNotifyCommand(command string, env []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), NotifyCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) // #nosec G204 - command comes from the repository's config file
+	cmd.Env = append(os.Environ(), env...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agentmail/internal/config"
+	"agentmail/internal/mail"
+	"agentmail/internal/tmux"
+)
+
+// delivered records the notifications sent through the strategy hooks.
+type delivered struct {
+	strategy string
+	window   string
+	text     string
+	env      []string
+}
+
+// fakeStrategies replaces the notification strategies for the duration of a test.
+func fakeStrategies(t *testing.T) *[]delivered {
+	t.Helper()
+	var got []delivered
+	oldKeys, oldShow, oldBell, oldRun := typeKeys, showMessage, ringBell, runCommand
+	typeKeys = func(window, text string) error {
+		got = append(got, delivered{strategy: config.NotifyKeys, window: window, text: text})
+		return nil
+	}
+	showMessage = func(window, text string) error {
+		got = append(got, delivered{strategy: config.NotifyDisplay, window: window, text: text})
+		return nil
+	}
+	ringBell = func(window string) error {
+		got = append(got, delivered{strategy: config.NotifyBell, window: window})
+		return nil
+	}
+	runCommand = func(command string, env []string) error {
+		got = append(got, delivered{strategy: config.NotifyCommand, text: command, env: env})
+		return nil
+	}
+	t.Cleanup(func() { typeKeys, showMessage, ringBell, runCommand = oldKeys, oldShow, oldBell, oldRun })
+	return &got
+}
+
+func TestNotification_Text(t *testing.T) {
+	n := newNotification("agent-2", []mail.Message{
+		{From: "agent-1", Subject: "Deploy done"},
+		{From: "agent-3", Message: "later"},
+	})
+
+	got := n.Text("/mail {count} from {sender}: {subject} (for {recipient})")
+	if got != "/mail 2 from agent-1: Deploy done (for agent-2)" {
+		t.Errorf("Unexpected text: %q", got)
+	}
+
+	// Without a subject the body preview is used, and line breaks never reach the prompt
+	n = newNotification("agent-2", []mail.Message{{From: "agent-1", Message: "build failed\nsee log"}})
+	if got := n.Text("{subject}\n{count}"); strings.ContainsAny(got, "\r\n") || !strings.HasPrefix(got, "build failed") {
+		t.Errorf("Expected a single line starting with the preview, got %q", got)
+	}
+}
+
+func TestRecipientNotifier_UsesConfigAndRecipientOverrides(t *testing.T) {
+	for _, key := range []string{"notify_strategy", "notify_template", "notify_command"} {
+		t.Setenv(config.Env(key), "")
+	}
+	got := fakeStrategies(t)
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".agentmail"), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	for _, kv := range [][2]string{{"notify_strategy", "display"}, {"notify_template", "{count} new from {sender}"}} {
+		if err := config.Set(tmpDir, kv[0], kv[1]); err != nil {
+			t.Fatalf("config.Set failed: %v", err)
+		}
+	}
+	_ = mail.Append(tmpDir, mail.Message{ID: "ntfy0001", From: "agent-1", To: "agent-2", Message: "hi"})
+	_ = mail.Append(tmpDir, mail.Message{ID: "ntfy0002", From: "agent-1", To: "agent-3", Message: "hi"})
+	_ = mail.UpdateRecipientState(tmpDir, "agent-3", mail.StatusReady, false)
+	if err := mail.SetNotifyPreference(tmpDir, "agent-3", config.NotifyKeys, "/inbox"); err != nil {
+		t.Fatalf("SetNotifyPreference failed: %v", err)
+	}
+
+	notify := RecipientNotifier(tmpDir, nil)
+	if err := notify("agent-2"); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+	if err := notify("agent-3"); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+
+	want := []delivered{
+		{strategy: config.NotifyDisplay, window: "agent-2", text: "1 new from agent-1"},
+		{strategy: config.NotifyKeys, window: "agent-3", text: "/inbox"},
+	}
+	if len(*got) != len(want) {
+		t.Fatalf("Expected %d notifications, got %+v", len(want), *got)
+	}
+	for i := range want {
+		if (*got)[i].strategy != want[i].strategy || (*got)[i].window != want[i].window || (*got)[i].text != want[i].text {
+			t.Errorf("Notification %d: expected %+v, got %+v", i, want[i], (*got)[i])
+		}
+	}
+}
+
+func TestDeliver_BellAndCommand(t *testing.T) {
+	got := fakeStrategies(t)
+	n := Notification{Recipient: "agent-2", Sender: "agent-1", Count: 3, Subject: "Review"}
+
+	if err := deliver(config.NotifyBell, config.DefaultNotifyTemplate, "", n); err != nil {
+		t.Fatalf("bell failed: %v", err)
+	}
+	if err := deliver(config.NotifyCommand, "{count} from {sender}", "notify-send agentmail", n); err != nil {
+		t.Fatalf("command failed: %v", err)
+	}
+	if err := deliver(config.NotifyCommand, config.DefaultNotifyTemplate, "", n); err == nil {
+		t.Error("Expected the command strategy to fail without notify_command")
+	}
+	if err := deliver("carrier-pigeon", config.DefaultNotifyTemplate, "", n); err == nil {
+		t.Error("Expected an unknown strategy to fail")
+	}
+
+	if len(*got) != 2 || (*got)[0].strategy != config.NotifyBell || (*got)[0].window != "agent-2" {
+		t.Fatalf("Expected a bell and a command, got %+v", *got)
+	}
+	env := strings.Join((*got)[1].env, "\n")
+	for _, want := range []string{"AGENTMAIL_RECIPIENT=agent-2", "AGENTMAIL_SENDER=agent-1", "AGENTMAIL_COUNT=3", "AGENTMAIL_SUBJECT=Review", "AGENTMAIL_TEXT=3 from agent-1"} {
+		if !strings.Contains(env, want) {
+			t.Errorf("Expected %s in the command environment, got:\n%s", want, env)
+		}
+	}
+}
+
+func TestRunNotifyCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "notified")
+
+	if err := runNotifyCommand(`printf '%s' "$AGENTMAIL_TEXT" > "$OUT"`, []string{"AGENTMAIL_TEXT=2 new", "OUT=" + out}); err != nil {
+		t.Fatalf("runNotifyCommand failed: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || string(data) != "2 new" {
+		t.Errorf("Expected the command to see AGENTMAIL_TEXT, got %q, %v", data, err)
+	}
+
+	err = runNotifyCommand("echo nope >&2; exit 3", nil)
+	if err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("Expected the failure to include the command output, got %v", err)
+	}
+}
+
+func TestRecipientNotifier_NotifiesRenamedWindowByPane(t *testing.T) {
+	t.Setenv(config.Env("notify_strategy"), config.NotifyDisplay)
+	got := fakeStrategies(t)
+
+	// A fake tmux where the window of agent-1's pane %3 is now called "reviewer"
+	dir := t.TempDir()
+	script := "#!/bin/sh\nprintf '100\\t%%3\\tweb\\t/src/web\\treviewer\\n100\\t%%4\\tweb\\t/src/web\\tlead\\n'\n"
+	if err := os.WriteFile(filepath.Join(dir, "tmux"), []byte(script), 0700); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TMUX", "/tmp/tmux-fake/default,1,0")
+	t.Setenv("TMUX_PANE", "")
+
+	tmpDir := t.TempDir()
+	_ = mail.Append(tmpDir, mail.Message{ID: "ntfy0003", From: "lead", To: "agent-1", Message: "hi"})
+	agent := tmux.Pane{ID: "%3", Session: "web", Window: "agent-1", Server: "100"}
+	if _, err := mail.Register(tmpDir, agent, []tmux.Pane{agent}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if exists, err := MailboxChecker(tmpDir)("agent-1"); err != nil || !exists {
+		t.Errorf("Expected the renamed window's mailbox to exist, got %v, %v", exists, err)
+	}
+	if err := RecipientNotifier(tmpDir, nil)("agent-1"); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+	if len(*got) != 1 || (*got)[0].window != "%3" {
+		t.Errorf("Expected a notification to pane %%3, got %+v", *got)
+	}
+}
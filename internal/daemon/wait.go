@@ -0,0 +1,75 @@
+// Package daemon provides functionality for the mailman daemon process.
+// This file contains the blocking wait used by receive --wait and the MCP server.
+package daemon
+
+import (
+	"context"
+	"time"
+
+	"agentmail/internal/mail"
+)
+
+// WaitForMail blocks until the recipient's mailbox contains an unread message,
+// the timeout elapses, or ctx is canceled. A timeout of zero waits indefinitely.
+// Returns true if an unread message is available, false on timeout.
+func WaitForMail(ctx context.Context, repoRoot, recipient string, timeout time.Duration) (bool, error) {
+	return WaitFor(ctx, repoRoot, timeout, func() bool {
+		unread, err := mail.FindUnread(repoRoot, recipient)
+		return err == nil && len(unread) > 0
+	})
+}
+
+// WaitFor blocks until cond returns true, the timeout elapses, or ctx is canceled.
+// cond is evaluated once up front and again whenever .agentmail/ changes.
+// It reuses FileWatcher, so changes are picked up after the debounce window,
+// scheduled messages are picked up when they become due, and the fallback
+// timer is a safety net. A timeout of zero waits indefinitely.
+// Returns true if cond was satisfied, false on timeout.
+func WaitFor(ctx context.Context, repoRoot string, timeout time.Duration, cond func() bool) (bool, error) {
+	fw, err := NewFileWatcher(repoRoot)
+	if err != nil {
+		return false, err
+	}
+	defer fw.Close() // G104: best-effort cleanup
+
+	fw.SetNextDue(func() time.Time { return nextDue(repoRoot) })
+	if err := fw.AddWatches(); err != nil {
+		return false, err
+	}
+
+	// Check after watches are in place so a change made in between is not missed
+	if cond() {
+		return true, nil
+	}
+
+	found := make(chan struct{}, 1)
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- fw.Run(func() {
+			if cond() {
+				select {
+				case found <- struct{}{}:
+				default:
+				}
+			}
+		})
+	}()
+
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	select {
+	case <-found:
+		return true, nil
+	case err := <-runErr:
+		return false, err
+	case <-timeoutC:
+		return false, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"agentmail/internal/mail"
+)
+
+func TestWaitForMail_ReturnsImmediatelyWhenMailPresent(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := mail.Append(tmpDir, mail.Message{ID: "msg00001", From: "agent-1", To: "agent-2", Message: "hi"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	start := time.Now()
+	found, err := WaitForMail(context.Background(), tmpDir, "agent-2", 5*time.Second)
+	if err != nil {
+		t.Fatalf("WaitForMail failed: %v", err)
+	}
+	if !found {
+		t.Error("Expected mail to be found")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("WaitForMail should return immediately, took %v", time.Since(start))
+	}
+}
+
+func TestWaitForMail_WakesOnDelivery(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = mail.Append(tmpDir, mail.Message{ID: "msg00001", From: "agent-1", To: "agent-2", Message: "hi"})
+	}()
+
+	start := time.Now()
+	found, err := WaitForMail(context.Background(), tmpDir, "agent-2", 10*time.Second)
+	if err != nil {
+		t.Fatalf("WaitForMail failed: %v", err)
+	}
+	if !found {
+		t.Error("Expected mail to be found")
+	}
+	// Delivery + debounce window, well under the timeout
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("WaitForMail took too long to wake: %v", elapsed)
+	}
+}
+
+func TestWaitForMail_IgnoresOtherMailboxes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = mail.Append(tmpDir, mail.Message{ID: "msg00001", From: "agent-1", To: "agent-3", Message: "hi"})
+	}()
+
+	found, err := WaitForMail(context.Background(), tmpDir, "agent-2", time.Second)
+	if err != nil {
+		t.Fatalf("WaitForMail failed: %v", err)
+	}
+	if found {
+		t.Error("Mail for another agent should not wake the waiter")
+	}
+}
+
+func TestWaitForMail_Timeout(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	start := time.Now()
+	found, err := WaitForMail(context.Background(), tmpDir, "agent-2", 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForMail failed: %v", err)
+	}
+	if found {
+		t.Error("Expected timeout with empty mailbox")
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("WaitForMail returned before timeout: %v", elapsed)
+	}
+}
+
+func TestWaitForMail_ContextCanceled(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := WaitForMail(ctx, tmpDir, "agent-2", 0)
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"agentmail/internal/config"
 	"agentmail/internal/mail"
 
 	"github.com/fsnotify/fsnotify"
@@ -26,11 +27,13 @@ const (
 	ModePolling
 )
 
-// DefaultDebounceWindow is the default debounce window for file events (500ms per FR-011).
-const DefaultDebounceWindow = 500 * time.Millisecond
+// DefaultDebounceWindow is the default debounce window for file events (500ms per FR-011;
+// debounce_window in the config file).
+const DefaultDebounceWindow = config.DefaultDebounceWindow
 
-// FallbackTimerInterval is the interval for the safety net notification check in watching mode (60s per FR-012).
-const FallbackTimerInterval = 60 * time.Second
+// FallbackTimerInterval is the default interval for the safety net notification check in watching mode
+// (60s per FR-012; fallback_interval in the config file).
+const FallbackTimerInterval = config.DefaultFallbackInterval
 
 // Debouncer coalesces rapid file change events using a trailing-edge debounce.
 // It ensures that a callback is only triggered after no triggers have occurred
@@ -99,10 +102,13 @@ type FileWatcher struct {
 	debouncer    *Debouncer        // Debouncer for coalescing events
 	mailboxDir   string            // Path to .agentmail/mailboxes/
 	agentmailDir string            // Path to .agentmail/
+	storeFile    string            // Path to the bolt store database (.agentmail/agentmail.db)
 	stopChan     chan struct{}     // Signal to stop the watcher
 	mode         MonitoringMode    // Current monitoring mode
 	mu           sync.Mutex        // Protects mode
 	logger       io.Writer         // Logger for foreground mode (nil = no logging)
+	nextDue      func() time.Time  // Returns when the next scheduled message is due (zero = none)
+	fallback     time.Duration     // Interval of the safety net check
 }
 
 // log writes a formatted message to the logger if configured.
@@ -127,13 +133,16 @@ func NewFileWatcher(repoRoot string) (*FileWatcher, error) {
 		return nil, err
 	}
 
+	cfg := loadConfig(repoRoot)
 	fw := &FileWatcher{
 		watcher:      watcher,
-		debouncer:    NewDebouncer(DefaultDebounceWindow),
+		debouncer:    NewDebouncer(cfg.DebounceWindow),
 		mailboxDir:   filepath.Join(repoRoot, mail.MailDir),
 		agentmailDir: filepath.Join(repoRoot, mail.RootDir),
+		storeFile:    filepath.Join(repoRoot, mail.BoltFile),
 		stopChan:     make(chan struct{}),
 		mode:         ModeWatching,
+		fallback:     cfg.FallbackInterval,
 	}
 
 	return fw, nil
@@ -144,6 +153,31 @@ func (fw *FileWatcher) SetLogger(logger io.Writer) {
 	fw.logger = logger
 }
 
+// SetNextDue sets the function used to find the next scheduled delivery time.
+// When set, Run wakes up at that time instead of waiting for the fallback timer.
+func (fw *FileWatcher) SetNextDue(nextDue func() time.Time) {
+	fw.nextDue = nextDue
+}
+
+// scheduleDue returns a timer that fires when the next scheduled message is due,
+// or nil if nothing is due before the fallback timer would fire anyway.
+// It is called right after processFunc, so a due time in the past has already been handled.
+func (fw *FileWatcher) scheduleDue() *time.Timer {
+	if fw.nextDue == nil {
+		return nil
+	}
+	due := fw.nextDue()
+	if due.IsZero() {
+		return nil
+	}
+	wait := time.Until(due)
+	if wait <= 0 || wait >= fw.fallback {
+		return nil // Already due (handled by this check) or the fallback timer fires first
+	}
+	fw.log("Next scheduled message due in %v", wait.Round(time.Second))
+	return time.NewTimer(wait)
+}
+
 // AddWatches adds watches for .agentmail/ and .agentmail/mailboxes/ directories (FR-001, FR-004).
 func (fw *FileWatcher) AddWatches() error {
 	// Watch .agentmail/ for recipients.jsonl changes (FR-005)
@@ -183,10 +217,10 @@ func (fw *FileWatcher) isMailboxEvent(event fsnotify.Event) bool {
 	return strings.HasSuffix(event.Name, ".jsonl")
 }
 
-// isRecipientsEvent checks if the event is a Write to recipients.jsonl (FR-005).
+// isRecipientsEvent checks if the event is a Write or Create of recipients.jsonl (FR-005).
 func (fw *FileWatcher) isRecipientsEvent(event fsnotify.Event) bool {
-	// Must be Write operation (status changes are writes, not creates)
-	if !event.Has(fsnotify.Write) {
+	// Status changes rewrite the file by renaming a new one over it, which is a Create
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
 		return false
 	}
 
@@ -195,6 +229,12 @@ func (fw *FileWatcher) isRecipientsEvent(event fsnotify.Event) bool {
 	return event.Name == expectedPath
 }
 
+// isStoreEvent checks if the event is a Write to the bolt store database,
+// which holds both mailboxes and recipient state when that backend is configured.
+func (fw *FileWatcher) isStoreEvent(event fsnotify.Event) bool {
+	return event.Has(fsnotify.Write) && event.Name == fw.storeFile
+}
+
 // isMailboxDirCreate checks if the mailboxes directory was just created.
 // This handles the case where mailboxes/ doesn't exist at startup (FR-008).
 func (fw *FileWatcher) isMailboxDirCreate(event fsnotify.Event) bool {
@@ -210,10 +250,35 @@ func (fw *FileWatcher) isMailboxDirCreate(event fsnotify.Event) bool {
 // processFunc is always called in this goroutine (not in a timer goroutine) to avoid data races.
 func (fw *FileWatcher) Run(processFunc func()) error {
 	// Create fallback ticker for safety net (FR-012)
-	fallbackTicker := time.NewTicker(FallbackTimerInterval)
+	fallbackTicker := time.NewTicker(fw.fallback)
 	defer fallbackTicker.Stop()
 
-	fw.log("Starting file watcher event loop (fallback interval: %v)", FallbackTimerInterval)
+	fw.log("Starting file watcher event loop (fallback interval: %v)", fw.fallback)
+
+	// Wake-up timer for the next scheduled message (nil channel blocks forever)
+	var dueTimer *time.Timer
+	var dueC <-chan time.Time
+	process := func() {
+		processFunc()
+		if dueTimer != nil {
+			dueTimer.Stop()
+		}
+		dueTimer = fw.scheduleDue()
+		dueC = nil
+		if dueTimer != nil {
+			dueC = dueTimer.C
+		}
+	}
+	defer func() {
+		if dueTimer != nil {
+			dueTimer.Stop()
+		}
+	}()
+
+	// Messages scheduled before the loop started need a wake-up too
+	if dueTimer = fw.scheduleDue(); dueTimer != nil {
+		dueC = dueTimer.C
+	}
 
 	for {
 		select {
@@ -242,6 +307,13 @@ func (fw *FileWatcher) Run(processFunc func()) error {
 				continue
 			}
 
+			// Check if this is a bolt store write (mail or recipient state changed)
+			if fw.isStoreEvent(event) {
+				fw.log("Store change detected (%s)", event.Op)
+				fw.debouncer.Trigger()
+				continue
+			}
+
 			// Check if mailboxes directory was created (FR-008)
 			if fw.isMailboxDirCreate(event) {
 				fw.log("Mailboxes directory created, adding watch")
@@ -252,7 +324,7 @@ func (fw *FileWatcher) Run(processFunc func()) error {
 		case <-fw.debouncer.Ready():
 			// Debounce window expired - run processFunc in this goroutine to avoid data races
 			fw.log("Debounce window expired: running notification check")
-			processFunc()
+			process()
 
 		case err, ok := <-fw.watcher.Errors:
 			if !ok {
@@ -265,7 +337,11 @@ func (fw *FileWatcher) Run(processFunc func()) error {
 		case <-fallbackTicker.C:
 			fw.log("Fallback timer tick: running notification check")
 			// Safety net: check for notifications even if no events (FR-012)
-			processFunc()
+			process()
+
+		case <-dueC:
+			fw.log("Scheduled message due: running notification check")
+			process()
 		}
 	}
 }
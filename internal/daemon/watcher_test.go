@@ -373,6 +373,44 @@ func TestFileWatcher_Run_CallsProcessFuncOnRecipientsEvent(t *testing.T) {
 	}
 }
 
+func TestFileWatcher_Run_CallsProcessFuncOnRecipientsRename(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	fw, err := NewFileWatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("NewFileWatcher failed: %v", err)
+	}
+	if err := fw.AddWatches(); err != nil {
+		t.Fatalf("AddWatches failed: %v", err)
+	}
+
+	var called atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		_ = fw.Run(func() { called.Add(1) })
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// Rewrites rename a temp file over recipients.jsonl instead of writing to it
+	tmpFile := filepath.Join(tmpDir, ".agentmail", ".recipients.jsonl.tmp-1")
+	if err := os.WriteFile(tmpFile, []byte("{\"recipient\":\"agent1\"}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+	if err := os.Rename(tmpFile, filepath.Join(tmpDir, ".agentmail", "recipients.jsonl")); err != nil {
+		t.Fatalf("Failed to rename temp file: %v", err)
+	}
+
+	// Wait for debounce window (500ms) + buffer
+	time.Sleep(700 * time.Millisecond)
+	_ = fw.Close()
+	<-done
+
+	if called.Load() < 1 {
+		t.Errorf("Expected processFunc to be called on recipients rename, got %d", called.Load())
+	}
+}
+
 func TestFileWatcher_Run_StopsOnClose(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "agentmail-watcher-test-*")
 	if err != nil {
@@ -416,3 +454,42 @@ func TestFileWatcher_Run_StopsOnClose(t *testing.T) {
 		t.Error("Watcher did not stop within timeout after Close")
 	}
 }
+
+func TestFileWatcher_Run_WakesAtNextDue(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	fw, err := NewFileWatcher(tmpDir)
+	if err != nil {
+		t.Fatalf("NewFileWatcher failed: %v", err)
+	}
+
+	err = fw.AddWatches()
+	if err != nil {
+		t.Fatalf("AddWatches failed: %v", err)
+	}
+
+	// A message due shortly, long before the fallback timer
+	due := time.Now().Add(200 * time.Millisecond)
+	fw.SetNextDue(func() time.Time {
+		if time.Now().Before(due) {
+			return due
+		}
+		return time.Time{}
+	})
+
+	var called atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		_ = fw.Run(func() { called.Add(1) })
+		close(done)
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+
+	if called.Load() != 1 {
+		t.Errorf("Expected processFunc to be called once at the due time, got %d", called.Load())
+	}
+
+	_ = fw.Close()
+	<-done
+}
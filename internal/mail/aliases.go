@@ -0,0 +1,564 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"agentmail/internal/tmux"
+)
+
+// AliasesFile is the alias table, which records the tmux pane reading each mailbox
+const AliasesFile = ".agentmail/aliases.jsonl"
+
+// ErrRecipientNotFound is returned when an address matches no pane of the tmux server.
+var ErrRecipientNotFound = errors.New("recipient not found")
+
+// Alias binds a mailbox to the tmux pane of the agent reading it.
+//
+// A mailbox is named after the window its agent first used agentmail in.
+// Pane IDs survive renames, so the table keeps the mailbox with the pane when
+// the window is renamed, and records where the pane is now. A window whose name
+// is already taken by a live pane of another window, e.g. the same name in
+// another session, gets a session-qualified mailbox such as "api:agent-1".
+type Alias struct {
+	Mailbox   string    `json:"mailbox"`
+	Pane      string    `json:"pane"`             // Pane ID, e.g. "%12"
+	Server    string    `json:"server,omitempty"` // PID of the tmux server the pane ID belongs to
+	Session   string    `json:"session"`          // Session holding the pane
+	Window    string    `json:"window"`           // Window holding the pane; differs from Mailbox after a rename
+	UpdatedAt time.Time `json:"updated_at"`       // When the pane was registered or last moved (zero if unregistered)
+}
+
+// Address returns the session-qualified window of the alias, e.g. "api:agent-1".
+func (a Alias) Address() string {
+	return a.tmuxPane().Address()
+}
+
+// tmuxPane returns the pane an alias refers to.
+func (a Alias) tmuxPane() tmux.Pane {
+	return tmux.Pane{ID: a.Pane, Session: a.Session, Window: a.Window, Server: a.Server}
+}
+
+// matches reports whether the alias was registered for the pane.
+// An alias from before a tmux server restart doesn't match a pane that reuses its ID.
+func (a Alias) matches(pane tmux.Pane) bool {
+	return a.Pane == pane.ID && (a.Server == "" || a.Server == pane.Server)
+}
+
+// paneAlias returns the alias binding mailbox to pane.
+func paneAlias(mailbox string, pane tmux.Pane) Alias {
+	return Alias{Mailbox: mailbox, Pane: pane.ID, Server: pane.Server, Session: pane.Session, Window: pane.Window}
+}
+
+// ReadAliases returns the alias table. A missing table is empty;
+// lines that cannot be decoded are skipped.
+func ReadAliases(repoRoot string) ([]Alias, error) {
+	data, err := os.ReadFile(filepath.Join(repoRoot, AliasesFile)) // #nosec G304 - AliasesFile is a constant
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return parseAliases(data), nil
+}
+
+// parseAliases decodes the lines of the alias table, skipping malformed ones.
+func parseAliases(data []byte) []Alias {
+	var aliases []Alias
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var alias Alias
+		if err := json.Unmarshal(line, &alias); err != nil || alias.Mailbox == "" || alias.Pane == "" {
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// modifyAliases runs fn on the alias table under an exclusive lock and stores the
+// result if fn reports a change.
+func modifyAliases(repoRoot string, fn func([]Alias) ([]Alias, bool)) error {
+	if err := ensureRootDir(repoRoot); err != nil {
+		return err
+	}
+	path := filepath.Join(repoRoot, AliasesFile)
+	file, err := lockFile(path, os.O_CREATE|os.O_RDWR, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlockFile(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	aliases, changed := fn(parseAliases(data))
+	if !changed {
+		return nil
+	}
+	return replaceFile(path, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for _, alias := range aliases {
+			if err := encoder.Encode(alias); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Bind returns the alias of every pane in panes, in the same order: the mailbox it
+// reads and where it is now. Registered panes keep their mailbox and UpdatedAt.
+// Any other pane reads the mailbox named after its window, unless a pane of another
+// window already holds that name: then it reads its session-qualified address.
+// Earlier panes win ties, so callers list the current session first.
+func Bind(aliases []Alias, panes []tmux.Pane) []Alias {
+	bound := make([]Alias, len(panes))
+	registered := make([]bool, len(panes))
+	var claimed []Alias
+	for i, pane := range panes {
+		for _, alias := range aliases {
+			if alias.matches(pane) {
+				bound[i] = paneAlias(alias.Mailbox, pane)
+				bound[i].UpdatedAt = alias.UpdatedAt
+				registered[i] = true
+				claimed = append(claimed, bound[i])
+				break
+			}
+		}
+	}
+
+	for i, pane := range panes {
+		if registered[i] {
+			continue
+		}
+		bound[i] = paneAlias(defaultMailbox(claimed, pane), pane)
+		claimed = append(claimed, bound[i])
+	}
+	return bound
+}
+
+// defaultMailbox returns the mailbox of an unregistered pane, given the mailboxes
+// other live panes hold: its window name, shared with the other panes of its window,
+// or its session-qualified address if a pane elsewhere holds the name.
+func defaultMailbox(claimed []Alias, pane tmux.Pane) string {
+	for _, other := range claimed {
+		if other.Mailbox != pane.Window {
+			continue
+		}
+		if other.Session == pane.Session && other.Window == pane.Window {
+			return pane.Window // Another pane of the same window
+		}
+		return pane.Address()
+	}
+	return pane.Window
+}
+
+// findAddress returns the bound pane an address refers to. A pane ID ("%12") or a
+// session-qualified window ("api:agent-1") is looked up in all, the panes of every
+// session. A bare window name is looked up in local, the panes reading this
+// repository's mail, as a window of the given session. Failing that, an address
+// naming a mailbox of local finds its pane, so a renamed window is still reachable
+// by its old name.
+func findAddress(all, local []Alias, session, address string) (Alias, bool) {
+	match, in := func(a Alias) bool {
+		return (session == "" || a.Session == session) && a.Window == address
+	}, local
+	if tmux.IsPaneID(address) {
+		match, in = func(a Alias) bool { return a.Pane == address }, all
+	} else if s, w, ok := tmux.SplitAddress(address); ok {
+		match, in = func(a Alias) bool { return a.Session == s && a.Window == w }, all
+	}
+	for _, a := range in {
+		if match(a) {
+			return a, true
+		}
+	}
+	return findMailbox(local, address)
+}
+
+// findMailbox returns the pane reading mailbox, preferring the registered one.
+func findMailbox(bound []Alias, mailbox string) (Alias, bool) {
+	found, ok := Alias{}, false
+	for _, a := range bound {
+		if a.Mailbox != mailbox {
+			continue
+		}
+		if !a.UpdatedAt.IsZero() {
+			return a, true
+		}
+		if !ok {
+			found, ok = a, true
+		}
+	}
+	return found, ok
+}
+
+// Register records in the alias table that pane reads its mailbox, or where it
+// moved, and returns the mailbox. panes are the live panes, used to tell which
+// window names are taken by the panes reading this repository's mail (see
+// repoPanes). A mailbox whose pane is gone passes to pane.
+func Register(repoRoot string, pane tmux.Pane, panes []tmux.Pane) (string, error) {
+	if !containsPane(panes, pane) {
+		panes = append([]tmux.Pane{pane}, panes...)
+	}
+
+	var mailbox string
+	err := modifyAliases(repoRoot, func(aliases []Alias) ([]Alias, bool) {
+		for _, a := range Bind(aliases, repoPanes(repoRoot, aliases, panes, pane.Session)) {
+			if a.Pane == pane.ID {
+				mailbox = a.Mailbox
+				break
+			}
+		}
+
+		now := time.Now()
+		for i, a := range aliases {
+			if !a.matches(pane) {
+				continue
+			}
+			if a.Session == pane.Session && a.Window == pane.Window && a.Server == pane.Server {
+				return aliases, false
+			}
+			aliases[i] = paneAlias(a.Mailbox, pane)
+			aliases[i].UpdatedAt = now
+			return aliases, true
+		}
+
+		kept := make([]Alias, 0, len(aliases)+1)
+		for _, a := range aliases {
+			if a.Mailbox != mailbox {
+				kept = append(kept, a)
+				continue
+			}
+			for _, live := range panes {
+				if a.matches(live) {
+					return aliases, false // Another pane of the window holds the mailbox
+				}
+			}
+		}
+		alias := paneAlias(mailbox, pane)
+		alias.UpdatedAt = now
+		return append(kept, alias), true
+	})
+	return mailbox, err
+}
+
+// ensureRegistered returns the mailbox of pane, registering it only if the alias
+// table doesn't record it where it is: on its first use, or after its window was
+// renamed or moved. Otherwise the table is only read, so the commands run on every
+// receive, status or send don't rewrite it.
+func ensureRegistered(repoRoot string, pane tmux.Pane, panes []tmux.Pane) (string, error) {
+	aliases, err := ReadAliases(repoRoot)
+	if err != nil {
+		return "", err
+	}
+	if !containsPane(panes, pane) {
+		panes = append([]tmux.Pane{pane}, panes...)
+	}
+
+	var mailbox string
+	for _, a := range Bind(aliases, repoPanes(repoRoot, aliases, panes, pane.Session)) {
+		if a.Pane == pane.ID {
+			mailbox = a.Mailbox
+			break
+		}
+	}
+	for _, a := range aliases {
+		if a.Mailbox != mailbox {
+			continue
+		}
+		if a.matches(pane) {
+			if a.Session == pane.Session && a.Window == pane.Window && a.Server == pane.Server {
+				return mailbox, nil
+			}
+			continue
+		}
+		for _, live := range panes {
+			if a.matches(live) {
+				return mailbox, nil // Another pane of the window holds the mailbox
+			}
+		}
+	}
+	return Register(repoRoot, pane, panes)
+}
+
+// repoPanes returns the panes that read mail in the repository at repoRoot: those
+// of the given session, and those of other sessions that are registered in aliases,
+// its alias table, or work in it (#{pane_current_path}). A pane of another session
+// working in another repository reads that repository's mailboxes instead.
+func repoPanes(repoRoot string, aliases []Alias, panes []tmux.Pane, session string) []tmux.Pane {
+	var kept []tmux.Pane
+	for _, pane := range panes {
+		if (session != "" && pane.Session == session) || isRegistered(aliases, pane) || sameDir(paneRepository(pane), repoRoot) {
+			kept = append(kept, pane)
+		}
+	}
+	return kept
+}
+
+// isRegistered reports whether an alias was registered for pane.
+func isRegistered(aliases []Alias, pane tmux.Pane) bool {
+	for _, a := range aliases {
+		if a.matches(pane) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPane reports whether panes holds pane.
+func containsPane(panes []tmux.Pane, pane tmux.Pane) bool {
+	for _, p := range panes {
+		if p.ID == pane.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// aliasRoot returns repoRoot, or the git root if it is empty.
+// Outside a repository it returns "": there is no alias table.
+func aliasRoot(repoRoot string) string {
+	if repoRoot != "" {
+		return repoRoot
+	}
+	root, _ := FindGitRoot() // G104: outside a repository mailboxes are plain window names
+	return root
+}
+
+// Pane lookups, replaced by tests to run without tmux.
+var (
+	listPanes   = tmux.ListPanes
+	currentPane = tmux.GetCurrentPane
+)
+
+// sessionPanes returns every pane of the tmux server, those of the current
+// session first, and the name of the current session.
+func sessionPanes() ([]tmux.Pane, string, error) {
+	panes, err := listPanes()
+	if err != nil {
+		return nil, "", err
+	}
+	current, err := currentPane()
+	if err != nil {
+		return panes, "", nil // Not started from a pane: no session comes first
+	}
+	return sessionFirst(panes, current.Session), current.Session, nil
+}
+
+// sessionFirst stable-sorts the panes of session before those of other sessions.
+func sessionFirst(panes []tmux.Pane, session string) []tmux.Pane {
+	sort.SliceStable(panes, func(i, j int) bool {
+		return panes[i].Session == session && panes[j].Session != session
+	})
+	return panes
+}
+
+// CurrentMailbox returns the mailbox of the tmux pane this process runs in,
+// from the alias table of the repository at repoRoot (the git root if empty).
+// The pane is registered on first use and when its window was renamed.
+func CurrentMailbox(repoRoot string) (string, error) {
+	pane, err := currentPane()
+	if err != nil {
+		return "", err
+	}
+	repoRoot = aliasRoot(repoRoot)
+	if repoRoot == "" {
+		return pane.Window, nil
+	}
+	panes, _, err := sessionPanes()
+	if err != nil {
+		return "", err
+	}
+	return ensureRegistered(repoRoot, pane, panes)
+}
+
+// Target is where a message to an address is delivered.
+type Target struct {
+	Mailbox  string    // Mailbox read by the pane
+	RepoRoot string    // Repository whose store holds the mailbox, if not this one
+	Pane     tmux.Pane // Pane the address refers to
+}
+
+// Resolve returns the mailbox of the pane an address refers to: a pane ID ("%12"),
+// a session-qualified window ("api:agent-1"), a window of the current session, or
+// a mailbox of this repository whose window was renamed. A bare name never reaches
+// a pane of another session working in another repository; that takes its
+// session-qualified address. A pane not yet in the alias table is registered, so
+// its mailbox stays the same if its window is renamed later.
+//
+// A pane that is now in another session and works in another repository
+// (#{pane_current_path}) reads its mail there, even if it was registered here
+// before it moved: the target is its mailbox in that repository's store.
+// Returns ErrRecipientNotFound if no live pane matches.
+func Resolve(repoRoot string, address string) (Target, error) {
+	panes, session, err := sessionPanes()
+	if err != nil {
+		return Target{}, err
+	}
+	repoRoot = aliasRoot(repoRoot)
+	var aliases []Alias
+	if repoRoot != "" {
+		if aliases, err = ReadAliases(repoRoot); err != nil {
+			return Target{}, err
+		}
+	}
+
+	local := Bind(aliases, repoPanes(repoRoot, aliases, panes, session))
+	alias, ok := findAddress(Bind(aliases, panes), local, session, address)
+	if !ok {
+		return Target{}, ErrRecipientNotFound
+	}
+	target := Target{Mailbox: alias.Mailbox, Pane: alias.tmuxPane()}
+	for _, p := range panes {
+		if p.ID == alias.Pane {
+			target.Pane = p
+			break
+		}
+	}
+
+	if target.Pane.Session != session {
+		if root := paneRepository(target.Pane); root != "" && !sameDir(root, repoRoot) {
+			target.RepoRoot = root
+			target.Mailbox, err = ensureRegistered(root, target.Pane, sessionFirst(panes, target.Pane.Session))
+			return target, err
+		}
+	}
+	if repoRoot == "" {
+		return target, nil
+	}
+	target.Mailbox, err = ensureRegistered(repoRoot, target.Pane, panes)
+	return target, err
+}
+
+// paneRepository returns the git root of the directory pane works in, or "" if none.
+func paneRepository(pane tmux.Pane) string {
+	if pane.Path == "" {
+		return ""
+	}
+	root, _ := FindGitRootFrom(pane.Path) // G104: a pane outside a repository reads no store
+	return root
+}
+
+// sameDir reports whether a and b are the same directory.
+func sameDir(a, b string) bool {
+	if a == "" || b == "" {
+		return a == b
+	}
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return os.SameFile(infoA, infoB)
+}
+
+// CurrentAddress returns the session-qualified window of the pane this process
+// runs in. Messages to another repository are sent from it, since the sender's
+// mailbox means nothing there; a reply to it resolves back to this pane.
+func CurrentAddress() (string, error) {
+	pane, err := currentPane()
+	if err != nil {
+		return "", err
+	}
+	return pane.Address(), nil
+}
+
+// LocateMailbox returns the live pane reading mailbox, in any session (see repoPanes).
+// ok is false if no pane reads it, e.g. because its window was closed.
+func LocateMailbox(repoRoot string, mailbox string) (alias Alias, ok bool, err error) {
+	bound, err := boundPanes(repoRoot)
+	if err != nil {
+		return Alias{}, false, err
+	}
+	alias, ok = findMailbox(bound, mailbox)
+	return alias, ok, nil
+}
+
+// LiveMailboxes returns the mailboxes read by a pane in any session, without
+// duplicates. Panes of other sessions working in other repositories are skipped.
+func LiveMailboxes(repoRoot string) ([]string, error) {
+	bound, err := boundPanes(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	var mailboxes []string
+	seen := make(map[string]bool)
+	for _, a := range bound {
+		if !seen[a.Mailbox] {
+			seen[a.Mailbox] = true
+			mailboxes = append(mailboxes, a.Mailbox)
+		}
+	}
+	return mailboxes, nil
+}
+
+// SessionMailboxes returns one alias per mailbox of the current session, in window
+// order: the registered pane of the mailbox, or else its first pane.
+func SessionMailboxes(repoRoot string) ([]Alias, error) {
+	panes, session, err := sessionPanes()
+	if err != nil {
+		return nil, err
+	}
+	bound, err := bindPanes(repoRoot, panes, session)
+	if err != nil {
+		return nil, err
+	}
+
+	var mailboxes []Alias
+	index := make(map[string]int)
+	for _, a := range bound {
+		if session != "" && a.Session != session {
+			continue
+		}
+		i, seen := index[a.Mailbox]
+		if !seen {
+			index[a.Mailbox] = len(mailboxes)
+			mailboxes = append(mailboxes, a)
+		} else if mailboxes[i].UpdatedAt.IsZero() && !a.UpdatedAt.IsZero() {
+			mailboxes[i] = a
+		}
+	}
+	return mailboxes, nil
+}
+
+// MailboxNames returns the mailboxes of aliases.
+func MailboxNames(aliases []Alias) []string {
+	names := make([]string, len(aliases))
+	for i, a := range aliases {
+		names[i] = a.Mailbox
+	}
+	return names
+}
+
+// boundPanes returns the alias of every pane of the tmux server reading the mail
+// of repoRoot.
+func boundPanes(repoRoot string) ([]Alias, error) {
+	panes, session, err := sessionPanes()
+	if err != nil {
+		return nil, err
+	}
+	return bindPanes(repoRoot, panes, session)
+}
+
+// bindPanes binds the panes reading the mail of repoRoot (the git root if empty)
+// with its alias table, session being the current one.
+func bindPanes(repoRoot string, panes []tmux.Pane, session string) ([]Alias, error) {
+	var aliases []Alias
+	if repoRoot = aliasRoot(repoRoot); repoRoot != "" {
+		var err error
+		if aliases, err = ReadAliases(repoRoot); err != nil {
+			return nil, err
+		}
+	}
+	return Bind(aliases, repoPanes(repoRoot, aliases, panes, session)), nil
+}
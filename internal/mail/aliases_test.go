@@ -0,0 +1,274 @@
+package mail
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agentmail/internal/tmux"
+)
+
+// pane returns a pane of tmux server "100".
+func pane(id, session, window string) tmux.Pane {
+	return tmux.Pane{ID: id, Session: session, Window: window, Server: "100"}
+}
+
+// fakePanes makes the pane lookups report panes, the first being the current one.
+// Tests change a pane's session, window or path by changing the slice.
+func fakePanes(t *testing.T, panes []tmux.Pane) {
+	t.Helper()
+	list, current := listPanes, currentPane
+	t.Cleanup(func() { listPanes, currentPane = list, current })
+	listPanes = func() ([]tmux.Pane, error) { return append([]tmux.Pane(nil), panes...), nil }
+	currentPane = func() (tmux.Pane, error) { return panes[0], nil }
+}
+
+// gitRepo returns a new directory that FindGitRootFrom takes for a repository root.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	return repo
+}
+
+func TestBind_CollidingWindowNames(t *testing.T) {
+	panes := []tmux.Pane{
+		pane("%1", "web", "agent-1"),
+		pane("%2", "web", "agent-1"), // Second pane of the same window
+		pane("%3", "api", "agent-1"),
+		pane("%4", "api", "lead"),
+	}
+
+	bound := Bind(nil, panes)
+	want := []string{"agent-1", "agent-1", "api:agent-1", "lead"}
+	for i, mailbox := range want {
+		if bound[i].Mailbox != mailbox {
+			t.Errorf("Pane %s: expected mailbox %q, got %q", panes[i].ID, mailbox, bound[i].Mailbox)
+		}
+	}
+}
+
+func TestRegister_MailboxSurvivesRename(t *testing.T) {
+	repoRoot := t.TempDir()
+	agent := pane("%3", "web", "agent-1")
+	panes := []tmux.Pane{agent, pane("%4", "web", "lead")}
+
+	mailbox, err := Register(repoRoot, agent, panes)
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if mailbox != "agent-1" {
+		t.Fatalf("Expected mailbox agent-1, got %q", mailbox)
+	}
+
+	// The window is renamed: the pane keeps its mailbox and the table records the new name
+	renamed := pane("%3", "web", "reviewer")
+	panes[0] = renamed
+	if mailbox, err = Register(repoRoot, renamed, panes); err != nil || mailbox != "agent-1" {
+		t.Fatalf("Expected mailbox agent-1 after rename, got %q, %v", mailbox, err)
+	}
+	aliases, err := ReadAliases(repoRoot)
+	if err != nil {
+		t.Fatalf("ReadAliases failed: %v", err)
+	}
+	if len(aliases) != 1 || aliases[0].Window != "reviewer" || aliases[0].Mailbox != "agent-1" {
+		t.Errorf("Expected one alias agent-1 -> reviewer, got %+v", aliases)
+	}
+
+	bound := Bind(aliases, panes)
+	for _, address := range []string{"reviewer", "agent-1", "%3", "web:reviewer"} {
+		if a, ok := findAddress(bound, bound, "web", address); !ok || a.Mailbox != "agent-1" {
+			t.Errorf("Address %q: expected mailbox agent-1, got %+v, %v", address, a, ok)
+		}
+	}
+	if _, ok := findAddress(bound, bound, "web", "api:reviewer"); ok {
+		t.Error("Expected no pane for a window of another session")
+	}
+
+	// A new window takes the old name while the renamed one is alive
+	newcomer := pane("%9", "web", "agent-1")
+	panes = append(panes, newcomer)
+	if mailbox, err = Register(repoRoot, newcomer, panes); err != nil || mailbox != "web:agent-1" {
+		t.Errorf("Expected a qualified mailbox for the newcomer, got %q, %v", mailbox, err)
+	}
+	bound = Bind(mustReadAliases(t, repoRoot), panes)
+	if a, ok := findAddress(bound, bound, "web", "agent-1"); !ok || a.Pane != "%9" {
+		t.Errorf("Expected the window name to address the window now holding it, got %+v", a)
+	}
+}
+
+func TestRegister_MailboxPassesOnWhenPaneIsGone(t *testing.T) {
+	repoRoot := t.TempDir()
+	if _, err := Register(repoRoot, pane("%3", "web", "agent-1"), nil); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	// The tmux server restarted: pane IDs start over and %3 is someone else now
+	restarted := tmux.Pane{ID: "%3", Session: "web", Window: "lead", Server: "200"}
+	agent := tmux.Pane{ID: "%5", Session: "web", Window: "agent-1", Server: "200"}
+	panes := []tmux.Pane{restarted, agent}
+
+	if mailbox, err := Register(repoRoot, restarted, panes); err != nil || mailbox != "lead" {
+		t.Errorf("Expected a reused pane ID to get its own mailbox, got %q, %v", mailbox, err)
+	}
+	if mailbox, err := Register(repoRoot, agent, panes); err != nil || mailbox != "agent-1" {
+		t.Errorf("Expected the new agent-1 window to inherit the mailbox, got %q, %v", mailbox, err)
+	}
+
+	aliases := mustReadAliases(t, repoRoot)
+	if len(aliases) != 2 {
+		t.Fatalf("Expected the stale alias to be replaced, got %+v", aliases)
+	}
+	if a, ok := findMailbox(Bind(aliases, panes), "agent-1"); !ok || a.Pane != "%5" {
+		t.Errorf("Expected agent-1 to be read by %%5, got %+v", a)
+	}
+}
+
+func TestCurrentMailbox_WritesOnlyOnFirstUseAndRename(t *testing.T) {
+	repoRoot := t.TempDir()
+	panes := []tmux.Pane{pane("%1", "web", "lead"), pane("%3", "web", "agent-1")}
+	fakePanes(t, panes)
+
+	if mailbox, err := CurrentMailbox(repoRoot); err != nil || mailbox != "lead" {
+		t.Fatalf("Expected mailbox lead, got %q, %v", mailbox, err)
+	}
+	if target, err := Resolve(repoRoot, "agent-1"); err != nil || target.Mailbox != "agent-1" {
+		t.Fatalf("Expected mailbox agent-1, got %+v, %v", target, err)
+	}
+	if aliases := mustReadAliases(t, repoRoot); len(aliases) != 2 {
+		t.Fatalf("Expected both panes registered on first use, got %+v", aliases)
+	}
+
+	// Registered panes that stay where they are only read the table
+	path := filepath.Join(repoRoot, AliasesFile)
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := CurrentMailbox(repoRoot); err != nil {
+			t.Fatalf("CurrentMailbox failed: %v", err)
+		}
+		if _, err := Resolve(repoRoot, "%3"); err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if !os.SameFile(before, after) || !after.ModTime().Equal(before.ModTime()) {
+		t.Error("Expected the alias table not to be rewritten")
+	}
+
+	// A rename is recorded
+	panes[0].Window = "boss"
+	if mailbox, err := CurrentMailbox(repoRoot); err != nil || mailbox != "lead" {
+		t.Fatalf("Expected mailbox lead after the rename, got %q, %v", mailbox, err)
+	}
+	if a, ok := findMailbox(mustReadAliases(t, repoRoot), "lead"); !ok || a.Window != "boss" {
+		t.Errorf("Expected the rename in the alias table, got %+v", a)
+	}
+}
+
+func TestResolve_RegisteredPaneMovedToAnotherSession(t *testing.T) {
+	webRepo, apiRepo := gitRepo(t), gitRepo(t)
+	lead := tmux.Pane{ID: "%1", Session: "web", Window: "lead", Server: "100", Path: webRepo}
+	worker := tmux.Pane{ID: "%5", Session: "web", Window: "worker", Server: "100", Path: webRepo}
+	panes := []tmux.Pane{lead, worker}
+	fakePanes(t, panes)
+
+	target, err := Resolve(webRepo, "worker")
+	if err != nil || target.RepoRoot != "" || target.Mailbox != "worker" {
+		t.Fatalf("Expected worker in this repository, got %+v, %v", target, err)
+	}
+	if _, ok := findMailbox(mustReadAliases(t, webRepo), "worker"); !ok {
+		t.Fatal("Expected worker registered here")
+	}
+
+	// The window moves to the api session and its pane to the api repository
+	panes[1].Session, panes[1].Path = "api", filepath.Join(apiRepo, "cmd")
+	target, err = Resolve(webRepo, "api:worker")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if !sameDir(target.RepoRoot, apiRepo) || target.Mailbox != "worker" {
+		t.Errorf("Expected worker in the api repository, got %+v", target)
+	}
+	if _, ok := findMailbox(mustReadAliases(t, apiRepo), "worker"); !ok {
+		t.Error("Expected worker registered in the api repository")
+	}
+
+	// A registered pane that moved to another session of the same repository stays local
+	panes[1].Path = webRepo
+	if target, err = Resolve(webRepo, "api:worker"); err != nil || target.RepoRoot != "" {
+		t.Errorf("Expected worker in this repository, got %+v, %v", target, err)
+	}
+}
+
+func TestResolve_BareNameStaysInThisRepository(t *testing.T) {
+	webRepo, apiRepo := gitRepo(t), gitRepo(t)
+	fakePanes(t, []tmux.Pane{
+		{ID: "%1", Session: "web", Window: "lead", Server: "100", Path: webRepo},
+		{ID: "%5", Session: "api", Window: "agent-1", Server: "100", Path: filepath.Join(apiRepo, "cmd")},
+	})
+
+	if target, err := Resolve(webRepo, "agent-1"); !errors.Is(err, ErrRecipientNotFound) {
+		t.Errorf("Expected ErrRecipientNotFound for a window of another repository, got %+v, %v", target, err)
+	}
+	if target, err := Resolve(webRepo, "api:agent-1"); err != nil || !sameDir(target.RepoRoot, apiRepo) {
+		t.Errorf("Expected the session-qualified address to reach the api repository, got %+v, %v", target, err)
+	}
+}
+
+func TestLiveMailboxes_SkipsPanesOfOtherRepositories(t *testing.T) {
+	webRepo, apiRepo := gitRepo(t), gitRepo(t)
+	panes := []tmux.Pane{
+		{ID: "%1", Session: "web", Window: "lead", Server: "100", Path: webRepo},
+		{ID: "%5", Session: "api", Window: "agent-1", Server: "100", Path: filepath.Join(apiRepo, "cmd")},
+		{ID: "%7", Session: "docs", Window: "writer", Server: "100", Path: webRepo},
+	}
+	fakePanes(t, panes)
+
+	if _, ok, err := LocateMailbox(webRepo, "agent-1"); err != nil || ok {
+		t.Errorf("Expected no pane for a window working in another repository, got %v, %v", ok, err)
+	}
+	if alias, ok, err := LocateMailbox(webRepo, "writer"); err != nil || !ok || alias.Pane != "%7" {
+		t.Errorf("Expected the docs pane working here to read writer, got %+v, %v, %v", alias, ok, err)
+	}
+	mailboxes, err := LiveMailboxes(webRepo)
+	if err != nil {
+		t.Fatalf("LiveMailboxes failed: %v", err)
+	}
+	if strings.Join(mailboxes, " ") != "lead writer" {
+		t.Errorf("Expected [lead writer], got %v", mailboxes)
+	}
+
+	// A pane registered here still reads its mailbox after moving to another repository
+	if _, err := Register(webRepo, panes[1], panes); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if _, ok, err := LocateMailbox(webRepo, "agent-1"); err != nil || !ok {
+		t.Errorf("Expected the registered pane to read agent-1, got %v, %v", ok, err)
+	}
+}
+
+func TestReadAliases_SkipsMalformedLines(t *testing.T) {
+	aliases := parseAliases([]byte("not json\n{\"mailbox\":\"agent-1\",\"pane\":\"%1\"}\n{\"pane\":\"%2\"}\n"))
+	if len(aliases) != 1 || aliases[0].Mailbox != "agent-1" {
+		t.Errorf("Expected one valid alias, got %+v", aliases)
+	}
+}
+
+func mustReadAliases(t *testing.T, repoRoot string) []Alias {
+	t.Helper()
+	aliases, err := ReadAliases(repoRoot)
+	if err != nil {
+		t.Fatalf("ReadAliases failed: %v", err)
+	}
+	return aliases
+}
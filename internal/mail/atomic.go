@@ -0,0 +1,109 @@
+package mail
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Fault-injection points, replaced by tests to simulate failing disks.
+var (
+	tempWriter = func(w io.Writer) io.Writer { return w } // Wraps writes to the temp file of replaceFile
+	renameFile = os.Rename                                // Moves the temp file over the original
+)
+
+// lockFile opens the file at path with flag and flocks it with how (LOCK_SH or LOCK_EX).
+// Rewrites replace a file by renaming a new one over it, so by the time the lock is
+// acquired the open file may no longer be the one at path. lockFile then retries on
+// the current file, which keeps every reader and writer serialized on the same inode.
+// Release the file with unlockFile.
+func lockFile(path string, flag int, how int) (*os.File, error) {
+	for {
+		file, err := os.OpenFile(path, flag, 0600) // #nosec G304 - callers validate path with safePath; G302 - restricted file permissions
+		if err != nil {
+			return nil, err
+		}
+		if err := syscall.Flock(int(file.Fd()), how); err != nil {
+			_ = file.Close() // G104: error intentionally ignored in cleanup path
+			return nil, err
+		}
+
+		current, err := isCurrentFile(file, path)
+		if err != nil {
+			unlockFile(file)
+			return nil, err
+		}
+		if current {
+			return file, nil
+		}
+		unlockFile(file) // Replaced or removed while we waited: try again
+	}
+}
+
+// isCurrentFile reports whether path still refers to the open file.
+func isCurrentFile(file *os.File, path string) (bool, error) {
+	opened, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return os.SameFile(opened, current), nil
+}
+
+// unlockFile releases a file locked by lockFile.
+func unlockFile(file *os.File) {
+	_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) // G104: unlock errors don't affect the result
+	_ = file.Close()                                   // G104: close errors don't affect the result
+}
+
+// replaceFile atomically replaces the file at path with the output of write.
+// The output goes to a temp file in the same directory, which is fsynced and renamed
+// over path, so a crash, full disk or kill leaves either the old or the new file,
+// never a partial one. The caller must hold the exclusive lock of the file at path
+// until replaceFile returns; processes waiting for it then retry on the new file (see lockFile).
+func replaceFile(path string, write func(w io.Writer) error) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()           // G104: may already be closed
+			_ = os.Remove(tmp.Name()) // G104: best-effort cleanup of the temp file
+		}
+	}()
+
+	buf := bufio.NewWriter(tempWriter(tmp))
+	if err = write(buf); err != nil {
+		return err
+	}
+	if err = buf.Flush(); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = renameFile(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Persist the rename itself. The new file is complete either way, so a
+	// failure here is not reported: the caller's change has been made.
+	if d, dirErr := os.Open(dir); dirErr == nil { // #nosec G304 - directory of a validated path
+		_ = d.Sync()  // G104: best-effort, see above
+		_ = d.Close() // G104: best-effort, see above
+	}
+	return nil
+}
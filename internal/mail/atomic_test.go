@@ -0,0 +1,175 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var errDiskFull = errors.New("no space left on device")
+
+// failingWriter passes through the first n bytes, then fails like a full disk.
+type failingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > f.n {
+		written, _ := f.w.Write(p[:f.n])
+		f.n = 0
+		return written, errDiskFull
+	}
+	f.n -= len(p)
+	return f.w.Write(p)
+}
+
+// failWritesAfter makes rewrites fail after n bytes for the rest of the test.
+func failWritesAfter(t *testing.T, n int) {
+	t.Helper()
+	original := tempWriter
+	tempWriter = func(w io.Writer) io.Writer { return &failingWriter{w: w, n: n} }
+	t.Cleanup(func() { tempWriter = original })
+}
+
+// rewriteMailbox rewrites a mailbox through Store.Modify without changing its messages.
+func rewriteMailbox(repoRoot, recipient string) error {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return err
+	}
+	return store.Modify(recipient, func(messages []Message) ([]Message, bool, error) {
+		return messages, true, nil
+	})
+}
+
+// assertNoTempFiles checks that failed rewrites cleaned up after themselves.
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("Temp file left behind: %s", entry.Name())
+		}
+	}
+}
+
+func TestRewrite_WriteFailureLosesNoMessage(t *testing.T) {
+	tmpDir := t.TempDir()
+	var original []Message
+	for i := 0; i < 20; i++ {
+		msg := Message{ID: fmt.Sprintf("m%02d", i), From: "agent-1", To: "agent-2", Message: strings.Repeat("x", 300)}
+		original = append(original, msg)
+	}
+	if err := WriteAll(tmpDir, "agent-2", original); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+	filePath := filepath.Join(tmpDir, MailDir, "agent-2.jsonl")
+	before, _ := os.ReadFile(filePath)
+
+	// Fail at the start, mid-line, on a line boundary and just before the end
+	for _, n := range []int{0, 1, 100, len(before) / 2, len(before) - 1} {
+		t.Run(fmt.Sprintf("after_%d_bytes", n), func(t *testing.T) {
+			failWritesAfter(t, n)
+
+			err := rewriteMailbox(tmpDir, "agent-2")
+			if !errors.Is(err, errDiskFull) {
+				t.Fatalf("Expected the write failure, got %v", err)
+			}
+
+			after, _ := os.ReadFile(filePath)
+			if string(after) != string(before) {
+				t.Fatalf("Mailbox changed by a failed rewrite:\n%s", after)
+			}
+			messages, _ := ReadAll(tmpDir, "agent-2")
+			if len(messages) != len(original) {
+				t.Errorf("Expected %d messages, got %d", len(original), len(messages))
+			}
+			assertNoTempFiles(t, filepath.Join(tmpDir, MailDir))
+		})
+	}
+}
+
+func TestRewrite_RenameFailureLosesNoMessage(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "a", From: "agent-1", To: "agent-2", Message: "one"})
+	_ = Append(tmpDir, Message{ID: "b", From: "agent-1", To: "agent-2", Message: "two"})
+
+	originalRename := renameFile
+	renameFile = func(string, string) error { return errDiskFull }
+	t.Cleanup(func() { renameFile = originalRename })
+
+	if err := WriteAll(tmpDir, "agent-2", nil); !errors.Is(err, errDiskFull) {
+		t.Fatalf("Expected the rename failure, got %v", err)
+	}
+
+	messages, _ := ReadAll(tmpDir, "agent-2")
+	if len(messages) != 2 {
+		t.Errorf("Expected both messages kept, got %+v", messages)
+	}
+	assertNoTempFiles(t, filepath.Join(tmpDir, MailDir))
+}
+
+func TestRewriteRecipients_WriteFailureKeepsState(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"agent-1", "agent-2", "agent-3"} {
+		if err := UpdateRecipientState(tmpDir, name, StatusReady, false); err != nil {
+			t.Fatalf("UpdateRecipientState failed: %v", err)
+		}
+	}
+
+	failWritesAfter(t, 10)
+	if err := UpdateRecipientState(tmpDir, "agent-4", StatusWork, false); !errors.Is(err, errDiskFull) {
+		t.Fatalf("Expected the write failure, got %v", err)
+	}
+
+	recipients, err := ReadAllRecipients(tmpDir)
+	if err != nil || len(recipients) != 3 {
+		t.Errorf("Expected the 3 stored recipients, got %+v, %v", recipients, err)
+	}
+	assertNoTempFiles(t, filepath.Join(tmpDir, RootDir))
+}
+
+func TestRewrite_SerializesWithAppend(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "seed", From: "agent-1", To: "agent-2", Message: "seed"})
+
+	const appends = 2000
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	// Rewrite the mailbox continuously while messages are appended
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				if err := rewriteMailbox(tmpDir, "agent-2"); err != nil {
+					t.Errorf("Rewrite failed: %v", err)
+					return
+				}
+			}
+		}
+	}()
+
+	for i := 0; i < appends; i++ {
+		if err := Append(tmpDir, Message{ID: fmt.Sprintf("n%03d", i), From: "agent-1", To: "agent-2", Message: "hi"}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	messages, _ := ReadAll(tmpDir, "agent-2")
+	if len(messages) != appends+1 {
+		t.Errorf("Expected %d messages, got %d: appends were lost to a rewrite", appends+1, len(messages))
+	}
+}
@@ -0,0 +1,230 @@
+package mail
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+// DeadLetterDir is the directory for messages that could not be delivered
+const DeadLetterDir = ".agentmail/deadletter"
+
+// DeadLetterFile is the file holding all dead-lettered messages
+const DeadLetterFile = ".agentmail/deadletter/messages.jsonl"
+
+// Dead-letter reasons recorded in Message.DeadReason
+const (
+	ReasonUndeliverable    = "undeliverable"     // Recipient window is gone
+	ReasonMaxNotifications = "max-notifications" // Recipient was notified too many times without reading
+	ReasonExpired          = "expired"           // TTL ran out before the message was read
+)
+
+// RecordNotification increments the notification count of every unread,
+// non-leased, due message in the recipient's mailbox. The mailman calls it after
+// notifying an agent, so messages that are never picked up can be dead-lettered.
+// Each counted message also gets a notified delivery event.
+func RecordNotification(repoRoot string, recipient string) error {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	// The counts are appended as update records, so notifying doesn't rewrite the mailbox
+	now := time.Now()
+	notified, err := store.UpdateUnread(recipient, func(msg *Message) bool {
+		if msg.InFlight(now) || msg.Pending(now) || msg.Expired(now) {
+			return false
+		}
+		msg.Notified++
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	// Events are recorded after the mailbox lock is released
+	for _, msg := range notified {
+		recordEvent(store, EventNotified, msg)
+	}
+	return nil
+}
+
+// deadReason returns why an unread message should be dead-lettered, or "" to keep it.
+// windows == nil skips the undeliverable check; maxNotified <= 0 disables the notification limit.
+func deadReason(msg Message, windowSet map[string]bool, undeliverableAfter time.Duration, maxNotified int, now time.Time) string {
+	if msg.ReadFlag || msg.InFlight(now) || msg.Pending(now) || msg.Expired(now) {
+		return "" // Expired messages are handled by ExpireMessages
+	}
+	if windowSet != nil && !windowSet[msg.To] && !msg.CreatedAt.IsZero() && now.Sub(msg.CreatedAt) >= undeliverableAfter {
+		return ReasonUndeliverable
+	}
+	if maxNotified > 0 && msg.Notified > maxNotified {
+		return ReasonMaxNotifications
+	}
+	return ""
+}
+
+// DeadLetterSweep moves unread messages that cannot be delivered to the dead-letter mailbox:
+// messages older than undeliverableAfter whose recipient is not in windows, and
+// messages notified about more than maxNotified times.
+// Pass nil windows to skip the undeliverable check (e.g. outside tmux) and
+// maxNotified <= 0 to disable the notification limit.
+// Returns the number of messages moved.
+func DeadLetterSweep(repoRoot string, windows []string, undeliverableAfter time.Duration, maxNotified int) (int, error) {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return 0, err
+	}
+
+	recipients, err := store.ListMailboxes()
+	if err != nil {
+		return 0, err
+	}
+
+	var windowSet map[string]bool
+	if windows != nil {
+		windowSet = make(map[string]bool, len(windows))
+		for _, w := range windows {
+			windowSet[w] = true
+		}
+	}
+
+	now := time.Now()
+	reason := func(msg Message) string {
+		return deadReason(msg, windowSet, undeliverableAfter, maxNotified, now)
+	}
+
+	moved := 0
+	for _, recipient := range recipients {
+		dead, err := store.DeadLetter(recipient, reason)
+		moved += len(dead)
+		if err != nil {
+			return moved, err
+		}
+	}
+
+	return moved, nil
+}
+
+// CountDeadLetterCandidates counts messages DeadLetterSweep would move without moving them.
+// This is used for dry-run mode.
+func CountDeadLetterCandidates(repoRoot string, windows []string, undeliverableAfter time.Duration, maxNotified int) (int, error) {
+	recipients, err := ListMailboxRecipients(repoRoot)
+	if err != nil {
+		return 0, err
+	}
+
+	var windowSet map[string]bool
+	if windows != nil {
+		windowSet = make(map[string]bool, len(windows))
+		for _, w := range windows {
+			windowSet[w] = true
+		}
+	}
+
+	now := time.Now()
+	count := 0
+	for _, recipient := range recipients {
+		messages, err := ReadAll(repoRoot, recipient)
+		if err != nil {
+			return count, err
+		}
+		for _, msg := range messages {
+			if deadReason(msg, windowSet, undeliverableAfter, maxNotified, now) != "" {
+				count++
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// ListDeadLetters returns all dead-lettered messages, oldest first.
+// Returns an empty list if the dead-letter mailbox doesn't exist.
+func ListDeadLetters(repoRoot string) ([]Message, error) {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := store.ReadDeadLetters()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+	return messages, nil
+}
+
+// Requeue moves a dead-lettered message into the mailbox of the given window.
+// Delivery state (read flag, lease, attempts, notifications, expiry, reason) is reset,
+// and the message keeps its ID. Returns ErrMessageNotFound if the ID is not dead-lettered.
+func Requeue(repoRoot string, messageID string, to string) (Message, error) {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return Message{}, err
+	}
+
+	dead, err := store.ReadDeadLetters()
+	if err != nil {
+		return Message{}, err
+	}
+	var msg Message
+	found := false
+	for _, m := range dead {
+		if m.ID == messageID {
+			msg, found = m, true
+			break
+		}
+	}
+	if !found {
+		return Message{}, ErrMessageNotFound
+	}
+
+	msg.To = to
+	msg.ReadFlag = false
+	msg.LeaseUntil = time.Time{}
+	msg.Attempts = 0
+	msg.Notified = 0
+	msg.ExpiresAt = time.Time{}
+	msg.DeadReason = ""
+
+	// Deliver before removing the dead letter, so a crash in between
+	// duplicates the message rather than losing it
+	if err := Append(repoRoot, msg); err != nil {
+		return Message{}, err
+	}
+	err = store.ModifyDeadLetters(func(messages []Message) ([]Message, bool, error) {
+		for i := range messages {
+			if messages[i].ID == messageID {
+				return append(messages[:i:i], messages[i+1:]...), true, nil
+			}
+		}
+		return nil, false, nil // Already requeued by another process
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+// PurgeDeadLetters permanently deletes every dead-lettered message.
+// Returns the number of messages deleted.
+func PurgeDeadLetters(repoRoot string) (int, error) {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	err = store.ModifyDeadLetters(func(messages []Message) ([]Message, bool, error) {
+		purged = len(messages)
+		return nil, purged > 0, nil
+	})
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	return purged, err
+}
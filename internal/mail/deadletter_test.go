@@ -0,0 +1,171 @@
+package mail
+
+import (
+	"testing"
+	"time"
+)
+
+// appendAged appends a message and backdates its CreatedAt.
+func appendAged(t *testing.T, repoRoot string, msg Message, age time.Duration) {
+	t.Helper()
+	if err := Append(repoRoot, msg); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	messages, err := ReadAll(repoRoot, msg.To)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	for i := range messages {
+		if messages[i].ID == msg.ID {
+			messages[i].CreatedAt = time.Now().Add(-age)
+		}
+	}
+	if err := WriteAll(repoRoot, msg.To, messages); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+}
+
+func TestRecordNotification_CountsUnreadOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := WriteAll(tmpDir, "agent-2", []Message{
+		{ID: "unread01", From: "agent-1", To: "agent-2", Message: "a"},
+		{ID: "read0001", From: "agent-1", To: "agent-2", Message: "b", ReadFlag: true},
+		{ID: "leased01", From: "agent-1", To: "agent-2", Message: "c", LeaseUntil: time.Now().Add(time.Hour)},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	if err := RecordNotification(tmpDir, "agent-2"); err != nil {
+		t.Fatalf("RecordNotification failed: %v", err)
+	}
+
+	messages, err := ReadAll(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	want := map[string]int{"unread01": 1, "read0001": 0, "leased01": 0}
+	for _, msg := range messages {
+		if msg.Notified != want[msg.ID] {
+			t.Errorf("Message %s: expected %d notifications, got %d", msg.ID, want[msg.ID], msg.Notified)
+		}
+	}
+
+	// Missing mailbox is not an error
+	if err := RecordNotification(tmpDir, "nobody"); err != nil {
+		t.Errorf("RecordNotification on missing mailbox failed: %v", err)
+	}
+}
+
+func TestDeadLetterSweep_MovesUndeliverableAndOverNotified(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	appendAged(t, tmpDir, Message{ID: "gone0001", From: "agent-1", To: "agent-gone", Message: "orphan"}, 2*time.Hour)
+	appendAged(t, tmpDir, Message{ID: "gone0002", From: "agent-1", To: "agent-gone", Message: "too recent"}, time.Minute)
+	appendAged(t, tmpDir, Message{ID: "live0001", From: "agent-1", To: "agent-2", Message: "old but live"}, 2*time.Hour)
+	if err := Append(tmpDir, Message{ID: "noisy001", From: "agent-1", To: "agent-2", Message: "ignored", Notified: 4}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	moved, err := DeadLetterSweep(tmpDir, []string{"agent-1", "agent-2"}, time.Hour, 3)
+	if err != nil {
+		t.Fatalf("DeadLetterSweep failed: %v", err)
+	}
+	if moved != 2 {
+		t.Errorf("Expected 2 messages moved, got %d", moved)
+	}
+
+	dead, err := ListDeadLetters(tmpDir)
+	if err != nil {
+		t.Fatalf("ListDeadLetters failed: %v", err)
+	}
+	reasons := make(map[string]string)
+	for _, msg := range dead {
+		reasons[msg.ID] = msg.DeadReason
+	}
+	if reasons["gone0001"] != ReasonUndeliverable || reasons["noisy001"] != ReasonMaxNotifications || len(reasons) != 2 {
+		t.Errorf("Unexpected dead letters: %v", reasons)
+	}
+
+	gone, _ := ReadAll(tmpDir, "agent-gone")
+	if len(gone) != 1 || gone[0].ID != "gone0002" {
+		t.Errorf("Expected only the recent message to stay in agent-gone, got %+v", gone)
+	}
+	live, _ := ReadAll(tmpDir, "agent-2")
+	if len(live) != 1 || live[0].ID != "live0001" {
+		t.Errorf("Expected only live0001 to stay in agent-2, got %+v", live)
+	}
+}
+
+func TestDeadLetterSweep_NilWindowsSkipsUndeliverableCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	appendAged(t, tmpDir, Message{ID: "gone0001", From: "agent-1", To: "agent-gone", Message: "orphan"}, 2*time.Hour)
+
+	count, err := CountDeadLetterCandidates(tmpDir, nil, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("CountDeadLetterCandidates failed: %v", err)
+	}
+	moved, err := DeadLetterSweep(tmpDir, nil, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("DeadLetterSweep failed: %v", err)
+	}
+	if count != 0 || moved != 0 {
+		t.Errorf("Expected nothing dead-lettered without a window list, got count=%d moved=%d", count, moved)
+	}
+}
+
+func TestRequeue_DeliversToNewWindowAndResetsState(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Append(tmpDir, Message{ID: "noisy001", From: "agent-1", To: "agent-gone", Message: "task", Notified: 5, Attempts: 2}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if _, err := DeadLetterSweep(tmpDir, nil, time.Hour, 3); err != nil {
+		t.Fatalf("DeadLetterSweep failed: %v", err)
+	}
+
+	requeued, err := Requeue(tmpDir, "noisy001", "agent-3")
+	if err != nil {
+		t.Fatalf("Requeue failed: %v", err)
+	}
+	if requeued.To != "agent-3" || requeued.Notified != 0 || requeued.Attempts != 0 || requeued.DeadReason != "" {
+		t.Errorf("Requeued message not reset: %+v", requeued)
+	}
+
+	unread, err := FindUnread(tmpDir, "agent-3")
+	if err != nil {
+		t.Fatalf("FindUnread failed: %v", err)
+	}
+	if len(unread) != 1 || unread[0].ID != "noisy001" || unread[0].Message != "task" {
+		t.Errorf("Expected requeued message in agent-3 mailbox, got %+v", unread)
+	}
+
+	dead, _ := ListDeadLetters(tmpDir)
+	if len(dead) != 0 {
+		t.Errorf("Expected dead-letter mailbox to be empty, got %d", len(dead))
+	}
+
+	if _, err := Requeue(tmpDir, "noisy001", "agent-3"); err != ErrMessageNotFound {
+		t.Errorf("Expected ErrMessageNotFound on second requeue, got %v", err)
+	}
+}
+
+func TestPurgeDeadLetters(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if purged, err := PurgeDeadLetters(tmpDir); err != nil || purged != 0 {
+		t.Errorf("Expected 0 purged from missing file, got %d (err: %v)", purged, err)
+	}
+
+	if err := appendDeadLetters(tmpDir, []Message{{ID: "a"}, {ID: "b"}}); err != nil {
+		t.Fatalf("appendDeadLetters failed: %v", err)
+	}
+	purged, err := PurgeDeadLetters(tmpDir)
+	if err != nil {
+		t.Fatalf("PurgeDeadLetters failed: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("Expected 2 purged, got %d", purged)
+	}
+	if dead, _ := ListDeadLetters(tmpDir); len(dead) != 0 {
+		t.Errorf("Expected no dead letters after purge, got %d", len(dead))
+	}
+}
@@ -0,0 +1,213 @@
+package mail
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventsFile is the delivery event log of the JSONL store
+const EventsFile = ".agentmail/events.jsonl"
+
+// Delivery events recorded per message
+const (
+	EventQueued   = "queued"   // Stored in the recipient's mailbox
+	EventNotified = "notified" // The mailman notified the recipient while it was unread
+	EventRead     = "read"     // Received, read or leased by the recipient (CLI or MCP)
+	EventAcked    = "acked"    // Acknowledged by the recipient
+)
+
+// States of a message reported by MessageStatus in addition to the inbox states
+const (
+	StateScheduled    = "scheduled"     // In the mailbox, but not due yet
+	StateDeadLettered = "dead-lettered" // Moved to the dead-letter mailbox
+	StateRemoved      = "removed"       // Deleted by cleanup; only its events remain
+)
+
+// Event is one step in the delivery of a message.
+type Event struct {
+	MessageID string    `json:"id"`        // ID of the message
+	Event     string    `json:"event"`     // queued, notified, read or acked
+	At        time.Time `json:"at"`        // When it happened
+	Recipient string    `json:"recipient"` // Mailbox the message was delivered to
+}
+
+// DeliveryStatus is where a message is and what happened to it so far.
+type DeliveryStatus struct {
+	Message Message // The stored message; only ID is set if it was removed
+	State   string  // Inbox state (see Message.State), StateScheduled, StateDeadLettered or StateRemoved
+	Events  []Event // Delivery events of the message, oldest first
+}
+
+// MessageStatus looks a message up in every mailbox and the dead-letter mailbox
+// and collects its delivery events. A message removed by cleanup is reported
+// with StateRemoved as long as its events are kept.
+// Returns ErrMessageNotFound if neither the message nor any event is found.
+func MessageStatus(repoRoot string, id string) (DeliveryStatus, error) {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return DeliveryStatus{}, err
+	}
+
+	events, err := store.ReadEvents()
+	if err != nil {
+		return DeliveryStatus{}, err
+	}
+	status := DeliveryStatus{Message: Message{ID: id}, State: StateRemoved}
+	for _, event := range events {
+		if event.MessageID == id {
+			status.Events = append(status.Events, event)
+		}
+	}
+
+	now := time.Now()
+	msg, err := FindMessage(repoRoot, id)
+	switch {
+	case err == nil:
+		status.Message = msg
+		status.State = msg.State(now)
+		if msg.Pending(now) {
+			status.State = StateScheduled
+		}
+		return status, nil
+	case err != ErrMessageNotFound:
+		return DeliveryStatus{}, err
+	}
+
+	dead, err := store.ReadDeadLetters()
+	if err != nil {
+		return DeliveryStatus{}, err
+	}
+	for _, msg := range dead {
+		if msg.ID == id {
+			status.Message = msg
+			status.State = StateDeadLettered
+			return status, nil
+		}
+	}
+
+	if len(status.Events) == 0 {
+		return DeliveryStatus{}, ErrMessageNotFound
+	}
+	return status, nil
+}
+
+// recordEvent appends a delivery event for msg. Events are status history only:
+// a failed write must not fail the delivery step it describes.
+func recordEvent(store Store, event string, msg Message) {
+	_ = store.AppendEvent(Event{MessageID: msg.ID, Event: event, At: time.Now(), Recipient: msg.To}) // G104: best-effort, see above
+}
+
+// readReceipt builds the system message that tells a sender their message was read.
+func readReceipt(msg Message) (Message, error) {
+	id, err := GenerateID()
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{
+		ID:        id,
+		From:      SystemSender,
+		To:        msg.From,
+		Message:   fmt.Sprintf("Message #%s to %s was read", msg.ID, msg.To),
+		InReplyTo: msg.ID,
+		ThreadID:  msg.ThreadRoot(),
+	}, nil
+}
+
+// sendReceipt mails the read receipt msg's sender asked for with send --receipt,
+// into the sender's own repository if msg came from another one. Like the event
+// it accompanies, the receipt is best-effort: the message was read either way.
+func sendReceipt(repoRoot string, msg Message) {
+	if !msg.Receipt || msg.From == SystemSender {
+		return
+	}
+	receipt, err := readReceipt(msg)
+	if err != nil {
+		return
+	}
+	root, mailbox := senderMailbox(repoRoot, msg)
+	receipt.To = mailbox
+	_ = Append(root, receipt) // G104: best-effort, see above
+}
+
+// CleanOldEvents removes the events of messages that are no longer stored in any
+// mailbox or the dead-letter mailbox once they are older than the threshold.
+// Returns the number of events removed.
+func CleanOldEvents(repoRoot string, threshold time.Duration) (int, error) {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return 0, err
+	}
+	stored, err := storedMessageIDs(store)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-threshold)
+	removed := 0
+	err = store.ModifyEvents(func(events []Event) ([]Event, bool, error) {
+		var remaining []Event
+		for _, event := range events {
+			if stored[event.MessageID] || event.At.After(cutoff) {
+				remaining = append(remaining, event)
+			}
+		}
+		removed = len(events) - len(remaining)
+		return remaining, removed > 0, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// CountOldEvents counts the events CleanOldEvents would remove without removing them.
+// This is used for dry-run mode.
+func CountOldEvents(repoRoot string, threshold time.Duration) (int, error) {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return 0, err
+	}
+	stored, err := storedMessageIDs(store)
+	if err != nil {
+		return 0, err
+	}
+	events, err := store.ReadEvents()
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-threshold)
+	count := 0
+	for _, event := range events {
+		if !stored[event.MessageID] && !event.At.After(cutoff) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// storedMessageIDs returns the IDs of every message in a mailbox or the dead-letter mailbox.
+func storedMessageIDs(store Store) (map[string]bool, error) {
+	recipients, err := store.ListMailboxes()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool)
+	for _, recipient := range recipients {
+		messages, err := store.ReadAll(recipient)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range messages {
+			ids[msg.ID] = true
+		}
+	}
+	dead, err := store.ReadDeadLetters()
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range dead {
+		ids[msg.ID] = true
+	}
+	return ids, nil
+}
@@ -0,0 +1,234 @@
+package mail
+
+import (
+	"testing"
+	"time"
+)
+
+// eventNames returns the event names of a status in order.
+func eventNames(status DeliveryStatus) []string {
+	var names []string
+	for _, event := range status.Events {
+		names = append(names, event.Event)
+	}
+	return names
+}
+
+func equalNames(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMessageStatus_RecordsLifecycle(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Append(tmpDir, Message{ID: "life0001", From: "agent-1", To: "agent-2", Message: "hi"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	status, err := MessageStatus(tmpDir, "life0001")
+	if err != nil {
+		t.Fatalf("MessageStatus failed: %v", err)
+	}
+	if status.State != StateUnread || !equalNames(eventNames(status), []string{EventQueued}) {
+		t.Fatalf("Expected unread with queued event, got %s %v", status.State, eventNames(status))
+	}
+
+	if err := RecordNotification(tmpDir, "agent-2"); err != nil {
+		t.Fatalf("RecordNotification failed: %v", err)
+	}
+	if err := MarkAsRead(tmpDir, "agent-2", "life0001"); err != nil {
+		t.Fatalf("MarkAsRead failed: %v", err)
+	}
+	// Reading again is not a new delivery step
+	if err := MarkAsRead(tmpDir, "agent-2", "life0001"); err != nil {
+		t.Fatalf("MarkAsRead failed: %v", err)
+	}
+
+	status, err = MessageStatus(tmpDir, "life0001")
+	if err != nil {
+		t.Fatalf("MessageStatus failed: %v", err)
+	}
+	want := []string{EventQueued, EventNotified, EventRead}
+	if status.State != StateRead || !equalNames(eventNames(status), want) {
+		t.Errorf("Expected read with %v, got %s %v", want, status.State, eventNames(status))
+	}
+	if status.Message.From != "agent-1" || status.Events[0].Recipient != "agent-2" {
+		t.Errorf("Expected message and recipient in status, got %+v", status)
+	}
+}
+
+func TestMessageStatus_LeaseAndAck(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "lease001", From: "agent-1", To: "agent-2", Message: "build it"})
+
+	if _, err := Lease(tmpDir, "agent-2", "lease001", time.Hour); err != nil {
+		t.Fatalf("Lease failed: %v", err)
+	}
+	if err := Ack(tmpDir, "agent-2", "lease001"); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	// Acking twice is a no-op
+	if err := Ack(tmpDir, "agent-2", "lease001"); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+
+	status, err := MessageStatus(tmpDir, "lease001")
+	if err != nil {
+		t.Fatalf("MessageStatus failed: %v", err)
+	}
+	want := []string{EventQueued, EventRead, EventAcked}
+	if !equalNames(eventNames(status), want) {
+		t.Errorf("Expected %v, got %v", want, eventNames(status))
+	}
+}
+
+func TestMessageStatus_ScheduledDeadLetteredAndRemoved(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "later001", From: "agent-1", To: "agent-2", DeliverAfter: time.Now().Add(time.Hour)})
+	_ = Append(tmpDir, Message{ID: "dead0001", From: "agent-1", To: "agent-2"})
+
+	status, err := MessageStatus(tmpDir, "later001")
+	if err != nil || status.State != StateScheduled {
+		t.Errorf("Expected scheduled, got %+v, %v", status, err)
+	}
+
+	store, _ := OpenStore(tmpDir)
+	if _, err := store.DeadLetter("agent-2", func(msg Message) string {
+		if msg.ID == "dead0001" {
+			return ReasonUndeliverable
+		}
+		return ""
+	}); err != nil {
+		t.Fatalf("DeadLetter failed: %v", err)
+	}
+	status, err = MessageStatus(tmpDir, "dead0001")
+	if err != nil || status.State != StateDeadLettered || status.Message.DeadReason != ReasonUndeliverable {
+		t.Errorf("Expected dead-lettered, got %+v, %v", status, err)
+	}
+
+	// A message deleted from the store is still reported while its events are kept
+	_ = store.ModifyDeadLetters(func([]Message) ([]Message, bool, error) { return nil, true, nil })
+	status, err = MessageStatus(tmpDir, "dead0001")
+	if err != nil || status.State != StateRemoved || status.Message.ID != "dead0001" || len(status.Events) != 1 {
+		t.Errorf("Expected removed with its queued event, got %+v, %v", status, err)
+	}
+
+	if _, err := MessageStatus(tmpDir, "missing1"); err != ErrMessageNotFound {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+}
+
+func TestReceipt_SentOnFirstRead(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "rcpt0001", From: "agent-1", To: "agent-2", Message: "review", Receipt: true})
+	_ = Append(tmpDir, Message{ID: "norc0001", From: "agent-1", To: "agent-2", Message: "fyi"})
+
+	for i := 0; i < 2; i++ {
+		if err := MarkAsRead(tmpDir, "agent-2", "rcpt0001"); err != nil {
+			t.Fatalf("MarkAsRead failed: %v", err)
+		}
+	}
+	if err := MarkAsRead(tmpDir, "agent-2", "norc0001"); err != nil {
+		t.Fatalf("MarkAsRead failed: %v", err)
+	}
+
+	receipts, err := ReadAll(tmpDir, "agent-1")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(receipts) != 1 {
+		t.Fatalf("Expected exactly one receipt, got %+v", receipts)
+	}
+	receipt := receipts[0]
+	if receipt.From != SystemSender || receipt.InReplyTo != "rcpt0001" || receipt.Message != "Message #rcpt0001 to agent-2 was read" {
+		t.Errorf("Unexpected receipt: %+v", receipt)
+	}
+	if receipt.Receipt {
+		t.Error("Receipts must not ask for receipts")
+	}
+}
+
+func TestReceipt_GoesToSenderRepository(t *testing.T) {
+	webRepo, apiRepo := t.TempDir(), t.TempDir()
+	_ = Append(apiRepo, Message{ID: "rcpt0004", From: "web:lead", To: "agent-1", Message: "schema", Receipt: true, OriginRepo: webRepo, OriginMailbox: "lead"})
+
+	if err := MarkAsRead(apiRepo, "agent-1", "rcpt0004"); err != nil {
+		t.Fatalf("MarkAsRead failed: %v", err)
+	}
+
+	receipts, err := ReadAll(webRepo, "lead")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(receipts) != 1 || receipts[0].InReplyTo != "rcpt0004" || receipts[0].To != "lead" {
+		t.Errorf("Expected the receipt in lead's mailbox of the sender's repository, got %+v", receipts)
+	}
+	if stray, _ := ReadAll(apiRepo, "web:lead"); len(stray) != 0 {
+		t.Errorf("Expected no receipt in the recipient's repository, got %+v", stray)
+	}
+}
+
+func TestReceipt_LeasedMessageSendsOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "rcpt0002", From: "agent-1", To: "agent-2", Message: "build", Receipt: true})
+
+	// The lease expires and the message is leased again before it is acked
+	if _, err := Lease(tmpDir, "agent-2", "rcpt0002", time.Millisecond); err != nil {
+		t.Fatalf("Lease failed: %v", err)
+	}
+	if _, err := Lease(tmpDir, "agent-2", "rcpt0002", time.Hour); err != nil {
+		t.Fatalf("Lease failed: %v", err)
+	}
+	if err := Ack(tmpDir, "agent-2", "rcpt0002"); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+
+	receipts, _ := ReadAll(tmpDir, "agent-1")
+	if len(receipts) != 1 {
+		t.Errorf("Expected one receipt for the first lease, got %+v", receipts)
+	}
+}
+
+func TestReceipt_AckWithoutLease(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "rcpt0003", From: "agent-1", To: "agent-2", Message: "note", Receipt: true})
+
+	if err := Ack(tmpDir, "agent-2", "rcpt0003"); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	receipts, _ := ReadAll(tmpDir, "agent-1")
+	if len(receipts) != 1 || receipts[0].InReplyTo != "rcpt0003" {
+		t.Errorf("Expected a receipt for the acked message, got %+v", receipts)
+	}
+}
+
+func TestCleanOldEvents_KeepsStoredAndRecentMessages(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, _ := OpenStore(tmpDir)
+	old := time.Now().Add(-72 * time.Hour)
+	_ = store.Append(Message{ID: "kept0001", From: "agent-1", To: "agent-2"})
+	_ = store.AppendEvent(Event{MessageID: "kept0001", Event: EventQueued, At: old, Recipient: "agent-2"})
+	_ = store.AppendEvent(Event{MessageID: "gone0001", Event: EventQueued, At: old, Recipient: "agent-2"})
+	_ = store.AppendEvent(Event{MessageID: "gone0002", Event: EventQueued, At: time.Now(), Recipient: "agent-2"})
+
+	count, err := CountOldEvents(tmpDir, 48*time.Hour)
+	if err != nil || count != 1 {
+		t.Fatalf("Expected 1 event to remove, got %d, %v", count, err)
+	}
+	removed, err := CleanOldEvents(tmpDir, 48*time.Hour)
+	if err != nil || removed != 1 {
+		t.Fatalf("Expected 1 event removed, got %d, %v", removed, err)
+	}
+
+	events, _ := store.ReadEvents()
+	if len(events) != 2 || events[0].MessageID != "kept0001" || events[1].MessageID != "gone0002" {
+		t.Errorf("Expected kept0001 and gone0002 events, got %+v", events)
+	}
+}
@@ -0,0 +1,133 @@
+package mail
+
+import (
+	"fmt"
+	"time"
+)
+
+// SystemSender is the From address of messages generated by agentmail itself.
+const SystemSender = "agentmail"
+
+// senderMailbox returns where the sender of msg reads system messages about it:
+// the repository whose store holds its mailbox, and the mailbox. That is the
+// sender's own repository for a message delivered from another one.
+func senderMailbox(repoRoot string, msg Message) (root, mailbox string) {
+	if msg.OriginRepo != "" && msg.OriginMailbox != "" {
+		return msg.OriginRepo, msg.OriginMailbox
+	}
+	return repoRoot, msg.From
+}
+
+// Expired reports whether the message has a TTL that has run out.
+func (m Message) Expired(now time.Time) bool {
+	return !m.ExpiresAt.IsZero() && !now.Before(m.ExpiresAt)
+}
+
+// ExpiryTime computes the expires_at timestamp for a send with the given TTL.
+// The TTL counts from delivery, so scheduled messages get their full TTL once due.
+// Returns the zero time when ttl is zero (never expires).
+func ExpiryTime(ttl time.Duration, deliverAfter time.Time, now time.Time) (time.Time, error) {
+	if ttl < 0 {
+		return time.Time{}, fmt.Errorf("ttl must not be negative")
+	}
+	if ttl == 0 {
+		return time.Time{}, nil
+	}
+	if deliverAfter.After(now) {
+		return deliverAfter.Add(ttl), nil
+	}
+	return now.Add(ttl), nil
+}
+
+// expiryNotice builds the system message that tells a sender their message expired unread.
+func expiryNotice(msg Message) (Message, error) {
+	id, err := GenerateID()
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{
+		ID:        id,
+		From:      SystemSender,
+		To:        msg.From,
+		Message:   fmt.Sprintf("Message #%s to %s expired unread", msg.ID, msg.To),
+		InReplyTo: msg.ID,
+		ThreadID:  msg.ThreadRoot(),
+	}, nil
+}
+
+// ExpireMessages moves expired unread messages from every mailbox to the
+// dead-letter mailbox with reason "expired". Senders that asked for it
+// (NotifyExpired) receive a system message naming the expired message, in
+// their own repository's store if the message came from another repository.
+// Returns the number of messages expired.
+func ExpireMessages(repoRoot string) (int, error) {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return 0, err
+	}
+
+	recipients, err := store.ListMailboxes()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	reason := func(msg Message) string {
+		if !msg.ReadFlag && !msg.InFlight(now) && msg.Expired(now) {
+			return ReasonExpired
+		}
+		return ""
+	}
+
+	var expired []Message
+	for _, recipient := range recipients {
+		dead, err := store.DeadLetter(recipient, reason)
+		expired = append(expired, dead...)
+		if err != nil {
+			return len(expired), err
+		}
+	}
+
+	// Notices are sent after all mailbox locks are released
+	for _, msg := range expired {
+		if !msg.NotifyExpired || msg.From == SystemSender {
+			continue
+		}
+		notice, err := expiryNotice(msg)
+		if err != nil {
+			return len(expired), err
+		}
+		root, mailbox := senderMailbox(repoRoot, msg)
+		notice.To = mailbox
+		if err := Append(root, notice); err != nil {
+			return len(expired), err
+		}
+	}
+
+	return len(expired), nil
+}
+
+// CountExpired counts messages ExpireMessages would move without moving them.
+// This is used for dry-run mode.
+func CountExpired(repoRoot string) (int, error) {
+	recipients, err := ListMailboxRecipients(repoRoot)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	count := 0
+	for _, recipient := range recipients {
+		messages, err := ReadAll(repoRoot, recipient)
+		if err != nil {
+			return count, err
+		}
+		for _, msg := range messages {
+			if !msg.ReadFlag && !msg.InFlight(now) && msg.Expired(now) {
+				count++
+			}
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,133 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpiryTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 16, 30, 0, 0, time.UTC)
+
+	got, err := ExpiryTime(0, time.Time{}, now)
+	if err != nil || !got.IsZero() {
+		t.Errorf("Expected no expiry without TTL, got %v (err=%v)", got, err)
+	}
+
+	got, err = ExpiryTime(10*time.Minute, time.Time{}, now)
+	if err != nil || !got.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("Expected expiry 10m from now, got %v (err=%v)", got, err)
+	}
+
+	// The TTL of a scheduled message starts when it becomes due
+	due := now.Add(time.Hour)
+	got, err = ExpiryTime(10*time.Minute, due, now)
+	if err != nil || !got.Equal(due.Add(10*time.Minute)) {
+		t.Errorf("Expected expiry 10m after delivery, got %v (err=%v)", got, err)
+	}
+
+	if _, err := ExpiryTime(-time.Minute, time.Time{}, now); err == nil {
+		t.Error("Expected error for negative TTL")
+	}
+}
+
+func TestFindUnread_SkipsExpired(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now()
+
+	if err := WriteAll(tmpDir, "agent-2", []Message{
+		{ID: "stale001", From: "agent-1", To: "agent-2", Message: "hold off", ExpiresAt: now.Add(-time.Minute)},
+		{ID: "fresh001", From: "agent-1", To: "agent-2", Message: "go ahead", ExpiresAt: now.Add(time.Hour)},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	unread, err := FindUnread(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("FindUnread failed: %v", err)
+	}
+	if len(unread) != 1 || unread[0].ID != "fresh001" {
+		t.Errorf("Expected only the unexpired message, got %+v", unread)
+	}
+}
+
+func TestExpireMessages_NotifiesSenderRepository(t *testing.T) {
+	webRepo, apiRepo := t.TempDir(), t.TempDir()
+	if err := WriteAll(apiRepo, "agent-1", []Message{
+		{ID: "stale003", From: "web:lead", To: "agent-1", Message: "hold off", ExpiresAt: time.Now().Add(-time.Minute), NotifyExpired: true, OriginRepo: webRepo, OriginMailbox: "lead"},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	if expired, err := ExpireMessages(apiRepo); err != nil || expired != 1 {
+		t.Fatalf("Expected 1 expired message, got %d (err=%v)", expired, err)
+	}
+	notices, err := ReadAll(webRepo, "lead")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(notices) != 1 || notices[0].InReplyTo != "stale003" {
+		t.Errorf("Expected the notice in lead's mailbox of the sender's repository, got %+v", notices)
+	}
+	if stray, _ := ReadAll(apiRepo, "web:lead"); len(stray) != 0 {
+		t.Errorf("Expected no notice in the recipient's repository, got %+v", stray)
+	}
+}
+
+func TestExpireMessages_DeadLettersAndNotifiesSender(t *testing.T) {
+	tmpDir := t.TempDir()
+	past := time.Now().Add(-time.Minute)
+
+	if err := WriteAll(tmpDir, "agent-2", []Message{
+		{ID: "stale001", From: "agent-1", To: "agent-2", Message: "hold off", ExpiresAt: past, NotifyExpired: true},
+		{ID: "stale002", From: "agent-3", To: "agent-2", Message: "quiet", ExpiresAt: past},
+		{ID: "read0001", From: "agent-1", To: "agent-2", Message: "seen", ExpiresAt: past, ReadFlag: true},
+		{ID: "keep0001", From: "agent-1", To: "agent-2", Message: "no ttl"},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	if count, err := CountExpired(tmpDir); err != nil || count != 2 {
+		t.Fatalf("Expected 2 expired candidates, got %d (err=%v)", count, err)
+	}
+
+	expired, err := ExpireMessages(tmpDir)
+	if err != nil {
+		t.Fatalf("ExpireMessages failed: %v", err)
+	}
+	if expired != 2 {
+		t.Errorf("Expected 2 expired messages, got %d", expired)
+	}
+
+	remaining, err := ReadAll(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(remaining) != 2 {
+		t.Errorf("Expected read and TTL-less messages to remain, got %+v", remaining)
+	}
+
+	dead, err := ListDeadLetters(tmpDir)
+	if err != nil {
+		t.Fatalf("ListDeadLetters failed: %v", err)
+	}
+	if len(dead) != 2 || dead[0].DeadReason != ReasonExpired {
+		t.Errorf("Expected 2 expired dead letters, got %+v", dead)
+	}
+
+	// Only the sender that asked for it gets a notice
+	notices, err := ReadAll(tmpDir, "agent-1")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(notices) != 1 {
+		t.Fatalf("Expected 1 notice for agent-1, got %+v", notices)
+	}
+	notice := notices[0]
+	if notice.From != SystemSender || notice.InReplyTo != "stale001" || !strings.Contains(notice.Message, "expired unread") {
+		t.Errorf("Unexpected notice: %+v", notice)
+	}
+	if others, _ := ReadAll(tmpDir, "agent-3"); len(others) != 0 {
+		t.Errorf("Expected no notice for agent-3, got %+v", others)
+	}
+}
@@ -0,0 +1,245 @@
+package mail
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExportVersion is the format version written to JSON exports.
+const ExportVersion = 1
+
+// Export formats
+const (
+	FormatMbox     = "mbox"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// ErrUnknownFormat is returned for an export format other than mbox, json or markdown.
+var ErrUnknownFormat = errors.New("unknown export format (use mbox, json or markdown)")
+
+// Export is the document written by a JSON export and read back by import.
+type Export struct {
+	Version    int       `json:"agentmail_export"` // ExportVersion
+	ExportedAt time.Time `json:"exported_at"`      // When the export was taken
+	Messages   []Message `json:"messages"`         // Every exported message, oldest first
+}
+
+// TranscriptFilter selects the messages of a transcript. The zero value selects everything.
+type TranscriptFilter struct {
+	Thread string    // Only the conversation containing this message ID
+	Since  time.Time // Only messages created at or after this time
+}
+
+// Transcript merges every mailbox into one list of messages, oldest first.
+// Returns ErrMessageNotFound if filter.Thread is set and does not exist.
+func Transcript(repoRoot string, filter TranscriptFilter) ([]Message, error) {
+	var messages []Message
+	if filter.Thread != "" {
+		thread, err := FindThread(repoRoot, filter.Thread)
+		if err != nil {
+			return nil, err
+		}
+		messages = thread
+	} else {
+		recipients, err := ListMailboxRecipients(repoRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, recipient := range recipients {
+			mailbox, err := ReadAll(repoRoot, recipient)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, mailbox...)
+		}
+		// Stable sort keeps mailbox order for messages with equal timestamps
+		sort.SliceStable(messages, func(i, j int) bool {
+			return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+		})
+	}
+
+	if filter.Since.IsZero() {
+		return messages, nil
+	}
+	var since []Message
+	for _, msg := range messages {
+		if !msg.CreatedAt.Before(filter.Since) {
+			since = append(since, msg)
+		}
+	}
+	return since, nil
+}
+
+// WriteExport writes messages to w in the given format.
+func WriteExport(w io.Writer, format string, messages []Message) error {
+	switch format {
+	case FormatJSON:
+		return writeJSONExport(w, messages)
+	case FormatMbox:
+		return writeMbox(w, messages)
+	case FormatMarkdown:
+		return writeMarkdown(w, messages)
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+// writeJSONExport writes an Export document that Import can read back.
+func writeJSONExport(w io.Writer, messages []Message) error {
+	if messages == nil {
+		messages = []Message{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(Export{
+		Version:    ExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Messages:   messages,
+	})
+}
+
+// writeMbox writes messages in mboxrd format: body lines starting with
+// "From " (after any number of '>') are quoted with one more '>'.
+func writeMbox(w io.Writer, messages []Message) error {
+	bw := bufio.NewWriter(w)
+	for _, msg := range messages {
+		fmt.Fprintf(bw, "From %s %s\n", mboxAddress(msg.From), msg.CreatedAt.UTC().Format(time.ANSIC))
+		fmt.Fprintf(bw, "From: %s\n", mboxAddress(msg.From))
+		fmt.Fprintf(bw, "To: %s\n", mboxAddress(msg.To))
+		fmt.Fprintf(bw, "Date: %s\n", msg.CreatedAt.Format(time.RFC1123Z))
+		fmt.Fprintf(bw, "Message-ID: %s\n", mboxMessageID(msg.ID))
+		if msg.InReplyTo != "" {
+			fmt.Fprintf(bw, "In-Reply-To: %s\n", mboxMessageID(msg.InReplyTo))
+			fmt.Fprintf(bw, "References: %s\n", mboxMessageID(msg.ThreadRoot()))
+		}
+		if msg.Subject != "" {
+			fmt.Fprintf(bw, "Subject: %s\n", msg.Subject)
+		}
+		if msg.Priority != "" && msg.Priority != PriorityNormal {
+			fmt.Fprintf(bw, "X-Agentmail-Priority: %s\n", msg.Priority)
+		}
+		if msg.ReadFlag {
+			fmt.Fprintln(bw, "Status: RO")
+		}
+		for _, key := range msg.HeaderKeys() {
+			fmt.Fprintf(bw, "X-Agentmail-Header: %s=%s\n", key, msg.Headers[key])
+		}
+		fmt.Fprintln(bw)
+		for _, line := range strings.Split(strings.TrimSuffix(msg.Message, "\n"), "\n") {
+			if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+				line = ">" + line
+			}
+			fmt.Fprintln(bw, line)
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+// mboxAddress turns a window name into a mail address.
+func mboxAddress(window string) string {
+	return window + "@agentmail"
+}
+
+// mboxMessageID turns a message ID into a Message-ID header value.
+func mboxMessageID(id string) string {
+	return "<" + id + "@agentmail>"
+}
+
+// writeMarkdown writes messages as a readable Markdown transcript.
+func writeMarkdown(w io.Writer, messages []Message) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# AgentMail transcript")
+	fmt.Fprintln(bw)
+	fmt.Fprintf(bw, "%d message(s)", len(messages))
+	if len(messages) > 0 {
+		fmt.Fprintf(bw, ", %s to %s", messages[0].CreatedAt.Format(time.RFC3339), messages[len(messages)-1].CreatedAt.Format(time.RFC3339))
+	}
+	fmt.Fprintln(bw)
+
+	for _, msg := range messages {
+		fmt.Fprintln(bw)
+		fmt.Fprintf(bw, "## %s → %s · %s\n", msg.From, msg.To, msg.CreatedAt.Format(time.RFC3339))
+		fmt.Fprintln(bw)
+		if msg.Subject != "" {
+			fmt.Fprintf(bw, "**%s**\n", msg.Subject)
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintf(bw, "`%s`", msg.ID)
+		if msg.InReplyTo != "" {
+			fmt.Fprintf(bw, " · in reply to `%s`", msg.InReplyTo)
+		}
+		if msg.Priority != "" && msg.Priority != PriorityNormal {
+			fmt.Fprintf(bw, " · %s", msg.Priority)
+		}
+		for _, key := range msg.HeaderKeys() {
+			fmt.Fprintf(bw, " · `%s=%s`", key, msg.Headers[key])
+		}
+		fmt.Fprintln(bw)
+		fmt.Fprintln(bw)
+		fmt.Fprintln(bw, strings.TrimSuffix(msg.Message, "\n"))
+	}
+	return bw.Flush()
+}
+
+// ReadExport decodes a JSON export written by WriteExport.
+func ReadExport(r io.Reader) (Export, error) {
+	var export Export
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return Export{}, fmt.Errorf("invalid JSON export: %w", err)
+	}
+	if export.Version < 1 || export.Version > ExportVersion {
+		return Export{}, fmt.Errorf("unsupported export version %d (supported %d)", export.Version, ExportVersion)
+	}
+	return export, nil
+}
+
+// Import appends exported messages to their recipients' mailboxes with IDs,
+// timestamps and read state preserved. A message whose ID is already in its
+// recipient's mailbox is skipped, so importing the same export twice is harmless.
+// Returns the number of messages imported and skipped.
+func Import(repoRoot string, messages []Message) (int, int, error) {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	known := make(map[string]map[string]bool)
+	imported, skipped := 0, 0
+	for _, msg := range messages {
+		if msg.ID == "" || msg.To == "" {
+			return imported, skipped, fmt.Errorf("message %d has no id or recipient", imported+skipped+1)
+		}
+
+		ids, ok := known[msg.To]
+		if !ok {
+			existing, err := store.ReadAll(msg.To)
+			if err != nil {
+				return imported, skipped, err
+			}
+			ids = make(map[string]bool, len(existing))
+			for _, m := range existing {
+				ids[m.ID] = true
+			}
+			known[msg.To] = ids
+		}
+		if ids[msg.ID] {
+			skipped++
+			continue
+		}
+
+		if err := store.Append(msg); err != nil {
+			return imported, skipped, err
+		}
+		ids[msg.ID] = true
+		imported++
+	}
+	return imported, skipped, nil
+}
@@ -0,0 +1,172 @@
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTranscriptMessages(t *testing.T, repoRoot string) time.Time {
+	t.Helper()
+	base := time.Date(2026, 1, 14, 9, 0, 0, 0, time.UTC)
+	if err := WriteAll(repoRoot, "agent-2", []Message{
+		{ID: "q1", From: "agent-1", To: "agent-2", Subject: "Schema", Message: "Switch to v2?\nFrom now on?", CreatedAt: base},
+		{ID: "n1", From: "agent-3", To: "agent-2", Message: "unrelated", ReadFlag: true, CreatedAt: base.Add(2 * time.Minute)},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+	if err := WriteAll(repoRoot, "agent-1", []Message{
+		{ID: "a1", From: "agent-2", To: "agent-1", Message: "Yes", InReplyTo: "q1", ThreadID: "q1", Headers: map[string]string{"task": "42"}, CreatedAt: base.Add(time.Minute)},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+	return base
+}
+
+func transcriptIDs(messages []Message) string {
+	var ids []string
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestTranscript(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := writeTranscriptMessages(t, tmpDir)
+
+	tests := []struct {
+		name   string
+		filter TranscriptFilter
+		want   string
+	}{
+		{"all mailboxes merged by time", TranscriptFilter{}, "q1,a1,n1"},
+		{"thread", TranscriptFilter{Thread: "a1"}, "q1,a1"},
+		{"since", TranscriptFilter{Since: base.Add(time.Minute)}, "a1,n1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := Transcript(tmpDir, tt.filter)
+			if err != nil {
+				t.Fatalf("Transcript failed: %v", err)
+			}
+			if got := transcriptIDs(messages); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := Transcript(tmpDir, TranscriptFilter{Thread: "missing"}); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+}
+
+func TestWriteExport_Mbox(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTranscriptMessages(t, tmpDir)
+	messages, _ := Transcript(tmpDir, TranscriptFilter{})
+
+	var buf bytes.Buffer
+	if err := WriteExport(&buf, FormatMbox, messages[:2]); err != nil {
+		t.Fatalf("WriteExport failed: %v", err)
+	}
+	expected := "From agent-1@agentmail Wed Jan 14 09:00:00 2026\n" +
+		"From: agent-1@agentmail\n" +
+		"To: agent-2@agentmail\n" +
+		"Date: Wed, 14 Jan 2026 09:00:00 +0000\n" +
+		"Message-ID: <q1@agentmail>\n" +
+		"Subject: Schema\n" +
+		"\n" +
+		"Switch to v2?\n" +
+		">From now on?\n" +
+		"\n" +
+		"From agent-2@agentmail Wed Jan 14 09:01:00 2026\n" +
+		"From: agent-2@agentmail\n" +
+		"To: agent-1@agentmail\n" +
+		"Date: Wed, 14 Jan 2026 09:01:00 +0000\n" +
+		"Message-ID: <a1@agentmail>\n" +
+		"In-Reply-To: <q1@agentmail>\n" +
+		"References: <q1@agentmail>\n" +
+		"X-Agentmail-Header: task=42\n" +
+		"\n" +
+		"Yes\n" +
+		"\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected mbox:\n%s", buf.String())
+	}
+}
+
+func TestWriteExport_Markdown(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTranscriptMessages(t, tmpDir)
+	messages, _ := Transcript(tmpDir, TranscriptFilter{Thread: "q1"})
+
+	var buf bytes.Buffer
+	if err := WriteExport(&buf, FormatMarkdown, messages); err != nil {
+		t.Fatalf("WriteExport failed: %v", err)
+	}
+	for _, want := range []string{
+		"# AgentMail transcript\n\n2 message(s), 2026-01-14T09:00:00Z to 2026-01-14T09:01:00Z\n",
+		"## agent-1 → agent-2 · 2026-01-14T09:00:00Z\n\n**Schema**\n\n`q1`\n\nSwitch to v2?\nFrom now on?\n",
+		"## agent-2 → agent-1 · 2026-01-14T09:01:00Z\n\n`a1` · in reply to `q1` · `task=42`\n\nYes\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	if err := WriteExport(&buf, "pdf", messages); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	srcDir := t.TempDir()
+	writeTranscriptMessages(t, srcDir)
+	messages, _ := Transcript(srcDir, TranscriptFilter{})
+
+	var buf bytes.Buffer
+	if err := WriteExport(&buf, FormatJSON, messages); err != nil {
+		t.Fatalf("WriteExport failed: %v", err)
+	}
+	export, err := ReadExport(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+
+	dstDir := t.TempDir()
+	// One message is already there and must not be duplicated
+	_ = WriteAll(dstDir, "agent-2", []Message{{ID: "n1", From: "agent-3", To: "agent-2", Message: "unrelated"}})
+
+	imported, skipped, err := Import(dstDir, export.Messages)
+	if err != nil || imported != 2 || skipped != 1 {
+		t.Fatalf("Expected 2 imported and 1 skipped, got %d, %d, %v", imported, skipped, err)
+	}
+
+	copied, _ := Transcript(dstDir, TranscriptFilter{Thread: "q1"})
+	if transcriptIDs(copied) != "q1,a1" {
+		t.Fatalf("Expected thread q1,a1 after import, got %s", transcriptIDs(copied))
+	}
+	if !copied[0].CreatedAt.Equal(messages[0].CreatedAt) || copied[1].Headers["task"] != "42" {
+		t.Errorf("Expected timestamps and headers preserved, got %+v", copied)
+	}
+
+	// Importing again changes nothing
+	imported, skipped, _ = Import(dstDir, export.Messages)
+	if imported != 0 || skipped != 3 {
+		t.Errorf("Expected everything skipped on re-import, got %d imported, %d skipped", imported, skipped)
+	}
+}
+
+func TestReadExport_Invalid(t *testing.T) {
+	for _, input := range []string{`not json`, `{"agentmail_export": 99, "messages": []}`, `{"messages": []}`} {
+		if _, err := ReadExport(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected error for %s", input)
+		}
+	}
+	if _, _, err := Import(t.TempDir(), []Message{{ID: "x"}}); err == nil {
+		t.Error("Expected error for a message without recipient")
+	}
+}
@@ -0,0 +1,141 @@
+package mail
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GroupsFile is the filename for named recipient groups
+const GroupsFile = ".agentmail/groups"
+
+// AllAddress is the group address that expands to every window in the session
+const AllAddress = "@all"
+
+// ErrUnknownGroup is returned when a group address is not defined in the groups file.
+var ErrUnknownGroup = errors.New("unknown group")
+
+// ErrNoRecipients is returned when a group address expands to no deliverable windows.
+var ErrNoRecipients = errors.New("no recipients")
+
+// IsGroupAddress reports whether the address refers to a group (starts with "@").
+func IsGroupAddress(address string) bool {
+	return strings.HasPrefix(address, "@")
+}
+
+// LoadGroups reads named groups from .agentmail/groups.
+// Each line has the form "@name: window-1, window-2". Blank lines and lines
+// starting with "#" are ignored. Returns nil (no error) if the file doesn't exist.
+func LoadGroups(repoRoot string) (map[string][]string, error) {
+	path := filepath.Join(repoRoot, GroupsFile) // #nosec G304 - GroupsFile is a constant
+	file, err := os.Open(path)                  // #nosec G304 - path is constructed from constant
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	groups := make(map[string][]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, members, found := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !found || !IsGroupAddress(name) || name == AllAddress {
+			continue // Skip malformed lines and attempts to redefine @all
+		}
+
+		for _, member := range strings.Split(members, ",") {
+			member = strings.TrimSpace(member)
+			if member != "" {
+				groups[name] = append(groups[name], member)
+			}
+		}
+	}
+	return groups, scanner.Err()
+}
+
+// ExpandAddress resolves a group address to the list of windows that should
+// receive a copy. "@all" expands to every window; other group addresses are
+// looked up in groups. Members that are not current windows, the sender, and
+// ignored windows are excluded. Duplicates are removed, preserving order.
+func ExpandAddress(address string, windows []string, groups map[string][]string, sender string, ignored map[string]bool) ([]string, error) {
+	var members []string
+	if address == AllAddress {
+		members = windows
+	} else {
+		var ok bool
+		members, ok = groups[address]
+		if !ok {
+			return nil, ErrUnknownGroup
+		}
+	}
+
+	windowSet := make(map[string]bool, len(windows))
+	for _, w := range windows {
+		windowSet[w] = true
+	}
+
+	seen := make(map[string]bool)
+	var recipients []string
+	for _, member := range members {
+		if !windowSet[member] || member == sender || ignored[member] || seen[member] {
+			continue
+		}
+		seen[member] = true
+		recipients = append(recipients, member)
+	}
+
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+	return recipients, nil
+}
+
+// Broadcast appends a copy of msg to each recipient's mailbox.
+// Every copy gets its own message ID and shares a single BroadcastID,
+// which is returned. msg.ID and msg.To are overwritten per copy.
+func Broadcast(repoRoot string, msg Message, recipients []string) (string, error) {
+	broadcastID, err := GenerateID()
+	if err != nil {
+		return "", err
+	}
+
+	msg.BroadcastID = broadcastID
+	copies := make([]Message, len(recipients))
+	for i, recipient := range recipients {
+		id, err := GenerateID()
+		if err != nil {
+			return "", err
+		}
+		copies[i] = msg
+		copies[i].ID = id
+		copies[i].To = recipient
+	}
+	send := func() ([]Message, error) {
+		for _, c := range copies {
+			if err := appendMessage(repoRoot, c); err != nil {
+				return nil, err
+			}
+		}
+		return copies, nil
+	}
+
+	if msg.IdempotencyKey == "" {
+		_, err = send()
+	} else {
+		err = sendOnce(repoRoot, msg.From, msg.IdempotencyKey, send)
+	}
+	if err != nil {
+		return "", err
+	}
+	return broadcastID, nil
+}
@@ -0,0 +1,145 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIsGroupAddress(t *testing.T) {
+	tests := map[string]bool{
+		"@all":       true,
+		"@reviewers": true,
+		"agent-1":    false,
+		"":           false,
+	}
+	for address, want := range tests {
+		if got := IsGroupAddress(address); got != want {
+			t.Errorf("IsGroupAddress(%q) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestLoadGroups_FileNotExists(t *testing.T) {
+	groups, err := LoadGroups(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadGroups should not error on missing file: %v", err)
+	}
+	if groups != nil {
+		t.Errorf("Expected nil groups, got %v", groups)
+	}
+}
+
+func TestLoadGroups_ParsesFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, RootDir), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+
+	content := `# Team groups
+@reviewers: agent-2, agent-3
+
+@builders:agent-4
+@all: agent-9
+not-a-group: agent-5
+@empty:
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, GroupsFile), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write groups file: %v", err)
+	}
+
+	groups, err := LoadGroups(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadGroups failed: %v", err)
+	}
+
+	want := map[string][]string{
+		"@reviewers": {"agent-2", "agent-3"},
+		"@builders":  {"agent-4"},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("LoadGroups = %v, want %v", groups, want)
+	}
+}
+
+func TestExpandAddress_All(t *testing.T) {
+	windows := []string{"agent-1", "agent-2", "agent-3", "monitor"}
+	ignored := map[string]bool{"monitor": true}
+
+	got, err := ExpandAddress(AllAddress, windows, nil, "agent-1", ignored)
+	if err != nil {
+		t.Fatalf("ExpandAddress failed: %v", err)
+	}
+
+	want := []string{"agent-2", "agent-3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandAddress(@all) = %v, want %v", got, want)
+	}
+}
+
+func TestExpandAddress_NamedGroup(t *testing.T) {
+	windows := []string{"agent-1", "agent-2", "agent-3"}
+	groups := map[string][]string{
+		"@reviewers": {"agent-3", "agent-1", "agent-gone", "agent-3", "agent-2"},
+	}
+
+	got, err := ExpandAddress("@reviewers", windows, groups, "agent-1", nil)
+	if err != nil {
+		t.Fatalf("ExpandAddress failed: %v", err)
+	}
+
+	// Sender, missing windows and duplicates are dropped; group order is kept
+	want := []string{"agent-3", "agent-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandAddress(@reviewers) = %v, want %v", got, want)
+	}
+}
+
+func TestExpandAddress_Errors(t *testing.T) {
+	windows := []string{"agent-1"}
+
+	if _, err := ExpandAddress("@unknown", windows, nil, "agent-1", nil); err != ErrUnknownGroup {
+		t.Errorf("Expected ErrUnknownGroup, got %v", err)
+	}
+
+	if _, err := ExpandAddress(AllAddress, windows, nil, "agent-1", nil); err != ErrNoRecipients {
+		t.Errorf("Expected ErrNoRecipients when only the sender exists, got %v", err)
+	}
+}
+
+func TestBroadcast_SharedBroadcastID(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	msg := Message{From: "agent-1", Message: "stop and rebase"}
+	broadcastID, err := Broadcast(tmpDir, msg, []string{"agent-2", "agent-3"})
+	if err != nil {
+		t.Fatalf("Broadcast failed: %v", err)
+	}
+	if broadcastID == "" {
+		t.Fatal("Expected non-empty broadcast ID")
+	}
+
+	ids := make(map[string]bool)
+	for _, recipient := range []string{"agent-2", "agent-3"} {
+		messages, err := ReadAll(tmpDir, recipient)
+		if err != nil {
+			t.Fatalf("ReadAll(%s) failed: %v", recipient, err)
+		}
+		if len(messages) != 1 {
+			t.Fatalf("Expected 1 message for %s, got %d", recipient, len(messages))
+		}
+		got := messages[0]
+		if got.To != recipient || got.From != "agent-1" || got.Message != "stop and rebase" {
+			t.Errorf("Unexpected copy for %s: %+v", recipient, got)
+		}
+		if got.BroadcastID != broadcastID {
+			t.Errorf("Expected broadcast ID %q, got %q", broadcastID, got.BroadcastID)
+		}
+		ids[got.ID] = true
+	}
+
+	if len(ids) != 2 {
+		t.Error("Each copy should have its own message ID")
+	}
+}
@@ -0,0 +1,96 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidHeader is returned when a header is not a valid key=value pair.
+var ErrInvalidHeader = errors.New("invalid header (must be key=value, key without spaces)")
+
+// ValidateSubject checks that a subject fits on one line.
+func ValidateSubject(subject string) error {
+	if strings.ContainsAny(subject, "\r\n") {
+		return errors.New("subject must be a single line")
+	}
+	return nil
+}
+
+// validateHeader checks a single header key and value.
+func validateHeader(key, value string) error {
+	if key == "" || strings.ContainsAny(key, "= \t\r\n") {
+		return fmt.Errorf("%w: %q", ErrInvalidHeader, key)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("header %q value must be a single line", key)
+	}
+	return nil
+}
+
+// ValidateHeaders checks every key and value of a header map.
+func ValidateHeaders(headers map[string]string) error {
+	for key, value := range headers {
+		if err := validateHeader(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseHeaders parses "key=value" pairs, as given to repeated --header flags,
+// into a header map. Later pairs override earlier ones with the same key.
+// Returns nil for an empty list.
+func ParseHeaders(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	headers := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidHeader, pair)
+		}
+		if err := validateHeader(key, value); err != nil {
+			return nil, err
+		}
+		headers[key] = value
+	}
+	return headers, nil
+}
+
+// HeaderKeys returns the message's header keys in sorted order, for stable output.
+func (m Message) HeaderKeys() []string {
+	keys := make([]string, 0, len(m.Headers))
+	for key := range m.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MatchHeaders reports whether the message carries every header in filter with the same value.
+// An empty filter matches every message.
+func (m Message) MatchHeaders(filter map[string]string) bool {
+	for key, value := range filter {
+		if got, ok := m.Headers[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterByHeaders returns the messages matching filter, preserving order.
+func FilterByHeaders(messages []Message, filter map[string]string) []Message {
+	if len(filter) == 0 {
+		return messages
+	}
+	var matched []Message
+	for _, msg := range messages {
+		if msg.MatchHeaders(filter) {
+			matched = append(matched, msg)
+		}
+	}
+	return matched
+}
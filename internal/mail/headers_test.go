@@ -0,0 +1,61 @@
+package mail
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders([]string{"task=123", "team=infra", "note=a=b", "task=456"})
+	if err != nil {
+		t.Fatalf("ParseHeaders failed: %v", err)
+	}
+	if len(headers) != 3 || headers["task"] != "456" || headers["team"] != "infra" || headers["note"] != "a=b" {
+		t.Errorf("Unexpected headers: %v", headers)
+	}
+
+	if headers, err := ParseHeaders(nil); err != nil || headers != nil {
+		t.Errorf("Expected nil headers for no pairs, got %v (err=%v)", headers, err)
+	}
+}
+
+func TestParseHeaders_Invalid(t *testing.T) {
+	for _, pair := range []string{"task", "=123", "my task=123"} {
+		if _, err := ParseHeaders([]string{pair}); !errors.Is(err, ErrInvalidHeader) {
+			t.Errorf("ParseHeaders(%q): expected ErrInvalidHeader, got %v", pair, err)
+		}
+	}
+	if _, err := ParseHeaders([]string{"task=1\n2"}); err == nil {
+		t.Error("Expected error for multi-line header value")
+	}
+}
+
+func TestValidateSubject(t *testing.T) {
+	if err := ValidateSubject("CI failure on main"); err != nil {
+		t.Errorf("Expected valid subject, got %v", err)
+	}
+	if err := ValidateSubject("line one\nline two"); err == nil {
+		t.Error("Expected error for multi-line subject")
+	}
+}
+
+func TestFilterByHeaders(t *testing.T) {
+	messages := []Message{
+		{ID: "a", Headers: map[string]string{"task": "123", "team": "infra"}},
+		{ID: "b", Headers: map[string]string{"task": "456"}},
+		{ID: "c"},
+	}
+
+	if got := FilterByHeaders(messages, nil); len(got) != 3 {
+		t.Errorf("Expected empty filter to match all, got %d", len(got))
+	}
+
+	got := FilterByHeaders(messages, map[string]string{"task": "123"})
+	if len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("Expected only message a, got %+v", got)
+	}
+
+	if got := FilterByHeaders(messages, map[string]string{"task": "123", "team": "web"}); len(got) != 0 {
+		t.Errorf("Expected every filter header to be required, got %+v", got)
+	}
+}
@@ -0,0 +1,209 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"agentmail/internal/config"
+)
+
+// IdempotencyWindow is how long a send's idempotency key is remembered unless
+// idempotency_window is set in the config file. A retry with the same sender and
+// key within the window returns the original message.
+const IdempotencyWindow = config.DefaultIdempotencyWindow
+
+// MaxIdempotencyKeyLength is the longest idempotency key accepted
+const MaxIdempotencyKeyLength = 128
+
+// KeysDir holds one idempotency key index per sender
+const KeysDir = ".agentmail/keys"
+
+// DuplicateError is returned by Append and Broadcast when the sender already sent
+// a message with the same idempotency key within the window. Nothing is delivered.
+type DuplicateError struct {
+	Copies []Message // The original send; every copy for a group send
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("idempotency key %q was already used by %s", e.Copies[0].IdempotencyKey, e.Copies[0].From)
+}
+
+// keyEntry is one line of a sender's key index: a send made with a key.
+type keyEntry struct {
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+	Copies    []keyCopy `json:"copies"`
+}
+
+// keyCopy locates one message of a keyed send.
+type keyCopy struct {
+	To string `json:"to"`
+	ID string `json:"id"`
+}
+
+// ValidateIdempotencyKey checks that an idempotency key is a short single line.
+// The empty key (no deduplication) is valid.
+func ValidateIdempotencyKey(key string) error {
+	if len(key) > MaxIdempotencyKeyLength {
+		return fmt.Errorf("idempotency key exceeds maximum length of %d", MaxIdempotencyKeyLength)
+	}
+	if strings.ContainsAny(key, "\r\n") {
+		return fmt.Errorf("idempotency key must be a single line")
+	}
+	return nil
+}
+
+// FindByIdempotencyKey returns the message sender already sent with key within
+// the idempotency window, looking it up in the sender's key index. For a group send
+// it returns every copy of the broadcast. Returns nil if there is none, so the send
+// should go ahead. Messages already removed by cleanup or moved to the dead-letter
+// mailbox are not found.
+//
+// This is a quick check before a send; Append and Broadcast repeat it under the
+// index lock and return a DuplicateError if a concurrent send got there first.
+func FindByIdempotencyKey(repoRoot string, sender string, key string) ([]Message, error) {
+	if key == "" {
+		return nil, nil
+	}
+	path, err := keyIndexPath(repoRoot, sender)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path) // #nosec G304 - path is under KeysDir
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries, _ := liveKeyEntries(data, idempotencyWindow(repoRoot))
+	return keyedCopies(repoRoot, entries, key)
+}
+
+// sendOnce runs send, which delivers the messages of one send, unless sender
+// already used key within the idempotency window. The lookup, the delivery and
+// the index update happen under the lock of the sender's key index, so concurrent
+// retries deliver once.
+func sendOnce(repoRoot string, sender string, key string, send func() ([]Message, error)) error {
+	if err := ensureRootDir(repoRoot); err != nil {
+		return err
+	}
+	path, err := keyIndexPath(repoRoot, sender)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil { // G301: restricted directory permissions
+		return err
+	}
+	file, err := lockFile(path, os.O_CREATE|os.O_RDWR, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlockFile(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	entries, expired := liveKeyEntries(data, idempotencyWindow(repoRoot))
+	copies, err := keyedCopies(repoRoot, entries, key)
+	if err != nil {
+		return err
+	}
+	if len(copies) > 0 {
+		return &DuplicateError{Copies: copies}
+	}
+
+	sent, err := send()
+	if err != nil {
+		return err
+	}
+	entry := keyEntry{Key: key, CreatedAt: time.Now()}
+	for _, msg := range sent {
+		entry.Copies = append(entry.Copies, keyCopy{To: msg.To, ID: msg.ID})
+	}
+
+	if !expired {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		_, err = file.Write(append(line, '\n'))
+		return err
+	}
+	// Drop expired keys while rewriting, so the index stays small
+	return replaceFile(path, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for _, e := range append(entries, entry) {
+			if err := encoder.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// keyIndexPath returns the key index of sender.
+func keyIndexPath(repoRoot string, sender string) (string, error) {
+	return safePath(filepath.Join(repoRoot, KeysDir), sender+".jsonl")
+}
+
+// idempotencyWindow returns the configured idempotency window.
+func idempotencyWindow(repoRoot string) time.Duration {
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return IdempotencyWindow
+	}
+	return cfg.IdempotencyWindow
+}
+
+// liveKeyEntries parses a key index, keeping entries made within window and
+// skipping unreadable lines. expired reports whether any entry was dropped.
+func liveKeyEntries(data []byte, window time.Duration) (entries []keyEntry, expired bool) {
+	cutoff := time.Now().Add(-window)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var entry keyEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.CreatedAt.Before(cutoff) {
+			expired = true
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, expired
+}
+
+// keyedCopies returns the messages of the latest entry for key that are still in
+// their mailboxes.
+func keyedCopies(repoRoot string, entries []keyEntry, key string) ([]Message, error) {
+	if key == "" {
+		return nil, nil
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Key != key {
+			continue
+		}
+		var copies []Message
+		for _, c := range entries[i].Copies {
+			messages, err := ReadAll(repoRoot, c.To)
+			if err != nil {
+				return nil, err
+			}
+			for _, msg := range messages {
+				if msg.ID == c.ID {
+					copies = append(copies, msg)
+				}
+			}
+		}
+		return copies, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,145 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"agentmail/internal/config"
+)
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	for _, key := range []string{"", "deploy-42", strings.Repeat("k", MaxIdempotencyKeyLength)} {
+		if err := ValidateIdempotencyKey(key); err != nil {
+			t.Errorf("ValidateIdempotencyKey(%q) failed: %v", key, err)
+		}
+	}
+	for _, key := range []string{"a\nb", strings.Repeat("k", MaxIdempotencyKeyLength+1)} {
+		if err := ValidateIdempotencyKey(key); err == nil {
+			t.Errorf("ValidateIdempotencyKey(%q) should fail", key)
+		}
+	}
+}
+
+func TestFindByIdempotencyKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "orig0001", From: "agent-1", To: "agent-2", Message: "deploy done", IdempotencyKey: "deploy-42"})
+	_ = Append(tmpDir, Message{ID: "othr0001", From: "agent-3", To: "agent-2", Message: "deploy done", IdempotencyKey: "deploy-42"})
+
+	copies, err := FindByIdempotencyKey(tmpDir, "agent-1", "deploy-42")
+	if err != nil {
+		t.Fatalf("FindByIdempotencyKey failed: %v", err)
+	}
+	if len(copies) != 1 || copies[0].ID != "orig0001" {
+		t.Errorf("Expected orig0001, got %+v", copies)
+	}
+
+	// Keys are per sender, and the empty key never matches
+	if copies, _ := FindByIdempotencyKey(tmpDir, "agent-1", "other"); len(copies) != 0 {
+		t.Errorf("Expected no match for another key, got %+v", copies)
+	}
+	if copies, _ := FindByIdempotencyKey(tmpDir, "agent-4", "deploy-42"); len(copies) != 0 {
+		t.Errorf("Expected no match for another sender, got %+v", copies)
+	}
+	if copies, _ := FindByIdempotencyKey(tmpDir, "agent-1", ""); len(copies) != 0 {
+		t.Errorf("Expected no match for the empty key, got %+v", copies)
+	}
+}
+
+func TestFindByIdempotencyKey_OutsideWindow(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, RootDir), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, config.File), []byte("idempotency_window = \"50ms\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = Append(tmpDir, Message{ID: "old00001", From: "agent-1", To: "agent-2", IdempotencyKey: "k"})
+	time.Sleep(100 * time.Millisecond)
+
+	copies, err := FindByIdempotencyKey(tmpDir, "agent-1", "k")
+	if err != nil || len(copies) != 0 {
+		t.Errorf("Expected the old send to be forgotten, got %+v, %v", copies, err)
+	}
+
+	// A new send with the key goes ahead and drops the expired entry from the index
+	if err := Append(tmpDir, Message{ID: "new00001", From: "agent-1", To: "agent-2", IdempotencyKey: "k"}); err != nil {
+		t.Fatalf("Append after the window failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, KeysDir, "agent-1.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), "\n") != 1 || !strings.Contains(string(data), "new00001") {
+		t.Errorf("Expected only the new send in the key index, got %q", data)
+	}
+}
+
+func TestAppend_DuplicateKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Append(tmpDir, Message{ID: "orig0001", From: "agent-1", To: "agent-2", IdempotencyKey: "k"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	err := Append(tmpDir, Message{ID: "retry001", From: "agent-1", To: "agent-2", IdempotencyKey: "k"})
+	var duplicate *DuplicateError
+	if !errors.As(err, &duplicate) || len(duplicate.Copies) != 1 || duplicate.Copies[0].ID != "orig0001" {
+		t.Fatalf("Expected a DuplicateError for orig0001, got %v", err)
+	}
+	messages, _ := ReadAll(tmpDir, "agent-2")
+	if len(messages) != 1 {
+		t.Errorf("Expected the retry not to be delivered, got %d messages", len(messages))
+	}
+}
+
+func TestAppend_ConcurrentSameKeyDeliversOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	const senders = 8
+
+	var wg sync.WaitGroup
+	errs := make([]error, senders)
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = Append(tmpDir, Message{ID: fmt.Sprintf("try%05d", i), From: "agent-1", To: "agent-2", IdempotencyKey: "k"})
+		}(i)
+	}
+	wg.Wait()
+
+	delivered := 0
+	for _, err := range errs {
+		var duplicate *DuplicateError
+		switch {
+		case err == nil:
+			delivered++
+		case !errors.As(err, &duplicate):
+			t.Errorf("Append failed: %v", err)
+		}
+	}
+	messages, _ := ReadAll(tmpDir, "agent-2")
+	if delivered != 1 || len(messages) != 1 {
+		t.Errorf("Expected one delivery, got %d successful appends and %d messages", delivered, len(messages))
+	}
+}
+
+func TestFindByIdempotencyKey_Broadcast(t *testing.T) {
+	tmpDir := t.TempDir()
+	broadcastID, err := Broadcast(tmpDir, Message{From: "agent-1", Message: "rebase", IdempotencyKey: "rebase-1"}, []string{"agent-2", "agent-3"})
+	if err != nil {
+		t.Fatalf("Broadcast failed: %v", err)
+	}
+
+	copies, err := FindByIdempotencyKey(tmpDir, "agent-1", "rebase-1")
+	if err != nil {
+		t.Fatalf("FindByIdempotencyKey failed: %v", err)
+	}
+	if len(copies) != 2 || copies[0].BroadcastID != broadcastID || copies[1].BroadcastID != broadcastID {
+		t.Errorf("Expected both copies of %s, got %+v", broadcastID, copies)
+	}
+}
@@ -15,6 +15,12 @@ func FindGitRoot() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return FindGitRootFrom(dir)
+}
+
+// FindGitRootFrom walks up the directory tree from dir looking for a .git directory.
+// Returns the directory containing .git, or an error if not found.
+func FindGitRootFrom(dir string) (string, error) {
 	for {
 		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
 			return dir, nil
@@ -51,3 +57,9 @@ func LoadIgnoreList(gitRoot string) (map[string]bool, error) {
 	}
 	return ignored, scanner.Err()
 }
+
+// Ignores reports whether the ignore list of the target's repository holds its
+// mailbox or the name of its window.
+func Ignores(ignoreList map[string]bool, target Target) bool {
+	return ignoreList[target.Mailbox] || ignoreList[target.Pane.Window]
+}
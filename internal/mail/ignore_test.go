@@ -264,3 +264,19 @@ another-ignored
 		t.Error("Should not find 'not-ignored' in ignore list")
 	}
 }
+
+func TestFindGitRootFrom_WalksUpFromDir(t *testing.T) {
+	repo := t.TempDir()
+	sub := filepath.Join(repo, "cmd", "api")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	root, err := FindGitRootFrom(sub)
+	if err != nil || root != repo {
+		t.Errorf("FindGitRootFrom(%q) = %q, %v; want %q", sub, root, err, repo)
+	}
+}
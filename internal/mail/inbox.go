@@ -0,0 +1,125 @@
+package mail
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// PreviewLength is the maximum number of characters of a message shown in list output
+const PreviewLength = 60
+
+// Inbox states of a delivered message, as listed by inbox
+const (
+	StateUnread  = "unread"
+	StateRead    = "read"
+	StateLeased  = "leased"  // Received with a lease that has not expired or been acked
+	StateExpired = "expired" // TTL passed while unread; dead-lettered by the mailman
+)
+
+// InboxFilter selects the messages listed by ListInbox. The zero value lists everything.
+type InboxFilter struct {
+	UnreadOnly bool      // Only messages that are not read yet (leased and expired included)
+	From       string    // Only messages from this sender
+	Since      time.Time // Only messages delivered at or after this time
+	Limit      int       // At most this many messages, newest first (0 = no limit)
+}
+
+// DeliveredAt returns when the message reached the mailbox: its scheduled
+// delivery time if it had one, otherwise its creation time.
+func (m Message) DeliveredAt() time.Time {
+	if m.DeliverAfter.After(m.CreatedAt) {
+		return m.DeliverAfter
+	}
+	return m.CreatedAt
+}
+
+// Preview returns the first line of a message, truncated to PreviewLength for list output.
+func Preview(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	runes := []rune(line)
+	if len(runes) > PreviewLength {
+		return string(runes[:PreviewLength]) + "..."
+	}
+	return line
+}
+
+// Summary returns the subject of the message, or a preview of its body if it has none.
+func (m Message) Summary() string {
+	if m.Subject != "" {
+		return m.Subject
+	}
+	return Preview(m.Message)
+}
+
+// State returns the inbox state of a delivered message at now.
+func (m Message) State(now time.Time) string {
+	switch {
+	case m.ReadFlag:
+		return StateRead
+	case m.InFlight(now):
+		return StateLeased
+	case m.Expired(now):
+		return StateExpired
+	default:
+		return StateUnread
+	}
+}
+
+// ListInbox returns the delivered messages in a recipient's mailbox that match
+// the filter, newest first. Scheduled messages that are not due yet are not
+// listed; nothing is marked as read.
+func ListInbox(repoRoot string, recipient string, filter InboxFilter) ([]Message, error) {
+	messages, err := ReadAll(repoRoot, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var listed []Message
+	for _, msg := range messages {
+		if msg.Pending(now) {
+			continue
+		}
+		if filter.UnreadOnly && msg.ReadFlag {
+			continue
+		}
+		if filter.From != "" && msg.From != filter.From {
+			continue
+		}
+		if !filter.Since.IsZero() && msg.DeliveredAt().Before(filter.Since) {
+			continue
+		}
+		listed = append(listed, msg)
+	}
+
+	// Stable sort keeps the newest-appended message first among equal timestamps
+	for i, j := 0, len(listed)-1; i < j; i, j = i+1, j-1 {
+		listed[i], listed[j] = listed[j], listed[i]
+	}
+	sort.SliceStable(listed, func(i, j int) bool {
+		return listed[i].DeliveredAt().After(listed[j].DeliveredAt())
+	})
+
+	if filter.Limit > 0 && len(listed) > filter.Limit {
+		listed = listed[:filter.Limit]
+	}
+	return listed, nil
+}
+
+// FindInMailbox returns a delivered message from a recipient's mailbox, read or not.
+// Returns ErrMessageNotFound if the mailbox holds no such message or it is not due yet.
+func FindInMailbox(repoRoot string, recipient string, id string) (Message, error) {
+	messages, err := ReadAll(repoRoot, recipient)
+	if err != nil {
+		return Message{}, err
+	}
+
+	now := time.Now()
+	for _, msg := range messages {
+		if msg.ID == id && !msg.Pending(now) {
+			return msg, nil
+		}
+	}
+	return Message{}, ErrMessageNotFound
+}
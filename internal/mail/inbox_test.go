@@ -0,0 +1,113 @@
+package mail
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestListInbox(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now()
+	messages := []Message{
+		{ID: "old", From: "agent-1", To: "agent-2", Message: "old", ReadFlag: true, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "mid", From: "agent-3", To: "agent-2", Message: "mid", CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "new", From: "agent-1", To: "agent-2", Message: "new", CreatedAt: now.Add(-time.Minute)},
+		{ID: "later", From: "agent-1", To: "agent-2", Message: "later", CreatedAt: now.Add(-time.Hour), DeliverAfter: now.Add(time.Hour)},
+	}
+	if err := WriteAll(tmpDir, "agent-2", messages); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	ids := func(filter InboxFilter) []string {
+		t.Helper()
+		listed, err := ListInbox(tmpDir, "agent-2", filter)
+		if err != nil {
+			t.Fatalf("ListInbox failed: %v", err)
+		}
+		var ids []string
+		for _, msg := range listed {
+			ids = append(ids, msg.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name   string
+		filter InboxFilter
+		want   []string
+	}{
+		{"all, newest first, scheduled hidden", InboxFilter{}, []string{"new", "mid", "old"}},
+		{"unread", InboxFilter{UnreadOnly: true}, []string{"new", "mid"}},
+		{"from", InboxFilter{From: "agent-1"}, []string{"new", "old"}},
+		{"since", InboxFilter{Since: now.Add(-150 * time.Minute)}, []string{"new", "mid"}},
+		{"limit", InboxFilter{Limit: 1}, []string{"new"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(tt.filter)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+
+	if unread, _ := FindUnread(tmpDir, "agent-2"); len(unread) != 2 {
+		t.Errorf("ListInbox must not mark messages read, got %d unread", len(unread))
+	}
+}
+
+func TestFindInMailbox(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "a", From: "agent-1", To: "agent-2", Message: "one"})
+	_ = MarkAsRead(tmpDir, "agent-2", "a")
+	_ = Append(tmpDir, Message{ID: "later", From: "agent-1", To: "agent-2", DeliverAfter: time.Now().Add(time.Hour)})
+
+	msg, err := FindInMailbox(tmpDir, "agent-2", "a")
+	if err != nil || msg.ID != "a" || !msg.ReadFlag {
+		t.Errorf("Expected read message a, got %+v, %v", msg, err)
+	}
+	if _, err := FindInMailbox(tmpDir, "agent-2", "later"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected scheduled message hidden, got %v", err)
+	}
+	if _, err := FindInMailbox(tmpDir, "agent-3", "a"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected other mailboxes not searched, got %v", err)
+	}
+}
+
+func TestMessageState(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		msg  Message
+		want string
+	}{
+		{Message{}, StateUnread},
+		{Message{ReadFlag: true}, StateRead},
+		{Message{LeaseUntil: now.Add(time.Minute)}, StateLeased},
+		{Message{LeaseUntil: now.Add(-time.Minute)}, StateUnread},
+		{Message{ExpiresAt: now.Add(-time.Minute)}, StateExpired},
+	}
+	for _, tt := range tests {
+		if got := tt.msg.State(now); got != tt.want {
+			t.Errorf("State(%+v) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	long := "0123456789012345678901234567890123456789012345678901234567890123456789"
+	if got := (Message{Message: long}).Summary(); got != long[:PreviewLength]+"..." {
+		t.Errorf("Expected truncated preview, got %q", got)
+	}
+	if got := (Message{Message: "first\nsecond"}).Summary(); got != "first" {
+		t.Errorf("Expected first line, got %q", got)
+	}
+	if got := (Message{Subject: "Build", Message: "body"}).Summary(); got != "Build" {
+		t.Errorf("Expected subject, got %q", got)
+	}
+}
@@ -0,0 +1,59 @@
+package mail
+
+import "time"
+
+// InFlight reports whether the message is leased and the lease has not expired at now.
+// In-flight messages are hidden from FindUnread until they are acknowledged or the lease runs out.
+func (m Message) InFlight(now time.Time) bool {
+	return !m.ReadFlag && !m.LeaseUntil.IsZero() && now.Before(m.LeaseUntil)
+}
+
+// Lease marks an unread message as in-flight for the given duration and
+// increments its delivery attempts. If the message is not acknowledged with Ack
+// before the lease expires, it returns to the queue and is delivered again.
+// Each lease is recorded as a read event; the first one mails the sender the
+// read receipt they asked for.
+// Returns the updated message, or ErrMessageNotFound if the ID is not in the mailbox.
+func Lease(repoRoot string, recipient string, messageID string, d time.Duration) (Message, error) {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return Message{}, err
+	}
+	msg, err := store.UpdateMessage(recipient, messageID, func(msg *Message) {
+		msg.LeaseUntil = time.Now().Add(d)
+		msg.Attempts++
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	recordEvent(store, EventRead, msg)
+	if msg.Attempts == 1 {
+		sendReceipt(repoRoot, msg)
+	}
+	return msg, nil
+}
+
+// Ack completes a delivered message: it is marked as read and its lease is cleared.
+// Acknowledging an already-read message is a no-op. A message acked without being
+// leased first mails the sender the read receipt they asked for.
+// Returns ErrMessageNotFound if the ID is not in the mailbox.
+func Ack(repoRoot string, recipient string, messageID string) error {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return err
+	}
+	var before Message
+	_, err = store.UpdateMessage(recipient, messageID, func(msg *Message) {
+		before = *msg
+		msg.ReadFlag = true
+		msg.LeaseUntil = time.Time{}
+	})
+	if err != nil || before.ReadFlag {
+		return err
+	}
+	recordEvent(store, EventAcked, before)
+	if before.Attempts == 0 {
+		sendReceipt(repoRoot, before)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package mail
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLease_HidesMessageUntilExpiry(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Append(tmpDir, Message{ID: "lease001", From: "agent-1", To: "agent-2", Message: "build it"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	leased, err := Lease(tmpDir, "agent-2", "lease001", time.Hour)
+	if err != nil {
+		t.Fatalf("Lease failed: %v", err)
+	}
+	if leased.Attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", leased.Attempts)
+	}
+	if !leased.InFlight(time.Now()) {
+		t.Error("Leased message should be in-flight")
+	}
+
+	unread, err := FindUnread(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("FindUnread failed: %v", err)
+	}
+	if len(unread) != 0 {
+		t.Errorf("In-flight message should be hidden, got %d unread", len(unread))
+	}
+
+	// Once the lease has expired the message is back in the queue
+	if leased.InFlight(time.Now().Add(2 * time.Hour)) {
+		t.Error("InFlight should be false after the lease deadline")
+	}
+}
+
+func TestLease_ExpiredLeaseRedelivers(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Append(tmpDir, Message{ID: "lease001", From: "agent-1", To: "agent-2", Message: "build it"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	if _, err := Lease(tmpDir, "agent-2", "lease001", time.Millisecond); err != nil {
+		t.Fatalf("Lease failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	unread, err := FindUnread(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("FindUnread failed: %v", err)
+	}
+	if len(unread) != 1 {
+		t.Fatalf("Expired lease should return the message to the queue, got %d unread", len(unread))
+	}
+
+	// Redelivery increments the attempt counter
+	leased, err := Lease(tmpDir, "agent-2", "lease001", time.Hour)
+	if err != nil {
+		t.Fatalf("Lease failed: %v", err)
+	}
+	if leased.Attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", leased.Attempts)
+	}
+}
+
+func TestAck_MarksReadAndClearsLease(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Append(tmpDir, Message{ID: "lease001", From: "agent-1", To: "agent-2", Message: "build it"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if _, err := Lease(tmpDir, "agent-2", "lease001", time.Millisecond); err != nil {
+		t.Fatalf("Lease failed: %v", err)
+	}
+
+	if err := Ack(tmpDir, "agent-2", "lease001"); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+
+	messages, err := ReadAll(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if !messages[0].ReadFlag || !messages[0].LeaseUntil.IsZero() {
+		t.Errorf("Expected read message without lease, got %+v", messages[0])
+	}
+	if messages[0].Attempts != 1 {
+		t.Errorf("Ack should keep the attempt count, got %d", messages[0].Attempts)
+	}
+
+	// Acked messages never come back, even after the old lease deadline
+	time.Sleep(10 * time.Millisecond)
+	unread, err := FindUnread(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("FindUnread failed: %v", err)
+	}
+	if len(unread) != 0 {
+		t.Errorf("Acked message should not be redelivered, got %d unread", len(unread))
+	}
+}
+
+func TestAck_UnknownMessage(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := Ack(tmpDir, "agent-2", "missing1"); err != ErrMessageNotFound {
+		t.Errorf("Expected ErrMessageNotFound for missing mailbox, got %v", err)
+	}
+
+	if err := Append(tmpDir, Message{ID: "lease001", From: "agent-1", To: "agent-2", Message: "x"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := Ack(tmpDir, "agent-2", "missing1"); err != ErrMessageNotFound {
+		t.Errorf("Expected ErrMessageNotFound for unknown ID, got %v", err)
+	}
+}
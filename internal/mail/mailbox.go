@@ -1,9 +1,7 @@
 package mail
 
 import (
-	"encoding/json"
 	"errors"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -88,10 +86,10 @@ func TryLockWithTimeout(file *os.File, timeout time.Duration) error {
 }
 
 // EnsureMailDir creates the .agentmail/ and .agentmail/mailboxes/ directories if they don't exist.
+// A new store is stamped with the current format version.
 func EnsureMailDir(repoRoot string) error {
 	// Create root directory first
-	rootPath := filepath.Join(repoRoot, RootDir)
-	if err := os.MkdirAll(rootPath, 0750); err != nil { // G301: restricted directory permissions
+	if err := ensureRootDir(repoRoot); err != nil {
 		return err
 	}
 
@@ -100,379 +98,200 @@ func EnsureMailDir(repoRoot string) error {
 	return os.MkdirAll(mailPath, 0750) // G301: restricted directory permissions
 }
 
-// Append adds a message to the recipient's mailbox file with file locking.
+// Append adds a message to the recipient's mailbox, setting its creation timestamp,
+// and records it as queued.
+//
+// A message with an idempotency key is delivered only if its sender didn't use the
+// key within the idempotency window; otherwise Append returns a DuplicateError.
 func Append(repoRoot string, msg Message) error {
-	// Ensure mail directory exists
-	if err := EnsureMailDir(repoRoot); err != nil {
-		return err
-	}
-
-	// Build file path for recipient with path traversal protection (G304)
-	mailDir := filepath.Join(repoRoot, MailDir)
-	filePath, err := safePath(mailDir, msg.To+".jsonl")
-	if err != nil {
-		return err
+	if msg.IdempotencyKey == "" {
+		return appendMessage(repoRoot, msg)
 	}
+	return sendOnce(repoRoot, msg.From, msg.IdempotencyKey, func() ([]Message, error) {
+		return []Message{msg}, appendMessage(repoRoot, msg)
+	})
+}
 
-	// Open file for appending (create if not exists)
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 - path validated by safePath; G302 - restricted file permissions
+// appendMessage adds a message to the recipient's mailbox without checking its
+// idempotency key.
+func appendMessage(repoRoot string, msg Message) error {
+	store, err := OpenStore(repoRoot)
 	if err != nil {
 		return err
 	}
 
-	// Acquire exclusive lock on the file
-	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
-		_ = file.Close() // G104: error intentionally ignored in cleanup path
-		return err
-	}
-
 	// Set creation timestamp
 	msg.CreatedAt = time.Now()
 
-	// Marshal message to JSON
-	data, err := json.Marshal(msg)
-	if err != nil {
-		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) // G104: error intentionally ignored in cleanup path
-		_ = file.Close()
+	if err := store.Append(msg); err != nil {
 		return err
 	}
-
-	// Write JSON line (append newline)
-	_, err = file.Write(append(data, '\n'))
-
-	// Unlock before close (correct order)
-	_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) // G104: unlock errors don't affect the write result
-	_ = file.Close()                                   // G104: close errors don't affect the write result
-	return err
+	recordEvent(store, EventQueued, msg)
+	return nil
 }
 
-// ReadAll reads all messages from a recipient's mailbox file.
+// ReadAll reads all messages from a recipient's mailbox.
 func ReadAll(repoRoot string, recipient string) ([]Message, error) {
-	// Build file path with path traversal protection (G304)
-	mailDir := filepath.Join(repoRoot, MailDir)
-	filePath, err := safePath(mailDir, recipient+".jsonl")
-	if err != nil {
-		return nil, err
-	}
-
-	// Open file for reading
-	file, err := os.Open(filePath) // #nosec G304 - path validated by safePath
+	store, err := OpenStore(repoRoot)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []Message{}, nil
-		}
 		return nil, err
 	}
-	defer file.Close()
-
-	// Acquire shared lock
-	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_SH); err != nil {
-		return nil, err
-	}
-	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	return store.ReadAll(recipient)
+}
 
-	data, err := io.ReadAll(file)
+// FindUnread returns all unread messages for a recipient, highest priority first
+// and in FIFO order within each priority.
+// Messages under an unexpired lease are in-flight and not returned;
+// once the lease expires they are returned again.
+// Scheduled messages are not returned until their deliver_after time,
+// and expired messages are never returned.
+func FindUnread(repoRoot string, recipient string) ([]Message, error) {
+	store, err := OpenStore(repoRoot)
 	if err != nil {
 		return nil, err
 	}
-
-	var messages []Message
-	lines := strings.Split(string(data), "\n")
-
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		var msg Message
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			return nil, err
-		}
-		messages = append(messages, msg)
-	}
-
-	return messages, nil
-}
-
-// FindUnread returns all unread messages for a recipient in FIFO order.
-func FindUnread(repoRoot string, recipient string) ([]Message, error) {
-	messages, err := ReadAll(repoRoot, recipient)
+	messages, err := store.ReadPending(recipient)
 	if err != nil {
 		return nil, err
 	}
 
+	now := time.Now()
 	var unread []Message
 	for _, msg := range messages {
-		if !msg.ReadFlag {
+		if !msg.ReadFlag && !msg.InFlight(now) && !msg.Pending(now) && !msg.Expired(now) {
 			unread = append(unread, msg)
 		}
 	}
 
+	sortByPriority(unread)
 	return unread, nil
 }
 
-// writeAllLocked writes all messages to an already-locked file.
-// The caller is responsible for locking and unlocking.
-func writeAllLocked(file *os.File, messages []Message) error {
-	// Truncate the file
-	if err := file.Truncate(0); err != nil {
-		return err
-	}
-	if _, err := file.Seek(0, 0); err != nil {
-		return err
-	}
-
-	// Write each message as a JSON line
-	for _, msg := range messages {
-		data, err := json.Marshal(msg)
-		if err != nil {
-			return err
-		}
-		if _, err := file.Write(append(data, '\n')); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// WriteAll writes all messages to a recipient's mailbox file with locking.
+// WriteAll replaces all messages in a recipient's mailbox.
 func WriteAll(repoRoot string, recipient string, messages []Message) error {
-	// Ensure mail directory exists
-	if err := EnsureMailDir(repoRoot); err != nil {
-		return err
-	}
-
-	// Build file path with path traversal protection (G304)
-	mailDir := filepath.Join(repoRoot, MailDir)
-	filePath, err := safePath(mailDir, recipient+".jsonl")
-	if err != nil {
-		return err
-	}
-
-	// Open file for read/write
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0600) // #nosec G304 - path validated by safePath; G302 - restricted file permissions
+	store, err := OpenStore(repoRoot)
 	if err != nil {
 		return err
 	}
-
-	// Acquire exclusive lock
-	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
-		_ = file.Close() // G104: error intentionally ignored in cleanup path
-		return err
-	}
-
-	// Write messages
-	writeErr := writeAllLocked(file, messages)
-
-	// Unlock before close (correct order)
-	_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) // G104: unlock errors don't affect the write result
-	_ = file.Close()                                   // G104: close errors don't affect the write result
-	return writeErr
+	return store.WriteAll(recipient, messages)
 }
 
-// CleanOldMessages removes read messages older than the threshold from a mailbox file.
+// CleanOldMessages removes read messages older than the threshold from a mailbox.
 // Messages without CreatedAt (zero value) are skipped (not deleted).
 // Unread messages are NEVER deleted regardless of age.
 // Returns the number of messages removed.
 func CleanOldMessages(repoRoot string, recipient string, threshold time.Duration) (int, error) {
-	// Build file path with path traversal protection (G304)
-	mailDir := filepath.Join(repoRoot, MailDir)
-	filePath, err := safePath(mailDir, recipient+".jsonl")
+	store, err := OpenStore(repoRoot)
 	if err != nil {
 		return 0, err
 	}
 
-	// Open file for read/write
-	file, err := os.OpenFile(filePath, os.O_RDWR, 0600) // #nosec G304 - path validated by safePath; G302 - restricted file permissions
-	if err != nil {
-		if os.IsNotExist(err) {
-			return 0, nil // No mailbox file, nothing to clean
-		}
-		return 0, err
-	}
-
-	// Acquire exclusive lock for atomic read-modify-write
-	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
-		_ = file.Close() // G104: error intentionally ignored in cleanup path
-		return 0, err
-	}
-
-	// Read all messages while holding lock
-	data, err := os.ReadFile(filePath) // #nosec G304 - path validated by safePath
-	if err != nil {
-		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) // G104: error intentionally ignored in cleanup path
-		_ = file.Close()
-		return 0, err
-	}
-
-	var messages []Message
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		var msg Message
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) // G104: error intentionally ignored in cleanup path
-			_ = file.Close()
-			return 0, err
-		}
-		messages = append(messages, msg)
-	}
-
 	// Filter messages - keep if:
 	// 1. Unread (never delete unread messages)
 	// 2. Read AND has timestamp AND age <= threshold (recent read messages)
 	// Note: Read messages without timestamp are eligible for deletion
 	cutoff := time.Now().Add(-threshold)
-	var remaining []Message
-	for _, msg := range messages {
-		// Keep unread messages (NEVER delete unread)
-		if !msg.ReadFlag {
-			remaining = append(remaining, msg)
-			continue
-		}
+	removedCount := 0
+	err = store.Modify(recipient, func(messages []Message) ([]Message, bool, error) {
+		var remaining []Message
+		for _, msg := range messages {
+			// Keep unread messages (NEVER delete unread)
+			if !msg.ReadFlag {
+				remaining = append(remaining, msg)
+				continue
+			}
 
-		// Keep recent read messages (has timestamp and age <= threshold)
-		if !msg.CreatedAt.IsZero() && msg.CreatedAt.After(cutoff) {
-			remaining = append(remaining, msg)
+			// Keep recent read messages (has timestamp and age <= threshold)
+			if !msg.CreatedAt.IsZero() && msg.CreatedAt.After(cutoff) {
+				remaining = append(remaining, msg)
+			}
+			// Read messages without timestamp OR old read messages are removed
 		}
-		// Read messages without timestamp OR old read messages are removed
-	}
-
-	// Calculate removed count
-	removedCount := len(messages) - len(remaining)
 
-	// Only write if messages were actually removed
-	var writeErr error
-	if removedCount > 0 {
-		writeErr = writeAllLocked(file, remaining)
+		// Only write if messages were actually removed
+		removedCount = len(messages) - len(remaining)
+		return remaining, removedCount > 0, nil
+	})
+	if os.IsNotExist(err) {
+		return 0, nil // No mailbox, nothing to clean
 	}
-
-	// Unlock before close (correct order)
-	_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) // G104: unlock errors don't affect the write result
-	_ = file.Close()                                   // G104: close errors don't affect the write result
-	return removedCount, writeErr
+	if err != nil {
+		return 0, err
+	}
+	return removedCount, nil
 }
 
 // MarkAsRead marks a specific message as read in the recipient's mailbox.
-// This function is atomic - it holds a lock during the entire read-modify-write cycle.
+// This function is atomic - it holds a lock during the entire update.
+// The JSONL store appends a read record instead of rewriting the mailbox, so the
+// cost does not grow with mailbox history. Marking a message that is not in the
+// mailbox, or already read, is a no-op. The first read is recorded as a delivery
+// event and mails the sender a read receipt if they asked for one.
 func MarkAsRead(repoRoot string, recipient string, messageID string) error {
-	// Ensure mail directory exists
-	if err := EnsureMailDir(repoRoot); err != nil {
-		return err
-	}
-
-	// Build file path with path traversal protection (G304)
-	mailDir := filepath.Join(repoRoot, MailDir)
-	filePath, err := safePath(mailDir, recipient+".jsonl")
+	store, err := OpenStore(repoRoot)
 	if err != nil {
 		return err
 	}
-
-	// Open file for read/write
-	file, err := os.OpenFile(filePath, os.O_RDWR, 0600) // #nosec G304 - path validated by safePath; G302 - restricted file permissions
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil // No messages to mark
-		}
+	before, err := store.MarkRead(recipient, messageID)
+	if err != nil || before.ID == "" || before.ReadFlag {
 		return err
 	}
-
-	// Acquire exclusive lock for atomic read-modify-write
-	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
-		_ = file.Close() // G104: error intentionally ignored in cleanup path
-		return err
+	recordEvent(store, EventRead, before)
+	if before.Attempts == 0 {
+		sendReceipt(repoRoot, before) // A leased message got its receipt when it was leased
 	}
+	return nil
+}
 
-	// Read all messages while holding lock
-	data, err := os.ReadFile(filePath) // #nosec G304 - path validated by safePath
+// CompactMailboxes compacts every mailbox with at least minGarbage superseded
+// read/update records (pass 1 to compact every mailbox that has any).
+// Returns the number of mailboxes compacted.
+func CompactMailboxes(repoRoot string, minGarbage int) (int, error) {
+	store, err := OpenStore(repoRoot)
 	if err != nil {
-		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) // G104: error intentionally ignored in cleanup path
-		_ = file.Close()
-		return err
+		return 0, err
 	}
 
-	var messages []Message
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		var msg Message
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) // G104: error intentionally ignored in cleanup path
-			_ = file.Close()
-			return err
-		}
-		messages = append(messages, msg)
+	recipients, err := store.ListMailboxes()
+	if err != nil {
+		return 0, err
 	}
 
-	// Find and update the message
-	for i := range messages {
-		if messages[i].ID == messageID {
-			messages[i].ReadFlag = true
-			break
+	compacted := 0
+	for _, recipient := range recipients {
+		done, err := store.Compact(recipient, minGarbage)
+		if err != nil {
+			return compacted, err
+		}
+		if done {
+			compacted++
 		}
 	}
 
-	// Write back while still holding lock
-	writeErr := writeAllLocked(file, messages)
-
-	// Unlock before close (correct order)
-	_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) // G104: unlock errors don't affect the write result
-	_ = file.Close()                                   // G104: close errors don't affect the write result
-	return writeErr
+	return compacted, nil
 }
 
-// RemoveEmptyMailboxes removes mailbox files that contain zero messages.
-// Returns the number of mailbox files removed.
+// RemoveEmptyMailboxes removes mailboxes that contain zero messages.
+// Returns the number of mailboxes removed.
 func RemoveEmptyMailboxes(repoRoot string) (int, error) {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return 0, err
+	}
+
 	// List all mailbox recipients
-	recipients, err := ListMailboxRecipients(repoRoot)
+	recipients, err := store.ListMailboxes()
 	if err != nil {
 		return 0, err
 	}
 
-	mailDir := filepath.Join(repoRoot, MailDir)
 	removedCount := 0
-
 	for _, recipient := range recipients {
-		// Build file path with path traversal protection (G304)
-		filePath, err := safePath(mailDir, recipient+".jsonl")
-		if err != nil {
-			continue // Skip invalid paths
-		}
-
-		// Check if the file is empty (0 bytes or 0 messages)
-		info, err := os.Stat(filePath)
+		removed, err := store.RemoveEmptyMailbox(recipient)
 		if err != nil {
-			if os.IsNotExist(err) {
-				continue // Already gone
-			}
 			return removedCount, err
 		}
-
-		// If file size is 0, it's definitely empty
-		if info.Size() == 0 {
-			if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-				return removedCount, err
-			}
-			removedCount++
-			continue
-		}
-
-		// If file has content, check if it has any messages
-		// (could be empty after message cleanup left just whitespace/empty lines)
-		messages, err := ReadAll(repoRoot, recipient)
-		if err != nil {
-			continue // Skip files we can't read
-		}
-
-		if len(messages) == 0 {
-			if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-				return removedCount, err
-			}
+		if removed {
 			removedCount++
 		}
 	}
@@ -506,42 +325,20 @@ func CountOldMessages(repoRoot string, recipient string, threshold time.Duration
 	return count, nil
 }
 
-// CountEmptyMailboxes counts mailbox files that contain zero messages without removing them.
+// CountEmptyMailboxes counts mailboxes that contain zero messages without removing them.
 // This is used for dry-run mode.
-// Returns the count of mailbox files that would be removed.
+// Returns the count of mailboxes that would be removed.
 func CountEmptyMailboxes(repoRoot string) (int, error) {
 	recipients, err := ListMailboxRecipients(repoRoot)
 	if err != nil {
 		return 0, err
 	}
 
-	mailDir := filepath.Join(repoRoot, MailDir)
 	count := 0
-
 	for _, recipient := range recipients {
-		filePath, err := safePath(mailDir, recipient+".jsonl")
-		if err != nil {
-			continue
-		}
-
-		info, err := os.Stat(filePath)
-		if err != nil {
-			if os.IsNotExist(err) {
-				continue
-			}
-			return count, err
-		}
-
-		// If file size is 0, it's definitely empty
-		if info.Size() == 0 {
-			count++
-			continue
-		}
-
-		// If file has content, check if it has any messages
 		messages, err := ReadAll(repoRoot, recipient)
 		if err != nil {
-			continue
+			continue // Skip mailboxes we can't read
 		}
 
 		if len(messages) == 0 {
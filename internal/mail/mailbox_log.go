@@ -0,0 +1,188 @@
+package mail
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// A JSONL mailbox is an append-only log. A line without "op" is a message;
+// read and update records appended later change an earlier message by ID,
+// so marking a message read never rewrites the file. Compaction folds the
+// log back into one line per message.
+const (
+	opRead   = "read"   // Tombstone: the message with this ID was read
+	opUpdate = "update" // The message with this ID was replaced by the record's message
+)
+
+// CompactThreshold is the number of read/update records a mailbox log may
+// accumulate before the mailman compacts it.
+const CompactThreshold = 1000
+
+// logRecord is one line of a mailbox log.
+type logRecord struct {
+	Op string `json:"op,omitempty"`
+	Message
+}
+
+// readRecord is the tombstone appended when a message is read.
+type readRecord struct {
+	Op string `json:"op"`
+	ID string `json:"id"`
+}
+
+// mailboxCursor is kept next to a mailbox log in <recipient>.cursor.
+// It lets readers skip the part of the log where every message is already read.
+type mailboxCursor struct {
+	Offset  int64 `json:"offset"`  // Every message stored before this byte offset is read
+	Garbage int   `json:"garbage"` // Read/update records appended since the last compaction
+}
+
+// parseRecords decodes mailbox log data, skipping blank lines.
+// base is the file offset of data; the offset of each record is returned alongside it.
+// Lines that cannot be decoded are skipped and returned as bad records.
+func parseRecords(data []byte, base int64) ([]logRecord, []int64, []BadRecord) {
+	var records []logRecord
+	var offsets []int64
+	var bad []BadRecord
+	offset := base
+	for _, line := range strings.Split(string(data), "\n") {
+		start := offset
+		offset += int64(len(line)) + 1
+		if line == "" {
+			continue
+		}
+		var record logRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			bad = append(bad, BadRecord{Offset: start, Reason: err.Error(), Line: line})
+			continue
+		}
+		switch record.Op {
+		case "", opRead, opUpdate:
+		default:
+			bad = append(bad, BadRecord{Offset: start, Reason: fmt.Sprintf("unknown mailbox record op %q", record.Op), Line: line})
+			continue
+		}
+		records = append(records, record)
+		offsets = append(offsets, start)
+	}
+	return records, offsets, bad
+}
+
+// foldRecords applies read and update records to the messages they refer to.
+// Messages keep the order in which they were appended; origins[i] is the index
+// of the record that stored messages[i]. Records for unknown IDs are ignored
+// (their message lies before the part of the log that was read).
+func foldRecords(records []logRecord) (messages []Message, origins []int) {
+	index := make(map[string]int)
+	for i, record := range records {
+		switch record.Op {
+		case "":
+			if _, seen := index[record.ID]; !seen {
+				index[record.ID] = len(messages)
+			}
+			messages = append(messages, record.Message)
+			origins = append(origins, i)
+		case opRead:
+			if j, ok := index[record.ID]; ok {
+				messages[j].ReadFlag = true
+			}
+		case opUpdate:
+			if j, ok := index[record.ID]; ok {
+				messages[j] = record.Message
+			}
+		}
+	}
+	return messages, origins
+}
+
+// hasLogUpdates reports whether any record is a read or update record, i.e. the log can be compacted.
+func hasLogUpdates(records []logRecord) bool {
+	for _, record := range records {
+		if record.Op != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// readCursor loads a mailbox cursor. A missing, unreadable or inconsistent cursor
+// yields offset 0, so the whole log is read.
+// file is the open mailbox log, used to check the offset lands on a line boundary.
+func readCursor(path string, file *os.File) mailboxCursor {
+	data, err := os.ReadFile(path) // #nosec G304 - callers validate path with safePath
+	if err != nil {
+		return mailboxCursor{}
+	}
+	var cursor mailboxCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Offset < 0 {
+		return mailboxCursor{}
+	}
+	if cursor.Offset == 0 {
+		return cursor
+	}
+
+	// The cursor must point just past a newline of the current log
+	info, err := file.Stat()
+	if err != nil || cursor.Offset > info.Size() {
+		return mailboxCursor{}
+	}
+	prev := make([]byte, 1)
+	if _, err := file.ReadAt(prev, cursor.Offset-1); err != nil || prev[0] != '\n' {
+		return mailboxCursor{}
+	}
+	return cursor
+}
+
+// writeCursor stores a mailbox cursor. The caller must hold the mailbox's exclusive lock.
+func writeCursor(path string, cursor mailboxCursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600) // #nosec G306 - restricted file permissions
+}
+
+// removeCursor deletes a mailbox cursor, e.g. before the log is rewritten.
+// The caller must hold the mailbox's exclusive lock.
+func removeCursor(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// readRecordsFrom reads and decodes the mailbox log from offset to the end.
+func readRecordsFrom(file *os.File, offset int64) ([]logRecord, []int64, []BadRecord, error) {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, nil, nil, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	records, offsets, bad := parseRecords(data, offset)
+	return records, offsets, bad, nil
+}
+
+// terminateLine appends a newline if the file doesn't end with one, so a line
+// torn by a crash stays on its own line instead of swallowing the next record.
+// The file must be open for reading and writing at its end, and the caller
+// must hold its exclusive lock.
+func terminateLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	_, err = file.Write([]byte{'\n'})
+	return err
+}
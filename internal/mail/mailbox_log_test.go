@@ -0,0 +1,282 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readCursorFile(t *testing.T, repoRoot, recipient string) (mailboxCursor, bool) {
+	t.Helper()
+	file, err := os.Open(filepath.Join(repoRoot, MailDir, recipient+".jsonl"))
+	if err != nil {
+		t.Fatalf("Open mailbox failed: %v", err)
+	}
+	defer file.Close()
+	cursorPath := filepath.Join(repoRoot, MailDir, recipient+".cursor")
+	if _, err := os.Stat(cursorPath); os.IsNotExist(err) {
+		return mailboxCursor{}, false
+	}
+	return readCursor(cursorPath, file), true
+}
+
+func TestMarkAsRead_AppendsTombstoneWithoutRewriting(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "a", From: "agent-1", To: "agent-2", Message: "one"})
+	_ = Append(tmpDir, Message{ID: "b", From: "agent-1", To: "agent-2", Message: "two"})
+
+	filePath := filepath.Join(tmpDir, MailDir, "agent-2.jsonl")
+	before, _ := os.ReadFile(filePath)
+
+	if err := MarkAsRead(tmpDir, "agent-2", "a"); err != nil {
+		t.Fatalf("MarkAsRead failed: %v", err)
+	}
+
+	after, _ := os.ReadFile(filePath)
+	if !strings.HasPrefix(string(after), string(before)) {
+		t.Fatalf("Mailbox was rewritten instead of appended to:\n%s", after)
+	}
+	if tail := strings.TrimPrefix(string(after), string(before)); tail != `{"op":"read","id":"a"}`+"\n" {
+		t.Errorf("Expected a single read record, got %q", tail)
+	}
+
+	messages, _ := ReadAll(tmpDir, "agent-2")
+	if len(messages) != 2 || !messages[0].ReadFlag || messages[1].ReadFlag {
+		t.Errorf("Expected a read and b unread, got %+v", messages)
+	}
+
+	// The cursor now skips the read message
+	cursor, ok := readCursorFile(t, tmpDir, "agent-2")
+	if !ok || cursor.Garbage != 1 {
+		t.Fatalf("Expected cursor with 1 garbage record, got %+v (exists: %v)", cursor, ok)
+	}
+	lines := strings.SplitAfter(string(before), "\n")
+	if cursor.Offset != int64(len(lines[0])) {
+		t.Errorf("Expected cursor at b (offset %d), got %d", len(lines[0]), cursor.Offset)
+	}
+}
+
+func TestMarkAsRead_CursorWaitsForOldestUnread(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "normal", From: "agent-1", To: "agent-2", Message: "later"})
+	_ = Append(tmpDir, Message{ID: "urgent", From: "agent-1", To: "agent-2", Message: "now", Priority: PriorityUrgent})
+
+	// Priority order reads the second message first; the cursor must not skip the first
+	if err := MarkAsRead(tmpDir, "agent-2", "urgent"); err != nil {
+		t.Fatalf("MarkAsRead failed: %v", err)
+	}
+	if cursor, _ := readCursorFile(t, tmpDir, "agent-2"); cursor.Offset != 0 {
+		t.Errorf("Expected cursor to stay at the unread first message, got offset %d", cursor.Offset)
+	}
+	unread, _ := FindUnread(tmpDir, "agent-2")
+	if len(unread) != 1 || unread[0].ID != "normal" {
+		t.Fatalf("Expected normal still unread, got %+v", unread)
+	}
+
+	if err := MarkAsRead(tmpDir, "agent-2", "normal"); err != nil {
+		t.Fatalf("MarkAsRead failed: %v", err)
+	}
+	info, _ := os.Stat(filepath.Join(tmpDir, MailDir, "agent-2.jsonl"))
+	if cursor, _ := readCursorFile(t, tmpDir, "agent-2"); cursor.Offset != info.Size() {
+		t.Errorf("Expected cursor at end of log (%d), got %d", info.Size(), cursor.Offset)
+	}
+
+	// New mail after the cursor is found; old mail is still readable in full
+	_ = Append(tmpDir, Message{ID: "new", From: "agent-1", To: "agent-2", Message: "hi"})
+	unread, _ = FindUnread(tmpDir, "agent-2")
+	if len(unread) != 1 || unread[0].ID != "new" {
+		t.Errorf("Expected only new unread, got %+v", unread)
+	}
+	if all, _ := ReadAll(tmpDir, "agent-2"); len(all) != 3 {
+		t.Errorf("Expected 3 messages in history, got %d", len(all))
+	}
+}
+
+func TestLeaseAck_AppendUpdateRecords(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "a", From: "agent-1", To: "agent-2", Message: "one"})
+	_ = MarkAsRead(tmpDir, "agent-2", "a") // Moves the cursor past a
+	_ = Append(tmpDir, Message{ID: "b", From: "agent-1", To: "agent-2", Message: "two"})
+
+	if _, err := Lease(tmpDir, "agent-2", "b", time.Minute); err != nil {
+		t.Fatalf("Lease failed: %v", err)
+	}
+	// Updating a message before the cursor falls back to the whole log
+	if _, err := Lease(tmpDir, "agent-2", "a", time.Minute); err != nil {
+		t.Fatalf("Lease of read message failed: %v", err)
+	}
+	if err := Ack(tmpDir, "agent-2", "b"); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+
+	messages, _ := ReadAll(tmpDir, "agent-2")
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %+v", messages)
+	}
+	if !messages[1].ReadFlag || !messages[1].LeaseUntil.IsZero() || messages[1].Attempts != 1 {
+		t.Errorf("Expected b acked after one attempt, got %+v", messages[1])
+	}
+	if !messages[0].ReadFlag || messages[0].Attempts != 1 {
+		t.Errorf("Expected a still read with one attempt, got %+v", messages[0])
+	}
+	if _, err := Lease(tmpDir, "agent-2", "missing", time.Minute); err != ErrMessageNotFound {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+}
+
+func TestRecordNotification_AppendsUpdatesAndKeepsCursor(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "a", From: "agent-1", To: "agent-2", Message: "one"})
+	_ = Append(tmpDir, Message{ID: "b", From: "agent-1", To: "agent-2", Message: "two"})
+	_ = Append(tmpDir, Message{ID: "c", From: "agent-1", To: "agent-2", Message: "three"})
+	if err := MarkAsRead(tmpDir, "agent-2", "a"); err != nil {
+		t.Fatalf("MarkAsRead failed: %v", err)
+	}
+	cursorBefore, _ := readCursorFile(t, tmpDir, "agent-2")
+
+	filePath := filepath.Join(tmpDir, MailDir, "agent-2.jsonl")
+	before, _ := os.ReadFile(filePath)
+	if err := RecordNotification(tmpDir, "agent-2"); err != nil {
+		t.Fatalf("RecordNotification failed: %v", err)
+	}
+
+	after, _ := os.ReadFile(filePath)
+	if !strings.HasPrefix(string(after), string(before)) {
+		t.Fatalf("Mailbox was rewritten instead of appended to:\n%s", after)
+	}
+	if tail := strings.TrimPrefix(string(after), string(before)); strings.Count(tail, `"op":"update"`) != 2 {
+		t.Errorf("Expected an update record per unread message, got %q", tail)
+	}
+	cursor, ok := readCursorFile(t, tmpDir, "agent-2")
+	if !ok || cursor.Offset != cursorBefore.Offset || cursor.Garbage != cursorBefore.Garbage+2 {
+		t.Errorf("Expected the cursor to stay at %d with 2 more garbage records, got %+v (exists: %v)", cursorBefore.Offset, cursor, ok)
+	}
+
+	messages, _ := ReadAll(tmpDir, "agent-2")
+	for _, msg := range messages {
+		if want := map[string]int{"a": 0, "b": 1, "c": 1}[msg.ID]; msg.Notified != want {
+			t.Errorf("Message %s: expected %d notifications, got %d", msg.ID, want, msg.Notified)
+		}
+	}
+}
+
+func TestReadCursor_InvalidCursorReadsWholeLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "a", From: "agent-1", To: "agent-2", Message: "one"})
+	cursorPath := filepath.Join(tmpDir, MailDir, "agent-2.cursor")
+
+	for _, content := range []string{`{"offset":5}`, `{"offset":99999}`, `garbage`} {
+		if err := os.WriteFile(cursorPath, []byte(content), 0600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		unread, err := FindUnread(tmpDir, "agent-2")
+		if err != nil || len(unread) != 1 {
+			t.Errorf("Cursor %s: expected the unread message, got %+v, %v", content, unread, err)
+		}
+	}
+}
+
+func TestRewrite_DropsCursor(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "a", From: "agent-1", To: "agent-2", Message: "one"})
+	_ = MarkAsRead(tmpDir, "agent-2", "a")
+	if _, ok := readCursorFile(t, tmpDir, "agent-2"); !ok {
+		t.Fatal("Expected a cursor after MarkAsRead")
+	}
+
+	// A rewrite moves every line, so the cursor must go
+	if err := WriteAll(tmpDir, "agent-2", []Message{{ID: "x", To: "agent-2"}, {ID: "y", To: "agent-2"}}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+	if _, ok := readCursorFile(t, tmpDir, "agent-2"); ok {
+		t.Error("Expected WriteAll to remove the cursor")
+	}
+	unread, _ := FindUnread(tmpDir, "agent-2")
+	if len(unread) != 2 {
+		t.Errorf("Expected both rewritten messages unread, got %+v", unread)
+	}
+}
+
+func TestCompactMailboxes(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("m%d", i)
+		_ = Append(tmpDir, Message{ID: id, From: "agent-1", To: "agent-2", Message: id})
+		_ = MarkAsRead(tmpDir, "agent-2", id)
+	}
+	_ = Append(tmpDir, Message{ID: "clean", From: "agent-1", To: "agent-3", Message: "x"})
+
+	// Below the threshold nothing happens
+	compacted, err := CompactMailboxes(tmpDir, 10)
+	if err != nil || compacted != 0 {
+		t.Fatalf("Expected no compaction below threshold, got %d, %v", compacted, err)
+	}
+
+	compacted, err = CompactMailboxes(tmpDir, 1)
+	if err != nil || compacted != 1 {
+		t.Fatalf("Expected agent-2 compacted, got %d, %v", compacted, err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tmpDir, MailDir, "agent-2.jsonl"))
+	if strings.Contains(string(data), `"op"`) || strings.Count(string(data), "\n") != 3 {
+		t.Errorf("Expected 3 plain message lines after compaction, got:\n%s", data)
+	}
+	messages, _ := ReadAll(tmpDir, "agent-2")
+	for _, msg := range messages {
+		if !msg.ReadFlag {
+			t.Errorf("Compaction lost read state of %s", msg.ID)
+		}
+	}
+
+	// Compacting again is a no-op
+	if compacted, _ := CompactMailboxes(tmpDir, 1); compacted != 0 {
+		t.Errorf("Expected nothing left to compact, got %d", compacted)
+	}
+}
+
+// benchmarkReceive measures one receive (append, find unread, mark read) on a
+// mailbox that already holds size read messages.
+func benchmarkReceive(b *testing.B, size int) {
+	tmpDir := b.TempDir()
+	history := make([]Message, size)
+	for i := range history {
+		history[i] = Message{
+			ID:        fmt.Sprintf("h%07d", i),
+			From:      "agent-1",
+			To:        "agent-2",
+			Message:   "an old message that was read long ago",
+			ReadFlag:  true,
+			CreatedAt: time.Now(),
+		}
+	}
+	if err := WriteAll(tmpDir, "agent-2", history); err != nil {
+		b.Fatalf("WriteAll failed: %v", err)
+	}
+
+	receive := func(id string) {
+		if err := Append(tmpDir, Message{ID: id, From: "agent-1", To: "agent-2", Message: "hello"}); err != nil {
+			b.Fatalf("Append failed: %v", err)
+		}
+		unread, err := FindUnread(tmpDir, "agent-2")
+		if err != nil || len(unread) != 1 {
+			b.Fatalf("FindUnread: expected 1 message, got %d (%v)", len(unread), err)
+		}
+		if err := MarkAsRead(tmpDir, "agent-2", unread[0].ID); err != nil {
+			b.Fatalf("MarkAsRead failed: %v", err)
+		}
+	}
+
+	// The first receive after a rewrite scans the whole log once to place the cursor
+	receive("warmup")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		receive(fmt.Sprintf("n%07d", i))
+	}
+}
+
+func BenchmarkReceive_1k(b *testing.B)   { benchmarkReceive(b, 1000) }
+func BenchmarkReceive_10k(b *testing.B)  { benchmarkReceive(b, 10000) }
+func BenchmarkReceive_100k(b *testing.B) { benchmarkReceive(b, 100000) }
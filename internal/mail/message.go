@@ -9,12 +9,39 @@ import (
 // Message represents a communication between agents.
 // T008: Message struct with JSON tags
 type Message struct {
-	ID        string    `json:"id"`                   // Short unique identifier (8 chars, base62)
-	From      string    `json:"from"`                 // Sender tmux window name
-	To        string    `json:"to"`                   // Recipient tmux window name
-	Message   string    `json:"message"`              // Body text
-	ReadFlag  bool      `json:"read_flag"`            // Read status (default: false)
-	CreatedAt time.Time `json:"created_at,omitempty"` // Timestamp for age-based cleanup
+	ID             string            `json:"id"`                        // Short unique identifier (8 chars, base62)
+	From           string            `json:"from"`                      // Sender tmux window name
+	To             string            `json:"to"`                        // Recipient tmux window name
+	Message        string            `json:"message"`                   // Body text
+	Subject        string            `json:"subject,omitempty"`         // Optional one-line summary for triage
+	Headers        map[string]string `json:"headers,omitempty"`         // Optional free-form metadata, e.g. task=123
+	ReadFlag       bool              `json:"read_flag"`                 // Read status (default: false)
+	CreatedAt      time.Time         `json:"created_at,omitempty"`      // Timestamp for age-based cleanup
+	InReplyTo      string            `json:"in_reply_to,omitempty"`     // ID of the message this one replies to
+	ThreadID       string            `json:"thread_id,omitempty"`       // ID of the first message in the conversation
+	BroadcastID    string            `json:"broadcast_id,omitempty"`    // Shared by all copies of a group send
+	ExpectsReply   bool              `json:"expects_reply,omitempty"`   // Sender is blocked waiting for a reply (ask)
+	Priority       string            `json:"priority,omitempty"`        // low, normal, high or urgent (empty means normal)
+	DeliverAfter   time.Time         `json:"deliver_after,omitempty"`   // Scheduled delivery time (zero means immediately)
+	ExpiresAt      time.Time         `json:"expires_at,omitempty"`      // Unread messages are not delivered after this time (zero means never)
+	NotifyExpired  bool              `json:"notify_expired,omitempty"`  // Send the sender a system message if it expires unread
+	Receipt        bool              `json:"receipt,omitempty"`         // Send the sender a system message when it is read
+	IdempotencyKey string            `json:"idempotency_key,omitempty"` // Client-supplied dedupe key; a retry with the same key returns this message
+	LeaseUntil     time.Time         `json:"lease_until,omitempty"`     // In-flight deadline for leased receives (zero means not leased)
+	Attempts       int               `json:"attempts,omitempty"`        // Number of times the message was delivered under a lease
+	Notified       int               `json:"notified,omitempty"`        // Number of mailman notifications sent while it was unread
+	DeadReason     string            `json:"dead_reason,omitempty"`     // Why the message was moved to the dead-letter mailbox
+	OriginRepo     string            `json:"origin_repo,omitempty"`     // Root of the sender's repository, for a message delivered from another repository
+	OriginMailbox  string            `json:"origin_mailbox,omitempty"`  // Sender's mailbox in OriginRepo, where receipts and expiry notices go
+}
+
+// ThreadRoot returns the ID of the conversation this message belongs to.
+// Messages without a ThreadID start their own thread, so their own ID is returned.
+func (m Message) ThreadRoot() string {
+	if m.ThreadID != "" {
+		return m.ThreadID
+	}
+	return m.ID
 }
 
 // base62 character set for ID generation
@@ -2,6 +2,7 @@ package mail
 
 import (
 	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -92,8 +93,8 @@ func TestMessage_JSONRoundTrip(t *testing.T) {
 		t.Fatalf("Failed to unmarshal: %v", err)
 	}
 
-	// Compare
-	if original != restored {
+	// Compare (DeepEqual: Headers makes Message non-comparable)
+	if !reflect.DeepEqual(original, restored) {
 		t.Errorf("Round-trip failed: original %+v != restored %+v", original, restored)
 	}
 }
@@ -118,7 +119,7 @@ func TestMessage_JSONWithSpecialCharacters(t *testing.T) {
 		t.Fatalf("Failed to unmarshal message with special chars: %v", err)
 	}
 
-	if msg != restored {
+	if !reflect.DeepEqual(msg, restored) {
 		t.Errorf("Round-trip with special chars failed: original %+v != restored %+v", msg, restored)
 	}
 }
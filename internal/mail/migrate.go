@@ -0,0 +1,309 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupDir holds the copies of the store that migrate takes before upgrading it
+const BackupDir = ".agentmail/backups"
+
+// Migration upgrades a store from version From to From+1.
+type Migration struct {
+	From        int
+	Description string
+	// Apply performs the upgrade and returns one line per change. With dryRun set
+	// it only returns the changes it would make.
+	Apply func(repoRoot string, dryRun bool) ([]string, error)
+}
+
+// migrations lists every upgrade step in order. Adding a store format means
+// bumping CurrentVersion and appending the step from the previous version.
+var migrations = []Migration{
+	{From: 0, Description: "move mailboxes and recipient state from .git/ to .agentmail/", Apply: migrateLegacyLayout},
+}
+
+// MigrationStep is a migration run (or planned) by Migrate.
+type MigrationStep struct {
+	Migration
+	Changes []string // One line per change made (or planned)
+}
+
+// MigrationResult describes a (planned) run of Migrate.
+type MigrationResult struct {
+	From   int             // Store version before migrating
+	To     int             // Store version after migrating
+	Steps  []MigrationStep // Steps run (or planned) in order; on failure the last one failed
+	Backup string          // Backup directory, relative to the repository root ("" for dry runs and no-ops)
+}
+
+// Migrate upgrades the store of a repository to CurrentVersion. Before the first
+// step the store (and any legacy data) is copied to .agentmail/backups/. With dryRun
+// set nothing is written and the result lists the planned changes.
+// Returns an error wrapping ErrStoreTooNew if the store is newer than this build.
+func Migrate(repoRoot string, dryRun bool) (MigrationResult, error) {
+	version, err := ReadVersion(repoRoot)
+	if err != nil {
+		return MigrationResult{}, err
+	}
+	result := MigrationResult{From: version, To: version}
+	if version > CurrentVersion {
+		return result, fmt.Errorf("%w (store version %d, supported %d)", ErrStoreTooNew, version, CurrentVersion)
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if m.From >= version {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		return result, nil
+	}
+
+	if !dryRun {
+		backup, err := backupStore(repoRoot, version)
+		if err != nil {
+			return result, fmt.Errorf("backup failed: %w", err)
+		}
+		result.Backup = backup
+	}
+
+	for _, m := range pending {
+		changes, err := m.Apply(repoRoot, dryRun)
+		result.Steps = append(result.Steps, MigrationStep{Migration: m, Changes: changes})
+		if err != nil {
+			return result, fmt.Errorf("migration from version %d failed: %w", m.From, err)
+		}
+		result.To = m.From + 1
+		if !dryRun {
+			if err := ensureRootDir(repoRoot); err != nil {
+				return result, err
+			}
+			if err := writeVersion(repoRoot, result.To); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// backupStore copies .agentmail/ (without earlier backups) and any legacy data to
+// .agentmail/backups/v<version>-<timestamp>/ and returns that directory relative to repoRoot.
+func backupStore(repoRoot string, version int) (string, error) {
+	name := fmt.Sprintf("v%d-%s", version, time.Now().UTC().Format("20060102T150405Z"))
+	rel := filepath.Join(BackupDir, name)
+	dest := filepath.Join(repoRoot, rel)
+	if err := os.MkdirAll(dest, 0750); err != nil { // G301: restricted directory permissions
+		return "", err
+	}
+
+	skip := filepath.Join(repoRoot, BackupDir)
+	sources := []struct{ from, to string }{
+		{filepath.Join(repoRoot, RootDir), filepath.Join(dest, "agentmail")},
+		{filepath.Join(repoRoot, legacyMailDir), filepath.Join(dest, "git", "mail")},
+		{filepath.Join(repoRoot, legacyRecipientsFile), filepath.Join(dest, "git", filepath.Base(legacyRecipientsFile))},
+	}
+	for _, src := range sources {
+		if err := copyTree(src.from, src.to, skip); err != nil {
+			return "", err
+		}
+	}
+	return rel, nil
+}
+
+// copyTree copies a file or directory tree, skipping the path skip. A missing source is ignored.
+func copyTree(from, to, skip string) error {
+	return filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == from {
+				return nil
+			}
+			return err
+		}
+		if path == skip {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0750) // G301: restricted directory permissions
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies a regular file with restricted permissions.
+func copyFile(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0750); err != nil { // G301: restricted directory permissions
+		return err
+	}
+	src, err := os.Open(from) // #nosec G304 - paths come from walking the store
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) // #nosec G304 - path inside the backup directory
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close() // G104: the copy error is reported
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		_ = dst.Close() // G104: the sync error is reported
+		return err
+	}
+	return dst.Close()
+}
+
+// migrateLegacyLayout moves version 0 data into the store: the messages of each
+// .git/mail/<recipient>.jsonl are appended to the recipient's mailbox (skipping IDs
+// it already holds), and recipient state from .git/mail-recipients.jsonl is added
+// for recipients the store doesn't know yet. The legacy files are then removed.
+func migrateLegacyLayout(repoRoot string, dryRun bool) ([]string, error) {
+	var changes []string
+
+	files, err := filepath.Glob(filepath.Join(repoRoot, legacyMailDir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var store Store
+	if !dryRun {
+		if store, err = OpenStore(repoRoot); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range files {
+		recipient := strings.TrimSuffix(filepath.Base(file), ".jsonl")
+		data, err := os.ReadFile(file) // #nosec G304 - path from globbing the legacy directory
+		if err != nil {
+			return changes, err
+		}
+		legacy, bad := parseMessages(data)
+
+		existing, err := ReadAll(repoRoot, recipient)
+		if err != nil {
+			return changes, err
+		}
+		known := make(map[string]bool, len(existing))
+		for _, msg := range existing {
+			known[msg.ID] = true
+		}
+
+		moved := 0
+		for _, msg := range legacy {
+			if msg.ID != "" && known[msg.ID] {
+				continue
+			}
+			msg.To = recipient
+			if !dryRun {
+				if err := store.Append(msg); err != nil {
+					return changes, err
+				}
+			}
+			moved++
+		}
+		if !dryRun {
+			if err := quarantine(repoRoot, recipient, inFile(bad, repoRoot, file)); err != nil {
+				return changes, err
+			}
+			if err := os.Remove(file); err != nil {
+				return changes, err
+			}
+		}
+		change := fmt.Sprintf("%s: %d message(s) -> mailbox %s", relPath(repoRoot, file), moved, recipient)
+		if len(bad) > 0 {
+			change += fmt.Sprintf(" (%d corrupt line(s) quarantined)", len(bad))
+		}
+		changes = append(changes, change)
+	}
+
+	recipientsPath := filepath.Join(repoRoot, legacyRecipientsFile)
+	data, err := os.ReadFile(recipientsPath) // #nosec G304 - legacyRecipientsFile is a constant
+	if err != nil && !os.IsNotExist(err) {
+		return changes, err
+	}
+	if err == nil {
+		legacy, bad := parseRecipients(data)
+		added := 0
+		if dryRun {
+			current, err := ReadAllRecipients(repoRoot)
+			if err != nil {
+				return changes, err
+			}
+			added = len(newRecipients(current, legacy))
+		} else {
+			err := store.ModifyRecipients(func(current []RecipientState) ([]RecipientState, bool, error) {
+				missing := newRecipients(current, legacy)
+				added = len(missing)
+				return append(current, missing...), added > 0, nil
+			})
+			if err != nil {
+				return changes, err
+			}
+			if err := quarantine(repoRoot, recipientsQuarantine, inFile(bad, repoRoot, recipientsPath)); err != nil {
+				return changes, err
+			}
+			if err := os.Remove(recipientsPath); err != nil {
+				return changes, err
+			}
+		}
+		changes = append(changes, fmt.Sprintf("%s: %d recipient state(s) added", legacyRecipientsFile, added))
+	}
+
+	if !dryRun {
+		// Drops .git/mail/ once it is empty; a leftover mailman.pid keeps it
+		_ = os.Remove(filepath.Join(repoRoot, legacyMailDir)) // G104: best-effort cleanup
+	}
+	return changes, nil
+}
+
+// newRecipients returns the legacy recipient states whose recipient is not in current.
+func newRecipients(current, legacy []RecipientState) []RecipientState {
+	known := make(map[string]bool, len(current))
+	for _, state := range current {
+		known[state.Recipient] = true
+	}
+	var missing []RecipientState
+	for _, state := range legacy {
+		if state.Recipient != "" && !known[state.Recipient] {
+			known[state.Recipient] = true
+			missing = append(missing, state)
+		}
+	}
+	return missing
+}
+
+// inFile sets the File of bad records read from path.
+func inFile(bad []BadRecord, repoRoot, path string) []BadRecord {
+	for i := range bad {
+		bad[i].File = relPath(repoRoot, path)
+	}
+	return bad
+}
+
+// relPath returns path relative to the repository root, or path itself if that fails.
+func relPath(repoRoot, path string) string {
+	rel, err := filepath.Rel(repoRoot, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,135 @@
+package mail
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLegacyMailbox(t *testing.T, repoRoot, recipient string, lines ...string) {
+	t.Helper()
+	dir := filepath.Join(repoRoot, legacyMailDir)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	data := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(dir, recipient+".jsonl"), []byte(data), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func writeLegacyRecipients(t *testing.T, repoRoot string, lines ...string) {
+	t.Helper()
+	_ = os.MkdirAll(filepath.Join(repoRoot, ".git"), 0750)
+	data := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(repoRoot, legacyRecipientsFile), []byte(data), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestMigrate_DryRunChangesNothing(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeLegacyMailbox(t, tmpDir, "agent-2", `{"id":"a","from":"agent-1","message":"one"}`)
+	writeLegacyRecipients(t, tmpDir, `{"recipient":"agent-2","status":"ready"}`)
+
+	result, err := Migrate(tmpDir, true)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if result.From != 0 || result.To != 1 || len(result.Steps) != 1 || result.Backup != "" {
+		t.Fatalf("Unexpected dry run result: %+v", result)
+	}
+	changes := result.Steps[0].Changes
+	if len(changes) != 2 || changes[0] != ".git/mail/agent-2.jsonl: 1 message(s) -> mailbox agent-2" ||
+		changes[1] != ".git/mail-recipients.jsonl: 1 recipient state(s) added" {
+		t.Errorf("Unexpected planned changes: %q", changes)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, RootDir)); !os.IsNotExist(err) {
+		t.Errorf("Expected no .agentmail/ after a dry run, got %v", err)
+	}
+	if version, _ := ReadVersion(tmpDir); version != 0 {
+		t.Errorf("Expected version 0 after a dry run, got %d", version)
+	}
+}
+
+func TestMigrate_LegacyLayout(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeLegacyMailbox(t, tmpDir, "agent-2",
+		`{"id":"a","from":"agent-1","message":"one"}`,
+		`{torn`,
+		`{"id":"b","from":"agent-1","message":"two","read_flag":true}`)
+	writeLegacyRecipients(t, tmpDir,
+		`{"recipient":"agent-2","status":"ready"}`,
+		`{"recipient":"agent-3","status":"work"}`)
+
+	// Written after the switch to .agentmail/: a is already there, agent-2 has state
+	_ = Append(tmpDir, Message{ID: "a", From: "agent-1", To: "agent-2", Message: "one"})
+	_ = WriteAllRecipients(tmpDir, []RecipientState{{Recipient: "agent-2", Status: StatusWork}})
+
+	result, err := Migrate(tmpDir, false)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if result.To != CurrentVersion || !strings.HasPrefix(result.Backup, filepath.Join(BackupDir, "v0-")) {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	if !strings.Contains(result.Steps[0].Changes[0], "1 message(s) -> mailbox agent-2 (1 corrupt line(s) quarantined)") {
+		t.Errorf("Unexpected change: %q", result.Steps[0].Changes[0])
+	}
+
+	messages, _ := ReadAll(tmpDir, "agent-2")
+	if len(messages) != 2 || messages[1].ID != "b" || messages[1].To != "agent-2" || !messages[1].ReadFlag {
+		t.Errorf("Expected a then migrated b, got %+v", messages)
+	}
+	recipients, _ := ReadAllRecipients(tmpDir)
+	if len(recipients) != 2 || recipients[0].Status != StatusWork || recipients[1].Recipient != "agent-3" {
+		t.Errorf("Expected existing agent-2 state kept and agent-3 added, got %+v", recipients)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, legacyMailDir)); !os.IsNotExist(err) {
+		t.Errorf("Expected .git/mail/ removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, legacyRecipientsFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected legacy recipients removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, QuarantineDir, "agent-2.bad")); err != nil {
+		t.Errorf("Expected the torn line quarantined: %v", err)
+	}
+	if version, err := ReadVersion(tmpDir); err != nil || version != CurrentVersion {
+		t.Errorf("Expected VERSION %d, got %d, %v", CurrentVersion, version, err)
+	}
+
+	// The backup holds the store as it was before the migration
+	backup := filepath.Join(tmpDir, result.Backup)
+	if data, err := os.ReadFile(filepath.Join(backup, "git", "mail", "agent-2.jsonl")); err != nil || !strings.Contains(string(data), `"id":"b"`) {
+		t.Errorf("Expected legacy mailbox in backup, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(backup, "git", "mail-recipients.jsonl")); err != nil {
+		t.Errorf("Expected legacy recipients in backup: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(backup, "agentmail", "mailboxes", "agent-2.jsonl")); err != nil || strings.Contains(string(data), `"id":"b"`) {
+		t.Errorf("Expected pre-migration mailbox in backup, got %q, %v", data, err)
+	}
+
+	// Migrating again is a no-op
+	result, err = Migrate(tmpDir, false)
+	if err != nil || len(result.Steps) != 0 || result.Backup != "" {
+		t.Errorf("Expected no-op, got %+v, %v", result, err)
+	}
+}
+
+func TestMigrate_NewerStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "a", From: "agent-1", To: "agent-2"})
+	_ = writeVersion(tmpDir, CurrentVersion+1)
+
+	if _, err := Migrate(tmpDir, false); !errors.Is(err, ErrStoreTooNew) {
+		t.Errorf("Expected ErrStoreTooNew, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, BackupDir)); !os.IsNotExist(err) {
+		t.Errorf("Expected no backup for a newer store, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"errors"
+	"sort"
+)
+
+// Priority levels for Message.Priority. An empty priority means PriorityNormal.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// ErrInvalidPriority is returned when a priority is not one of the known levels.
+var ErrInvalidPriority = errors.New("invalid priority (must be low, normal, high or urgent)")
+
+// priorityRanks orders priorities from lowest to highest.
+var priorityRanks = map[string]int{
+	PriorityLow:    0,
+	"":             1,
+	PriorityNormal: 1,
+	PriorityHigh:   2,
+	PriorityUrgent: 3,
+}
+
+// ValidatePriority returns ErrInvalidPriority unless p is a known level or empty.
+func ValidatePriority(p string) error {
+	if _, ok := priorityRanks[p]; !ok {
+		return ErrInvalidPriority
+	}
+	return nil
+}
+
+// IsUrgent reports whether the message has urgent priority.
+func (m Message) IsUrgent() bool {
+	return m.Priority == PriorityUrgent
+}
+
+// sortByPriority orders messages highest priority first.
+// The sort is stable, so messages of equal priority keep their FIFO order.
+func sortByPriority(messages []Message) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return priorityRanks[messages[i].Priority] > priorityRanks[messages[j].Priority]
+	})
+}
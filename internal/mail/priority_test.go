@@ -0,0 +1,46 @@
+package mail
+
+import "testing"
+
+func TestValidatePriority(t *testing.T) {
+	for _, p := range []string{"", PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent} {
+		if err := ValidatePriority(p); err != nil {
+			t.Errorf("ValidatePriority(%q) = %v, want nil", p, err)
+		}
+	}
+	for _, p := range []string{"critical", "URGENT", " "} {
+		if err := ValidatePriority(p); err != ErrInvalidPriority {
+			t.Errorf("ValidatePriority(%q) = %v, want ErrInvalidPriority", p, err)
+		}
+	}
+}
+
+func TestFindUnread_HighestPriorityOldestFirst(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := WriteAll(tmpDir, "agent-2", []Message{
+		{ID: "normal01", To: "agent-2", Message: "status 1"},
+		{ID: "low00001", To: "agent-2", Message: "fyi", Priority: PriorityLow},
+		{ID: "high0001", To: "agent-2", Message: "review", Priority: PriorityHigh},
+		{ID: "normal02", To: "agent-2", Message: "status 2", Priority: PriorityNormal},
+		{ID: "urgent01", To: "agent-2", Message: "abort, main is broken", Priority: PriorityUrgent},
+		{ID: "urgent02", To: "agent-2", Message: "also urgent", Priority: PriorityUrgent},
+		{ID: "urgent00", To: "agent-2", Message: "already read", Priority: PriorityUrgent, ReadFlag: true},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	unread, err := FindUnread(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("FindUnread failed: %v", err)
+	}
+
+	want := []string{"urgent01", "urgent02", "high0001", "normal01", "normal02", "low00001"}
+	if len(unread) != len(want) {
+		t.Fatalf("Expected %d unread, got %d", len(want), len(unread))
+	}
+	for i, id := range want {
+		if unread[i].ID != id {
+			t.Errorf("unread[%d] = %s, want %s", i, unread[i].ID, id)
+		}
+	}
+}
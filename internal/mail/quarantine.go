@@ -0,0 +1,82 @@
+package mail
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// QuarantineDir holds records that could not be decoded, one <mailbox>.bad file per store file
+const QuarantineDir = ".agentmail/quarantine"
+
+// Quarantine names of the store files that are not mailboxes
+const (
+	recipientsQuarantine = "recipients"
+	deadLetterQuarantine = "deadletter"
+	eventsQuarantine     = "events"
+)
+
+// BadRecord is a stored record that could not be decoded. Readers skip bad records;
+// rewrites and RepairStore move them to the quarantine directory.
+type BadRecord struct {
+	File          string    `json:"file"`           // Store file, relative to the repository root
+	Offset        int64     `json:"offset"`         // Byte offset of the line (bolt store: record sequence number)
+	Reason        string    `json:"reason"`         // Why the record could not be decoded
+	Line          string    `json:"line"`           // The raw record
+	QuarantinedAt time.Time `json:"quarantined_at"` // When the record was moved to quarantine
+}
+
+// quarantine appends bad records to .agentmail/quarantine/<name>.bad.
+// Callers quarantine records before rewriting the file without them,
+// so a crash in between duplicates rather than loses a record.
+func quarantine(repoRoot string, name string, bad []BadRecord) error {
+	if len(bad) == 0 {
+		return nil
+	}
+
+	dir := filepath.Join(repoRoot, QuarantineDir)
+	if err := os.MkdirAll(dir, 0750); err != nil { // G301: restricted directory permissions
+		return err
+	}
+	filePath, err := safePath(dir, name+".bad")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 - path validated by safePath; G302 - restricted file permissions
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+
+	now := time.Now()
+	for _, record := range bad {
+		record.QuarantinedAt = now
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RepairStore scans every mailbox, the dead-letter mailbox and the recipient state
+// for records that cannot be decoded and returns them. With fix set, the bad records
+// are quarantined and the clean records rewritten.
+func RepairStore(repoRoot string, fix bool) ([]BadRecord, error) {
+	store, err := OpenStore(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	return store.Repair(fix)
+}
@@ -0,0 +1,223 @@
+package mail
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// writeCorruptMailbox writes a mailbox with a garbage line between two messages
+// and a torn last line. Returns the mailbox path.
+func writeCorruptMailbox(t *testing.T, repoRoot, recipient string) string {
+	t.Helper()
+	if err := EnsureMailDir(repoRoot); err != nil {
+		t.Fatalf("EnsureMailDir failed: %v", err)
+	}
+	content := `{"id":"a","from":"agent-1","to":"` + recipient + `","message":"one","read_flag":false}` + "\n" +
+		"this is not json\n" +
+		`{"id":"b","from":"agent-1","to":"` + recipient + `","message":"two","read_flag":false}` + "\n" +
+		`{"id":"c","from":"agent-1","to":"`
+	filePath := filepath.Join(repoRoot, MailDir, recipient+".jsonl")
+	if err := os.WriteFile(filePath, []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return filePath
+}
+
+func readQuarantine(t *testing.T, repoRoot, name string) []BadRecord {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(repoRoot, QuarantineDir, name+".bad"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	var records []BadRecord
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record BadRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Bad quarantine line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestReadAll_SkipsCorruptLines(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeCorruptMailbox(t, tmpDir, "agent-2")
+
+	messages, err := ReadAll(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(messages) != 2 || messages[0].ID != "a" || messages[1].ID != "b" {
+		t.Errorf("Expected a and b, got %+v", messages)
+	}
+
+	unread, err := FindUnread(tmpDir, "agent-2")
+	if err != nil || len(unread) != 2 {
+		t.Errorf("Expected 2 unread messages, got %d, %v", len(unread), err)
+	}
+	if err := MarkAsRead(tmpDir, "agent-2", "a"); err != nil {
+		t.Errorf("MarkAsRead failed: %v", err)
+	}
+
+	// Reading never moves anything to quarantine
+	if records := readQuarantine(t, tmpDir, "agent-2"); records != nil {
+		t.Errorf("Expected no quarantine on read, got %+v", records)
+	}
+}
+
+func TestAppend_AfterTornLine(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeCorruptMailbox(t, tmpDir, "agent-2")
+
+	if err := Append(tmpDir, Message{ID: "d", From: "agent-1", To: "agent-2", Message: "three"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	// The new message must not be glued to the torn line
+	messages, _ := ReadAll(tmpDir, "agent-2")
+	if len(messages) != 3 || messages[2].ID != "d" {
+		t.Errorf("Expected a, b, d, got %+v", messages)
+	}
+}
+
+func TestModify_QuarantinesCorruptLines(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := writeCorruptMailbox(t, tmpDir, "agent-2")
+
+	if err := rewriteMailbox(tmpDir, "agent-2"); err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+
+	records := readQuarantine(t, tmpDir, "agent-2")
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 quarantined records, got %+v", records)
+	}
+	if records[0].File != filepath.Join(MailDir, "agent-2.jsonl") || records[0].Line != "this is not json" {
+		t.Errorf("Unexpected quarantine record: %+v", records[0])
+	}
+	firstLine := strings.Index(`{"id":"a","from":"agent-1","to":"agent-2","message":"one","read_flag":false}`+"\n", "\n") + 1
+	if records[0].Offset != int64(firstLine) || records[0].Reason == "" || records[0].QuarantinedAt.IsZero() {
+		t.Errorf("Expected offset %d, a reason and a timestamp, got %+v", firstLine, records[0])
+	}
+
+	data, _ := os.ReadFile(filePath)
+	if strings.Contains(string(data), "not json") || strings.Count(string(data), "\n") != 2 {
+		t.Errorf("Expected only the clean messages to be rewritten, got:\n%s", data)
+	}
+}
+
+func TestReadAllRecipients_SkipsCorruptLines(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(tmpDir, RootDir), 0750)
+	content := `{"recipient":"agent-1","status":"ready"}` + "\n" + "{garbage\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, RecipientsFile), []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	recipients, err := ReadAllRecipients(tmpDir)
+	if err != nil || len(recipients) != 1 {
+		t.Fatalf("Expected 1 recipient, got %+v, %v", recipients, err)
+	}
+
+	if err := UpdateRecipientState(tmpDir, "agent-2", StatusWork, false); err != nil {
+		t.Fatalf("UpdateRecipientState failed: %v", err)
+	}
+	if records := readQuarantine(t, tmpDir, recipientsQuarantine); len(records) != 1 || records[0].Line != "{garbage" {
+		t.Errorf("Expected the garbage line quarantined, got %+v", records)
+	}
+	if recipients, _ := ReadAllRecipients(tmpDir); len(recipients) != 2 {
+		t.Errorf("Expected 2 recipients after update, got %+v", recipients)
+	}
+}
+
+func TestRepairStore_JSONL(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := writeCorruptMailbox(t, tmpDir, "agent-2")
+	_ = Append(tmpDir, Message{ID: "x", From: "agent-1", To: "agent-3", Message: "clean"})
+	if err := os.WriteFile(filepath.Join(tmpDir, RecipientsFile), []byte("nope\n"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	before, _ := os.ReadFile(filePath)
+
+	// A check without fix changes nothing
+	bad, err := RepairStore(tmpDir, false)
+	if err != nil {
+		t.Fatalf("RepairStore failed: %v", err)
+	}
+	if len(bad) != 3 {
+		t.Fatalf("Expected 3 bad records, got %+v", bad)
+	}
+	if after, _ := os.ReadFile(filePath); string(after) != string(before) {
+		t.Error("Check without fix modified the mailbox")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, QuarantineDir)); !os.IsNotExist(err) {
+		t.Error("Check without fix created the quarantine directory")
+	}
+
+	bad, err = RepairStore(tmpDir, true)
+	if err != nil || len(bad) != 3 {
+		t.Fatalf("Expected 3 repaired records, got %+v, %v", bad, err)
+	}
+	if records := readQuarantine(t, tmpDir, "agent-2"); len(records) != 2 {
+		t.Errorf("Expected 2 quarantined mailbox records, got %+v", records)
+	}
+	if records := readQuarantine(t, tmpDir, recipientsQuarantine); len(records) != 1 {
+		t.Errorf("Expected 1 quarantined recipient record, got %+v", records)
+	}
+	if messages, _ := ReadAll(tmpDir, "agent-2"); len(messages) != 2 {
+		t.Errorf("Expected the clean messages kept, got %+v", messages)
+	}
+
+	if bad, _ := RepairStore(tmpDir, false); len(bad) != 0 {
+		t.Errorf("Expected a clean store after repair, got %+v", bad)
+	}
+}
+
+func TestRepairStore_Bolt(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := NewBoltStore(tmpDir)
+	_ = store.Append(Message{ID: "a", From: "agent-1", To: "agent-2", Message: "one"})
+	_ = store.Append(Message{ID: "b", From: "agent-1", To: "agent-2", Message: "two"})
+
+	// Corrupt the second message directly in the database
+	db, err := bolt.Open(filepath.Join(tmpDir, BoltFile), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("bolt.Open failed: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(mailboxesBucket).Bucket([]byte("agent-2")).Put(seqKey(2), []byte("{torn"))
+	})
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("Corrupting record failed: %v", err)
+	}
+
+	messages, err := store.ReadAll("agent-2")
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("Expected the clean message, got %+v, %v", messages, err)
+	}
+
+	bad, err := store.Repair(false)
+	if err != nil || len(bad) != 1 || bad[0].Offset != 2 {
+		t.Fatalf("Expected record 2 reported, got %+v, %v", bad, err)
+	}
+	if _, err := store.Repair(true); err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+	if records := readQuarantine(t, tmpDir, "agent-2"); len(records) != 1 || records[0].Line != "{torn" {
+		t.Errorf("Expected the torn record quarantined, got %+v", records)
+	}
+	if bad, _ := store.Repair(false); len(bad) != 0 {
+		t.Errorf("Expected a clean store after repair, got %+v", bad)
+	}
+}
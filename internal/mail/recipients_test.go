@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"agentmail/internal/config"
 )
 
 // TestReadAllRecipients_Empty - returns empty slice when file doesn't exist
@@ -1125,3 +1127,63 @@ func TestUpdateLastReadAt_PreservesOtherRecipients(t *testing.T) {
 		}
 	}
 }
+
+func TestRecipientState_ShouldNotifyUsesConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.NotifyDebounce = 10 * time.Second
+	cfg.WorkProtection = 5 * time.Minute
+
+	ready := RecipientState{Status: StatusReady, NotifiedAt: time.Now().Add(-30 * time.Second)}
+	if !ready.ShouldNotify(cfg) {
+		t.Error("Expected a ready agent notified 30s ago to be notified with a 10s debounce")
+	}
+	if ready.ShouldNotify(config.Default()) {
+		t.Error("Expected a ready agent notified 30s ago to be skipped with the default debounce")
+	}
+
+	working := RecipientState{Status: StatusWork, UpdatedAt: time.Now().Add(-10 * time.Minute)}
+	if !working.ShouldNotify(cfg) {
+		t.Error("Expected protection to end after the configured 5m")
+	}
+	if working.ShouldNotify(config.Default()) {
+		t.Error("Expected the default 1h protection to still apply")
+	}
+}
+
+func TestSetNotifyPreference(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := UpdateRecipientState(tmpDir, "agent-1", StatusReady, false); err != nil {
+		t.Fatalf("UpdateRecipientState failed: %v", err)
+	}
+
+	if err := SetNotifyPreference(tmpDir, "agent-1", config.NotifyDisplay, "{count} new"); err != nil {
+		t.Fatalf("SetNotifyPreference failed: %v", err)
+	}
+	// An empty strategy keeps the current one
+	if err := SetNotifyPreference(tmpDir, "agent-1", "", "/mail"); err != nil {
+		t.Fatalf("SetNotifyPreference failed: %v", err)
+	}
+	recipients, _ := ReadAllRecipients(tmpDir)
+	if recipients[0].Notify != config.NotifyDisplay || recipients[0].NotifyTemplate != "/mail" {
+		t.Errorf("Expected display and /mail, got %+v", recipients[0])
+	}
+
+	if err := SetNotifyPreference(tmpDir, "agent-1", NotifyDefault, ""); err != nil {
+		t.Fatalf("SetNotifyPreference failed: %v", err)
+	}
+	recipients, _ = ReadAllRecipients(tmpDir)
+	if recipients[0].Notify != "" || recipients[0].NotifyTemplate != "" {
+		t.Errorf("Expected overrides cleared, got %+v", recipients[0])
+	}
+
+	if err := SetNotifyPreference(tmpDir, "agent-1", "pager", ""); err == nil {
+		t.Error("Expected an invalid strategy to be rejected")
+	}
+	// Unknown recipients are not created
+	if err := SetNotifyPreference(tmpDir, "agent-9", config.NotifyBell, ""); err != nil {
+		t.Fatalf("SetNotifyPreference failed: %v", err)
+	}
+	if recipients, _ = ReadAllRecipients(tmpDir); len(recipients) != 1 {
+		t.Errorf("Expected only agent-1, got %+v", recipients)
+	}
+}
@@ -0,0 +1,82 @@
+package mail
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidSchedule is returned when a delivery time cannot be parsed.
+var ErrInvalidSchedule = errors.New("invalid delivery time (use RFC3339, \"2006-01-02 15:04\" or \"15:04\")")
+
+// ErrConflictingSchedule is returned when both an absolute and a relative delivery time are given.
+var ErrConflictingSchedule = errors.New("cannot combine a delivery time with a delivery delay")
+
+// Pending reports whether the message is scheduled for later delivery and not yet due.
+func (m Message) Pending(now time.Time) bool {
+	return !m.DeliverAfter.IsZero() && now.Before(m.DeliverAfter)
+}
+
+// DeliveryTime computes the deliver_after timestamp for a send.
+// at is an absolute time: RFC3339, "2006-01-02 15:04" or "15:04" in local time.
+// A bare clock time refers to its next occurrence, so "09:00" sent in the
+// evening means tomorrow morning. in is a delay relative to now.
+// Returns the zero time when neither is set (deliver immediately).
+func DeliveryTime(at string, in time.Duration, now time.Time) (time.Time, error) {
+	if at != "" && in != 0 {
+		return time.Time{}, ErrConflictingSchedule
+	}
+	if in < 0 {
+		return time.Time{}, errors.New("delivery delay must not be negative")
+	}
+	if in > 0 {
+		return now.Add(in), nil
+	}
+	if at == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, at); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", at, now.Location()); err == nil {
+		return t, nil
+	}
+	clock, err := time.ParseInLocation("15:04", at, now.Location())
+	if err != nil {
+		return time.Time{}, ErrInvalidSchedule
+	}
+	t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if !t.After(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// NextDue returns the earliest deliver_after time of any unread message that
+// is not yet due, across all mailboxes. The daemon uses it to wake up when a
+// scheduled message becomes deliverable. Returns false if nothing is scheduled.
+func NextDue(repoRoot string) (time.Time, bool, error) {
+	recipients, err := ListMailboxRecipients(repoRoot)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	now := time.Now()
+	var next time.Time
+	for _, recipient := range recipients {
+		messages, err := ReadAll(repoRoot, recipient)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		for _, msg := range messages {
+			if msg.ReadFlag || !msg.Pending(now) {
+				continue
+			}
+			if next.IsZero() || msg.DeliverAfter.Before(next) {
+				next = msg.DeliverAfter
+			}
+		}
+	}
+
+	return next, !next.IsZero(), nil
+}
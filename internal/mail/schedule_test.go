@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeliveryTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 16, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		at   string
+		in   time.Duration
+		want time.Time
+	}{
+		{"immediate", "", 0, time.Time{}},
+		{"delay", "", 20 * time.Minute, now.Add(20 * time.Minute)},
+		{"RFC3339", "2026-03-11T09:00:00Z", 0, time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)},
+		{"date and time", "2026-03-11 09:00", 0, time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)},
+		{"clock later today", "17:00", 0, time.Date(2026, 3, 10, 17, 0, 0, 0, time.UTC)},
+		{"clock already passed", "14:00", 0, time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DeliveryTime(tt.at, tt.in, now)
+			if err != nil {
+				t.Fatalf("DeliveryTime returned error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDeliveryTime_Errors(t *testing.T) {
+	now := time.Now()
+
+	if _, err := DeliveryTime("tomorrow", 0, now); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("Expected ErrInvalidSchedule, got %v", err)
+	}
+	if _, err := DeliveryTime("14:00", time.Minute, now); !errors.Is(err, ErrConflictingSchedule) {
+		t.Errorf("Expected ErrConflictingSchedule, got %v", err)
+	}
+	if _, err := DeliveryTime("", -time.Minute, now); err == nil {
+		t.Error("Expected error for negative delay")
+	}
+}
+
+func TestFindUnread_SkipsScheduledUntilDue(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now()
+
+	if err := WriteAll(tmpDir, "agent-2", []Message{
+		{ID: "due00001", From: "agent-1", To: "agent-2", Message: "due", DeliverAfter: now.Add(-time.Minute)},
+		{ID: "later001", From: "agent-1", To: "agent-2", Message: "later", DeliverAfter: now.Add(time.Hour)},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	unread, err := FindUnread(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("FindUnread failed: %v", err)
+	}
+	if len(unread) != 1 || unread[0].ID != "due00001" {
+		t.Errorf("Expected only the due message, got %+v", unread)
+	}
+}
+
+func TestNextDue(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if _, ok, err := NextDue(tmpDir); err != nil || ok {
+		t.Fatalf("Expected nothing scheduled, got ok=%v err=%v", ok, err)
+	}
+
+	soon := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	if err := WriteAll(tmpDir, "agent-2", []Message{
+		{ID: "later001", From: "agent-1", To: "agent-2", Message: "later", DeliverAfter: soon.Add(time.Hour)},
+		{ID: "read0001", From: "agent-1", To: "agent-2", Message: "read", ReadFlag: true, DeliverAfter: soon.Add(-time.Minute)},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+	if err := WriteAll(tmpDir, "agent-3", []Message{
+		{ID: "soon0001", From: "agent-1", To: "agent-3", Message: "soon", DeliverAfter: soon},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+
+	due, ok, err := NextDue(tmpDir)
+	if err != nil {
+		t.Fatalf("NextDue failed: %v", err)
+	}
+	if !ok || !due.Equal(soon) {
+		t.Errorf("Expected next due %v, got %v (ok=%v)", soon, due, ok)
+	}
+}
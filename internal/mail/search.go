@@ -0,0 +1,120 @@
+package mail
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SearchQuery selects the messages returned by Search.
+type SearchQuery struct {
+	Text  string    // Text to find; a case-insensitive substring unless Regex is set
+	Regex bool      // Treat Text as a Go regular expression (case-sensitive unless it starts with (?i))
+	From  string    // Only messages from this sender
+	To    string    // Only messages in this recipient's mailbox
+	Since time.Time // Only messages created at or after this time
+}
+
+// SearchMatch is a message found by Search.
+type SearchMatch struct {
+	Message
+	Match string `json:"match"` // The matching line: a body line, "Subject: ..." or "Header: key=value", shortened around the match
+}
+
+// Search looks for the query text in the subject, body and header values of every
+// message in every mailbox, read or not, and returns the matches oldest first.
+func Search(repoRoot string, query SearchQuery) ([]SearchMatch, error) {
+	pattern := "(?i)" + regexp.QuoteMeta(query.Text)
+	if query.Regex {
+		pattern = query.Text
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+
+	recipients, err := ListMailboxRecipients(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []SearchMatch
+	for _, recipient := range recipients {
+		if query.To != "" && recipient != query.To {
+			continue
+		}
+		messages, err := ReadAll(repoRoot, recipient)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range messages {
+			if query.From != "" && msg.From != query.From {
+				continue
+			}
+			if !query.Since.IsZero() && msg.CreatedAt.Before(query.Since) {
+				continue
+			}
+			if line, ok := matchMessage(re, msg); ok {
+				matches = append(matches, SearchMatch{Message: msg, Match: line})
+			}
+		}
+	}
+
+	// Stable sort keeps mailbox order for messages with equal timestamps
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].CreatedAt.Before(matches[j].CreatedAt)
+	})
+	return matches, nil
+}
+
+// matchMessage returns the first line of the message that matches re, checking
+// the subject, then the body, then the header values (sorted by key).
+func matchMessage(re *regexp.Regexp, msg Message) (string, bool) {
+	type field struct{ prefix, text string }
+	var fields []field
+	if msg.Subject != "" {
+		fields = append(fields, field{"Subject: ", msg.Subject})
+	}
+	for _, line := range strings.Split(msg.Message, "\n") {
+		fields = append(fields, field{"", line})
+	}
+	for _, key := range msg.HeaderKeys() {
+		fields = append(fields, field{"Header: " + key + "=", msg.Headers[key]})
+	}
+
+	for _, f := range fields {
+		if loc := re.FindStringIndex(f.text); loc != nil {
+			return f.prefix + snippet(f.text, loc[0]), true
+		}
+	}
+	return "", false
+}
+
+// snippet shortens a line to PreviewLength characters, keeping the match at byte offset start in view.
+func snippet(line string, start int) string {
+	runes := []rune(line)
+	if len(runes) <= PreviewLength {
+		return line
+	}
+
+	from := utf8.RuneCountInString(line[:start]) - PreviewLength/3
+	if from < 0 {
+		from = 0
+	}
+	if from > len(runes)-PreviewLength {
+		from = len(runes) - PreviewLength
+	}
+	to := from + PreviewLength
+
+	result := string(runes[from:to])
+	if from > 0 {
+		result = "..." + result
+	}
+	if to < len(runes) {
+		result += "..."
+	}
+	return result
+}
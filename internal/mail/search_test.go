@@ -0,0 +1,92 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeSearchMessages(t *testing.T, repoRoot string) {
+	t.Helper()
+	now := time.Now()
+	if err := WriteAll(repoRoot, "agent-2", []Message{
+		{ID: "m1", From: "agent-1", To: "agent-2", Message: "Plan:\nwe switch the Schema to v2", ReadFlag: true, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "m3", From: "agent-3", To: "agent-2", Message: "unrelated", Headers: map[string]string{"task": "schema-42"}, CreatedAt: now.Add(-time.Hour)},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+	if err := WriteAll(repoRoot, "agent-1", []Message{
+		{ID: "m2", From: "agent-2", To: "agent-1", Subject: "Schema review", Message: "looks good", CreatedAt: now.Add(-2 * time.Hour)},
+	}); err != nil {
+		t.Fatalf("WriteAll failed: %v", err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSearchMessages(t, tmpDir)
+
+	matches, err := Search(tmpDir, SearchQuery{Text: "schema"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	want := []struct{ id, match string }{
+		{"m1", "we switch the Schema to v2"},
+		{"m2", "Subject: Schema review"},
+		{"m3", "Header: task=schema-42"},
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("Expected %d matches across mailboxes, got %+v", len(want), matches)
+	}
+	for i, w := range want {
+		if matches[i].ID != w.id || matches[i].Match != w.match {
+			t.Errorf("Match %d: expected %s %q, got %s %q", i, w.id, w.match, matches[i].ID, matches[i].Match)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query SearchQuery
+		want  []string
+	}{
+		{"from", SearchQuery{Text: "schema", From: "agent-2"}, []string{"m2"}},
+		{"to", SearchQuery{Text: "schema", To: "agent-2"}, []string{"m1", "m3"}},
+		{"since", SearchQuery{Text: "schema", Since: time.Now().Add(-90 * time.Minute)}, []string{"m3"}},
+		{"regex is case-sensitive", SearchQuery{Text: `Schema (to|review)`, Regex: true}, []string{"m1", "m2"}},
+		{"regex", SearchQuery{Text: `schema-\d+`, Regex: true}, []string{"m3"}},
+		{"no match", SearchQuery{Text: "nothing like this"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := Search(tmpDir, tt.query)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			var ids []string
+			for _, m := range matches {
+				ids = append(ids, m.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected %v, got %v", tt.want, ids)
+			}
+		})
+	}
+
+	if _, err := Search(tmpDir, SearchQuery{Text: "(", Regex: true}); err == nil || !strings.Contains(err.Error(), "invalid regular expression") {
+		t.Errorf("Expected invalid regular expression error, got %v", err)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	line := strings.Repeat("a", 100) + "needle" + strings.Repeat("b", 100)
+	got := snippet(line, 100)
+	if !strings.HasPrefix(got, "...") || !strings.HasSuffix(got, "...") || !strings.Contains(got, "needle") {
+		t.Errorf("Expected the match kept in view, got %q", got)
+	}
+	if got := snippet("short needle", 6); got != "short needle" {
+		t.Errorf("Expected short lines unchanged, got %q", got)
+	}
+	if got := snippet(strings.Repeat("x", 70)+"end", 70); strings.HasSuffix(got, "...") || !strings.HasSuffix(got, "end") {
+		t.Errorf("Expected a match at the end to be kept, got %q", got)
+	}
+}
@@ -0,0 +1,150 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"agentmail/internal/config"
+)
+
+// Store is a storage backend for mailboxes, recipient state, the dead-letter mailbox
+// and the delivery event log.
+// The package-level functions (Append, ReadAll, UpdateRecipientState, ...) open the
+// store configured for the repository with OpenStore and delegate to it.
+//
+// Every method is safe to call from concurrent processes: read-modify-write
+// operations run under one exclusive lock. Readers skip records that cannot be
+// decoded; rewrites move them to the quarantine directory instead of dropping them.
+type Store interface {
+	// Append adds a message to the end of msg.To's mailbox as-is, creating the mailbox if needed.
+	Append(msg Message) error
+	// ReadAll returns a mailbox's messages in arrival order. A missing mailbox is empty.
+	ReadAll(recipient string) ([]Message, error)
+	// ReadPending returns at least every message of a mailbox that is not read yet,
+	// in arrival order. It may also return read messages; callers filter.
+	// Backends make this cheaper than ReadAll for mailboxes with a long history.
+	ReadPending(recipient string) ([]Message, error)
+	// WriteAll replaces a mailbox's messages, creating the mailbox if needed.
+	WriteAll(recipient string, messages []Message) error
+	// Modify runs fn on a mailbox's messages and stores the result if fn reports a change.
+	// Errors returned by fn are passed through. Returns an error satisfying os.IsNotExist
+	// if the mailbox doesn't exist.
+	Modify(recipient string, fn func([]Message) ([]Message, bool, error)) error
+	// UpdateMessage applies fn to a single message and returns the updated message.
+	// Returns ErrMessageNotFound if the mailbox doesn't hold the ID.
+	UpdateMessage(recipient string, messageID string, fn func(*Message)) (Message, error)
+	// UpdateUnread applies fn to every unread message of a mailbox and stores the
+	// messages for which it reports a change, without rewriting the mailbox.
+	// Returns the updated messages; a missing mailbox has none.
+	UpdateUnread(recipient string, fn func(*Message) bool) ([]Message, error)
+	// MarkRead marks a single message as read and returns it as it was before,
+	// so callers can tell whether this call read it. Unknown IDs are ignored
+	// and return the zero Message.
+	MarkRead(recipient string, messageID string) (Message, error)
+	// Compact rewrites a mailbox into its smallest form and reports whether it did.
+	// minGarbage lets a backend skip mailboxes with fewer than that many superseded records.
+	Compact(recipient string, minGarbage int) (bool, error)
+	// ListMailboxes returns the names of all mailboxes.
+	ListMailboxes() ([]string, error)
+	// RemoveEmptyMailbox deletes a mailbox that holds no messages and reports whether it did.
+	RemoveEmptyMailbox(recipient string) (bool, error)
+
+	// DeadLetter moves the messages of a mailbox for which reason returns a non-empty
+	// reason to the dead-letter mailbox, recording the reason. Dead letters are written
+	// before they are removed from the mailbox, so a crash duplicates rather than loses them.
+	// Returns the moved messages.
+	DeadLetter(recipient string, reason func(Message) string) ([]Message, error)
+	// ReadDeadLetters returns all dead-lettered messages in the order they were moved.
+	ReadDeadLetters() ([]Message, error)
+	// ModifyDeadLetters is Modify for the dead-letter mailbox.
+	ModifyDeadLetters(fn func([]Message) ([]Message, bool, error)) error
+
+	// ReadRecipients returns all recipient states. Missing state is empty.
+	ReadRecipients() ([]RecipientState, error)
+	// WriteRecipients replaces all recipient states.
+	WriteRecipients(recipients []RecipientState) error
+	// ModifyRecipients runs fn on the recipient states and stores the result
+	// if fn reports a change. Errors returned by fn are passed through.
+	ModifyRecipients(fn func([]RecipientState) ([]RecipientState, bool, error)) error
+
+	// AppendEvent adds a delivery event to the end of the event log.
+	AppendEvent(event Event) error
+	// ReadEvents returns every delivery event in the order it was recorded. A missing log is empty.
+	ReadEvents() ([]Event, error)
+	// ModifyEvents runs fn on the event log and stores the result if fn reports a change.
+	// Errors returned by fn are passed through.
+	ModifyEvents(fn func([]Event) ([]Event, bool, error)) error
+
+	// Repair scans every mailbox, the dead-letter mailbox and the recipient state for
+	// records that cannot be decoded and returns them. With fix set, the bad records
+	// are quarantined and the clean records rewritten.
+	Repair(fix bool) ([]BadRecord, error)
+}
+
+// OpenStore returns the store selected by the repository's configuration
+// (the "store" key of .agentmail/config.toml or AGENTMAIL_STORE).
+// Switching backends does not migrate existing mail.
+// If .agentmail/VERSION is newer than CurrentVersion, every write fails with ErrStoreTooNew.
+func OpenStore(repoRoot string) (Store, error) {
+	backend, err := storeBackend(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	version, err := ReadVersion(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var store Store
+	switch backend {
+	case config.StoreBolt:
+		store = NewBoltStore(repoRoot)
+	default:
+		store = NewJSONLStore(repoRoot)
+	}
+	if version > CurrentVersion {
+		return newReadOnlyStore(store, version), nil
+	}
+	return store, nil
+}
+
+// backendChoice is the store setting read for a repository, and the state of
+// the config file and AGENTMAIL_STORE it was read from.
+type backendChoice struct {
+	backend string
+	modTime time.Time
+	size    int64
+	env     string
+}
+
+// backends caches the store setting of each repository, so a command reads
+// the config file once rather than on every mail operation.
+var (
+	backends   = make(map[string]backendChoice)
+	backendsMu sync.Mutex
+)
+
+// storeBackend returns the store setting of the repository, reading the config
+// file again only if it or AGENTMAIL_STORE changed since the last call.
+func storeBackend(repoRoot string) (string, error) {
+	var current backendChoice
+	if info, err := os.Stat(filepath.Join(repoRoot, config.File)); err == nil {
+		current.modTime, current.size = info.ModTime(), info.Size()
+	}
+	current.env = os.Getenv(config.EnvStore)
+
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if cached, ok := backends[repoRoot]; ok && cached.modTime.Equal(current.modTime) && cached.size == current.size && cached.env == current.env {
+		return cached.backend, nil
+	}
+	backend, err := config.LoadStore(repoRoot)
+	if err != nil {
+		return "", err
+	}
+	current.backend = backend
+	backends[repoRoot] = current
+	return backend, nil
+}
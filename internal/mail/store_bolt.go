@@ -0,0 +1,737 @@
+package mail
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltFile is the single-file database used by the bolt store
+const BoltFile = ".agentmail/agentmail.db"
+
+// Bucket names of the bolt store
+var (
+	mailboxesBucket   = []byte("mailboxes")   // One nested bucket per mailbox: sequence -> message JSON
+	indexBucket       = []byte("index")       // One nested bucket per mailbox: message ID -> sequence
+	deadLettersBucket = []byte("deadletters") // sequence -> message JSON
+	recipientsBucket  = []byte("recipients")  // recipient name -> state JSON
+	eventsBucket      = []byte("events")      // sequence -> event JSON
+)
+
+// boltStore keeps all mail in one embedded bbolt database (pure Go, no CGO).
+// The database is opened per operation, so the file lock is only held while an
+// operation runs and several agentmail processes can share the store.
+type boltStore struct {
+	repoRoot string
+	path     string
+}
+
+// NewBoltStore returns the single-file database store for a repository.
+func NewBoltStore(repoRoot string) Store {
+	return &boltStore{repoRoot: repoRoot, path: filepath.Join(repoRoot, BoltFile)}
+}
+
+// update runs fn in a read-write transaction, creating the database if needed.
+func (s *boltStore) update(fn func(tx *bolt.Tx) error) error {
+	if err := ensureRootDir(s.repoRoot); err != nil {
+		return err
+	}
+	db, err := bolt.Open(s.path, 0600, nil) // Blocks until the exclusive lock is free
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+// view runs fn in a read-only transaction. fn is not called if the database doesn't exist.
+func (s *boltStore) view(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(s.path, 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+// notExist returns an error satisfying os.IsNotExist for a missing mailbox.
+func (s *boltStore) notExist(name string) error {
+	return &os.PathError{Op: "open", Path: s.path + ":" + name, Err: os.ErrNotExist}
+}
+
+// seqKey encodes a bucket sequence number as a big-endian key, so keys sort in insertion order.
+func seqKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// mailbox returns the message and index buckets of a mailbox, or nils if it doesn't exist.
+func mailbox(tx *bolt.Tx, recipient string) (*bolt.Bucket, *bolt.Bucket) {
+	boxes := tx.Bucket(mailboxesBucket)
+	index := tx.Bucket(indexBucket)
+	if boxes == nil || index == nil {
+		return nil, nil
+	}
+	return boxes.Bucket([]byte(recipient)), index.Bucket([]byte(recipient))
+}
+
+// createMailbox returns the message and index buckets of a mailbox, creating them if needed.
+func createMailbox(tx *bolt.Tx, recipient string) (*bolt.Bucket, *bolt.Bucket, error) {
+	boxes, err := tx.CreateBucketIfNotExists(mailboxesBucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	index, err := tx.CreateBucketIfNotExists(indexBucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	box, err := boxes.CreateBucketIfNotExists([]byte(recipient))
+	if err != nil {
+		return nil, nil, err
+	}
+	ids, err := index.CreateBucketIfNotExists([]byte(recipient))
+	if err != nil {
+		return nil, nil, err
+	}
+	return box, ids, nil
+}
+
+// putMessage appends a message to a mailbox bucket and records its ID in the index.
+func putMessage(box, ids *bolt.Bucket, msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	seq, err := box.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := seqKey(seq)
+	if err := box.Put(key, data); err != nil {
+		return err
+	}
+	if msg.ID == "" {
+		return nil
+	}
+	return ids.Put([]byte(msg.ID), key)
+}
+
+// readMessages decodes every message in a bucket in key order. Records that cannot
+// be decoded are skipped and returned as bad records of file.
+func readMessages(bucket *bolt.Bucket, file string) ([]Message, []BadRecord) {
+	var messages []Message
+	var bad []BadRecord
+	_ = bucket.ForEach(func(key, value []byte) error { // The callback never fails
+		var msg Message
+		if err := json.Unmarshal(value, &msg); err != nil {
+			bad = append(bad, badValue(file, key, value, err))
+			return nil
+		}
+		messages = append(messages, msg)
+		return nil
+	})
+	return messages, bad
+}
+
+// badValue describes a record that cannot be decoded. Sequence keys are reported as the offset.
+func badValue(file string, key, value []byte, err error) BadRecord {
+	record := BadRecord{File: file, Reason: err.Error(), Line: string(value)}
+	if len(key) == 8 {
+		record.Offset = int64(binary.BigEndian.Uint64(key)) // #nosec G115 - sequences stay far below 2^63
+	}
+	return record
+}
+
+// Names of the dead-letter, recipients and events buckets in bad records
+var (
+	deadLetterFile = BoltFile + "#" + string(deadLettersBucket)
+	recipientsFile = BoltFile + "#" + string(recipientsBucket)
+	eventsFile     = BoltFile + "#" + string(eventsBucket)
+)
+
+// mailboxFile names a mailbox bucket in bad records.
+func mailboxFile(recipient string) string {
+	return BoltFile + "#" + string(mailboxesBucket) + "/" + recipient
+}
+
+// replaceMailbox drops a mailbox's buckets and refills them with messages.
+func replaceMailbox(tx *bolt.Tx, recipient string, messages []Message) error {
+	if box, _ := mailbox(tx, recipient); box != nil {
+		if err := tx.Bucket(mailboxesBucket).DeleteBucket([]byte(recipient)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(indexBucket).DeleteBucket([]byte(recipient)); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+	}
+	box, ids, err := createMailbox(tx, recipient)
+	if err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		if err := putMessage(box, ids, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Append adds a message to the recipient's mailbox bucket.
+func (s *boltStore) Append(msg Message) error {
+	return s.update(func(tx *bolt.Tx) error {
+		box, ids, err := createMailbox(tx, msg.To)
+		if err != nil {
+			return err
+		}
+		return putMessage(box, ids, msg)
+	})
+}
+
+// ReadAll returns the messages of a mailbox bucket in arrival order.
+func (s *boltStore) ReadAll(recipient string) ([]Message, error) {
+	messages := []Message{}
+	err := s.view(func(tx *bolt.Tx) error {
+		box, _ := mailbox(tx, recipient)
+		if box == nil {
+			return nil
+		}
+		if read, _ := readMessages(box, mailboxFile(recipient)); read != nil {
+			messages = read
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ReadPending returns the whole mailbox: bolt reads are already indexed and cheap.
+func (s *boltStore) ReadPending(recipient string) ([]Message, error) {
+	return s.ReadAll(recipient)
+}
+
+// WriteAll replaces the messages of a mailbox bucket.
+func (s *boltStore) WriteAll(recipient string, messages []Message) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return replaceMailbox(tx, recipient, messages)
+	})
+}
+
+// Modify runs fn on a mailbox's messages inside one read-write transaction.
+func (s *boltStore) Modify(recipient string, fn func([]Message) ([]Message, bool, error)) error {
+	return s.update(func(tx *bolt.Tx) error {
+		box, _ := mailbox(tx, recipient)
+		if box == nil {
+			return s.notExist(recipient)
+		}
+		messages, bad := readMessages(box, mailboxFile(recipient))
+		messages, changed, err := fn(messages)
+		if err != nil || !changed {
+			return err
+		}
+		if err := quarantine(s.repoRoot, recipient, bad); err != nil {
+			return err
+		}
+		return replaceMailbox(tx, recipient, messages)
+	})
+}
+
+// UpdateMessage looks the message up by ID in the index and rewrites only that record.
+func (s *boltStore) UpdateMessage(recipient string, messageID string, fn func(*Message)) (Message, error) {
+	var updated Message
+	err := s.update(func(tx *bolt.Tx) error {
+		box, ids := mailbox(tx, recipient)
+		if box == nil || ids == nil {
+			return ErrMessageNotFound
+		}
+		key := ids.Get([]byte(messageID))
+		if key == nil {
+			return ErrMessageNotFound
+		}
+		value := box.Get(key)
+		if value == nil {
+			return ErrMessageNotFound
+		}
+
+		var msg Message
+		if err := json.Unmarshal(value, &msg); err != nil {
+			return err
+		}
+		fn(&msg)
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		updated = msg
+		return box.Put(key, data)
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	return updated, nil
+}
+
+// UpdateUnread rewrites the records of the unread messages fn changes in place.
+func (s *boltStore) UpdateUnread(recipient string, fn func(*Message) bool) ([]Message, error) {
+	var updated []Message
+	err := s.update(func(tx *bolt.Tx) error {
+		updated = nil
+		box, _ := mailbox(tx, recipient)
+		if box == nil {
+			return nil
+		}
+		var keys [][]byte
+		err := box.ForEach(func(key, value []byte) error {
+			var msg Message
+			if err := json.Unmarshal(value, &msg); err != nil || msg.ReadFlag || !fn(&msg) {
+				return nil // Bad records are left for Repair
+			}
+			keys = append(keys, append([]byte(nil), key...))
+			updated = append(updated, msg)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Written after the iteration, which a Put would invalidate
+		for i, key := range keys {
+			data, err := json.Marshal(updated[i])
+			if err != nil {
+				return err
+			}
+			if err := box.Put(key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// MarkRead sets the read flag of one message in place.
+func (s *boltStore) MarkRead(recipient string, messageID string) (Message, error) {
+	var before Message
+	_, err := s.UpdateMessage(recipient, messageID, func(msg *Message) {
+		before = *msg
+		msg.ReadFlag = true
+	})
+	if err == ErrMessageNotFound {
+		return Message{}, nil
+	}
+	return before, err
+}
+
+// Compact is a no-op: bolt updates records in place and reuses freed pages.
+func (s *boltStore) Compact(recipient string, minGarbage int) (bool, error) {
+	return false, nil
+}
+
+// ListMailboxes returns the names of all mailbox buckets.
+func (s *boltStore) ListMailboxes() ([]string, error) {
+	recipients := []string{}
+	err := s.view(func(tx *bolt.Tx) error {
+		boxes := tx.Bucket(mailboxesBucket)
+		if boxes == nil {
+			return nil
+		}
+		return boxes.ForEachBucket(func(name []byte) error {
+			recipients = append(recipients, string(name))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recipients, nil
+}
+
+// RemoveEmptyMailbox deletes a mailbox bucket that holds no messages.
+func (s *boltStore) RemoveEmptyMailbox(recipient string) (bool, error) {
+	removed := false
+	err := s.update(func(tx *bolt.Tx) error {
+		box, _ := mailbox(tx, recipient)
+		if box == nil {
+			return nil
+		}
+		if key, _ := box.Cursor().First(); key != nil {
+			return nil
+		}
+		if err := tx.Bucket(mailboxesBucket).DeleteBucket([]byte(recipient)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(indexBucket).DeleteBucket([]byte(recipient)); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		removed = true
+		return nil
+	})
+	return removed, err
+}
+
+// DeadLetter moves selected messages to the dead-letter bucket in one transaction,
+// so the move is atomic.
+func (s *boltStore) DeadLetter(recipient string, reason func(Message) string) ([]Message, error) {
+	var dead []Message
+	err := s.update(func(tx *bolt.Tx) error {
+		box, _ := mailbox(tx, recipient)
+		if box == nil {
+			return nil // No mailbox, nothing to move
+		}
+		messages, bad := readMessages(box, mailboxFile(recipient))
+
+		var remaining, moved []Message
+		for _, msg := range messages {
+			if r := reason(msg); r != "" {
+				msg.DeadReason = r
+				moved = append(moved, msg)
+				continue
+			}
+			remaining = append(remaining, msg)
+		}
+		if len(moved) == 0 {
+			return nil
+		}
+
+		bucket, err := tx.CreateBucketIfNotExists(deadLettersBucket)
+		if err != nil {
+			return err
+		}
+		for _, msg := range moved {
+			if err := putDeadLetter(bucket, msg); err != nil {
+				return err
+			}
+		}
+		if err := quarantine(s.repoRoot, recipient, bad); err != nil {
+			return err
+		}
+		if err := replaceMailbox(tx, recipient, remaining); err != nil {
+			return err
+		}
+		dead = moved
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dead, nil
+}
+
+// ReadDeadLetters returns the dead-letter bucket in the order messages were moved.
+func (s *boltStore) ReadDeadLetters() ([]Message, error) {
+	messages := []Message{}
+	err := s.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(deadLettersBucket)
+		if bucket == nil {
+			return nil
+		}
+		if read, _ := readMessages(bucket, deadLetterFile); read != nil {
+			messages = read
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ModifyDeadLetters runs fn on the dead-letter bucket inside one read-write transaction.
+func (s *boltStore) ModifyDeadLetters(fn func([]Message) ([]Message, bool, error)) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(deadLettersBucket)
+		if bucket == nil {
+			return s.notExist(string(deadLettersBucket))
+		}
+		messages, bad := readMessages(bucket, deadLetterFile)
+		messages, changed, err := fn(messages)
+		if err != nil || !changed {
+			return err
+		}
+		if err := quarantine(s.repoRoot, deadLetterQuarantine, bad); err != nil {
+			return err
+		}
+		if err := tx.DeleteBucket(deadLettersBucket); err != nil {
+			return err
+		}
+		bucket, err = tx.CreateBucket(deadLettersBucket)
+		if err != nil {
+			return err
+		}
+		for _, msg := range messages {
+			if err := putDeadLetter(bucket, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// putDeadLetter appends a message to the dead-letter bucket.
+func putDeadLetter(bucket *bolt.Bucket, msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	return bucket.Put(seqKey(seq), data)
+}
+
+// ReadRecipients returns all recipient states, ordered by name.
+func (s *boltStore) ReadRecipients() ([]RecipientState, error) {
+	recipients := []RecipientState{}
+	err := s.view(func(tx *bolt.Tx) error {
+		if read, _ := readRecipients(tx); read != nil {
+			recipients = read
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recipients, nil
+}
+
+// WriteRecipients replaces the recipients bucket.
+func (s *boltStore) WriteRecipients(recipients []RecipientState) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return replaceRecipients(tx, recipients)
+	})
+}
+
+// ModifyRecipients runs fn on the recipient states inside one read-write transaction.
+func (s *boltStore) ModifyRecipients(fn func([]RecipientState) ([]RecipientState, bool, error)) error {
+	return s.update(func(tx *bolt.Tx) error {
+		recipients, bad := readRecipients(tx)
+		recipients, changed, err := fn(recipients)
+		if err != nil || !changed {
+			return err
+		}
+		if err := quarantine(s.repoRoot, recipientsQuarantine, bad); err != nil {
+			return err
+		}
+		return replaceRecipients(tx, recipients)
+	})
+}
+
+// AppendEvent adds a delivery event to the events bucket.
+func (s *boltStore) AppendEvent(event Event) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(eventsBucket)
+		if err != nil {
+			return err
+		}
+		return putEvent(bucket, event)
+	})
+}
+
+// ReadEvents returns the events bucket in the order events were recorded.
+func (s *boltStore) ReadEvents() ([]Event, error) {
+	events := []Event{}
+	err := s.view(func(tx *bolt.Tx) error {
+		if read, _ := readEvents(tx); read != nil {
+			events = read
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ModifyEvents runs fn on the events bucket inside one read-write transaction.
+func (s *boltStore) ModifyEvents(fn func([]Event) ([]Event, bool, error)) error {
+	return s.update(func(tx *bolt.Tx) error {
+		events, bad := readEvents(tx)
+		events, changed, err := fn(events)
+		if err != nil || !changed {
+			return err
+		}
+		if err := quarantine(s.repoRoot, eventsQuarantine, bad); err != nil {
+			return err
+		}
+		if tx.Bucket(eventsBucket) != nil {
+			if err := tx.DeleteBucket(eventsBucket); err != nil {
+				return err
+			}
+		}
+		bucket, err := tx.CreateBucket(eventsBucket)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := putEvent(bucket, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// readEvents decodes the events bucket. A missing bucket is empty.
+// Records that cannot be decoded are skipped and returned as bad records.
+func readEvents(tx *bolt.Tx) ([]Event, []BadRecord) {
+	bucket := tx.Bucket(eventsBucket)
+	if bucket == nil {
+		return nil, nil
+	}
+	var events []Event
+	var bad []BadRecord
+	_ = bucket.ForEach(func(key, value []byte) error { // The callback never fails
+		var event Event
+		if err := json.Unmarshal(value, &event); err != nil {
+			bad = append(bad, badValue(eventsFile, key, value, err))
+			return nil
+		}
+		events = append(events, event)
+		return nil
+	})
+	return events, bad
+}
+
+// putEvent appends an event to the events bucket.
+func putEvent(bucket *bolt.Bucket, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	return bucket.Put(seqKey(seq), data)
+}
+
+// readRecipients decodes the recipients bucket. A missing bucket is empty.
+// Records that cannot be decoded are skipped and returned as bad records.
+func readRecipients(tx *bolt.Tx) ([]RecipientState, []BadRecord) {
+	bucket := tx.Bucket(recipientsBucket)
+	if bucket == nil {
+		return nil, nil
+	}
+	var recipients []RecipientState
+	var bad []BadRecord
+	_ = bucket.ForEach(func(key, value []byte) error { // The callback never fails
+		var state RecipientState
+		if err := json.Unmarshal(value, &state); err != nil {
+			bad = append(bad, badValue(recipientsFile+"/"+string(key), key, value, err))
+			return nil
+		}
+		recipients = append(recipients, state)
+		return nil
+	})
+	return recipients, bad
+}
+
+// replaceRecipients drops the recipients bucket and refills it.
+func replaceRecipients(tx *bolt.Tx, recipients []RecipientState) error {
+	if tx.Bucket(recipientsBucket) != nil {
+		if err := tx.DeleteBucket(recipientsBucket); err != nil {
+			return err
+		}
+	}
+	bucket, err := tx.CreateBucket(recipientsBucket)
+	if err != nil {
+		return err
+	}
+	for _, state := range recipients {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put([]byte(state.Recipient), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Repair decodes every record of the database. With fix set, the records that cannot
+// be decoded are quarantined and deleted in one transaction per bucket.
+func (s *boltStore) Repair(fix bool) ([]BadRecord, error) {
+	var found []BadRecord
+	check := func(tx *bolt.Tx) error {
+		if boxes := tx.Bucket(mailboxesBucket); boxes != nil {
+			err := boxes.ForEachBucket(func(name []byte) error {
+				_, bad := readMessages(boxes.Bucket(name), mailboxFile(string(name)))
+				if err := s.dropBad(fix, boxes.Bucket(name), string(name), bad); err != nil {
+					return err
+				}
+				found = append(found, bad...)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if bucket := tx.Bucket(deadLettersBucket); bucket != nil {
+			_, bad := readMessages(bucket, deadLetterFile)
+			if err := s.dropBad(fix, bucket, deadLetterQuarantine, bad); err != nil {
+				return err
+			}
+			found = append(found, bad...)
+		}
+		if bucket := tx.Bucket(recipientsBucket); bucket != nil {
+			_, bad := readRecipients(tx)
+			if fix && len(bad) > 0 {
+				if err := quarantine(s.repoRoot, recipientsQuarantine, bad); err != nil {
+					return err
+				}
+				for _, record := range bad {
+					// Recipient state is keyed by name, which badValue put in File
+					if err := bucket.Delete([]byte(strings.TrimPrefix(record.File, recipientsFile+"/"))); err != nil {
+						return err
+					}
+				}
+			}
+			found = append(found, bad...)
+		}
+		return nil
+	}
+
+	var err error
+	if fix {
+		if _, statErr := os.Stat(s.path); os.IsNotExist(statErr) {
+			return nil, nil // Don't create an empty database
+		}
+		err = s.update(check)
+	} else {
+		err = s.view(check)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// dropBad quarantines bad records of a sequence-keyed bucket and deletes them if fix is set.
+func (s *boltStore) dropBad(fix bool, bucket *bolt.Bucket, name string, bad []BadRecord) error {
+	if !fix || len(bad) == 0 {
+		return nil
+	}
+	if err := quarantine(s.repoRoot, name, bad); err != nil {
+		return err
+	}
+	for _, record := range bad {
+		if err := bucket.Delete(seqKey(uint64(record.Offset))); err != nil { // #nosec G115 - offsets are sequence numbers here
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,904 @@
+package mail
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// jsonlStore keeps one JSONL file per mailbox under .agentmail/mailboxes/,
+// recipient state in .agentmail/recipients.jsonl, dead letters in
+// .agentmail/deadletter/messages.jsonl and delivery events in
+// .agentmail/events.jsonl. Each file is guarded by flock.
+type jsonlStore struct {
+	repoRoot string
+}
+
+// NewJSONLStore returns the JSONL file store for a repository. This is the default backend.
+func NewJSONLStore(repoRoot string) Store {
+	return &jsonlStore{repoRoot: repoRoot}
+}
+
+// mailboxPath returns the mailbox file of a recipient with path traversal protection (G304).
+func (s *jsonlStore) mailboxPath(recipient string) (string, error) {
+	return safePath(filepath.Join(s.repoRoot, MailDir), recipient+".jsonl")
+}
+
+// cursorPath returns the read cursor file kept next to a recipient's mailbox log.
+func (s *jsonlStore) cursorPath(recipient string) (string, error) {
+	return safePath(filepath.Join(s.repoRoot, MailDir), recipient+".cursor")
+}
+
+// Append adds a message to the recipient's mailbox file with file locking.
+func (s *jsonlStore) Append(msg Message) error {
+	// Ensure mail directory exists
+	if err := EnsureMailDir(s.repoRoot); err != nil {
+		return err
+	}
+
+	filePath, err := s.mailboxPath(msg.To)
+	if err != nil {
+		return err
+	}
+
+	// Open file for appending (create if not exists) and acquire exclusive lock
+	file, err := lockFile(filePath, os.O_APPEND|os.O_CREATE|os.O_RDWR, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlockFile(file)
+
+	// Write JSON line, after finishing a line torn by a crash
+	if err := terminateLine(file); err != nil {
+		return err
+	}
+	return writeMessages(file, []Message{msg})
+}
+
+// ReadAll reads all messages from a recipient's mailbox file under a shared lock.
+// Lines that cannot be decoded are skipped.
+func (s *jsonlStore) ReadAll(recipient string) ([]Message, error) {
+	filePath, err := s.mailboxPath(recipient)
+	if err != nil {
+		return nil, err
+	}
+	messages, _, err := s.readMessagesFile(filePath)
+	return messages, err
+}
+
+// ReadPending reads the mailbox log from the read cursor on, so its cost depends on
+// the unread tail rather than the mailbox history.
+func (s *jsonlStore) ReadPending(recipient string) ([]Message, error) {
+	filePath, err := s.mailboxPath(recipient)
+	if err != nil {
+		return nil, err
+	}
+	cursorPath, err := s.cursorPath(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	// Acquire shared lock; the cursor only changes under the exclusive lock
+	file, err := lockFile(filePath, os.O_RDONLY, syscall.LOCK_SH)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Message{}, nil
+		}
+		return nil, err
+	}
+	defer unlockFile(file)
+
+	cursor := readCursor(cursorPath, file)
+	records, _, _, err := readRecordsFrom(file, cursor.Offset)
+	if err != nil {
+		return nil, err
+	}
+	messages, _ := foldRecords(records)
+	return messages, nil
+}
+
+// WriteAll writes all messages to a recipient's mailbox file with locking.
+func (s *jsonlStore) WriteAll(recipient string, messages []Message) error {
+	// Ensure mail directory exists
+	if err := EnsureMailDir(s.repoRoot); err != nil {
+		return err
+	}
+
+	filePath, err := s.mailboxPath(recipient)
+	if err != nil {
+		return err
+	}
+	cursorPath, err := s.cursorPath(recipient)
+	if err != nil {
+		return err
+	}
+
+	// Create the file if needed and acquire exclusive lock, so the rewrite serializes with Append
+	file, err := lockFile(filePath, os.O_CREATE|os.O_RDWR, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlockFile(file)
+
+	// Drop the cursor before the log changes under it, then write messages
+	if err := removeCursor(cursorPath); err != nil {
+		return err
+	}
+	return rewriteMessages(filePath, messages)
+}
+
+// Modify runs fn on a recipient's mailbox file under an exclusive lock.
+// A changed mailbox is rewritten compacted, one line per message.
+func (s *jsonlStore) Modify(recipient string, fn func([]Message) ([]Message, bool, error)) error {
+	filePath, err := s.mailboxPath(recipient)
+	if err != nil {
+		return err
+	}
+	cursorPath, err := s.cursorPath(recipient)
+	if err != nil {
+		return err
+	}
+	_, err = s.modifyMessagesFile(filePath, cursorPath, recipient, fn)
+	return err
+}
+
+// UpdateMessage appends an update record for one message instead of rewriting the log.
+func (s *jsonlStore) UpdateMessage(recipient string, messageID string, fn func(*Message)) (Message, error) {
+	return s.appendUpdate(recipient, messageID, fn, false)
+}
+
+// MarkRead appends a read tombstone for one message. Unknown IDs are ignored.
+func (s *jsonlStore) MarkRead(recipient string, messageID string) (Message, error) {
+	var before Message
+	_, err := s.appendUpdate(recipient, messageID, func(msg *Message) {
+		before = *msg
+		msg.ReadFlag = true
+	}, true)
+	if err == ErrMessageNotFound {
+		return Message{}, nil
+	}
+	return before, err
+}
+
+// UpdateUnread appends an update record for every unread message fn changes.
+// Unread messages all lie after the read cursor, so only that tail is read;
+// the cursor keeps its offset and counts the new records as garbage.
+func (s *jsonlStore) UpdateUnread(recipient string, fn func(*Message) bool) ([]Message, error) {
+	filePath, err := s.mailboxPath(recipient)
+	if err != nil {
+		return nil, err
+	}
+	cursorPath, err := s.cursorPath(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	// Acquire exclusive lock for the read-append cycle
+	file, err := lockFile(filePath, os.O_RDWR, syscall.LOCK_EX)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer unlockFile(file)
+
+	cursor := readCursor(cursorPath, file)
+	records, _, _, err := readRecordsFrom(file, cursor.Offset)
+	if err != nil {
+		return nil, err
+	}
+	messages, _ := foldRecords(records)
+
+	var updated []Message
+	var data []byte
+	for _, msg := range messages {
+		if msg.ReadFlag || !fn(&msg) {
+			continue
+		}
+		line, err := json.Marshal(logRecord{Op: opUpdate, Message: msg})
+		if err != nil {
+			return nil, err
+		}
+		data = append(append(data, line...), '\n')
+		updated = append(updated, msg)
+	}
+	if len(updated) == 0 {
+		return nil, nil
+	}
+
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		return nil, err
+	}
+	if err := terminateLine(file); err != nil {
+		return nil, err
+	}
+	if _, err := file.Write(data); err != nil {
+		return nil, err
+	}
+	cursor.Garbage += len(updated)
+	_ = writeCursor(cursorPath, cursor) // G104: best-effort, the garbage count only schedules compaction
+	return updated, nil
+}
+
+// appendUpdate applies fn to one message and appends the change to the mailbox log:
+// a read tombstone if tombstone is set, otherwise the whole updated message.
+// Only the log from the read cursor on is read unless the message lies before it.
+// The cursor then advances to the first message that is still unread.
+// Returns ErrMessageNotFound if the mailbox doesn't hold the ID.
+func (s *jsonlStore) appendUpdate(recipient string, messageID string, fn func(*Message), tombstone bool) (Message, error) {
+	filePath, err := s.mailboxPath(recipient)
+	if err != nil {
+		return Message{}, err
+	}
+	cursorPath, err := s.cursorPath(recipient)
+	if err != nil {
+		return Message{}, err
+	}
+
+	// Acquire exclusive lock for the read-append-advance cycle
+	file, err := lockFile(filePath, os.O_RDWR, syscall.LOCK_EX)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Message{}, ErrMessageNotFound
+		}
+		return Message{}, err
+	}
+	defer unlockFile(file)
+
+	cursor := readCursor(cursorPath, file)
+	records, offsets, _, err := readRecordsFrom(file, cursor.Offset)
+	if err != nil {
+		return Message{}, err
+	}
+	messages, origins := foldRecords(records)
+	i := indexOfMessage(messages, messageID)
+	if i < 0 && cursor.Offset > 0 {
+		// Read messages live before the cursor: fall back to the whole log
+		cursor.Offset = 0
+		if records, offsets, _, err = readRecordsFrom(file, 0); err != nil {
+			return Message{}, err
+		}
+		messages, origins = foldRecords(records)
+		i = indexOfMessage(messages, messageID)
+	}
+	if i < 0 {
+		return Message{}, ErrMessageNotFound
+	}
+
+	updated := messages[i]
+	fn(&updated)
+
+	var record interface{} = logRecord{Op: opUpdate, Message: updated}
+	if tombstone {
+		record = readRecord{Op: opRead, ID: messageID}
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return Message{}, err
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		return Message{}, err
+	}
+	if err := terminateLine(file); err != nil {
+		return Message{}, err
+	}
+	end, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return Message{}, err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return Message{}, err
+	}
+	messages[i] = updated
+
+	// Advance the cursor to the first unread message (or past the new record).
+	// A failed cursor write only costs speed: the old cursor is still valid.
+	next := end + int64(len(data)) + 1
+	for j, msg := range messages {
+		if !msg.ReadFlag {
+			next = offsets[origins[j]]
+			break
+		}
+	}
+	_ = writeCursor(cursorPath, mailboxCursor{Offset: next, Garbage: cursor.Garbage + 1}) // G104: best-effort, see above
+
+	return updated, nil
+}
+
+// Compact rewrites a mailbox log with one line per message, dropping read and update records.
+// Lines that cannot be decoded are quarantined. With minGarbage > 1 the log is only read
+// if its cursor counts that many appended records.
+func (s *jsonlStore) Compact(recipient string, minGarbage int) (bool, error) {
+	filePath, err := s.mailboxPath(recipient)
+	if err != nil {
+		return false, err
+	}
+	cursorPath, err := s.cursorPath(recipient)
+	if err != nil {
+		return false, err
+	}
+
+	file, err := lockFile(filePath, os.O_RDWR, syscall.LOCK_EX)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer unlockFile(file)
+
+	if minGarbage > 1 && readCursor(cursorPath, file).Garbage < minGarbage {
+		return false, nil
+	}
+
+	records, _, bad, err := readRecordsFrom(file, 0)
+	if err != nil {
+		return false, err
+	}
+	if !hasLogUpdates(records) && len(bad) == 0 {
+		return false, nil
+	}
+	messages, _ := foldRecords(records)
+
+	if err := quarantine(s.repoRoot, recipient, inFile(bad, s.repoRoot, filePath)); err != nil {
+		return false, err
+	}
+	if err := removeCursor(cursorPath); err != nil {
+		return false, err
+	}
+	if err := rewriteMessages(filePath, messages); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// indexOfMessage returns the index of the message with the given ID, or -1.
+func indexOfMessage(messages []Message, messageID string) int {
+	for i := range messages {
+		if messages[i].ID == messageID {
+			return i
+		}
+	}
+	return -1
+}
+
+// ListMailboxes scans the mailboxes directory for .jsonl files.
+func (s *jsonlStore) ListMailboxes() ([]string, error) {
+	mailPath := filepath.Join(s.repoRoot, MailDir)
+
+	entries, err := os.ReadDir(mailPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	var recipients []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		// Only include .jsonl files (mailbox files)
+		if !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		// Extract recipient name (remove .jsonl suffix)
+		recipient := strings.TrimSuffix(name, ".jsonl")
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
+
+// RemoveEmptyMailbox removes a mailbox file that is 0 bytes or holds no messages.
+// Files that cannot be read or hold lines that cannot be decoded are left alone.
+func (s *jsonlStore) RemoveEmptyMailbox(recipient string) (bool, error) {
+	filePath, err := s.mailboxPath(recipient)
+	if err != nil {
+		return false, nil // Skip invalid paths
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil // Already gone
+		}
+		return false, err
+	}
+
+	// If file has content, check if it has any messages
+	// (could be empty after message cleanup left just whitespace/empty lines)
+	if info.Size() > 0 {
+		messages, bad, err := s.readMessagesFile(filePath)
+		if err != nil || len(messages) > 0 || len(bad) > 0 {
+			return false, nil // Skip files we can't read or that need repair
+		}
+	}
+
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if cursorPath, err := s.cursorPath(recipient); err == nil {
+		_ = removeCursor(cursorPath) // G104: a stale cursor is ignored once the log is gone
+	}
+	return true, nil
+}
+
+// DeadLetter moves messages selected by reason from the mailbox file to the dead-letter file.
+// The dead-letter file is appended while the mailbox lock is held.
+func (s *jsonlStore) DeadLetter(recipient string, reason func(Message) string) ([]Message, error) {
+	var dead []Message
+	err := s.Modify(recipient, func(messages []Message) ([]Message, bool, error) {
+		var remaining []Message
+		for _, msg := range messages {
+			if r := reason(msg); r != "" {
+				msg.DeadReason = r
+				dead = append(dead, msg)
+				continue
+			}
+			remaining = append(remaining, msg)
+		}
+		if len(dead) == 0 {
+			return nil, false, nil
+		}
+
+		// Write dead letters before removing them from the mailbox, so a crash
+		// in between duplicates a message rather than losing it
+		if err := appendDeadLetters(s.repoRoot, dead); err != nil {
+			dead = nil
+			return nil, false, err
+		}
+		return remaining, true, nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil // No mailbox, nothing to move
+	}
+	return dead, err
+}
+
+// ReadDeadLetters reads the dead-letter file. Lines that cannot be decoded are skipped.
+func (s *jsonlStore) ReadDeadLetters() ([]Message, error) {
+	messages, _, err := s.readMessagesFile(filepath.Join(s.repoRoot, DeadLetterFile))
+	return messages, err
+}
+
+// ModifyDeadLetters runs fn on the dead-letter file under an exclusive lock.
+func (s *jsonlStore) ModifyDeadLetters(fn func([]Message) ([]Message, bool, error)) error {
+	_, err := s.modifyMessagesFile(filepath.Join(s.repoRoot, DeadLetterFile), "", deadLetterQuarantine, fn)
+	return err
+}
+
+// ReadRecipients reads and parses all recipient states from the recipients file.
+// Lines that cannot be decoded are skipped.
+func (s *jsonlStore) ReadRecipients() ([]RecipientState, error) {
+	recipients, _, err := s.readRecipientsFile()
+	return recipients, err
+}
+
+// readRecipientsFile reads the recipients file, returning the lines that cannot be decoded separately.
+func (s *jsonlStore) readRecipientsFile() ([]RecipientState, []BadRecord, error) {
+	filePath := filepath.Join(s.repoRoot, RecipientsFile) // #nosec G304 - RecipientsFile is a constant, not user input
+
+	data, err := os.ReadFile(filePath) // #nosec G304 - path is constructed from constant
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []RecipientState{}, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	recipients, bad := parseRecipients(data)
+	if recipients == nil {
+		recipients = []RecipientState{}
+	}
+	return recipients, inFile(bad, s.repoRoot, filePath), nil
+}
+
+// WriteRecipients writes all recipient states to the recipients file with file locking.
+func (s *jsonlStore) WriteRecipients(recipients []RecipientState) error {
+	filePath := filepath.Join(s.repoRoot, RecipientsFile) // #nosec G304 - RecipientsFile is a constant
+
+	// Ensure parent directory exists
+	if err := ensureRootDir(s.repoRoot); err != nil {
+		return err
+	}
+
+	// Create the file if needed and acquire exclusive lock
+	file, err := lockFile(filePath, os.O_CREATE|os.O_RDWR, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlockFile(file)
+
+	// Write recipients
+	return rewriteRecipients(filePath, recipients)
+}
+
+// ModifyRecipients runs fn on the recipients file under an exclusive lock.
+// The file is only created when fn reports a change.
+func (s *jsonlStore) ModifyRecipients(fn func([]RecipientState) ([]RecipientState, bool, error)) error {
+	_, err := s.modifyRecipientsFile(fn)
+	return err
+}
+
+// modifyRecipientsFile is ModifyRecipients. Lines that cannot be decoded are
+// quarantined and dropped even if fn reports no change; they are returned.
+func (s *jsonlStore) modifyRecipientsFile(fn func([]RecipientState) ([]RecipientState, bool, error)) ([]BadRecord, error) {
+	filePath := filepath.Join(s.repoRoot, RecipientsFile) // #nosec G304 - RecipientsFile is a constant
+
+	// Acquire exclusive lock for atomic read-modify-write
+	file, err := lockFile(filePath, os.O_RDWR, syscall.LOCK_EX)
+	if os.IsNotExist(err) {
+		// Nothing stored yet: only create the file if fn adds state
+		if _, changed, err := fn(nil); err != nil || !changed {
+			return nil, err
+		}
+		if err := ensureRootDir(s.repoRoot); err != nil {
+			return nil, err
+		}
+		file, err = lockFile(filePath, os.O_CREATE|os.O_RDWR, syscall.LOCK_EX)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer unlockFile(file)
+
+	// Read all recipient states while holding lock (another process may have created the file)
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	recipients, bad := parseRecipients(data)
+	bad = inFile(bad, s.repoRoot, filePath)
+
+	recipients, changed, err := fn(recipients)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		if len(bad) == 0 {
+			return nil, nil
+		}
+		recipients, _ = parseRecipients(data) // fn may return nil when unchanged
+	}
+
+	// Quarantine bad lines, then write back while still holding lock
+	if err := quarantine(s.repoRoot, recipientsQuarantine, bad); err != nil {
+		return nil, err
+	}
+	return bad, rewriteRecipients(filePath, recipients)
+}
+
+// AppendEvent appends a delivery event to the events file with file locking.
+func (s *jsonlStore) AppendEvent(event Event) error {
+	if err := ensureRootDir(s.repoRoot); err != nil {
+		return err
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	filePath := filepath.Join(s.repoRoot, EventsFile) // #nosec G304 - EventsFile is a constant
+	file, err := lockFile(filePath, os.O_APPEND|os.O_CREATE|os.O_RDWR, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlockFile(file)
+
+	if err := terminateLine(file); err != nil {
+		return err
+	}
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// ReadEvents reads the events file under a shared lock. Lines that cannot be decoded are skipped.
+func (s *jsonlStore) ReadEvents() ([]Event, error) {
+	filePath := filepath.Join(s.repoRoot, EventsFile) // #nosec G304 - EventsFile is a constant
+	file, err := lockFile(filePath, os.O_RDONLY, syscall.LOCK_SH)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Event{}, nil
+		}
+		return nil, err
+	}
+	defer unlockFile(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	events, _ := parseEvents(data)
+	if events == nil {
+		events = []Event{}
+	}
+	return events, nil
+}
+
+// ModifyEvents runs fn on the events file under an exclusive lock.
+// Lines that cannot be decoded are quarantined when the file is rewritten.
+func (s *jsonlStore) ModifyEvents(fn func([]Event) ([]Event, bool, error)) error {
+	filePath := filepath.Join(s.repoRoot, EventsFile) // #nosec G304 - EventsFile is a constant
+	file, err := lockFile(filePath, os.O_RDWR, syscall.LOCK_EX)
+	if err != nil {
+		if os.IsNotExist(err) {
+			_, _, err = fn(nil) // No events yet: nothing to store
+		}
+		return err
+	}
+	defer unlockFile(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	events, bad := parseEvents(data)
+	events, changed, err := fn(events)
+	if err != nil || !changed {
+		return err
+	}
+	if err := quarantine(s.repoRoot, eventsQuarantine, inFile(bad, s.repoRoot, filePath)); err != nil {
+		return err
+	}
+	return replaceFile(filePath, func(w io.Writer) error {
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(append(data, '\n')); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// parseEvents decodes JSONL event data, skipping blank lines.
+// Lines that cannot be decoded are skipped and returned as bad records.
+func parseEvents(data []byte) ([]Event, []BadRecord) {
+	var events []Event
+	var bad []BadRecord
+	var offset int64
+	for _, line := range strings.Split(string(data), "\n") {
+		start := offset
+		offset += int64(len(line)) + 1
+		if line == "" {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			bad = append(bad, BadRecord{Offset: start, Reason: err.Error(), Line: line})
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, bad
+}
+
+// rewriteMessages atomically replaces the JSONL file at path with messages (see replaceFile).
+// The caller must hold the file's exclusive lock.
+func rewriteMessages(path string, messages []Message) error {
+	return replaceFile(path, func(w io.Writer) error {
+		return writeMessages(w, messages)
+	})
+}
+
+// writeMessages writes messages as JSON lines, one write per line.
+// The caller is responsible for locking and unlocking.
+func writeMessages(w io.Writer, messages []Message) error {
+	// Write each message as a JSON line
+	for _, msg := range messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseMessages decodes JSONL message data, skipping blank lines and
+// applying read/update records of mailbox logs. Lines that cannot be decoded
+// are skipped and returned as bad records.
+func parseMessages(data []byte) ([]Message, []BadRecord) {
+	records, _, bad := parseRecords(data, 0)
+	messages, _ := foldRecords(records)
+	return messages, bad
+}
+
+// readMessagesFile reads a JSONL message file under a shared lock.
+// A missing file is empty. Lines that cannot be decoded are returned separately.
+func (s *jsonlStore) readMessagesFile(filePath string) ([]Message, []BadRecord, error) {
+	// Open file for reading and acquire shared lock
+	file, err := lockFile(filePath, os.O_RDONLY, syscall.LOCK_SH)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Message{}, nil, nil
+		}
+		return nil, nil, err
+	}
+	defer unlockFile(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	messages, bad := parseMessages(data)
+	if messages == nil {
+		messages = []Message{}
+	}
+	return messages, inFile(bad, s.repoRoot, filePath), nil
+}
+
+// modifyMessagesFile runs fn on the messages stored in an existing JSONL file
+// and writes the result back if fn reports a change. The whole read-modify-write
+// cycle happens under an exclusive lock. Errors returned by fn are passed through.
+// Lines that cannot be decoded are quarantined under name and dropped even if fn
+// reports no change; they are returned. A non-empty cursorPath is removed before
+// the file is rewritten.
+func (s *jsonlStore) modifyMessagesFile(filePath, cursorPath, name string, fn func([]Message) ([]Message, bool, error)) ([]BadRecord, error) {
+	// Acquire exclusive lock for atomic read-modify-write
+	file, err := lockFile(filePath, os.O_RDWR, syscall.LOCK_EX)
+	if err != nil {
+		return nil, err
+	}
+	defer unlockFile(file)
+
+	// Read all messages while holding lock
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	messages, bad := parseMessages(data)
+	bad = inFile(bad, s.repoRoot, filePath)
+
+	messages, changed, err := fn(messages)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		if len(bad) == 0 {
+			return nil, nil
+		}
+		messages, _ = parseMessages(data) // fn may return nil when unchanged
+	}
+
+	// Quarantine bad lines and drop the cursor, then write back while still holding lock
+	if err := quarantine(s.repoRoot, name, bad); err != nil {
+		return nil, err
+	}
+	if cursorPath != "" {
+		if err := removeCursor(cursorPath); err != nil {
+			return nil, err
+		}
+	}
+	return bad, rewriteMessages(filePath, messages)
+}
+
+// Repair scans every mailbox file, the dead-letter file and the recipients file.
+// With fix set, each file holding bad lines is rewritten under its lock without them,
+// after they were quarantined.
+func (s *jsonlStore) Repair(fix bool) ([]BadRecord, error) {
+	unchanged := func([]Message) ([]Message, bool, error) { return nil, false, nil }
+
+	var found []BadRecord
+	scan := func(filePath, cursorPath, name string) error {
+		var bad []BadRecord
+		var err error
+		if fix {
+			bad, err = s.modifyMessagesFile(filePath, cursorPath, name, unchanged)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			_, bad, err = s.readMessagesFile(filePath)
+		}
+		found = append(found, bad...)
+		return err
+	}
+
+	recipients, err := s.ListMailboxes()
+	if err != nil {
+		return nil, err
+	}
+	for _, recipient := range recipients {
+		filePath, err := s.mailboxPath(recipient)
+		if err != nil {
+			return found, err
+		}
+		cursorPath, err := s.cursorPath(recipient)
+		if err != nil {
+			return found, err
+		}
+		if err := scan(filePath, cursorPath, recipient); err != nil {
+			return found, err
+		}
+	}
+	if err := scan(filepath.Join(s.repoRoot, DeadLetterFile), "", deadLetterQuarantine); err != nil {
+		return found, err
+	}
+
+	var bad []BadRecord
+	if fix {
+		bad, err = s.modifyRecipientsFile(func([]RecipientState) ([]RecipientState, bool, error) {
+			return nil, false, nil
+		})
+	} else {
+		_, bad, err = s.readRecipientsFile()
+	}
+	return append(found, bad...), err
+}
+
+// appendDeadLetters appends messages to the dead-letter file with file locking.
+func appendDeadLetters(repoRoot string, messages []Message) error {
+	if err := ensureRootDir(repoRoot); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(repoRoot, DeadLetterDir), 0750); err != nil { // G301: restricted directory permissions
+		return err
+	}
+
+	filePath := filepath.Join(repoRoot, DeadLetterFile) // #nosec G304 - DeadLetterFile is a constant
+	file, err := lockFile(filePath, os.O_APPEND|os.O_CREATE|os.O_RDWR, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlockFile(file)
+
+	if err := terminateLine(file); err != nil {
+		return err
+	}
+	return writeMessages(file, messages)
+}
+
+// parseRecipients decodes JSONL recipient state data, skipping blank lines.
+// Lines that cannot be decoded are skipped and returned as bad records.
+func parseRecipients(data []byte) ([]RecipientState, []BadRecord) {
+	var recipients []RecipientState
+	var bad []BadRecord
+	var offset int64
+	for _, line := range strings.Split(string(data), "\n") {
+		start := offset
+		offset += int64(len(line)) + 1
+		if line == "" {
+			continue
+		}
+		var state RecipientState
+		if err := json.Unmarshal([]byte(line), &state); err != nil {
+			bad = append(bad, BadRecord{Offset: start, Reason: err.Error(), Line: line})
+			continue
+		}
+		recipients = append(recipients, state)
+	}
+	return recipients, bad
+}
+
+// rewriteRecipients atomically replaces the recipients file at path (see replaceFile).
+// The caller must hold the file's exclusive lock.
+func rewriteRecipients(path string, recipients []RecipientState) error {
+	return replaceFile(path, func(w io.Writer) error {
+		// Write each recipient state as a JSON line
+		for _, state := range recipients {
+			data, err := json.Marshal(state)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(append(data, '\n')); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,378 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"agentmail/internal/config"
+)
+
+// storeBackends lists every Store implementation; contract tests run against each.
+var storeBackends = []struct {
+	name string
+	open func(repoRoot string) Store
+}{
+	{config.StoreJSONL, NewJSONLStore},
+	{config.StoreBolt, NewBoltStore},
+}
+
+func forEachStore(t *testing.T, test func(t *testing.T, repoRoot string, store Store)) {
+	t.Helper()
+	for _, backend := range storeBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			repoRoot := t.TempDir()
+			test(t, repoRoot, backend.open(repoRoot))
+		})
+	}
+}
+
+func TestStore_AppendReadAllWriteAll(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repoRoot string, store Store) {
+		messages, err := store.ReadAll("agent-2")
+		if err != nil {
+			t.Fatalf("ReadAll on missing mailbox failed: %v", err)
+		}
+		if messages == nil || len(messages) != 0 {
+			t.Fatalf("Expected empty non-nil slice, got %#v", messages)
+		}
+
+		for _, id := range []string{"a", "b", "c"} {
+			if err := store.Append(Message{ID: id, From: "agent-1", To: "agent-2", Message: id}); err != nil {
+				t.Fatalf("Append failed: %v", err)
+			}
+		}
+		messages, err = store.ReadAll("agent-2")
+		if err != nil {
+			t.Fatalf("ReadAll failed: %v", err)
+		}
+		if len(messages) != 3 || messages[0].ID != "a" || messages[2].ID != "c" {
+			t.Fatalf("Expected a, b, c in arrival order, got %+v", messages)
+		}
+
+		if err := store.WriteAll("agent-2", messages[1:2]); err != nil {
+			t.Fatalf("WriteAll failed: %v", err)
+		}
+		messages, _ = store.ReadAll("agent-2")
+		if len(messages) != 1 || messages[0].ID != "b" {
+			t.Errorf("Expected only b after WriteAll, got %+v", messages)
+		}
+	})
+}
+
+func TestStore_ModifyAndUpdateMessage(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repoRoot string, store Store) {
+		err := store.Modify("agent-2", func(messages []Message) ([]Message, bool, error) {
+			return messages, false, nil
+		})
+		if !os.IsNotExist(err) {
+			t.Fatalf("Expected os.IsNotExist error for missing mailbox, got %v", err)
+		}
+		if _, err := store.UpdateMessage("agent-2", "a", func(*Message) {}); err != ErrMessageNotFound {
+			t.Fatalf("Expected ErrMessageNotFound for missing mailbox, got %v", err)
+		}
+
+		_ = store.Append(Message{ID: "a", To: "agent-2"})
+		_ = store.Append(Message{ID: "b", To: "agent-2"})
+
+		updated, err := store.UpdateMessage("agent-2", "b", func(msg *Message) { msg.ReadFlag = true })
+		if err != nil || !updated.ReadFlag {
+			t.Fatalf("UpdateMessage failed: %+v, %v", updated, err)
+		}
+		if _, err := store.UpdateMessage("agent-2", "zzz", func(*Message) {}); err != ErrMessageNotFound {
+			t.Errorf("Expected ErrMessageNotFound for unknown ID, got %v", err)
+		}
+
+		// Drop read messages, then make sure the ID index still works for the survivors
+		err = store.Modify("agent-2", func(messages []Message) ([]Message, bool, error) {
+			var unread []Message
+			for _, msg := range messages {
+				if !msg.ReadFlag {
+					unread = append(unread, msg)
+				}
+			}
+			return unread, true, nil
+		})
+		if err != nil {
+			t.Fatalf("Modify failed: %v", err)
+		}
+		if _, err := store.UpdateMessage("agent-2", "b", func(*Message) {}); err != ErrMessageNotFound {
+			t.Errorf("Expected removed message to be gone, got %v", err)
+		}
+		if _, err := store.UpdateMessage("agent-2", "a", func(msg *Message) { msg.Attempts = 2 }); err != nil {
+			t.Errorf("UpdateMessage after Modify failed: %v", err)
+		}
+		messages, _ := store.ReadAll("agent-2")
+		if len(messages) != 1 || messages[0].Attempts != 2 {
+			t.Errorf("Expected a with 2 attempts, got %+v", messages)
+		}
+	})
+}
+
+func TestStore_ListAndRemoveEmptyMailboxes(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repoRoot string, store Store) {
+		_ = store.Append(Message{ID: "a", To: "agent-1"})
+		_ = store.WriteAll("agent-2", nil)
+
+		names, err := store.ListMailboxes()
+		if err != nil {
+			t.Fatalf("ListMailboxes failed: %v", err)
+		}
+		if len(names) != 2 || names[0] != "agent-1" || names[1] != "agent-2" {
+			t.Fatalf("Expected [agent-1 agent-2], got %v", names)
+		}
+
+		if removed, err := store.RemoveEmptyMailbox("agent-1"); err != nil || removed {
+			t.Errorf("Expected non-empty mailbox to stay, got removed=%v err=%v", removed, err)
+		}
+		if removed, err := store.RemoveEmptyMailbox("agent-2"); err != nil || !removed {
+			t.Errorf("Expected empty mailbox to be removed, got removed=%v err=%v", removed, err)
+		}
+		names, _ = store.ListMailboxes()
+		if len(names) != 1 || names[0] != "agent-1" {
+			t.Errorf("Expected [agent-1], got %v", names)
+		}
+	})
+}
+
+func TestStore_DeadLetters(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repoRoot string, store Store) {
+		if dead, err := store.DeadLetter("agent-2", func(Message) string { return "x" }); err != nil || len(dead) != 0 {
+			t.Fatalf("Expected nothing moved from missing mailbox, got %v, %v", dead, err)
+		}
+
+		_ = store.Append(Message{ID: "keep", To: "agent-2"})
+		_ = store.Append(Message{ID: "drop", To: "agent-2"})
+
+		dead, err := store.DeadLetter("agent-2", func(msg Message) string {
+			if msg.ID == "drop" {
+				return ReasonUndeliverable
+			}
+			return ""
+		})
+		if err != nil {
+			t.Fatalf("DeadLetter failed: %v", err)
+		}
+		if len(dead) != 1 || dead[0].DeadReason != ReasonUndeliverable {
+			t.Fatalf("Expected one undeliverable message, got %+v", dead)
+		}
+
+		remaining, _ := store.ReadAll("agent-2")
+		if len(remaining) != 1 || remaining[0].ID != "keep" {
+			t.Errorf("Expected only keep in mailbox, got %+v", remaining)
+		}
+		letters, err := store.ReadDeadLetters()
+		if err != nil || len(letters) != 1 || letters[0].ID != "drop" {
+			t.Fatalf("Expected drop in dead letters, got %+v, %v", letters, err)
+		}
+
+		err = store.ModifyDeadLetters(func(messages []Message) ([]Message, bool, error) {
+			return nil, true, nil
+		})
+		if err != nil {
+			t.Fatalf("ModifyDeadLetters failed: %v", err)
+		}
+		letters, _ = store.ReadDeadLetters()
+		if len(letters) != 0 {
+			t.Errorf("Expected no dead letters, got %+v", letters)
+		}
+	})
+}
+
+func TestStore_Recipients(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repoRoot string, store Store) {
+		recipients, err := store.ReadRecipients()
+		if err != nil || recipients == nil || len(recipients) != 0 {
+			t.Fatalf("Expected empty non-nil recipients, got %#v, %v", recipients, err)
+		}
+
+		// An unchanged modify on an empty store must not create anything
+		err = store.ModifyRecipients(func(r []RecipientState) ([]RecipientState, bool, error) {
+			return r, false, nil
+		})
+		if err != nil {
+			t.Fatalf("ModifyRecipients failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(repoRoot, RecipientsFile)); !os.IsNotExist(err) {
+			t.Errorf("Expected no recipients file after unchanged modify, got %v", err)
+		}
+
+		now := time.Now().Truncate(time.Second)
+		err = store.WriteRecipients([]RecipientState{
+			{Recipient: "agent-1", Status: StatusReady, UpdatedAt: now},
+			{Recipient: "agent-2", Status: StatusWork, UpdatedAt: now},
+		})
+		if err != nil {
+			t.Fatalf("WriteRecipients failed: %v", err)
+		}
+
+		err = store.ModifyRecipients(func(r []RecipientState) ([]RecipientState, bool, error) {
+			for i := range r {
+				if r[i].Recipient == "agent-2" {
+					r[i].Status = StatusOffline
+				}
+			}
+			return r, true, nil
+		})
+		if err != nil {
+			t.Fatalf("ModifyRecipients failed: %v", err)
+		}
+
+		recipients, _ = store.ReadRecipients()
+		if len(recipients) != 2 || recipients[1].Status != StatusOffline || !recipients[0].UpdatedAt.Equal(now) {
+			t.Errorf("Unexpected recipients: %+v", recipients)
+		}
+	})
+}
+
+func TestStore_MarkReadReturnsPreviousState(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repoRoot string, store Store) {
+		_ = store.Append(Message{ID: "a", From: "agent-1", To: "agent-2"})
+
+		before, err := store.MarkRead("agent-2", "a")
+		if err != nil || before.ID != "a" || before.ReadFlag {
+			t.Fatalf("Expected unread a before first MarkRead, got %+v, %v", before, err)
+		}
+		before, err = store.MarkRead("agent-2", "a")
+		if err != nil || !before.ReadFlag {
+			t.Errorf("Expected read a before second MarkRead, got %+v, %v", before, err)
+		}
+		before, err = store.MarkRead("agent-2", "missing")
+		if err != nil || before.ID != "" {
+			t.Errorf("Expected zero Message for unknown ID, got %+v, %v", before, err)
+		}
+	})
+}
+
+func TestStore_UpdateUnread(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repoRoot string, store Store) {
+		_ = store.Append(Message{ID: "a", From: "agent-1", To: "agent-2"})
+		_ = store.Append(Message{ID: "b", From: "agent-1", To: "agent-2"})
+		_ = store.Append(Message{ID: "c", From: "agent-1", To: "agent-2"})
+		_, _ = store.MarkRead("agent-2", "a")
+
+		updated, err := store.UpdateUnread("agent-2", func(msg *Message) bool {
+			if msg.ID == "c" {
+				return false
+			}
+			msg.Notified++
+			return true
+		})
+		if err != nil || len(updated) != 1 || updated[0].ID != "b" {
+			t.Fatalf("Expected only b to be updated, got %+v, %v", updated, err)
+		}
+		messages, _ := store.ReadAll("agent-2")
+		for _, msg := range messages {
+			if want := map[string]int{"b": 1}[msg.ID]; msg.Notified != want {
+				t.Errorf("Message %s: expected %d notifications, got %d", msg.ID, want, msg.Notified)
+			}
+		}
+
+		if updated, err := store.UpdateUnread("nobody", func(*Message) bool { return true }); err != nil || len(updated) != 0 {
+			t.Errorf("Expected nothing to update in a missing mailbox, got %+v, %v", updated, err)
+		}
+	})
+}
+
+func TestStore_Events(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repoRoot string, store Store) {
+		events, err := store.ReadEvents()
+		if err != nil || events == nil || len(events) != 0 {
+			t.Fatalf("Expected empty non-nil events, got %#v, %v", events, err)
+		}
+		if err := store.ModifyEvents(func(events []Event) ([]Event, bool, error) {
+			return events, false, nil
+		}); err != nil {
+			t.Fatalf("ModifyEvents without events failed: %v", err)
+		}
+
+		now := time.Now().UTC().Truncate(time.Second)
+		for _, name := range []string{EventQueued, EventRead, EventAcked} {
+			if err := store.AppendEvent(Event{MessageID: "a", Event: name, At: now, Recipient: "agent-2"}); err != nil {
+				t.Fatalf("AppendEvent failed: %v", err)
+			}
+		}
+		events, err = store.ReadEvents()
+		if err != nil || len(events) != 3 || events[0].Event != EventQueued || events[2].Event != EventAcked {
+			t.Fatalf("Expected queued, read, acked in order, got %+v, %v", events, err)
+		}
+		if !events[1].At.Equal(now) || events[1].Recipient != "agent-2" {
+			t.Errorf("Expected event fields to round-trip, got %+v", events[1])
+		}
+
+		err = store.ModifyEvents(func(events []Event) ([]Event, bool, error) {
+			return events[:1], true, nil
+		})
+		if err != nil {
+			t.Fatalf("ModifyEvents failed: %v", err)
+		}
+		events, _ = store.ReadEvents()
+		if len(events) != 1 || events[0].Event != EventQueued {
+			t.Errorf("Expected only queued after ModifyEvents, got %+v", events)
+		}
+	})
+}
+
+func TestOpenStore_SelectsBackendFromConfig(t *testing.T) {
+	repoRoot := t.TempDir()
+	t.Setenv(config.EnvStore, config.StoreBolt)
+
+	if err := Append(repoRoot, Message{ID: "a", From: "agent-1", To: "agent-2", Message: "hi"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, BoltFile)); err != nil {
+		t.Fatalf("Expected bolt database to exist: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, MailDir, "agent-2.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("Expected no JSONL mailbox with the bolt store, got %v", err)
+	}
+
+	// Package-level operations work end-to-end on the bolt store
+	unread, err := FindUnread(repoRoot, "agent-2")
+	if err != nil || len(unread) != 1 || unread[0].CreatedAt.IsZero() {
+		t.Fatalf("Expected one unread message with CreatedAt, got %+v, %v", unread, err)
+	}
+	if err := MarkAsRead(repoRoot, "agent-2", "a"); err != nil {
+		t.Fatalf("MarkAsRead failed: %v", err)
+	}
+	if unread, _ := FindUnread(repoRoot, "agent-2"); len(unread) != 0 {
+		t.Errorf("Expected no unread messages, got %+v", unread)
+	}
+	if err := UpdateRecipientState(repoRoot, "agent-2", StatusReady, false); err != nil {
+		t.Fatalf("UpdateRecipientState failed: %v", err)
+	}
+	if recipients, _ := ReadAllRecipients(repoRoot); len(recipients) != 1 {
+		t.Errorf("Expected one recipient, got %+v", recipients)
+	}
+
+	t.Setenv(config.EnvStore, "sqlite")
+	if _, err := OpenStore(repoRoot); err == nil {
+		t.Error("Expected error for unknown store")
+	}
+}
+
+func TestRequeue_BoltStore(t *testing.T) {
+	repoRoot := t.TempDir()
+	t.Setenv(config.EnvStore, config.StoreBolt)
+
+	appendAged(t, repoRoot, Message{ID: "gone", From: "agent-1", To: "closed"}, 2*time.Hour)
+	moved, err := DeadLetterSweep(repoRoot, []string{"agent-1"}, time.Hour, 0)
+	if err != nil || moved != 1 {
+		t.Fatalf("Expected 1 moved, got %d, %v", moved, err)
+	}
+
+	msg, err := Requeue(repoRoot, "gone", "agent-1")
+	if err != nil {
+		t.Fatalf("Requeue failed: %v", err)
+	}
+	if msg.To != "agent-1" || msg.DeadReason != "" {
+		t.Errorf("Unexpected requeued message: %+v", msg)
+	}
+	if dead, _ := ListDeadLetters(repoRoot); len(dead) != 0 {
+		t.Errorf("Expected dead-letter mailbox to be empty, got %+v", dead)
+	}
+	if unread, _ := FindUnread(repoRoot, "agent-1"); len(unread) != 1 {
+		t.Errorf("Expected requeued message in agent-1's mailbox, got %+v", unread)
+	}
+}
@@ -0,0 +1,85 @@
+package mail
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrMessageNotFound is returned when a message ID does not exist in any mailbox.
+var ErrMessageNotFound = errors.New("message not found")
+
+// FindMessage searches every mailbox for a message with the given ID.
+// Returns ErrMessageNotFound if no mailbox contains it.
+func FindMessage(repoRoot string, id string) (Message, error) {
+	recipients, err := ListMailboxRecipients(repoRoot)
+	if err != nil {
+		return Message{}, err
+	}
+
+	for _, recipient := range recipients {
+		messages, err := ReadAll(repoRoot, recipient)
+		if err != nil {
+			return Message{}, err
+		}
+		for _, msg := range messages {
+			if msg.ID == id {
+				return msg, nil
+			}
+		}
+	}
+
+	return Message{}, ErrMessageNotFound
+}
+
+// FindThread returns every message in the conversation containing the given message ID.
+// Messages are collected from all mailboxes and sorted by CreatedAt (oldest first).
+// Returns ErrMessageNotFound if the ID does not exist.
+func FindThread(repoRoot string, id string) ([]Message, error) {
+	start, err := FindMessage(repoRoot, id)
+	if err != nil {
+		return nil, err
+	}
+	root := start.ThreadRoot()
+
+	recipients, err := ListMailboxRecipients(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var thread []Message
+	for _, recipient := range recipients {
+		messages, err := ReadAll(repoRoot, recipient)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range messages {
+			if msg.ThreadRoot() == root {
+				thread = append(thread, msg)
+			}
+		}
+	}
+
+	// Stable sort keeps mailbox order for messages with equal timestamps
+	sort.SliceStable(thread, func(i, j int) bool {
+		return thread[i].CreatedAt.Before(thread[j].CreatedAt)
+	})
+
+	return thread, nil
+}
+
+// FindReply returns the oldest unread message in the recipient's mailbox that
+// replies to the given message ID. The boolean is false if no reply has arrived.
+func FindReply(repoRoot string, recipient string, messageID string) (Message, bool, error) {
+	unread, err := FindUnread(repoRoot, recipient)
+	if err != nil {
+		return Message{}, false, err
+	}
+
+	for _, msg := range unread {
+		if msg.InReplyTo == messageID {
+			return msg, true, nil
+		}
+	}
+
+	return Message{}, false, nil
+}
@@ -0,0 +1,105 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeMailbox writes raw JSONL content to a recipient's mailbox file.
+func writeMailbox(t *testing.T, repoRoot, recipient, content string) {
+	t.Helper()
+
+	mailDir := filepath.Join(repoRoot, MailDir)
+	if err := os.MkdirAll(mailDir, 0755); err != nil {
+		t.Fatalf("Failed to create mail dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(mailDir, recipient+".jsonl"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write mailbox: %v", err)
+	}
+}
+
+func TestMessage_ThreadRoot(t *testing.T) {
+	root := Message{ID: "root0001"}
+	if got := root.ThreadRoot(); got != "root0001" {
+		t.Errorf("ThreadRoot() for new message = %q, want %q", got, "root0001")
+	}
+
+	reply := Message{ID: "reply001", InReplyTo: "root0001", ThreadID: "root0001"}
+	if got := reply.ThreadRoot(); got != "root0001" {
+		t.Errorf("ThreadRoot() for reply = %q, want %q", got, "root0001")
+	}
+}
+
+func TestFindMessage_AcrossMailboxes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeMailbox(t, tmpDir, "agent-1", `{"id":"msg00001","from":"agent-2","to":"agent-1","message":"one","read_flag":false}
+`)
+	writeMailbox(t, tmpDir, "agent-2", `{"id":"msg00002","from":"agent-1","to":"agent-2","message":"two","read_flag":true}
+`)
+
+	msg, err := FindMessage(tmpDir, "msg00002")
+	if err != nil {
+		t.Fatalf("FindMessage failed: %v", err)
+	}
+	if msg.To != "agent-2" || msg.Message != "two" {
+		t.Errorf("FindMessage returned wrong message: %+v", msg)
+	}
+}
+
+func TestFindMessage_NotFound(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeMailbox(t, tmpDir, "agent-1", `{"id":"msg00001","from":"agent-2","to":"agent-1","message":"one","read_flag":false}
+`)
+
+	_, err := FindMessage(tmpDir, "missing1")
+	if err != ErrMessageNotFound {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+}
+
+func TestFindThread_MergesMailboxesInOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Question from agent-1, answer from agent-2, follow-up from agent-1
+	writeMailbox(t, tmpDir, "agent-2", `{"id":"q0000001","from":"agent-1","to":"agent-2","message":"question","read_flag":true,"created_at":"2026-01-01T10:00:00Z"}
+{"id":"f0000001","from":"agent-1","to":"agent-2","message":"follow-up","read_flag":false,"created_at":"2026-01-01T10:02:00Z","in_reply_to":"a0000001","thread_id":"q0000001"}
+{"id":"other001","from":"agent-3","to":"agent-2","message":"unrelated","read_flag":false,"created_at":"2026-01-01T10:01:30Z"}
+`)
+	writeMailbox(t, tmpDir, "agent-1", `{"id":"a0000001","from":"agent-2","to":"agent-1","message":"answer","read_flag":true,"created_at":"2026-01-01T10:01:00Z","in_reply_to":"q0000001","thread_id":"q0000001"}
+`)
+
+	// Looking up any message in the thread returns the whole conversation
+	for _, id := range []string{"q0000001", "a0000001", "f0000001"} {
+		thread, err := FindThread(tmpDir, id)
+		if err != nil {
+			t.Fatalf("FindThread(%s) failed: %v", id, err)
+		}
+
+		var ids []string
+		for _, msg := range thread {
+			ids = append(ids, msg.ID)
+		}
+		want := []string{"q0000001", "a0000001", "f0000001"}
+		if len(ids) != len(want) {
+			t.Fatalf("FindThread(%s) returned %v, want %v", id, ids, want)
+		}
+		for i := range want {
+			if ids[i] != want[i] {
+				t.Errorf("FindThread(%s) returned %v, want %v", id, ids, want)
+				break
+			}
+		}
+	}
+}
+
+func TestFindThread_NotFound(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_, err := FindThread(tmpDir, "missing1")
+	if err != ErrMessageNotFound {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// VersionFile records the on-disk format version of a repository's store
+const VersionFile = ".agentmail/VERSION"
+
+// CurrentVersion is the newest store format this build reads and writes.
+//
+//	0: mailboxes in .git/mail/, recipients in .git/mail-recipients.jsonl (before 007-storage-restructure)
+//	1: everything under .agentmail/
+const CurrentVersion = 1
+
+// ErrStoreTooNew is returned by every write to a store whose VERSION is newer than CurrentVersion.
+var ErrStoreTooNew = errors.New("store was written by a newer agentmail; upgrade agentmail to write to it")
+
+// Legacy (version 0) locations, relative to the repository root
+const (
+	legacyMailDir        = ".git/mail"
+	legacyRecipientsFile = ".git/mail-recipients.jsonl"
+)
+
+// ReadVersion returns the store format version of a repository.
+// A store without VERSION is version 0 while legacy data is left in .git/,
+// and CurrentVersion otherwise (stores written before versioning share its layout).
+func ReadVersion(repoRoot string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(repoRoot, VersionFile)) // #nosec G304 - VersionFile is a constant
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return 0, err
+		}
+		if hasLegacyData(repoRoot) {
+			return 0, nil
+		}
+		return CurrentVersion, nil
+	}
+
+	version, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", VersionFile, strings.TrimSpace(string(data)))
+	}
+	return version, nil
+}
+
+// writeVersion records the store format version.
+func writeVersion(repoRoot string, version int) error {
+	return os.WriteFile(filepath.Join(repoRoot, VersionFile), []byte(strconv.Itoa(version)+"\n"), 0600) // #nosec G306 - restricted file permissions
+}
+
+// hasLegacyData reports whether version 0 mailboxes or recipient state are left in .git/.
+func hasLegacyData(repoRoot string) bool {
+	if _, err := os.Stat(filepath.Join(repoRoot, legacyRecipientsFile)); err == nil {
+		return true
+	}
+	matches, _ := filepath.Glob(filepath.Join(repoRoot, legacyMailDir, "*.jsonl"))
+	return len(matches) > 0
+}
+
+// CheckWritable is the gate of every write under .agentmail/: it fails with
+// ErrStoreTooNew if VERSION is newer than CurrentVersion, so an older binary
+// leaves state it doesn't understand alone. Mail, the alias table and the
+// config file are all refused.
+func CheckWritable(repoRoot string) error {
+	version, err := ReadVersion(repoRoot)
+	if err != nil {
+		return err
+	}
+	if version > CurrentVersion {
+		return tooNewError(version)
+	}
+	return nil
+}
+
+// tooNewError is the error of a write to a store at version.
+func tooNewError(version int) error {
+	return fmt.Errorf("%w (store version %d, supported %d)", ErrStoreTooNew, version, CurrentVersion)
+}
+
+// ensureRootDir checks that the store is writable, creates the .agentmail/
+// directory if needed and stamps a store without VERSION with CurrentVersion,
+// unless legacy data still needs migrating.
+func ensureRootDir(repoRoot string) error {
+	if err := CheckWritable(repoRoot); err != nil {
+		return err
+	}
+	rootPath := filepath.Join(repoRoot, RootDir)
+	if err := os.MkdirAll(rootPath, 0750); err != nil { // G301: restricted directory permissions
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(repoRoot, VersionFile)); !os.IsNotExist(err) {
+		return err
+	}
+	if hasLegacyData(repoRoot) {
+		return nil // Stays version 0 until "agentmail migrate"
+	}
+	return writeVersion(repoRoot, CurrentVersion)
+}
+
+// readOnlyStore wraps the store of a repository with a newer format version:
+// reads are passed through, every write fails with ErrStoreTooNew. It fails
+// writes that don't pass through ensureRootDir (updates of existing files)
+// without touching the disk.
+type readOnlyStore struct {
+	Store
+	err error
+}
+
+// newReadOnlyStore returns store with writes disabled for a store at version.
+func newReadOnlyStore(store Store, version int) Store {
+	return &readOnlyStore{
+		Store: store,
+		err:   tooNewError(version),
+	}
+}
+
+func (s *readOnlyStore) Append(Message) error                    { return s.err }
+func (s *readOnlyStore) WriteAll(string, []Message) error        { return s.err }
+func (s *readOnlyStore) Compact(string, int) (bool, error)       { return false, s.err }
+func (s *readOnlyStore) RemoveEmptyMailbox(string) (bool, error) { return false, s.err }
+func (s *readOnlyStore) WriteRecipients([]RecipientState) error  { return s.err }
+func (s *readOnlyStore) AppendEvent(Event) error                 { return s.err }
+
+func (s *readOnlyStore) MarkRead(string, string) (Message, error) {
+	return Message{}, s.err
+}
+
+func (s *readOnlyStore) Modify(string, func([]Message) ([]Message, bool, error)) error {
+	return s.err
+}
+
+func (s *readOnlyStore) UpdateMessage(string, string, func(*Message)) (Message, error) {
+	return Message{}, s.err
+}
+
+func (s *readOnlyStore) UpdateUnread(string, func(*Message) bool) ([]Message, error) {
+	return nil, s.err
+}
+
+func (s *readOnlyStore) DeadLetter(string, func(Message) string) ([]Message, error) {
+	return nil, s.err
+}
+
+func (s *readOnlyStore) ModifyDeadLetters(func([]Message) ([]Message, bool, error)) error {
+	return s.err
+}
+
+func (s *readOnlyStore) ModifyRecipients(func([]RecipientState) ([]RecipientState, bool, error)) error {
+	return s.err
+}
+
+func (s *readOnlyStore) ModifyEvents(func([]Event) ([]Event, bool, error)) error {
+	return s.err
+}
+
+// Repair only checks: fixing would write.
+func (s *readOnlyStore) Repair(fix bool) ([]BadRecord, error) {
+	if fix {
+		return nil, s.err
+	}
+	return s.Store.Repair(false)
+}
@@ -0,0 +1,116 @@
+package mail
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agentmail/internal/tmux"
+)
+
+func TestReadVersion_Defaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	if version, err := ReadVersion(tmpDir); err != nil || version != CurrentVersion {
+		t.Errorf("Expected CurrentVersion for an empty repository, got %d, %v", version, err)
+	}
+
+	writeLegacyMailbox(t, tmpDir, "agent-2", `{"id":"a","from":"agent-1","message":"hi"}`)
+	if version, err := ReadVersion(tmpDir); err != nil || version != 0 {
+		t.Errorf("Expected version 0 with legacy data, got %d, %v", version, err)
+	}
+}
+
+func TestReadVersion_Invalid(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(tmpDir, RootDir), 0750)
+	if err := os.WriteFile(filepath.Join(tmpDir, VersionFile), []byte("one\n"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := ReadVersion(tmpDir); err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Errorf("Expected invalid VERSION error, got %v", err)
+	}
+}
+
+func TestEnsureRootDir_StampsVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := Append(tmpDir, Message{ID: "a", From: "agent-1", To: "agent-2", Message: "hi"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, VersionFile))
+	if err != nil || strings.TrimSpace(string(data)) != "1" {
+		t.Errorf("Expected VERSION 1 after the first write, got %q, %v", data, err)
+	}
+
+	// A legacy store is left unstamped until it is migrated
+	legacyDir := t.TempDir()
+	writeLegacyMailbox(t, legacyDir, "agent-2", `{"id":"a","from":"agent-1","message":"hi"}`)
+	if err := Append(legacyDir, Message{ID: "b", From: "agent-1", To: "agent-2", Message: "hi"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(legacyDir, VersionFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected no VERSION for a legacy store, got %v", err)
+	}
+}
+
+func TestOpenStore_NewerVersionIsReadOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = Append(tmpDir, Message{ID: "a", From: "agent-1", To: "agent-2", Message: "hi"})
+	if err := writeVersion(tmpDir, CurrentVersion+1); err != nil {
+		t.Fatalf("writeVersion failed: %v", err)
+	}
+
+	messages, err := ReadAll(tmpDir, "agent-2")
+	if err != nil || len(messages) != 1 {
+		t.Errorf("Expected reads to work, got %+v, %v", messages, err)
+	}
+	if err := Append(tmpDir, Message{ID: "b", From: "agent-1", To: "agent-2"}); !errors.Is(err, ErrStoreTooNew) {
+		t.Errorf("Expected ErrStoreTooNew from Append, got %v", err)
+	}
+	if err := MarkAsRead(tmpDir, "agent-2", "a"); !errors.Is(err, ErrStoreTooNew) {
+		t.Errorf("Expected ErrStoreTooNew from MarkAsRead, got %v", err)
+	}
+	if err := WriteAllRecipients(tmpDir, nil); !errors.Is(err, ErrStoreTooNew) {
+		t.Errorf("Expected ErrStoreTooNew from WriteAllRecipients, got %v", err)
+	}
+	if _, err := RepairStore(tmpDir, true); !errors.Is(err, ErrStoreTooNew) {
+		t.Errorf("Expected ErrStoreTooNew from repair, got %v", err)
+	}
+	if _, err := RepairStore(tmpDir, false); err != nil {
+		t.Errorf("Expected the check to work, got %v", err)
+	}
+}
+
+func TestCheckWritable_NewerVersionRefusesAliasAndGroupWrites(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := writeAliases(t, tmpDir); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := writeVersion(tmpDir, CurrentVersion+1); err != nil {
+		t.Fatalf("writeVersion failed: %v", err)
+	}
+	before, _ := os.ReadFile(filepath.Join(tmpDir, AliasesFile))
+
+	if err := CheckWritable(tmpDir); !errors.Is(err, ErrStoreTooNew) {
+		t.Errorf("Expected ErrStoreTooNew from CheckWritable, got %v", err)
+	}
+	if err := writeAliases(t, tmpDir); !errors.Is(err, ErrStoreTooNew) {
+		t.Errorf("Expected ErrStoreTooNew from an alias write, got %v", err)
+	}
+	if after, _ := os.ReadFile(filepath.Join(tmpDir, AliasesFile)); string(after) != string(before) {
+		t.Errorf("Alias table changed:\n%s", after)
+	}
+	msg := Message{From: "agent-1", Message: "hi"}
+	if _, err := Broadcast(tmpDir, msg, []string{"agent-2", "agent-3"}); !errors.Is(err, ErrStoreTooNew) {
+		t.Errorf("Expected ErrStoreTooNew from a group send, got %v", err)
+	}
+}
+
+// writeAliases registers a pane in the alias table of repoRoot.
+func writeAliases(t *testing.T, repoRoot string) error {
+	t.Helper()
+	agent := pane("%3", "web", "agent-1")
+	_, err := Register(repoRoot, agent, []tmux.Pane{agent})
+	return err
+}
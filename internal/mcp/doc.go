@@ -1,12 +1,20 @@
 // Package mcp provides an MCP (Model Context Protocol) server implementation
 // for AgentMail, enabling AI agents to communicate via STDIO transport.
 //
-// The MCP server exposes AgentMail functionality through four tools:
+// The MCP server exposes AgentMail functionality through these tools:
 //
 //   - send: Send a message to another agent in the tmux session
+//   - ask: Send a message and block until the recipient replies
 //   - receive: Receive the oldest unread message from the agent's mailbox
-//   - status: Set the agent's availability status (ready/work/offline)
+//   - wait-for-message: Block until a message arrives, then receive it
+//   - ack: Acknowledge a message received with a lease
+//   - status: Set the agent's availability status (ready/work/offline) and how it is notified
 //   - list-recipients: List all available agents in the current tmux session
+//   - inbox: List the messages in the agent's mailbox without marking them read
+//   - read: Show any message in the agent's mailbox by ID
+//   - peek: Show the next unread message without marking it read
+//   - search: Search every mailbox for earlier messages
+//   - message-status: Show whether a sent message was queued, notified, read or acked
 //
 // The server uses the official MCP Go SDK from github.com/modelcontextprotocol/go-sdk
 // and communicates over STDIO transport, making it suitable for integration with
@@ -3,11 +3,14 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
+	"agentmail/internal/config"
+	"agentmail/internal/daemon"
 	"agentmail/internal/mail"
-	"agentmail/internal/tmux"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -53,14 +56,38 @@ func getHandlerOptions() *HandlerOptions {
 
 // SendResponse represents a successful send response.
 type SendResponse struct {
-	MessageID string `json:"message_id"` // Generated message ID
+	MessageID    string   `json:"message_id,omitempty"`    // Generated message ID (single recipient)
+	BroadcastID  string   `json:"broadcast_id,omitempty"`  // Shared ID of all copies (group address)
+	Recipients   []string `json:"recipients,omitempty"`    // Windows that received a copy (group address)
+	DeliverAfter string   `json:"deliver_after,omitempty"` // RFC 3339 scheduled delivery time (scheduled sends only)
+	Repository   string   `json:"repository,omitempty"`    // Repository the message was delivered to, if not this one
 }
 
 // ReceiveResponse represents a successful receive response with a message.
 type ReceiveResponse struct {
-	From    string `json:"from"`    // Sender window name
-	ID      string `json:"id"`      // Message ID
-	Message string `json:"message"` // Message content
+	From         string            `json:"from"`                    // Sender window name
+	ID           string            `json:"id"`                      // Message ID
+	Message      string            `json:"message"`                 // Message content
+	InReplyTo    string            `json:"in_reply_to,omitempty"`   // ID of the message this replies to
+	ThreadID     string            `json:"thread_id,omitempty"`     // Conversation ID (root message ID)
+	Priority     string            `json:"priority,omitempty"`      // low, high or urgent (omitted for normal)
+	ExpectsReply bool              `json:"expects_reply,omitempty"` // Sender is waiting for a reply (ask)
+	LeaseUntil   string            `json:"lease_until,omitempty"`   // RFC 3339 lease deadline (leased receives only)
+	Attempts     int               `json:"attempts,omitempty"`      // Delivery attempt number (leased receives only)
+	ExpiresAt    string            `json:"expires_at,omitempty"`    // RFC 3339 expiry time (messages with a TTL only)
+	Subject      string            `json:"subject,omitempty"`       // One-line subject (if set)
+	Headers      map[string]string `json:"headers,omitempty"`       // Free-form metadata (if set)
+}
+
+// AckResponse represents a successful ack response.
+type AckResponse struct {
+	Status string `json:"status"` // Always "ok" on success
+}
+
+// AskResponse represents a successful ask response: the question's ID and the reply.
+type AskResponse struct {
+	MessageID string          `json:"message_id"` // ID of the question that was sent
+	Reply     ReceiveResponse `json:"reply"`      // The reply that answered it
 }
 
 // ReceiveEmptyResponse represents a response when no messages are available.
@@ -80,26 +107,141 @@ type ListRecipientsResponse struct {
 
 // RecipientInfo represents a single recipient in the list-recipients response.
 type RecipientInfo struct {
-	Name      string `json:"name"`       // Window name
-	IsCurrent bool   `json:"is_current"` // True if this is the caller's window
+	Name      string `json:"name"`              // Mailbox name, to send to
+	IsCurrent bool   `json:"is_current"`        // True if this is the caller's window
+	Pane      string `json:"pane,omitempty"`    // Pane ID of the agent, e.g. "%12"
+	Session   string `json:"session,omitempty"` // Session holding the pane
+	Window    string `json:"window,omitempty"`  // Current window name, which differs from Name after a rename
+}
+
+// InboxResponse represents the response from the inbox tool.
+type InboxResponse struct {
+	Messages []InboxEntry `json:"messages"` // Newest first
+}
+
+// InboxEntry represents a single message in the inbox response.
+type InboxEntry struct {
+	ID          string `json:"id"`                 // Message ID
+	From        string `json:"from"`               // Sender window name
+	DeliveredAt string `json:"delivered_at"`       // RFC 3339 delivery time
+	State       string `json:"state"`              // unread, read, leased or expired
+	Subject     string `json:"subject,omitempty"`  // One-line subject (if set)
+	Preview     string `json:"preview"`            // First line of the message, truncated
+	Priority    string `json:"priority,omitempty"` // low, high or urgent (omitted for normal)
+}
+
+// ReadResponse represents a successful read response: the message plus its mailbox details.
+type ReadResponse struct {
+	ReceiveResponse
+	To          string `json:"to"`           // Recipient window name
+	DeliveredAt string `json:"delivered_at"` // RFC 3339 delivery time
+	State       string `json:"state"`        // unread, read, leased or expired
+}
+
+// SearchResponse represents the response from the search tool.
+type SearchResponse struct {
+	Matches []SearchResult `json:"matches"` // Oldest first
+}
+
+// SearchResult represents a single message in the search response.
+type SearchResult struct {
+	ID        string `json:"id"`                // Message ID
+	From      string `json:"from"`              // Sender window name
+	To        string `json:"to"`                // Recipient window name
+	CreatedAt string `json:"created_at"`        // RFC 3339 send time
+	Subject   string `json:"subject,omitempty"` // One-line subject (if set)
+	Match     string `json:"match"`             // The matching line, shortened around the match
+}
+
+// MessageStatusResponse represents the response from the message-status tool.
+type MessageStatusResponse struct {
+	ID      string       `json:"id"`                // Message ID
+	From    string       `json:"from,omitempty"`    // Sender window name (omitted once removed)
+	To      string       `json:"to,omitempty"`      // Recipient window name (omitted once removed)
+	Subject string       `json:"subject,omitempty"` // One-line subject (if set)
+	State   string       `json:"state"`             // unread, read, leased, expired, scheduled, dead-lettered or removed
+	Receipt bool         `json:"receipt,omitempty"` // The sender asked for a read receipt
+	Events  []EventEntry `json:"events"`            // Oldest first
+}
+
+// EventEntry represents a single delivery event in the message-status response.
+type EventEntry struct {
+	Event string `json:"event"` // queued, notified, read or acked
+	At    string `json:"at"`    // RFC 3339 time of the event
+}
+
+// messageSizeLimit returns the largest message the send and ask tools accept:
+// max_message_size from the repository's config file, or MaxMessageSize if the
+// repository or its config file cannot be read.
+func messageSizeLimit(opts *HandlerOptions) int {
+	var repoRoot string
+	if opts != nil {
+		repoRoot = opts.RepoRoot
+	}
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			return MaxMessageSize
+		}
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return MaxMessageSize
+	}
+	return cfg.MaxMessageSize
+}
+
+// formatSize formats a size in bytes for error messages, in KB when it is a whole number of kilobytes.
+func formatSize(size int) string {
+	if size%1024 == 0 {
+		return fmt.Sprintf("%dKB", size/1024)
+	}
+	return fmt.Sprintf("%d bytes", size)
 }
 
 // doSend implements the send handler logic.
 // It validates the message, stores it, and returns the response or an error.
-func doSend(ctx context.Context, recipient, message string) (any, error) {
+func doSend(ctx context.Context, params sendParams) (any, error) {
 	opts := getHandlerOptions()
 	if opts == nil {
 		opts = &HandlerOptions{}
 	}
 
+	recipient := params.Recipient
+	message := params.Message
+
 	// Validate message is not empty
 	if message == "" {
 		return nil, fmt.Errorf("no message provided")
 	}
 
-	// FR-013: Validate message size (64KB limit)
-	if len(message) > MaxMessageSize {
-		return nil, fmt.Errorf("message exceeds maximum size of 64KB")
+	// FR-013: Validate message size (64KB unless configured)
+	if limit := messageSizeLimit(opts); len(message) > limit {
+		return nil, fmt.Errorf("message exceeds maximum size of %s", formatSize(limit))
+	}
+
+	if err := mail.ValidatePriority(params.Priority); err != nil {
+		return nil, err
+	}
+	if err := mail.ValidateSubject(params.Subject); err != nil {
+		return nil, err
+	}
+	if err := mail.ValidateHeaders(params.Headers); err != nil {
+		return nil, err
+	}
+	if err := mail.ValidateIdempotencyKey(params.Key); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	deliverAfter, err := mail.DeliveryTime(params.DeliverAt, time.Duration(params.DeliverIn)*time.Second, now)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := mail.ExpiryTime(time.Duration(params.TTL)*time.Second, deliverAfter, now)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get sender identity
@@ -108,14 +250,27 @@ func doSend(ctx context.Context, recipient, message string) (any, error) {
 		sender = opts.MockSender
 	} else {
 		var err error
-		sender, err = tmux.GetCurrentWindow()
+		sender, err = mail.CurrentMailbox(opts.RepoRoot)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get current window: %w", err)
 		}
 	}
 
+	// A retry with the same idempotency key returns the original send instead of sending again
+	if params.Key != "" {
+		if response, err := findDuplicate(opts, sender, params.Key); err != nil || response != nil {
+			return response, err
+		}
+	}
+
+	// Group addresses (@all, @name) fan out to multiple mailboxes
+	if mail.IsGroupAddress(recipient) {
+		return doSendGroup(opts, params, sender, deliverAfter, expiresAt)
+	}
+
 	// FR-009: Validate recipient exists
 	var recipientExists bool
+	var target mail.Target
 	if opts.MockWindows != nil {
 		for _, w := range opts.MockWindows {
 			if w == recipient {
@@ -124,9 +279,12 @@ func doSend(ctx context.Context, recipient, message string) (any, error) {
 			}
 		}
 	} else {
-		var err error
-		recipientExists, err = tmux.WindowExists(recipient)
-		if err != nil {
+		// Pane IDs, session:window addresses and renamed windows all resolve to a mailbox
+		target, err = mail.Resolve(opts.RepoRoot, recipient)
+		switch {
+		case err == nil:
+			recipient, recipientExists = target.Mailbox, true
+		case !errors.Is(err, mail.ErrRecipientNotFound):
 			return nil, fmt.Errorf("failed to check recipient: %w", err)
 		}
 	}
@@ -135,38 +293,32 @@ func doSend(ctx context.Context, recipient, message string) (any, error) {
 		return nil, fmt.Errorf("recipient not found")
 	}
 
+	// A pane of another session working in another repository reads that repository's
+	// store: the message goes there, from an address that resolves back to the sender
+	if target.RepoRoot != "" {
+		return doSendElsewhere(opts, params, target, deliverAfter, expiresAt)
+	}
+
 	// Check if sending to self (not allowed)
 	if recipient == sender {
 		return nil, fmt.Errorf("cannot send message to self")
 	}
 
 	// Load and check ignore list
-	var ignoreList map[string]bool
-	if opts.MockIgnoreList != nil {
-		ignoreList = opts.MockIgnoreList
-	} else {
-		// Determine git root for loading ignore list.
-		// Errors are intentionally ignored: if we can't find git root or load
-		// the ignore list, we proceed without filtering - this is acceptable
-		// as the ignore list is optional.
-		gitRoot := opts.RepoRoot
-		if gitRoot == "" {
-			gitRoot, _ = mail.FindGitRoot() // Error ignored: proceed without ignore list
-		}
-		if gitRoot != "" {
-			ignoreList, _ = mail.LoadIgnoreList(gitRoot) // Error ignored: proceed without ignore list
-		}
-	}
+	ignoreList := loadIgnoreList(opts)
 
 	// Check if recipient is in ignore list
 	if ignoreList != nil && ignoreList[recipient] {
 		return nil, fmt.Errorf("recipient not found")
 	}
 
-	// Generate message ID
-	id, err := mail.GenerateID()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate message ID: %w", err)
+	// Generate message ID unless ask already chose one
+	id := params.id
+	if id == "" {
+		id, err = mail.GenerateID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate message ID: %w", err)
+		}
 	}
 
 	// Determine repository root
@@ -180,27 +332,302 @@ func doSend(ctx context.Context, recipient, message string) (any, error) {
 
 	// Store message
 	msg := mail.Message{
-		ID:       id,
-		From:     sender,
-		To:       recipient,
-		Message:  message,
-		ReadFlag: false,
+		ID:             id,
+		From:           sender,
+		To:             recipient,
+		Message:        message,
+		Subject:        params.Subject,
+		Headers:        params.Headers,
+		ReadFlag:       false,
+		ExpectsReply:   params.expectsReply,
+		Priority:       params.Priority,
+		DeliverAfter:   deliverAfter,
+		ExpiresAt:      expiresAt,
+		NotifyExpired:  params.NotifyExp,
+		Receipt:        params.Receipt,
+		IdempotencyKey: params.Key,
+	}
+
+	// Replies inherit the thread of the message they answer
+	if params.ReplyTo != "" {
+		original, err := mail.FindMessage(repoRoot, params.ReplyTo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find message %s: %w", params.ReplyTo, err)
+		}
+		msg.InReplyTo = original.ID
+		msg.ThreadID = original.ThreadRoot()
 	}
 
 	if err := mail.Append(repoRoot, msg); err != nil {
-		return nil, fmt.Errorf("failed to write message: %w", err)
+		return writeError(err)
 	}
 
 	// FR-004: Return response with message_id
 	return SendResponse{
-		MessageID: id,
+		MessageID:    id,
+		DeliverAfter: formatDeliverAfter(deliverAfter),
+	}, nil
+}
+
+// doSendElsewhere delivers a message into the store of another repository, whose
+// mailman notifies the recipient. The sender is named by its session-qualified
+// window there. The message records the sender's repository and mailbox, where
+// receipts and expiry notices go.
+func doSendElsewhere(opts *HandlerOptions, params sendParams, target mail.Target, deliverAfter, expiresAt time.Time) (any, error) {
+	sender, mailbox := opts.MockSender, opts.MockSender
+	if sender == "" {
+		var err error
+		sender, err = mail.CurrentAddress()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current window: %w", err)
+		}
+		if mailbox, err = mail.CurrentMailbox(opts.RepoRoot); err != nil {
+			return nil, fmt.Errorf("failed to get current window: %w", err)
+		}
+	}
+
+	// The same checks as a send in this repository: not to self, and not to a window
+	// ignored there, or ignored here by its session-qualified address
+	remote := *opts
+	remote.RepoRoot = target.RepoRoot
+	if target.Pane.Address() == sender {
+		return nil, fmt.Errorf("cannot send message to self")
+	}
+	if mail.Ignores(loadIgnoreList(&remote), target) || loadIgnoreList(opts)[target.Pane.Address()] {
+		return nil, fmt.Errorf("recipient not found")
+	}
+
+	// Retries are looked up where the original send went
+	if params.Key != "" {
+		if response, err := findDuplicate(&remote, sender, params.Key); err != nil || response != nil {
+			return response, err
+		}
+	}
+
+	id := params.id
+	if id == "" {
+		var err error
+		id, err = mail.GenerateID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate message ID: %w", err)
+		}
+	}
+
+	msg := mail.Message{
+		ID:             id,
+		From:           sender,
+		To:             target.Mailbox,
+		Message:        params.Message,
+		Subject:        params.Subject,
+		Headers:        params.Headers,
+		ReadFlag:       false,
+		ExpectsReply:   params.expectsReply,
+		Priority:       params.Priority,
+		DeliverAfter:   deliverAfter,
+		ExpiresAt:      expiresAt,
+		NotifyExpired:  params.NotifyExp,
+		Receipt:        params.Receipt,
+		IdempotencyKey: params.Key,
+	}
+
+	// Receipts and expiry notices come back to this repository, and a reply
+	// answers a message of its store
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		repoRoot, _ = mail.FindGitRoot() // G104: FindMessage reports the missing store
+	}
+	if repoRoot != "" {
+		msg.OriginRepo, msg.OriginMailbox = repoRoot, mailbox
+	}
+	if params.ReplyTo != "" {
+		original, err := mail.FindMessage(repoRoot, params.ReplyTo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find message %s: %w", params.ReplyTo, err)
+		}
+		msg.InReplyTo = original.ID
+		msg.ThreadID = original.ThreadRoot()
+	}
+
+	if err := mail.Append(target.RepoRoot, msg); err != nil {
+		return writeError(err)
+	}
+
+	return SendResponse{
+		MessageID:    id,
+		DeliverAfter: formatDeliverAfter(deliverAfter),
+		Repository:   target.RepoRoot,
+	}, nil
+}
+
+// findDuplicate returns the response of the send sender already made with key,
+// or nil if there is none and the send should go ahead.
+func findDuplicate(opts *HandlerOptions, sender string, key string) (any, error) {
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			return nil, fmt.Errorf("not in a git repository: %w", err)
+		}
+	}
+
+	copies, err := mail.FindByIdempotencyKey(repoRoot, sender, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check idempotency key: %w", err)
+	}
+	if len(copies) == 0 {
+		return nil, nil
+	}
+	return originalResponse(copies), nil
+}
+
+// writeError returns the result of a failed send. A concurrent send with the same
+// idempotency key that got there first is not a failure: its response is returned.
+func writeError(err error) (any, error) {
+	var duplicate *mail.DuplicateError
+	if errors.As(err, &duplicate) {
+		return originalResponse(duplicate.Copies), nil
+	}
+	return nil, fmt.Errorf("failed to write message: %w", err)
+}
+
+// originalResponse returns the response of an earlier send again.
+func originalResponse(copies []mail.Message) SendResponse {
+	original := copies[0]
+	if original.BroadcastID == "" {
+		return SendResponse{
+			MessageID:    original.ID,
+			DeliverAfter: formatDeliverAfter(original.DeliverAfter),
+		}
+	}
+	recipients := make([]string, len(copies))
+	for i, msg := range copies {
+		recipients[i] = msg.To
+	}
+	return SendResponse{
+		BroadcastID:  original.BroadcastID,
+		Recipients:   recipients,
+		DeliverAfter: formatDeliverAfter(original.DeliverAfter),
+	}
+}
+
+// loadIgnoreList returns the ignore list from mocks or .agentmailignore.
+// Errors are intentionally ignored: if we can't find git root or load
+// the ignore list, we proceed without filtering - this is acceptable
+// as the ignore list is optional.
+func loadIgnoreList(opts *HandlerOptions) map[string]bool {
+	if opts.MockIgnoreList != nil {
+		return opts.MockIgnoreList
+	}
+
+	gitRoot := opts.RepoRoot
+	if gitRoot == "" {
+		gitRoot, _ = mail.FindGitRoot() // Error ignored: proceed without ignore list
+	}
+	if gitRoot == "" {
+		return nil
+	}
+	ignoreList, _ := mail.LoadIgnoreList(gitRoot) // Error ignored: proceed without ignore list
+	return ignoreList
+}
+
+// doSendGroup delivers one copy of the message to every member of a group address.
+// The sender and ignored windows are excluded; all copies share one broadcast ID.
+func doSendGroup(opts *HandlerOptions, params sendParams, sender string, deliverAfter, expiresAt time.Time) (any, error) {
+	// Get list of windows in the session
+	var windows []string
+	if opts.MockWindows != nil {
+		windows = opts.MockWindows
+	} else {
+		mailboxes, err := mail.SessionMailboxes(opts.RepoRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list windows: %w", err)
+		}
+		windows = mail.MailboxNames(mailboxes)
+	}
+
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			return nil, fmt.Errorf("not in a git repository: %w", err)
+		}
+	}
+
+	groups, err := mail.LoadGroups(repoRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read groups: %w", err)
+	}
+
+	recipients, err := mail.ExpandAddress(params.Recipient, windows, groups, sender, loadIgnoreList(opts))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", params.Recipient, err)
+	}
+
+	msg := mail.Message{
+		From:           sender,
+		Message:        params.Message,
+		Subject:        params.Subject,
+		Headers:        params.Headers,
+		ReadFlag:       false,
+		Priority:       params.Priority,
+		DeliverAfter:   deliverAfter,
+		ExpiresAt:      expiresAt,
+		NotifyExpired:  params.NotifyExp,
+		Receipt:        params.Receipt,
+		IdempotencyKey: params.Key,
+	}
+
+	// Replies inherit the thread of the message they answer
+	if params.ReplyTo != "" {
+		original, err := mail.FindMessage(repoRoot, params.ReplyTo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find message %s: %w", params.ReplyTo, err)
+		}
+		msg.InReplyTo = original.ID
+		msg.ThreadID = original.ThreadRoot()
+	}
+
+	broadcastID, err := mail.Broadcast(repoRoot, msg, recipients)
+	if err != nil {
+		return writeError(err)
+	}
+
+	return SendResponse{
+		BroadcastID:  broadcastID,
+		Recipients:   recipients,
+		DeliverAfter: formatDeliverAfter(deliverAfter),
 	}, nil
 }
 
+// formatDeliverAfter formats a scheduled delivery time for responses ("" if immediate).
+func formatDeliverAfter(deliverAfter time.Time) string {
+	if deliverAfter.IsZero() {
+		return ""
+	}
+	return deliverAfter.Format(time.RFC3339)
+}
+
 // sendParams holds the unmarshaled parameters for the send tool.
 type sendParams struct {
-	Recipient string `json:"recipient"`
-	Message   string `json:"message"`
+	Recipient string            `json:"recipient"`
+	Message   string            `json:"message"`
+	ReplyTo   string            `json:"reply_to"`
+	Priority  string            `json:"priority"`
+	DeliverAt string            `json:"deliver_at"`
+	DeliverIn int               `json:"deliver_in_seconds"`
+	TTL       int               `json:"ttl_seconds"`
+	NotifyExp bool              `json:"notify_expired"`
+	Receipt   bool              `json:"receipt"`
+	Key       string            `json:"idempotency_key"`
+	Subject   string            `json:"subject"`
+	Headers   map[string]string `json:"headers"`
+
+	// Set internally by ask; never accepted from clients
+	id           string
+	expectsReply bool
 }
 
 // handleSend is the MCP handler function for the send tool.
@@ -219,7 +646,7 @@ func handleSend(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolRes
 		}
 	}
 
-	response, err := doSend(ctx, params.Recipient, params.Message)
+	response, err := doSend(ctx, params)
 	if err != nil {
 		return &mcp.CallToolResult{
 			IsError: true,
@@ -248,20 +675,25 @@ func handleSend(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolRes
 }
 
 // doReceive implements the receive handler logic.
+// With leaseSeconds > 0 the message is leased instead of marked as read.
 // It returns the response as a map for JSON encoding, or an error.
-func doReceive(ctx context.Context) (any, error) {
+func doReceive(ctx context.Context, leaseSeconds int) (any, error) {
 	opts := getHandlerOptions()
 	if opts == nil {
 		opts = &HandlerOptions{}
 	}
 
+	if leaseSeconds < 0 || leaseSeconds > MaxLeaseSeconds {
+		return nil, fmt.Errorf("lease_seconds must be between 1 and %d", MaxLeaseSeconds)
+	}
+
 	// Get receiver identity
 	var receiver string
 	if opts.MockReceiver != "" {
 		receiver = opts.MockReceiver
 	} else {
 		var err error
-		receiver, err = tmux.GetCurrentWindow()
+		receiver, err = mail.CurrentMailbox(opts.RepoRoot)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get current window: %w", err)
 		}
@@ -293,23 +725,68 @@ func doReceive(ctx context.Context) (any, error) {
 	// Get oldest unread message (FIFO - first in list) per FR-003
 	msg := unread[0]
 
-	// FR-012: Mark as read
-	if err := mail.MarkAsRead(repoRoot, receiver, msg.ID); err != nil {
+	if leaseSeconds > 0 {
+		// Keep the message in-flight until acked; it is redelivered if the lease expires
+		msg, err = mail.Lease(repoRoot, receiver, msg.ID, time.Duration(leaseSeconds)*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lease message: %w", err)
+		}
+	} else if err := mail.MarkAsRead(repoRoot, receiver, msg.ID); err != nil {
+		// FR-012: Mark as read
 		return nil, fmt.Errorf("failed to mark message as read: %w", err)
 	}
 
 	// Return response with from, id, message fields per data-model.md
-	return ReceiveResponse{
-		From:    msg.From,
-		ID:      msg.ID,
-		Message: msg.Message,
-	}, nil
+	return newReceiveResponse(msg), nil
+}
+
+// newReceiveResponse converts a delivered message to its MCP representation.
+func newReceiveResponse(msg mail.Message) ReceiveResponse {
+	response := ReceiveResponse{
+		From:         msg.From,
+		ID:           msg.ID,
+		Message:      msg.Message,
+		Subject:      msg.Subject,
+		Headers:      msg.Headers,
+		InReplyTo:    msg.InReplyTo,
+		ThreadID:     msg.ThreadID,
+		ExpectsReply: msg.ExpectsReply,
+		Attempts:     msg.Attempts,
+	}
+	if !msg.LeaseUntil.IsZero() {
+		response.LeaseUntil = msg.LeaseUntil.Format(time.RFC3339)
+	}
+	if msg.Priority != mail.PriorityNormal {
+		response.Priority = msg.Priority
+	}
+	if !msg.ExpiresAt.IsZero() {
+		response.ExpiresAt = msg.ExpiresAt.Format(time.RFC3339)
+	}
+	return response
+}
+
+// receiveParams holds the unmarshaled parameters for the receive tool.
+type receiveParams struct {
+	LeaseSeconds int `json:"lease_seconds"`
 }
 
 // handleReceive is the MCP handler function for the receive tool.
 // It wraps doReceive and formats the response as MCP content.
 func handleReceive(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	response, err := doReceive(ctx)
+	// Extract parameters from request by unmarshaling JSON
+	var params receiveParams
+	if req.Params != nil && req.Params.Arguments != nil {
+		if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("failed to parse arguments: %v", err)},
+				},
+			}, nil
+		}
+	}
+
+	response, err := doReceive(ctx, params.LeaseSeconds)
 	if err != nil {
 		return &mcp.CallToolResult{
 			IsError: true,
@@ -337,40 +814,49 @@ func handleReceive(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallTool
 	}, nil
 }
 
-// ValidStatus values for the status tool.
-var validStatuses = map[string]bool{
-	mail.StatusReady:   true,
-	mail.StatusWork:    true,
-	mail.StatusOffline: true,
-}
-
-// validateStatus checks if the provided status is valid.
-// Returns true if valid, false otherwise.
-func validateStatus(status string) bool {
-	return validStatuses[status]
-}
-
-// doStatus implements the status handler logic.
-// It validates the status, updates the recipient state, and returns the response or an error.
-func doStatus(ctx context.Context, status string) (any, error) {
+// doAsk implements the ask handler logic.
+// It sends a message marked as expecting a reply, waits until a reply to it
+// arrives in the caller's mailbox, marks the reply as read and returns it.
+func doAsk(ctx context.Context, params askParams) (any, error) {
 	opts := getHandlerOptions()
 	if opts == nil {
 		opts = &HandlerOptions{}
 	}
 
-	// T039: Validate status value (ready/work/offline only)
-	if !validateStatus(status) {
-		// T040: Return error message matching FR-016 format
-		return nil, fmt.Errorf("Invalid status: %s. Valid: ready, work, offline", status)
+	timeoutSeconds := params.TimeoutSeconds
+	if timeoutSeconds == 0 {
+		timeoutSeconds = DefaultAskTimeoutSeconds
+	}
+	if timeoutSeconds < 0 || timeoutSeconds > MaxWaitTimeoutSeconds {
+		return nil, fmt.Errorf("timeout_seconds must be between 1 and %d", MaxWaitTimeoutSeconds)
 	}
 
-	// Get agent identity (current tmux window)
-	var agent string
-	if opts.MockReceiver != "" {
-		agent = opts.MockReceiver
+	// A single correlation ID cannot match replies to several copies
+	if mail.IsGroupAddress(params.Recipient) {
+		return nil, fmt.Errorf("ask requires a single recipient, not a group address")
+	}
+
+	// Pre-generate the ID so the reply can be correlated with the question
+	id, err := mail.GenerateID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate message ID: %w", err)
+	}
+
+	if _, err := doSend(ctx, sendParams{
+		Recipient:    params.Recipient,
+		Message:      params.Message,
+		id:           id,
+		expectsReply: true,
+	}); err != nil {
+		return nil, err
+	}
+
+	// Get asker identity (where the reply will be delivered)
+	var asker string
+	if opts.MockSender != "" {
+		asker = opts.MockSender
 	} else {
-		var err error
-		agent, err = tmux.GetCurrentWindow()
+		asker, err = mail.CurrentMailbox(opts.RepoRoot)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get current window: %w", err)
 		}
@@ -379,37 +865,51 @@ func doStatus(ctx context.Context, status string) (any, error) {
 	// Determine repository root
 	repoRoot := opts.RepoRoot
 	if repoRoot == "" {
-		var err error
 		repoRoot, err = mail.FindGitRoot()
 		if err != nil {
 			return nil, fmt.Errorf("not in a git repository: %w", err)
 		}
 	}
 
-	// Reset notified flag when status is work or offline
-	resetNotified := (status == mail.StatusWork || status == mail.StatusOffline)
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	found, err := daemon.WaitFor(ctx, repoRoot, timeout, func() bool {
+		_, ok, _ := mail.FindReply(repoRoot, asker, id)
+		return ok
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for reply: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("no reply to %s within %v", id, timeout)
+	}
 
-	// Update recipient state using existing mail infrastructure
-	if err := mail.UpdateRecipientState(repoRoot, agent, status, resetNotified); err != nil {
-		return nil, fmt.Errorf("failed to update status: %w", err)
+	reply, _, err := mail.FindReply(repoRoot, asker, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reply: %w", err)
 	}
 
-	// T041: Return {"status": "ok"} on success
-	return StatusResponse{
-		Status: "ok",
+	if err := mail.MarkAsRead(repoRoot, asker, reply.ID); err != nil {
+		return nil, fmt.Errorf("failed to mark message as read: %w", err)
+	}
+
+	return AskResponse{
+		MessageID: id,
+		Reply:     newReceiveResponse(reply),
 	}, nil
 }
 
-// statusParams holds the unmarshaled parameters for the status tool.
-type statusParams struct {
-	Status string `json:"status"`
+// askParams holds the unmarshaled parameters for the ask tool.
+type askParams struct {
+	Recipient      string `json:"recipient"`
+	Message        string `json:"message"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
 }
 
-// handleStatus is the MCP handler function for the status tool.
-// It wraps doStatus and formats the response as MCP content.
-func handleStatus(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// handleAsk is the MCP handler function for the ask tool.
+// It wraps doAsk and formats the response as MCP content.
+func handleAsk(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Extract parameters from request by unmarshaling JSON
-	var params statusParams
+	var params askParams
 	if req.Params != nil && req.Params.Arguments != nil {
 		if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
 			return &mcp.CallToolResult{
@@ -421,7 +921,7 @@ func handleStatus(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolR
 		}
 	}
 
-	response, err := doStatus(ctx, params.Status)
+	response, err := doAsk(ctx, params)
 	if err != nil {
 		return &mcp.CallToolResult{
 			IsError: true,
@@ -449,84 +949,856 @@ func handleStatus(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolR
 	}, nil
 }
 
-// doListRecipients implements the list-recipients handler logic.
-// It returns all available agents (tmux windows) with the current window marked.
-// Ignored windows are excluded, but current window is always shown.
-func doListRecipients(ctx context.Context) (any, error) {
+// doAck implements the ack handler logic.
+// It marks a leased message in the caller's mailbox as read so it is not redelivered.
+func doAck(ctx context.Context, params ackParams) (any, error) {
 	opts := getHandlerOptions()
 	if opts == nil {
 		opts = &HandlerOptions{}
 	}
 
-	// Get current window (agent identity)
-	var currentWindow string
+	if params.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	// Get receiver identity
+	var receiver string
 	if opts.MockReceiver != "" {
-		currentWindow = opts.MockReceiver
+		receiver = opts.MockReceiver
 	} else {
 		var err error
-		currentWindow, err = tmux.GetCurrentWindow()
+		receiver, err = mail.CurrentMailbox(opts.RepoRoot)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get current window: %w", err)
 		}
 	}
 
-	// Get list of all windows
-	var windows []string
-	if opts.MockWindows != nil {
-		windows = opts.MockWindows
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			return nil, fmt.Errorf("not in a git repository: %w", err)
+		}
+	}
+
+	if err := mail.Ack(repoRoot, receiver, params.ID); err != nil {
+		if errors.Is(err, mail.ErrMessageNotFound) {
+			return nil, fmt.Errorf("message %s not found in your mailbox", params.ID)
+		}
+		return nil, fmt.Errorf("failed to acknowledge message: %w", err)
+	}
+
+	return AckResponse{Status: "ok"}, nil
+}
+
+// ackParams holds the unmarshaled parameters for the ack tool.
+type ackParams struct {
+	ID string `json:"id"`
+}
+
+// handleAck is the MCP handler function for the ack tool.
+// It wraps doAck and formats the response as MCP content.
+func handleAck(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters from request by unmarshaling JSON
+	var params ackParams
+	if req.Params != nil && req.Params.Arguments != nil {
+		if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("failed to parse arguments: %v", err)},
+				},
+			}, nil
+		}
+	}
+
+	response, err := doAck(ctx, params)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: err.Error()},
+			},
+		}, nil
+	}
+
+	// Encode response as JSON
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("failed to encode response: %v", err)},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, nil
+}
+
+// doWaitForMessage implements the wait-for-message handler logic.
+// It blocks until the caller's mailbox has an unread message or the timeout
+// elapses, then behaves exactly like receive.
+func doWaitForMessage(ctx context.Context, params waitForMessageParams) (any, error) {
+	opts := getHandlerOptions()
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+
+	timeoutSeconds := params.TimeoutSeconds
+	if timeoutSeconds == 0 {
+		timeoutSeconds = DefaultWaitTimeoutSeconds
+	}
+	if timeoutSeconds < 0 || timeoutSeconds > MaxWaitTimeoutSeconds {
+		return nil, fmt.Errorf("timeout_seconds must be between 1 and %d", MaxWaitTimeoutSeconds)
+	}
+	// Validate up front rather than after a long wait
+	if params.LeaseSeconds < 0 || params.LeaseSeconds > MaxLeaseSeconds {
+		return nil, fmt.Errorf("lease_seconds must be between 1 and %d", MaxLeaseSeconds)
+	}
+
+	// Get receiver identity
+	var receiver string
+	if opts.MockReceiver != "" {
+		receiver = opts.MockReceiver
 	} else {
 		var err error
-		windows, err = tmux.ListWindows()
+		receiver, err = mail.CurrentMailbox(opts.RepoRoot)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list windows: %w", err)
+			return nil, fmt.Errorf("failed to get current window: %w", err)
 		}
 	}
 
-	// Load ignore list
-	var ignoreList map[string]bool
-	if opts.MockIgnoreList != nil {
-		ignoreList = opts.MockIgnoreList
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			return nil, fmt.Errorf("not in a git repository: %w", err)
+		}
+	}
+
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	if _, err := daemon.WaitForMail(ctx, repoRoot, receiver, timeout); err != nil {
+		return nil, fmt.Errorf("failed to wait for messages: %w", err)
+	}
+
+	// On timeout the mailbox is still empty and receive reports "No unread messages"
+	return doReceive(ctx, params.LeaseSeconds)
+}
+
+// waitForMessageParams holds the unmarshaled parameters for the wait-for-message tool.
+type waitForMessageParams struct {
+	TimeoutSeconds int `json:"timeout_seconds"`
+	LeaseSeconds   int `json:"lease_seconds"`
+}
+
+// handleWaitForMessage is the MCP handler function for the wait-for-message tool.
+// It wraps doWaitForMessage and formats the response as MCP content.
+func handleWaitForMessage(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters from request by unmarshaling JSON
+	var params waitForMessageParams
+	if req.Params != nil && req.Params.Arguments != nil {
+		if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("failed to parse arguments: %v", err)},
+				},
+			}, nil
+		}
+	}
+
+	response, err := doWaitForMessage(ctx, params)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: err.Error()},
+			},
+		}, nil
+	}
+
+	// Encode response as JSON
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("failed to encode response: %v", err)},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, nil
+}
+
+// ValidStatus values for the status tool.
+var validStatuses = map[string]bool{
+	mail.StatusReady:   true,
+	mail.StatusWork:    true,
+	mail.StatusOffline: true,
+}
+
+// validateStatus checks if the provided status is valid.
+// Returns true if valid, false otherwise.
+func validateStatus(status string) bool {
+	return validStatuses[status]
+}
+
+// doStatus implements the status handler logic.
+// It validates the status, updates the recipient state, and returns the response or an error.
+func doStatus(ctx context.Context, params statusParams) (any, error) {
+	opts := getHandlerOptions()
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+	status := params.Status
+
+	// T039: Validate status value (ready/work/offline only)
+	if !validateStatus(status) {
+		// T040: Return error message matching FR-016 format
+		return nil, fmt.Errorf("Invalid status: %s. Valid: ready, work, offline", status)
+	}
+	if params.Notify != "" && params.Notify != mail.NotifyDefault {
+		if err := config.ValidateNotifyStrategy(params.Notify); err != nil {
+			return nil, err
+		}
+	}
+
+	// Get agent identity (current tmux window)
+	var agent string
+	if opts.MockReceiver != "" {
+		agent = opts.MockReceiver
 	} else {
-		// Determine git root for loading ignore list.
-		// Errors are intentionally ignored: if we can't find git root or load
-		// the ignore list, we proceed without filtering - this is acceptable
-		// as the ignore list is optional.
-		gitRoot := opts.RepoRoot
-		if gitRoot == "" {
-			gitRoot, _ = mail.FindGitRoot() // Error ignored: proceed without ignore list
+		var err error
+		agent, err = mail.CurrentMailbox(opts.RepoRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current window: %w", err)
 		}
-		if gitRoot != "" {
-			ignoreList, _ = mail.LoadIgnoreList(gitRoot) // Error ignored: proceed without ignore list
+	}
+
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			return nil, fmt.Errorf("not in a git repository: %w", err)
 		}
 	}
 
-	// Build recipients list, filtering ignored windows but always including current
-	recipients := []RecipientInfo{}
-	for _, window := range windows {
-		// Current window is always shown (even if in ignore list)
-		if window == currentWindow {
-			recipients = append(recipients, RecipientInfo{
-				Name:      window,
-				IsCurrent: true,
-			})
-		} else if ignoreList == nil || !ignoreList[window] {
-			// Only show non-current windows if they're not in the ignore list
-			recipients = append(recipients, RecipientInfo{
-				Name:      window,
-				IsCurrent: false,
-			})
+	// Reset notified flag when status is work or offline
+	resetNotified := (status == mail.StatusWork || status == mail.StatusOffline)
+
+	// Update recipient state using existing mail infrastructure
+	if err := mail.UpdateRecipientState(repoRoot, agent, status, resetNotified); err != nil {
+		return nil, fmt.Errorf("failed to update status: %w", err)
+	}
+	if params.Notify != "" || params.NotifyText != "" {
+		if err := mail.SetNotifyPreference(repoRoot, agent, params.Notify, params.NotifyText); err != nil {
+			return nil, fmt.Errorf("failed to update notification settings: %w", err)
 		}
 	}
 
-	return ListRecipientsResponse{
-		Recipients: recipients,
+	// T041: Return {"status": "ok"} on success
+	return StatusResponse{
+		Status: "ok",
 	}, nil
 }
 
-// handleListRecipients is the MCP handler function for the list-recipients tool.
-// It wraps doListRecipients and formats the response as MCP content.
-func handleListRecipients(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	response, err := doListRecipients(ctx)
+// statusParams holds the unmarshaled parameters for the status tool.
+type statusParams struct {
+	Status     string `json:"status"`
+	Notify     string `json:"notify"`
+	NotifyText string `json:"notify_text"`
+}
+
+// handleStatus is the MCP handler function for the status tool.
+// It wraps doStatus and formats the response as MCP content.
+func handleStatus(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters from request by unmarshaling JSON
+	var params statusParams
+	if req.Params != nil && req.Params.Arguments != nil {
+		if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("failed to parse arguments: %v", err)},
+				},
+			}, nil
+		}
+	}
+
+	response, err := doStatus(ctx, params)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: err.Error()},
+			},
+		}, nil
+	}
+
+	// Encode response as JSON
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("failed to encode response: %v", err)},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, nil
+}
+
+// doListRecipients implements the list-recipients handler logic.
+// It returns all available agents (tmux windows) with the current window marked.
+// Ignored windows are excluded, but current window is always shown.
+func doListRecipients(ctx context.Context) (any, error) {
+	opts := getHandlerOptions()
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+
+	// Get current window (agent identity)
+	var currentWindow string
+	if opts.MockReceiver != "" {
+		currentWindow = opts.MockReceiver
+	} else {
+		var err error
+		currentWindow, err = mail.CurrentMailbox(opts.RepoRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current window: %w", err)
+		}
+	}
+
+	// Get the mailbox of every window, with the pane reading it
+	var mailboxes []mail.Alias
+	if opts.MockWindows != nil {
+		for _, window := range opts.MockWindows {
+			mailboxes = append(mailboxes, mail.Alias{Mailbox: window})
+		}
+	} else {
+		var err error
+		mailboxes, err = mail.SessionMailboxes(opts.RepoRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list windows: %w", err)
+		}
+	}
+
+	// Load ignore list
+	ignoreList := loadIgnoreList(opts)
+
+	// Build recipients list, filtering ignored windows but always including current
+	recipients := []RecipientInfo{}
+	for _, mailbox := range mailboxes {
+		info := RecipientInfo{
+			Name:      mailbox.Mailbox,
+			IsCurrent: mailbox.Mailbox == currentWindow,
+			Pane:      mailbox.Pane,
+			Session:   mailbox.Session,
+			Window:    mailbox.Window,
+		}
+		// Current window is always shown (even if in ignore list)
+		if info.IsCurrent || ignoreList == nil || !ignoreList[mailbox.Mailbox] {
+			recipients = append(recipients, info)
+		}
+	}
+
+	return ListRecipientsResponse{
+		Recipients: recipients,
+	}, nil
+}
+
+// handleListRecipients is the MCP handler function for the list-recipients tool.
+// It wraps doListRecipients and formats the response as MCP content.
+func handleListRecipients(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	response, err := doListRecipients(ctx)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: err.Error()},
+			},
+		}, nil
+	}
+
+	// Encode response as JSON
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("failed to encode response: %v", err)},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, nil
+}
+
+// resolveMailbox returns the caller's window and the repository root.
+func resolveMailbox(opts *HandlerOptions) (string, string, error) {
+	receiver := opts.MockReceiver
+	if receiver == "" {
+		var err error
+		receiver, err = mail.CurrentMailbox(opts.RepoRoot)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get current window: %w", err)
+		}
+	}
+
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			return "", "", fmt.Errorf("not in a git repository: %w", err)
+		}
+	}
+	return receiver, repoRoot, nil
+}
+
+// doInbox implements the inbox handler logic.
+// It lists the caller's delivered messages, newest first, without marking any as read.
+func doInbox(ctx context.Context, params inboxParams) (any, error) {
+	opts := getHandlerOptions()
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+
+	if params.SinceSeconds < 0 {
+		return nil, fmt.Errorf("since_seconds must not be negative")
+	}
+	if params.Limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative")
+	}
+
+	receiver, repoRoot, err := resolveMailbox(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	filter := mail.InboxFilter{
+		UnreadOnly: params.Unread,
+		From:       params.From,
+		Limit:      params.Limit,
+	}
+	if params.SinceSeconds > 0 {
+		filter.Since = now.Add(-time.Duration(params.SinceSeconds) * time.Second)
+	}
+
+	messages, err := mail.ListInbox(repoRoot, receiver, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+
+	entries := []InboxEntry{}
+	for _, msg := range messages {
+		entry := InboxEntry{
+			ID:          msg.ID,
+			From:        msg.From,
+			DeliveredAt: msg.DeliveredAt().Format(time.RFC3339),
+			State:       msg.State(now),
+			Subject:     msg.Subject,
+			Preview:     mail.Preview(msg.Message),
+		}
+		if msg.Priority != mail.PriorityNormal {
+			entry.Priority = msg.Priority
+		}
+		entries = append(entries, entry)
+	}
+	return InboxResponse{Messages: entries}, nil
+}
+
+// inboxParams holds the unmarshaled parameters for the inbox tool.
+type inboxParams struct {
+	Unread       bool   `json:"unread"`
+	From         string `json:"from"`
+	SinceSeconds int    `json:"since_seconds"`
+	Limit        int    `json:"limit"`
+}
+
+// handleInbox is the MCP handler function for the inbox tool.
+// It wraps doInbox and formats the response as MCP content.
+func handleInbox(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters from request by unmarshaling JSON
+	var params inboxParams
+	if req.Params != nil && req.Params.Arguments != nil {
+		if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("failed to parse arguments: %v", err)},
+				},
+			}, nil
+		}
+	}
+
+	response, err := doInbox(ctx, params)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: err.Error()},
+			},
+		}, nil
+	}
+
+	// Encode response as JSON
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("failed to encode response: %v", err)},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, nil
+}
+
+// doRead implements the read handler logic.
+// It returns any delivered message in the caller's mailbox without changing it:
+// unread messages stay unread and leased messages stay leased.
+func doRead(ctx context.Context, params readParams) (any, error) {
+	opts := getHandlerOptions()
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+
+	if params.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	receiver, repoRoot, err := resolveMailbox(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := mail.FindInMailbox(repoRoot, receiver, params.ID)
+	if err != nil {
+		if errors.Is(err, mail.ErrMessageNotFound) {
+			return nil, fmt.Errorf("message %s not found in your mailbox", params.ID)
+		}
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+
+	return ReadResponse{
+		ReceiveResponse: newReceiveResponse(msg),
+		To:              msg.To,
+		DeliveredAt:     msg.DeliveredAt().Format(time.RFC3339),
+		State:           msg.State(time.Now()),
+	}, nil
+}
+
+// readParams holds the unmarshaled parameters for the read tool.
+type readParams struct {
+	ID string `json:"id"`
+}
+
+// handleRead is the MCP handler function for the read tool.
+// It wraps doRead and formats the response as MCP content.
+func handleRead(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters from request by unmarshaling JSON
+	var params readParams
+	if req.Params != nil && req.Params.Arguments != nil {
+		if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("failed to parse arguments: %v", err)},
+				},
+			}, nil
+		}
+	}
+
+	response, err := doRead(ctx, params)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: err.Error()},
+			},
+		}, nil
+	}
+
+	// Encode response as JSON
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("failed to encode response: %v", err)},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, nil
+}
+
+// doPeek implements the peek handler logic.
+// It returns the message receive would return next without marking it read or leasing it.
+func doPeek(ctx context.Context) (any, error) {
+	opts := getHandlerOptions()
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+
+	receiver, repoRoot, err := resolveMailbox(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := mail.FindUnread(repoRoot, receiver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+	if len(unread) == 0 {
+		return ReceiveEmptyResponse{
+			Status: "No unread messages",
+		}, nil
+	}
+	return newReceiveResponse(unread[0]), nil
+}
+
+// handlePeek is the MCP handler function for the peek tool.
+// It wraps doPeek and formats the response as MCP content.
+func handlePeek(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	response, err := doPeek(ctx)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: err.Error()},
+			},
+		}, nil
+	}
+
+	// Encode response as JSON
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("failed to encode response: %v", err)},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, nil
+}
+
+// doSearch implements the search handler logic.
+// It searches every mailbox, read or not, and returns the matches oldest first.
+func doSearch(ctx context.Context, params searchParams) (any, error) {
+	opts := getHandlerOptions()
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+
+	if params.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if params.SinceSeconds < 0 {
+		return nil, fmt.Errorf("since_seconds must not be negative")
+	}
+
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			return nil, fmt.Errorf("not in a git repository: %w", err)
+		}
+	}
+
+	query := mail.SearchQuery{
+		Text:  params.Query,
+		Regex: params.Regex,
+		From:  params.From,
+		To:    params.To,
+	}
+	if params.SinceSeconds > 0 {
+		query.Since = time.Now().Add(-time.Duration(params.SinceSeconds) * time.Second)
+	}
+
+	matches, err := mail.Search(repoRoot, query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []SearchResult{}
+	for _, match := range matches {
+		results = append(results, SearchResult{
+			ID:        match.ID,
+			From:      match.From,
+			To:        match.To,
+			CreatedAt: match.CreatedAt.Format(time.RFC3339),
+			Subject:   match.Subject,
+			Match:     match.Match,
+		})
+	}
+	return SearchResponse{Matches: results}, nil
+}
+
+// searchParams holds the unmarshaled parameters for the search tool.
+type searchParams struct {
+	Query        string `json:"query"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	SinceSeconds int    `json:"since_seconds"`
+	Regex        bool   `json:"regex"`
+}
+
+// handleSearch is the MCP handler function for the search tool.
+// It wraps doSearch and formats the response as MCP content.
+func handleSearch(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters from request by unmarshaling JSON
+	var params searchParams
+	if req.Params != nil && req.Params.Arguments != nil {
+		if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("failed to parse arguments: %v", err)},
+				},
+			}, nil
+		}
+	}
+
+	response, err := doSearch(ctx, params)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: err.Error()},
+			},
+		}, nil
+	}
+
+	// Encode response as JSON
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("failed to encode response: %v", err)},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, nil
+}
+
+// doMessageStatus implements the message-status handler logic.
+// It returns where a message is and its delivery events.
+func doMessageStatus(ctx context.Context, params messageStatusParams) (any, error) {
+	opts := getHandlerOptions()
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+
+	if params.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	// Determine repository root
+	repoRoot := opts.RepoRoot
+	if repoRoot == "" {
+		var err error
+		repoRoot, err = mail.FindGitRoot()
+		if err != nil {
+			return nil, fmt.Errorf("not in a git repository: %w", err)
+		}
+	}
+
+	status, err := mail.MessageStatus(repoRoot, params.ID)
+	if err != nil {
+		if errors.Is(err, mail.ErrMessageNotFound) {
+			return nil, fmt.Errorf("message %s not found", params.ID)
+		}
+		return nil, err
+	}
+
+	events := []EventEntry{}
+	for _, event := range status.Events {
+		events = append(events, EventEntry{Event: event.Event, At: event.At.Format(time.RFC3339)})
+	}
+	return MessageStatusResponse{
+		ID:      status.Message.ID,
+		From:    status.Message.From,
+		To:      status.Message.To,
+		Subject: status.Message.Subject,
+		State:   status.State,
+		Receipt: status.Message.Receipt,
+		Events:  events,
+	}, nil
+}
+
+// messageStatusParams holds the unmarshaled parameters for the message-status tool.
+type messageStatusParams struct {
+	ID string `json:"id"`
+}
+
+// handleMessageStatus is the MCP handler function for the message-status tool.
+// It wraps doMessageStatus and formats the response as MCP content.
+func handleMessageStatus(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract parameters from request by unmarshaling JSON
+	var params messageStatusParams
+	if req.Params != nil && req.Params.Arguments != nil {
+		if err := json.Unmarshal(req.Params.Arguments, &params); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("failed to parse arguments: %v", err)},
+				},
+			}, nil
+		}
+	}
+
+	response, err := doMessageStatus(ctx, params)
 	if err != nil {
 		return &mcp.CallToolResult{
 			IsError: true,
@@ -10,6 +10,9 @@ import (
 	"testing"
 	"time"
 
+	"agentmail/internal/config"
+	"agentmail/internal/mail"
+
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -225,7 +228,7 @@ func TestReceiveHandler_MessageMarkedAsReadAfterReceive(t *testing.T) {
 	// Create mailbox with one unread message
 	content := `{"id":"testID01","from":"agent-1","to":"agent-2","message":"Test message","read_flag":false}
 `
-	filePath := writeTestMessages(t, tmpDir, "agent-2", content)
+	writeTestMessages(t, tmpDir, "agent-2", content)
 
 	// Configure handler for testing
 	SetHandlerOptions(&HandlerOptions{
@@ -247,14 +250,14 @@ func TestReceiveHandler_MessageMarkedAsReadAfterReceive(t *testing.T) {
 		t.Fatalf("receiveHandler returned error result: %v", result.Content)
 	}
 
-	// FR-012: Verify message was marked as read in the file
-	fileContent, err := os.ReadFile(filePath)
+	// FR-012: Verify message was marked as read in the mailbox
+	messages, err := mail.ReadAll(tmpDir, "agent-2")
 	if err != nil {
-		t.Fatalf("Failed to read mailbox file: %v", err)
+		t.Fatalf("Failed to read mailbox: %v", err)
 	}
 
-	if !strings.Contains(string(fileContent), `"read_flag":true`) {
-		t.Errorf("Message should be marked as read after receive. File content: %s", fileContent)
+	if len(messages) != 1 || !messages[0].ReadFlag {
+		t.Errorf("Message should be marked as read after receive. Mailbox: %+v", messages)
 	}
 }
 
@@ -1025,6 +1028,41 @@ func TestSendHandler_MCPClientIntegration(t *testing.T) {
 	}
 }
 
+// Test send honors max_message_size from the config file
+func TestSendHandler_ConfiguredMaxSize(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+	t.Setenv(config.Env("max_message_size"), "")
+	if err := config.Set(tmpDir, "max_message_size", "1000"); err != nil {
+		t.Fatalf("config.Set failed: %v", err)
+	}
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockSender:    "agent-sender",
+		MockWindows:   []string{"agent-sender", "agent-receiver"},
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	result, err := sendHandler(ctx, makeSendRequest("agent-receiver", strings.Repeat("x", 1001)))
+	if err != nil {
+		t.Fatalf("sendHandler returned unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("sendHandler should reject a message over the configured size")
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; text != "message exceeds maximum size of 1000 bytes" {
+		t.Errorf("Unexpected error: %s", text)
+	}
+
+	result, err = sendHandler(ctx, makeSendRequest("agent-receiver", strings.Repeat("x", 1000)))
+	if err != nil || result.IsError {
+		t.Fatalf("sendHandler should accept a message at the configured size, got %v %+v", err, result)
+	}
+}
+
 // Test send exactly at 64KB boundary succeeds
 func TestSendHandler_ExactlyMaxSizeSucceeds(t *testing.T) {
 	tmpDir := setupTestMailbox(t)
@@ -1144,6 +1182,37 @@ func TestStatusHandler_UpdatesStatusAndReturnsOk(t *testing.T) {
 	}
 }
 
+// Test status stores the notification overrides
+func TestStatusHandler_NotifyOverrides(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockReceiver:  "test-agent",
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	args, _ := json.Marshal(map[string]string{"status": "ready", "notify": "display", "notify_text": "{count} new"})
+	result, err := statusHandler(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: ToolStatus, Arguments: args}})
+	if err != nil || result.IsError {
+		t.Fatalf("statusHandler failed: %v %+v", err, result)
+	}
+
+	recipients, _ := mail.ReadAllRecipients(tmpDir)
+	if len(recipients) != 1 || recipients[0].Notify != "display" || recipients[0].NotifyTemplate != "{count} new" {
+		t.Errorf("Expected notification overrides stored, got %+v", recipients)
+	}
+
+	args, _ = json.Marshal(map[string]string{"status": "ready", "notify": "pager"})
+	result, _ = statusHandler(ctx, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: ToolStatus, Arguments: args}})
+	if !result.IsError {
+		t.Error("Expected an invalid strategy to be rejected")
+	}
+}
+
 // T036: Test status with invalid value returns error (FR-016)
 func TestStatusHandler_InvalidValueReturnsError(t *testing.T) {
 	tmpDir := setupTestMailbox(t)
@@ -2804,3 +2873,929 @@ func TestServer_100ConsecutiveInvocations(t *testing.T) {
 			len(errors), strings.Join(errors[:maxErrors], "\n"))
 	}
 }
+
+// makeToolRequest creates a CallToolRequest for the named tool with arbitrary arguments.
+func makeToolRequest(name string, args map[string]any) *mcp.CallToolRequest {
+	data, _ := json.Marshal(args)
+	return &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{
+			Name:      name,
+			Arguments: data,
+		},
+	}
+}
+
+// resultText returns the text of a successful tool result, failing the test on error results.
+func resultText(t *testing.T, result *mcp.CallToolResult) string {
+	t.Helper()
+
+	if len(result.Content) == 0 {
+		t.Fatal("handler returned empty content")
+	}
+	textContent, ok := result.Content[0].(*mcp.TextContent)
+	if !ok {
+		t.Fatalf("handler content is not TextContent, got %T", result.Content[0])
+	}
+	if result.IsError {
+		t.Fatalf("handler returned error result: %s", textContent.Text)
+	}
+	return textContent.Text
+}
+
+// Test send with reply_to links the reply into the original thread
+func TestSendHandler_ReplyToInheritsThread(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	// agent-1 received a message that is already part of thread "root0001"
+	writeTestMessages(t, tmpDir, "agent-1", `{"id":"msg00002","from":"agent-2","to":"agent-1","message":"question","read_flag":true,"in_reply_to":"root0001","thread_id":"root0001"}
+`)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockSender:    "agent-1",
+		MockReceiver:  "agent-2",
+		MockWindows:   []string{"agent-1", "agent-2"},
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	result, err := sendHandler(ctx, makeToolRequest(ToolSend, map[string]any{
+		"recipient": "agent-2",
+		"message":   "answer",
+		"reply_to":  "msg00002",
+	}))
+	if err != nil {
+		t.Fatalf("sendHandler returned error: %v", err)
+	}
+	resultText(t, result)
+
+	// The reply is visible to agent-2 with thread metadata
+	result, err = receiveHandler(ctx, &mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("receiveHandler returned error: %v", err)
+	}
+
+	var response ReceiveResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.InReplyTo != "msg00002" {
+		t.Errorf("Expected in_reply_to 'msg00002', got %q", response.InReplyTo)
+	}
+	if response.ThreadID != "root0001" {
+		t.Errorf("Expected thread_id 'root0001', got %q", response.ThreadID)
+	}
+}
+
+// Test send with unknown reply_to returns an error and stores nothing
+func TestSendHandler_ReplyToUnknownMessageReturnsError(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockSender:    "agent-1",
+		MockWindows:   []string{"agent-1", "agent-2"},
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	result, err := sendHandler(context.Background(), makeToolRequest(ToolSend, map[string]any{
+		"recipient": "agent-2",
+		"message":   "answer",
+		"reply_to":  "missing1",
+	}))
+	if err != nil {
+		t.Fatalf("sendHandler returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("Expected error result for unknown reply_to")
+	}
+
+	mailboxPath := filepath.Join(tmpDir, ".agentmail", "mailboxes", "agent-2.jsonl")
+	if _, err := os.Stat(mailboxPath); !os.IsNotExist(err) {
+		t.Error("No message should be stored when reply_to is unknown")
+	}
+}
+
+// Test send to @all delivers a copy to every other agent with a shared broadcast ID
+func TestSendHandler_BroadcastAll(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck:  true,
+		MockSender:     "agent-1",
+		MockWindows:    []string{"agent-1", "agent-2", "agent-3"},
+		MockIgnoreList: map[string]bool{"agent-3": true},
+		RepoRoot:       tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	result, err := sendHandler(context.Background(), makeSendRequest("@all", "stop and rebase"))
+	if err != nil {
+		t.Fatalf("sendHandler returned error: %v", err)
+	}
+
+	var response SendResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.BroadcastID == "" {
+		t.Error("Expected broadcast_id in response")
+	}
+	if len(response.Recipients) != 1 || response.Recipients[0] != "agent-2" {
+		t.Errorf("Expected recipients [agent-2], got %v", response.Recipients)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".agentmail", "mailboxes", "agent-2.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to read mailbox: %v", err)
+	}
+	if !strings.Contains(string(data), response.BroadcastID) {
+		t.Errorf("Broadcast ID not stored with copy: %s", data)
+	}
+}
+
+// Test wait-for-message returns a message that arrives while waiting
+func TestWaitForMessageHandler_DeliversArrivingMessage(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		content := `{"id":"wait0001","from":"agent-1","to":"agent-2","message":"work is ready","read_flag":false}
+`
+		_ = os.WriteFile(filepath.Join(tmpDir, ".agentmail", "mailboxes", "agent-2.jsonl"), []byte(content), 0644)
+	}()
+
+	result, err := waitForMessageHandler(context.Background(), makeToolRequest(ToolWaitForMessage, map[string]any{
+		"timeout_seconds": 10,
+	}))
+	if err != nil {
+		t.Fatalf("waitForMessageHandler returned error: %v", err)
+	}
+
+	var response ReceiveResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.ID != "wait0001" || response.Message != "work is ready" {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+}
+
+// Test wait-for-message rejects out-of-range timeouts
+func TestWaitForMessageHandler_InvalidTimeoutReturnsError(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	result, err := waitForMessageHandler(context.Background(), makeToolRequest(ToolWaitForMessage, map[string]any{
+		"timeout_seconds": MaxWaitTimeoutSeconds + 1,
+	}))
+	if err != nil {
+		t.Fatalf("waitForMessageHandler returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for timeout above maximum")
+	}
+}
+
+// Test ask sends a question and returns the reply once it arrives
+func TestAskHandler_ReturnsReply(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockSender:    "agent-1",
+		MockWindows:   []string{"agent-1", "agent-2"},
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	// agent-2 answers the first question it finds
+	go func() {
+		for i := 0; i < 100; i++ {
+			unread, err := mail.FindUnread(tmpDir, "agent-2")
+			if err == nil && len(unread) > 0 {
+				_ = mail.Append(tmpDir, mail.Message{
+					ID:        "answ0001",
+					From:      "agent-2",
+					To:        "agent-1",
+					Message:   "yes",
+					InReplyTo: unread[0].ID,
+					ThreadID:  unread[0].ID,
+				})
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	result, err := askHandler(context.Background(), makeToolRequest(ToolAsk, map[string]any{
+		"recipient":       "agent-2",
+		"message":         "ready to merge?",
+		"timeout_seconds": 10,
+	}))
+	if err != nil {
+		t.Fatalf("askHandler returned error: %v", err)
+	}
+
+	var response AskResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Reply.ID != "answ0001" || response.Reply.Message != "yes" {
+		t.Errorf("Unexpected reply: %+v", response.Reply)
+	}
+	if response.Reply.InReplyTo != response.MessageID {
+		t.Errorf("Expected reply to %q, got %q", response.MessageID, response.Reply.InReplyTo)
+	}
+
+	questions, err := mail.ReadAll(tmpDir, "agent-2")
+	if err != nil || len(questions) != 1 {
+		t.Fatalf("Expected 1 question, got %d (err: %v)", len(questions), err)
+	}
+	if questions[0].ID != response.MessageID || !questions[0].ExpectsReply {
+		t.Errorf("Unexpected stored question: %+v", questions[0])
+	}
+}
+
+// Test ask rejects group addresses
+func TestAskHandler_GroupAddressReturnsError(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockSender:    "agent-1",
+		MockWindows:   []string{"agent-1", "agent-2"},
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	result, err := askHandler(context.Background(), makeToolRequest(ToolAsk, map[string]any{
+		"recipient": "@all",
+		"message":   "anyone?",
+	}))
+	if err != nil {
+		t.Fatalf("askHandler returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for group address")
+	}
+}
+
+// Test receive with lease_seconds keeps the message until ack
+func TestReceiveHandler_LeaseAndAck(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeTestMessages(t, tmpDir, "agent-2", `{"id":"lease001","from":"agent-1","to":"agent-2","message":"build it","read_flag":false}
+`)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	result, err := receiveHandler(ctx, makeToolRequest(ToolReceive, map[string]any{
+		"lease_seconds": 600,
+	}))
+	if err != nil {
+		t.Fatalf("receiveHandler returned error: %v", err)
+	}
+
+	var response ReceiveResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.ID != "lease001" || response.Attempts != 1 || response.LeaseUntil == "" {
+		t.Errorf("Unexpected leased response: %+v", response)
+	}
+
+	messages, err := mail.ReadAll(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if messages[0].ReadFlag {
+		t.Error("Leased message should not be marked as read before ack")
+	}
+
+	result, err = ackHandler(ctx, makeToolRequest(ToolAck, map[string]any{
+		"id": "lease001",
+	}))
+	if err != nil {
+		t.Fatalf("ackHandler returned error: %v", err)
+	}
+	if text := resultText(t, result); text != `{"status":"ok"}` {
+		t.Errorf("Unexpected ack response: %s", text)
+	}
+
+	messages, err = mail.ReadAll(tmpDir, "agent-2")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if !messages[0].ReadFlag {
+		t.Error("Acked message should be marked as read")
+	}
+}
+
+// Test ack of an unknown message returns an error
+func TestAckHandler_UnknownMessageReturnsError(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	result, err := ackHandler(context.Background(), makeToolRequest(ToolAck, map[string]any{
+		"id": "missing1",
+	}))
+	if err != nil {
+		t.Fatalf("ackHandler returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for unknown message")
+	}
+}
+
+// Test send stores priority and receive returns urgent mail first
+func TestSendHandler_PriorityOrdersReceive(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockSender:    "agent-1",
+		MockReceiver:  "agent-2",
+		MockWindows:   []string{"agent-1", "agent-2"},
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	for _, args := range []map[string]any{
+		{"recipient": "agent-2", "message": "routine"},
+		{"recipient": "agent-2", "message": "abort", "priority": "urgent"},
+	} {
+		result, err := sendHandler(ctx, makeToolRequest(ToolSend, args))
+		if err != nil {
+			t.Fatalf("sendHandler returned error: %v", err)
+		}
+		resultText(t, result)
+	}
+
+	result, err := receiveHandler(ctx, &mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("receiveHandler returned error: %v", err)
+	}
+
+	var response ReceiveResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Message != "abort" || response.Priority != "urgent" {
+		t.Errorf("Expected urgent message first, got %+v", response)
+	}
+}
+
+// Test send rejects unknown priorities
+func TestSendHandler_InvalidPriorityReturnsError(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockSender:    "agent-1",
+		MockWindows:   []string{"agent-1", "agent-2"},
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	result, err := sendHandler(context.Background(), makeToolRequest(ToolSend, map[string]any{
+		"recipient": "agent-2",
+		"message":   "hello",
+		"priority":  "critical",
+	}))
+	if err != nil {
+		t.Fatalf("sendHandler returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for invalid priority")
+	}
+}
+
+// Test scheduled sends are hidden from receive until due
+func TestSendHandler_DeliverInHidesMessageUntilDue(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockSender:    "agent-1",
+		MockReceiver:  "agent-2",
+		MockWindows:   []string{"agent-1", "agent-2"},
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	result, err := sendHandler(ctx, makeToolRequest(ToolSend, map[string]any{
+		"recipient":          "agent-2",
+		"message":            "check CI",
+		"deliver_in_seconds": 1200,
+	}))
+	if err != nil {
+		t.Fatalf("sendHandler returned error: %v", err)
+	}
+
+	var sent SendResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &sent); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if sent.DeliverAfter == "" {
+		t.Error("Expected deliver_after in send response")
+	}
+
+	result, err = receiveHandler(ctx, &mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("receiveHandler returned error: %v", err)
+	}
+	if !strings.Contains(resultText(t, result), "No unread messages") {
+		t.Errorf("Expected scheduled message to be hidden, got %s", resultText(t, result))
+	}
+}
+
+// Test ttl_seconds sets an expiry that receive reports
+func TestSendHandler_TTLReportedByReceive(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockSender:    "agent-1",
+		MockReceiver:  "agent-2",
+		MockWindows:   []string{"agent-1", "agent-2"},
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	result, err := sendHandler(ctx, makeToolRequest(ToolSend, map[string]any{
+		"recipient":   "agent-2",
+		"message":     "hold off on merging",
+		"ttl_seconds": 600,
+	}))
+	if err != nil {
+		t.Fatalf("sendHandler returned error: %v", err)
+	}
+	resultText(t, result)
+
+	result, err = receiveHandler(ctx, &mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("receiveHandler returned error: %v", err)
+	}
+
+	var response ReceiveResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.ExpiresAt == "" {
+		t.Errorf("Expected expires_at in receive response, got %+v", response)
+	}
+}
+
+// Test subject and headers round-trip through send and receive
+func TestSendHandler_SubjectAndHeadersInReceive(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockSender:    "agent-1",
+		MockReceiver:  "agent-2",
+		MockWindows:   []string{"agent-1", "agent-2"},
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	result, err := sendHandler(ctx, makeToolRequest(ToolSend, map[string]any{
+		"recipient": "agent-2",
+		"message":   "see the log",
+		"subject":   "CI failure",
+		"headers":   map[string]any{"task": "123"},
+	}))
+	if err != nil {
+		t.Fatalf("sendHandler returned error: %v", err)
+	}
+	resultText(t, result)
+
+	result, err = receiveHandler(ctx, &mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("receiveHandler returned error: %v", err)
+	}
+
+	var response ReceiveResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Subject != "CI failure" || response.Headers["task"] != "123" {
+		t.Errorf("Expected subject and headers in response, got %+v", response)
+	}
+}
+
+// Test inbox lists read and unread messages newest first without marking them read
+func TestInboxHandler_ListsMessages(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeTestMessages(t, tmpDir, "agent-2", `{"id":"inbox001","from":"agent-1","to":"agent-2","message":"old news\nmore","read_flag":true,"created_at":"2026-01-01T10:00:00Z"}
+{"id":"inbox002","from":"agent-3","to":"agent-2","message":"fresh","subject":"Build","priority":"high","read_flag":false,"created_at":"2026-01-01T11:00:00Z"}
+`)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	result, err := inboxHandler(ctx, makeToolRequest(ToolInbox, map[string]any{}))
+	if err != nil {
+		t.Fatalf("inboxHandler returned error: %v", err)
+	}
+	var response InboxResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(response.Messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %+v", response.Messages)
+	}
+	newest, oldest := response.Messages[0], response.Messages[1]
+	if newest.ID != "inbox002" || newest.State != "unread" || newest.Subject != "Build" || newest.Priority != "high" {
+		t.Errorf("Unexpected newest entry: %+v", newest)
+	}
+	if oldest.ID != "inbox001" || oldest.State != "read" || oldest.Preview != "old news" || oldest.DeliveredAt != "2026-01-01T10:00:00Z" {
+		t.Errorf("Unexpected oldest entry: %+v", oldest)
+	}
+
+	result, err = inboxHandler(ctx, makeToolRequest(ToolInbox, map[string]any{"unread": true, "from": "agent-3", "limit": 5}))
+	if err != nil {
+		t.Fatalf("inboxHandler returned error: %v", err)
+	}
+	response = InboxResponse{}
+	_ = json.Unmarshal([]byte(resultText(t, result)), &response)
+	if len(response.Messages) != 1 || response.Messages[0].ID != "inbox002" {
+		t.Errorf("Expected only inbox002, got %+v", response.Messages)
+	}
+
+	unread, _ := mail.FindUnread(tmpDir, "agent-2")
+	if len(unread) != 1 {
+		t.Error("inbox should not mark messages as read")
+	}
+}
+
+// Test read returns a read message and marks an unread one as read
+func TestReadHandler(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeTestMessages(t, tmpDir, "agent-2", `{"id":"read0001","from":"agent-1","to":"agent-2","message":"seen","read_flag":true}
+{"id":"read0002","from":"agent-1","to":"agent-2","message":"new","read_flag":false}
+`)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	for _, tc := range []struct{ id, state, message string }{
+		{"read0001", "read", "seen"},
+		{"read0002", "unread", "new"},
+	} {
+		result, err := readHandler(ctx, makeToolRequest(ToolRead, map[string]any{"id": tc.id}))
+		if err != nil {
+			t.Fatalf("readHandler returned error: %v", err)
+		}
+		var response ReadResponse
+		if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+			t.Fatalf("Failed to parse response JSON: %v", err)
+		}
+		if response.ID != tc.id || response.State != tc.state || response.Message != tc.message || response.To != "agent-2" {
+			t.Errorf("Unexpected read response: %+v", response)
+		}
+	}
+
+	if unread, _ := mail.FindUnread(tmpDir, "agent-2"); len(unread) != 1 || unread[0].ID != "read0002" {
+		t.Errorf("Expected read0002 to stay unread, got %+v", unread)
+	}
+
+	result, err := readHandler(ctx, makeToolRequest(ToolRead, map[string]any{"id": "missing1"}))
+	if err != nil {
+		t.Fatalf("readHandler returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for unknown message")
+	}
+}
+
+// Test peek returns the next message and leaves it unread
+func TestPeekHandler(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	result, err := peekHandler(ctx, makeToolRequest(ToolPeek, map[string]any{}))
+	if err != nil {
+		t.Fatalf("peekHandler returned error: %v", err)
+	}
+	if text := resultText(t, result); text != `{"status":"No unread messages"}` {
+		t.Errorf("Unexpected empty peek response: %s", text)
+	}
+
+	writeTestMessages(t, tmpDir, "agent-2", `{"id":"peek0001","from":"agent-1","to":"agent-2","message":"hello","read_flag":false}
+`)
+	for i := 0; i < 2; i++ {
+		result, err = peekHandler(ctx, makeToolRequest(ToolPeek, map[string]any{}))
+		if err != nil {
+			t.Fatalf("peekHandler returned error: %v", err)
+		}
+		var response ReceiveResponse
+		if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+			t.Fatalf("Failed to parse response JSON: %v", err)
+		}
+		if response.ID != "peek0001" {
+			t.Errorf("Peek %d: expected peek0001, got %+v", i, response)
+		}
+	}
+}
+
+// Test search finds messages in every mailbox
+func TestSearchHandler(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeTestMessages(t, tmpDir, "agent-2", `{"id":"srch0001","from":"agent-1","to":"agent-2","message":"we agreed on Postgres","read_flag":true,"created_at":"2026-01-01T10:00:00Z"}
+`)
+	writeTestMessages(t, tmpDir, "agent-3", `{"id":"srch0002","from":"agent-2","to":"agent-3","subject":"DB choice","message":"postgres it is","read_flag":false,"created_at":"2026-01-01T11:00:00Z"}
+`)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockReceiver:  "agent-2",
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	result, err := searchHandler(ctx, makeToolRequest(ToolSearch, map[string]any{"query": "postgres"}))
+	if err != nil {
+		t.Fatalf("searchHandler returned error: %v", err)
+	}
+	var response SearchResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(response.Matches) != 2 {
+		t.Fatalf("Expected 2 matches, got %+v", response.Matches)
+	}
+	if m := response.Matches[0]; m.ID != "srch0001" || m.To != "agent-2" || m.Match != "we agreed on Postgres" || m.CreatedAt != "2026-01-01T10:00:00Z" {
+		t.Errorf("Unexpected first match: %+v", m)
+	}
+	if m := response.Matches[1]; m.ID != "srch0002" || m.Subject != "DB choice" {
+		t.Errorf("Unexpected second match: %+v", m)
+	}
+
+	result, err = searchHandler(ctx, makeToolRequest(ToolSearch, map[string]any{"query": "^postgres", "regex": true}))
+	if err != nil {
+		t.Fatalf("searchHandler returned error: %v", err)
+	}
+	response = SearchResponse{}
+	_ = json.Unmarshal([]byte(resultText(t, result)), &response)
+	if len(response.Matches) != 1 || response.Matches[0].ID != "srch0002" {
+		t.Errorf("Expected only srch0002, got %+v", response.Matches)
+	}
+
+	result, err = searchHandler(ctx, makeToolRequest(ToolSearch, map[string]any{"query": "(", "regex": true}))
+	if err != nil {
+		t.Fatalf("searchHandler returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for an invalid regular expression")
+	}
+}
+
+// Test message-status follows a message sent with a receipt through receive
+func TestMessageStatusHandler_ReceiptAndEvents(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockSender:    "agent-1",
+		MockReceiver:  "agent-2",
+		MockWindows:   []string{"agent-1", "agent-2"},
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	result, err := sendHandler(ctx, makeToolRequest(ToolSend, map[string]any{"recipient": "agent-2", "message": "Please review", "receipt": true}))
+	if err != nil {
+		t.Fatalf("sendHandler returned error: %v", err)
+	}
+	var sent SendResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &sent); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+
+	if _, err := receiveHandler(ctx, makeToolRequest(ToolReceive, map[string]any{})); err != nil {
+		t.Fatalf("receiveHandler returned error: %v", err)
+	}
+
+	result, err = messageStatusHandler(ctx, makeToolRequest(ToolMessageStatus, map[string]any{"id": sent.MessageID}))
+	if err != nil {
+		t.Fatalf("messageStatusHandler returned error: %v", err)
+	}
+	var status MessageStatusResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &status); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if status.ID != sent.MessageID || status.From != "agent-1" || status.To != "agent-2" || status.State != "read" || !status.Receipt {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+	if len(status.Events) != 2 || status.Events[0].Event != "queued" || status.Events[1].Event != "read" {
+		t.Errorf("Expected queued and read events, got %+v", status.Events)
+	}
+
+	receipts, err := mail.ReadAll(tmpDir, "agent-1")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(receipts) != 1 || receipts[0].From != mail.SystemSender || receipts[0].InReplyTo != sent.MessageID {
+		t.Errorf("Expected a read receipt for the sender, got %+v", receipts)
+	}
+
+	result, err = messageStatusHandler(ctx, makeToolRequest(ToolMessageStatus, map[string]any{"id": "missing1"}))
+	if err != nil {
+		t.Fatalf("messageStatusHandler returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for an unknown message")
+	}
+}
+
+// Test a send retried with the same idempotency key returns the original message ID
+func TestSendHandler_IdempotencyKey(t *testing.T) {
+	tmpDir := setupTestMailbox(t)
+	defer os.RemoveAll(tmpDir)
+
+	SetHandlerOptions(&HandlerOptions{
+		SkipTmuxCheck: true,
+		MockSender:    "agent-sender",
+		MockWindows:   []string{"agent-sender", "agent-receiver"},
+		RepoRoot:      tmpDir,
+	})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	args := map[string]any{"recipient": "agent-receiver", "message": "Deploy finished", "idempotency_key": "deploy-42"}
+	var ids []string
+	for i := 0; i < 2; i++ {
+		result, err := sendHandler(ctx, makeToolRequest(ToolSend, args))
+		if err != nil {
+			t.Fatalf("sendHandler returned error: %v", err)
+		}
+		var response SendResponse
+		if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+			t.Fatalf("Failed to parse response JSON: %v", err)
+		}
+		ids = append(ids, response.MessageID)
+	}
+
+	if ids[0] == "" || ids[0] != ids[1] {
+		t.Errorf("Expected the retry to return the original ID, got %v", ids)
+	}
+	messages, err := mail.ReadAll(tmpDir, "agent-receiver")
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(messages) != 1 || messages[0].IdempotencyKey != "deploy-42" {
+		t.Errorf("Expected one stored message with the key, got %+v", messages)
+	}
+}
+
+// fakeSessions puts a fake tmux on PATH with two projects, each with its own session
+// and repository: lead (%1) in "web" and agent-1 (%5) in "api". Tools run in lead's pane.
+func fakeSessions(t *testing.T) (webRepo, apiRepo string) {
+	t.Helper()
+	webRepo, apiRepo = t.TempDir(), t.TempDir()
+	for _, repo := range []string{webRepo, apiRepo} {
+		if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+			t.Fatalf("Mkdir failed: %v", err)
+		}
+	}
+	lead := "100\\t%%1\\tweb\\t" + webRepo + "\\tlead\\n"
+	agent := "100\\t%%5\\tapi\\t" + apiRepo + "\\tagent-1\\n"
+	script := "#!/bin/sh\ncase \"$1 $3\" in\n" +
+		"'display-message %1') printf '" + lead + "' ;;\n" +
+		"'display-message %5') printf '" + agent + "' ;;\n" +
+		"list-panes*) printf '" + lead + agent + "' ;;\n" +
+		"esac\n"
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tmux"), []byte(script), 0700); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TMUX", "/tmp/tmux-fake/default,1,0")
+	t.Setenv("TMUX_PANE", "%1")
+	return webRepo, apiRepo
+}
+
+func TestSendHandler_SessionWindowInAnotherRepository(t *testing.T) {
+	webRepo, apiRepo := fakeSessions(t)
+	SetHandlerOptions(&HandlerOptions{SkipTmuxCheck: true, RepoRoot: webRepo})
+	defer SetHandlerOptions(nil)
+
+	ctx := context.Background()
+	args := map[string]any{"recipient": "api:agent-1", "message": "Schema changed", "receipt": true}
+	result, err := sendHandler(ctx, makeToolRequest(ToolSend, args))
+	if err != nil {
+		t.Fatalf("sendHandler returned error: %v", err)
+	}
+	var response SendResponse
+	if err := json.Unmarshal([]byte(resultText(t, result)), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Repository != apiRepo {
+		t.Errorf("Expected repository %s, got %+v", apiRepo, response)
+	}
+
+	messages, err := mail.ReadAll(apiRepo, "agent-1")
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("Expected the message in the api repository, got %+v, %v", messages, err)
+	}
+	if messages[0].From != "web:lead" || messages[0].OriginRepo != webRepo || messages[0].OriginMailbox != "lead" {
+		t.Errorf("Expected a message from web:lead recording its origin, got %+v", messages[0])
+	}
+
+	// The read receipt goes back to lead's repository
+	if err := mail.MarkAsRead(apiRepo, "agent-1", response.MessageID); err != nil {
+		t.Fatalf("MarkAsRead failed: %v", err)
+	}
+	if receipts, err := mail.ReadAll(webRepo, "lead"); err != nil || len(receipts) != 1 || receipts[0].InReplyTo != response.MessageID {
+		t.Errorf("Expected the receipt in lead's mailbox, got %+v, %v", receipts, err)
+	}
+
+	// A window ignored by its own repository is not a recipient
+	if err := os.WriteFile(filepath.Join(apiRepo, ".agentmailignore"), []byte("agent-1\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	result, err = sendHandler(ctx, makeToolRequest(ToolSend, args))
+	if err != nil {
+		t.Fatalf("sendHandler returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected an error result for an ignored window")
+	}
+	if messages, _ := mail.ReadAll(apiRepo, "agent-1"); len(messages) != 1 {
+		t.Errorf("Expected nothing more delivered, got %+v", messages)
+	}
+}
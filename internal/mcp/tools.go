@@ -5,68 +5,299 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"agentmail/internal/config"
+	"agentmail/internal/mail"
+
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// MaxMessageSize is the maximum allowed message size (64KB per FR-002).
-const MaxMessageSize = 65536
+// MaxMessageSize is the default maximum allowed message size (64KB per FR-002;
+// max_message_size in the config file).
+const MaxMessageSize = config.DefaultMaxMessageSize
+
+// DefaultWaitTimeoutSeconds is the wait-for-message timeout when none is given.
+const DefaultWaitTimeoutSeconds = 60
+
+// MaxWaitTimeoutSeconds is the longest a single wait-for-message call may block.
+const MaxWaitTimeoutSeconds = 3600
+
+// DefaultAskTimeoutSeconds is the ask timeout when none is given.
+const DefaultAskTimeoutSeconds = 300
+
+// MaxLeaseSeconds is the longest lease a receive may take on a message.
+const MaxLeaseSeconds = 86400
 
 // Tool names as constants for consistent reference.
 const (
 	ToolSend           = "send"
+	ToolAsk            = "ask"
 	ToolReceive        = "receive"
+	ToolWaitForMessage = "wait-for-message"
+	ToolAck            = "ack"
 	ToolStatus         = "status"
 	ToolListRecipients = "list-recipients"
+	ToolInbox          = "inbox"
+	ToolRead           = "read"
+	ToolPeek           = "peek"
+	ToolSearch         = "search"
+	ToolMessageStatus  = "message-status"
 )
 
 // SendArgs represents the input parameters for the send tool.
 type SendArgs struct {
-	// Recipient is the tmux window name of the recipient agent.
+	// Recipient is the tmux window name, session:window or pane ID of the recipient agent, "@all", or a named group.
 	Recipient string `json:"recipient"`
 	// Message is the message content to send (max 64KB).
 	Message string `json:"message"`
+	// ReplyTo is the optional ID of the message being replied to.
+	ReplyTo string `json:"reply_to,omitempty"`
+	// Subject is an optional one-line summary of the message.
+	Subject string `json:"subject,omitempty"`
+	// Headers are optional free-form key/value metadata, e.g. {"task": "123"}.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Priority is the optional message priority: low, normal, high or urgent.
+	Priority string `json:"priority,omitempty"`
+	// DeliverAt is the optional delivery time (RFC 3339, "2006-01-02 15:04" or "15:04").
+	DeliverAt string `json:"deliver_at,omitempty"`
+	// DeliverInSeconds is the optional delivery delay in seconds.
+	DeliverInSeconds int `json:"deliver_in_seconds,omitempty"`
+	// TTLSeconds is how long the message stays deliverable once delivered (0 = forever).
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+	// NotifyExpired requests a system message to the sender if the message expires unread.
+	NotifyExpired bool `json:"notify_expired,omitempty"`
+	// Receipt requests a system message to the sender when the message is first read.
+	Receipt bool `json:"receipt,omitempty"`
+	// IdempotencyKey makes retries safe: a repeat send with the same key returns the original message.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// AskArgs represents the input parameters for the ask tool.
+type AskArgs struct {
+	// Recipient is the tmux window name of the agent being asked.
+	Recipient string `json:"recipient"`
+	// Message is the question to send (max 64KB).
+	Message string `json:"message"`
+	// TimeoutSeconds is how long to wait for the reply (default 300, max 3600).
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
 }
 
 // ReceiveArgs represents the input parameters for the receive tool.
-// It has no parameters.
-type ReceiveArgs struct{}
+type ReceiveArgs struct {
+	// LeaseSeconds keeps the message in-flight until acked instead of marking it read (max 86400).
+	LeaseSeconds int `json:"lease_seconds,omitempty"`
+}
+
+// WaitForMessageArgs represents the input parameters for the wait-for-message tool.
+type WaitForMessageArgs struct {
+	// TimeoutSeconds is how long to wait for a message (default 60, max 3600).
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// LeaseSeconds keeps the message in-flight until acked instead of marking it read (max 86400).
+	LeaseSeconds int `json:"lease_seconds,omitempty"`
+}
+
+// AckArgs represents the input parameters for the ack tool.
+type AckArgs struct {
+	// ID is the ID of the leased message to acknowledge.
+	ID string `json:"id"`
+}
 
 // StatusArgs represents the input parameters for the status tool.
 type StatusArgs struct {
 	// Status is the availability status to set.
 	Status string `json:"status"`
+	// Notify is how the mailman notifies this agent (keys, display, bell, command, or default to clear).
+	Notify string `json:"notify,omitempty"`
+	// NotifyText is the notification text template for this agent.
+	NotifyText string `json:"notify_text,omitempty"`
 }
 
 // ListRecipientsArgs represents the input parameters for the list-recipients tool.
 // It has no parameters.
 type ListRecipientsArgs struct{}
 
+// InboxArgs represents the input parameters for the inbox tool.
+type InboxArgs struct {
+	// Unread only lists messages that are not read yet.
+	Unread bool `json:"unread,omitempty"`
+	// From only lists messages from this sender.
+	From string `json:"from,omitempty"`
+	// SinceSeconds only lists messages delivered within this many seconds.
+	SinceSeconds int `json:"since_seconds,omitempty"`
+	// Limit lists at most this many messages, newest first.
+	Limit int `json:"limit,omitempty"`
+}
+
+// ReadArgs represents the input parameters for the read tool.
+type ReadArgs struct {
+	// ID is the ID of the message to show.
+	ID string `json:"id"`
+}
+
+// PeekArgs represents the input parameters for the peek tool.
+// It has no parameters.
+type PeekArgs struct{}
+
+// SearchArgs represents the input parameters for the search tool.
+type SearchArgs struct {
+	// Query is the text to find in subjects, bodies and header values.
+	Query string `json:"query"`
+	// From only searches messages from this sender.
+	From string `json:"from,omitempty"`
+	// To only searches this recipient's mailbox.
+	To string `json:"to,omitempty"`
+	// SinceSeconds only searches messages sent within this many seconds.
+	SinceSeconds int `json:"since_seconds,omitempty"`
+	// Regex treats the query as a Go regular expression.
+	Regex bool `json:"regex,omitempty"`
+}
+
+// MessageStatusArgs represents the input parameters for the message-status tool.
+type MessageStatusArgs struct {
+	// ID is the ID of the message to look up.
+	ID string `json:"id"`
+}
+
 // sendToolSchema returns the JSON schema for the send tool input.
 // We define this manually to include maxLength constraint on message.
-func sendToolSchema() json.RawMessage {
+func sendToolSchema(maxSize int) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+		"type": "object",
+		"properties": {
+			"recipient": {
+				"type": "string",
+				"description": "The recipient agent: a tmux window name, session:window, a pane ID such as %%12, @all for every agent, or a @group from .agentmail/groups. An agent of another session working in another repository is reached by its session:window or pane ID, and gets the message in that repository's store"
+			},
+			"message": {
+				"type": "string",
+				"description": "The message content to send (max %s)",
+				"maxLength": %d
+			},
+			"reply_to": {
+				"type": "string",
+				"description": "Optional ID of the message being replied to; the reply joins its thread"
+			},
+			"subject": {
+				"type": "string",
+				"description": "Optional one-line subject so the recipient can triage without reading the body"
+			},
+			"headers": {
+				"type": "object",
+				"description": "Optional key/value metadata, e.g. {\"task\": \"123\"}; recipients can filter on it with receive --header",
+				"additionalProperties": {"type": "string"}
+			},
+			"priority": {
+				"type": "string",
+				"description": "Message priority; higher priorities are received first and urgent mail also notifies busy agents (default normal)",
+				"enum": ["low", "normal", "high", "urgent"]
+			},
+			"deliver_at": {
+				"type": "string",
+				"description": "Optional delivery time: RFC 3339, \"2006-01-02 15:04\" or \"15:04\" (next occurrence, local time). The recipient does not see the message until then"
+			},
+			"deliver_in_seconds": {
+				"type": "integer",
+				"description": "Optional delivery delay in seconds; cannot be combined with deliver_at",
+				"minimum": 1
+			},
+			"ttl_seconds": {
+				"type": "integer",
+				"description": "Optional time to live in seconds, counted from delivery. If still unread after that, the message is never received and is moved to the dead-letter mailbox",
+				"minimum": 1
+			},
+			"notify_expired": {
+				"type": "boolean",
+				"description": "With ttl_seconds, send the sender a system message (from \"agentmail\") if the message expires unread (default false)"
+			},
+			"receipt": {
+				"type": "boolean",
+				"description": "Send the sender a system message (from \"agentmail\") when the recipient first reads the message (default false)"
+			},
+			"idempotency_key": {
+				"type": "string",
+				"description": "Optional key that makes retries safe: sending again with the same key within idempotency_window (default 1 hour) returns the original message_id (or broadcast_id) instead of delivering a duplicate",
+				"maxLength": %d
+			}
+		},
+		"required": ["recipient", "message"],
+		"additionalProperties": false
+	}`, formatSize(maxSize), maxSize, mail.MaxIdempotencyKeyLength))
+}
+
+// askToolSchema returns the JSON schema for the ask tool input.
+func askToolSchema(maxSize int) json.RawMessage {
 	return json.RawMessage(fmt.Sprintf(`{
 		"type": "object",
 		"properties": {
 			"recipient": {
 				"type": "string",
-				"description": "The tmux window name of the recipient agent"
+				"description": "The tmux window name of the agent being asked"
 			},
 			"message": {
 				"type": "string",
-				"description": "The message content to send (max 64KB)",
+				"description": "The question to send (max %s)",
 				"maxLength": %d
+			},
+			"timeout_seconds": {
+				"type": "integer",
+				"description": "How long to wait for the reply, in seconds (default %d)",
+				"minimum": 1,
+				"maximum": %d
 			}
 		},
 		"required": ["recipient", "message"],
 		"additionalProperties": false
-	}`, MaxMessageSize))
+	}`, formatSize(maxSize), maxSize, DefaultAskTimeoutSeconds, MaxWaitTimeoutSeconds))
 }
 
 // receiveToolSchema returns the JSON schema for the receive tool input.
 func receiveToolSchema() json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+		"type": "object",
+		"properties": {
+			"lease_seconds": {
+				"type": "integer",
+				"description": "Keep the message in-flight for this many seconds instead of marking it read; ack it when done or it is delivered again",
+				"minimum": 1,
+				"maximum": %d
+			}
+		},
+		"additionalProperties": false
+	}`, MaxLeaseSeconds))
+}
+
+// waitForMessageToolSchema returns the JSON schema for the wait-for-message tool input.
+func waitForMessageToolSchema() json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+		"type": "object",
+		"properties": {
+			"timeout_seconds": {
+				"type": "integer",
+				"description": "How long to wait for a message, in seconds (default %d)",
+				"minimum": 1,
+				"maximum": %d
+			},
+			"lease_seconds": {
+				"type": "integer",
+				"description": "Keep the message in-flight for this many seconds instead of marking it read; ack it when done or it is delivered again",
+				"minimum": 1,
+				"maximum": %d
+			}
+		},
+		"additionalProperties": false
+	}`, DefaultWaitTimeoutSeconds, MaxWaitTimeoutSeconds, MaxLeaseSeconds))
+}
+
+// ackToolSchema returns the JSON schema for the ack tool input.
+func ackToolSchema() json.RawMessage {
 	return json.RawMessage(`{
 		"type": "object",
-		"properties": {},
+		"properties": {
+			"id": {
+				"type": "string",
+				"description": "The ID of the leased message to acknowledge"
+			}
+		},
+		"required": ["id"],
 		"additionalProperties": false
 	}`)
 }
@@ -81,6 +312,15 @@ func statusToolSchema() json.RawMessage {
 				"type": "string",
 				"description": "The availability status to set",
 				"enum": ["ready", "work", "offline"]
+			},
+			"notify": {
+				"type": "string",
+				"description": "Optional: how the mailman notifies you from now on: keys (type the text and press Enter), display (tmux status line), bell, command (the repository's notify_command), or default to clear your overrides",
+				"enum": ["keys", "display", "bell", "command", "default"]
+			},
+			"notify_text": {
+				"type": "string",
+				"description": "Optional notification text, e.g. \"/mail {count} from {sender}\"; {sender}, {count}, {subject} and {recipient} are filled in"
 			}
 		},
 		"required": ["status"],
@@ -97,19 +337,127 @@ func listRecipientsToolSchema() json.RawMessage {
 	}`)
 }
 
+// inboxToolSchema returns the JSON schema for the inbox tool input.
+func inboxToolSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"unread": {
+				"type": "boolean",
+				"description": "Only list messages that are not read yet (default false: read and unread)"
+			},
+			"from": {
+				"type": "string",
+				"description": "Only list messages from this sender"
+			},
+			"since_seconds": {
+				"type": "integer",
+				"description": "Only list messages delivered within this many seconds",
+				"minimum": 1
+			},
+			"limit": {
+				"type": "integer",
+				"description": "List at most this many messages, newest first",
+				"minimum": 1
+			}
+		},
+		"additionalProperties": false
+	}`)
+}
+
+// readToolSchema returns the JSON schema for the read tool input.
+func readToolSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"id": {
+				"type": "string",
+				"description": "The ID of the message to show"
+			}
+		},
+		"required": ["id"],
+		"additionalProperties": false
+	}`)
+}
+
+// peekToolSchema returns the JSON schema for the peek tool input.
+func peekToolSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {},
+		"additionalProperties": false
+	}`)
+}
+
+// searchToolSchema returns the JSON schema for the search tool input.
+func searchToolSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"query": {
+				"type": "string",
+				"description": "Text to find in the subject, body and header values of every message in every mailbox (case-insensitive substring)",
+				"minLength": 1
+			},
+			"from": {
+				"type": "string",
+				"description": "Only search messages from this sender"
+			},
+			"to": {
+				"type": "string",
+				"description": "Only search this recipient's mailbox"
+			},
+			"since_seconds": {
+				"type": "integer",
+				"description": "Only search messages sent within this many seconds",
+				"minimum": 1
+			},
+			"regex": {
+				"type": "boolean",
+				"description": "Treat the query as a Go regular expression; case-sensitive unless it starts with (?i) (default false)"
+			}
+		},
+		"required": ["query"],
+		"additionalProperties": false
+	}`)
+}
+
+// messageStatusToolSchema returns the JSON schema for the message-status tool input.
+func messageStatusToolSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"id": {
+				"type": "string",
+				"description": "The ID of the message, as returned by send"
+			}
+		},
+		"required": ["id"],
+		"additionalProperties": false
+	}`)
+}
+
 // RegisterTools registers all AgentMail tools with the MCP server.
 // Each tool is registered with its JSON schema and corresponding handler
 // that delegates to the implementation in handlers.go.
 func RegisterTools(s *Server) {
 	mcpServer := s.MCPServer()
+	maxSize := messageSizeLimit(getHandlerOptions())
 
 	// Register send tool with explicit schema
 	mcpServer.AddTool(&mcp.Tool{
 		Name:        ToolSend,
 		Description: "Send a message to another agent in a tmux window",
-		InputSchema: sendToolSchema(),
+		InputSchema: sendToolSchema(maxSize),
 	}, sendHandler)
 
+	// Register ask tool with explicit schema
+	mcpServer.AddTool(&mcp.Tool{
+		Name:        ToolAsk,
+		Description: "Send a message to another agent and wait for its reply (synchronous call)",
+		InputSchema: askToolSchema(maxSize),
+	}, askHandler)
+
 	// Register receive tool with explicit schema
 	mcpServer.AddTool(&mcp.Tool{
 		Name:        ToolReceive,
@@ -117,6 +465,20 @@ func RegisterTools(s *Server) {
 		InputSchema: receiveToolSchema(),
 	}, receiveHandler)
 
+	// Register wait-for-message tool with explicit schema
+	mcpServer.AddTool(&mcp.Tool{
+		Name:        ToolWaitForMessage,
+		Description: "Block until a message arrives in your mailbox (or the timeout elapses), then receive it",
+		InputSchema: waitForMessageToolSchema(),
+	}, waitForMessageHandler)
+
+	// Register ack tool with explicit schema
+	mcpServer.AddTool(&mcp.Tool{
+		Name:        ToolAck,
+		Description: "Acknowledge a message received with a lease so it is not delivered again",
+		InputSchema: ackToolSchema(),
+	}, ackHandler)
+
 	// Register status tool with explicit schema (includes enum)
 	mcpServer.AddTool(&mcp.Tool{
 		Name:        ToolStatus,
@@ -130,6 +492,41 @@ func RegisterTools(s *Server) {
 		Description: "List all available agents that can receive messages",
 		InputSchema: listRecipientsToolSchema(),
 	}, listRecipientsHandler)
+
+	// Register inbox tool with explicit schema
+	mcpServer.AddTool(&mcp.Tool{
+		Name:        ToolInbox,
+		Description: "List the messages in your mailbox, newest first, without marking them read",
+		InputSchema: inboxToolSchema(),
+	}, inboxHandler)
+
+	// Register read tool with explicit schema
+	mcpServer.AddTool(&mcp.Tool{
+		Name:        ToolRead,
+		Description: "Show any message in your mailbox by ID without marking it read",
+		InputSchema: readToolSchema(),
+	}, readHandler)
+
+	// Register peek tool with explicit schema
+	mcpServer.AddTool(&mcp.Tool{
+		Name:        ToolPeek,
+		Description: "Show the message receive would return next without marking it read",
+		InputSchema: peekToolSchema(),
+	}, peekHandler)
+
+	// Register search tool with explicit schema
+	mcpServer.AddTool(&mcp.Tool{
+		Name:        ToolSearch,
+		Description: "Search every agent's mailbox, read or not, e.g. to look up earlier decisions",
+		InputSchema: searchToolSchema(),
+	}, searchHandler)
+
+	// Register message-status tool with explicit schema
+	mcpServer.AddTool(&mcp.Tool{
+		Name:        ToolMessageStatus,
+		Description: "Show whether a message was queued, notified, read or acked, e.g. to check on mail you sent",
+		InputSchema: messageStatusToolSchema(),
+	}, messageStatusHandler)
 }
 
 // sendHandler handles the send tool invocation.
@@ -138,12 +535,30 @@ func sendHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolRe
 	return handleSend(ctx, req)
 }
 
+// askHandler handles the ask tool invocation.
+// Delegates to handleAsk in handlers.go for actual implementation.
+func askHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handleAsk(ctx, req)
+}
+
 // receiveHandler handles the receive tool invocation.
 // Delegates to handleReceive in handlers.go for actual implementation.
 func receiveHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return handleReceive(ctx, req)
 }
 
+// waitForMessageHandler handles the wait-for-message tool invocation.
+// Delegates to handleWaitForMessage in handlers.go for actual implementation.
+func waitForMessageHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handleWaitForMessage(ctx, req)
+}
+
+// ackHandler handles the ack tool invocation.
+// Delegates to handleAck in handlers.go for actual implementation.
+func ackHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handleAck(ctx, req)
+}
+
 // statusHandler handles the status tool invocation.
 // Delegates to handleStatus in handlers.go for actual implementation.
 func statusHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -155,3 +570,33 @@ func statusHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallTool
 func listRecipientsHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return handleListRecipients(ctx, req)
 }
+
+// inboxHandler handles the inbox tool invocation.
+// Delegates to handleInbox in handlers.go for actual implementation.
+func inboxHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handleInbox(ctx, req)
+}
+
+// readHandler handles the read tool invocation.
+// Delegates to handleRead in handlers.go for actual implementation.
+func readHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handleRead(ctx, req)
+}
+
+// peekHandler handles the peek tool invocation.
+// Delegates to handlePeek in handlers.go for actual implementation.
+func peekHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handlePeek(ctx, req)
+}
+
+// searchHandler handles the search tool invocation.
+// Delegates to handleSearch in handlers.go for actual implementation.
+func searchHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handleSearch(ctx, req)
+}
+
+// messageStatusHandler handles the message-status tool invocation.
+// Delegates to handleMessageStatus in handlers.go for actual implementation.
+func messageStatusHandler(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handleMessageStatus(ctx, req)
+}
@@ -50,8 +50,8 @@ func setupTestServer(t *testing.T) (*Server, *mcp.ClientSession, func()) {
 	return server, clientSession, cleanup
 }
 
-func TestRegisterTools_FourToolsExposed(t *testing.T) {
-	// T010: Test that all 4 tools are registered
+func TestRegisterTools_AllToolsExposed(t *testing.T) {
+	// T010: Test that all tools are registered
 	_, clientSession, cleanup := setupTestServer(t)
 	defer cleanup()
 
@@ -61,16 +61,24 @@ func TestRegisterTools_FourToolsExposed(t *testing.T) {
 		t.Fatalf("ListTools failed: %v", err)
 	}
 
-	if len(result.Tools) != 4 {
-		t.Errorf("expected 4 tools, got %d", len(result.Tools))
-	}
-
 	// Verify all expected tools are present
 	expectedTools := map[string]bool{
 		ToolSend:           false,
+		ToolAsk:            false,
+		ToolAck:            false,
 		ToolReceive:        false,
+		ToolWaitForMessage: false,
 		ToolStatus:         false,
 		ToolListRecipients: false,
+		ToolInbox:          false,
+		ToolRead:           false,
+		ToolPeek:           false,
+		ToolSearch:         false,
+		ToolMessageStatus:  false,
+	}
+
+	if len(result.Tools) != len(expectedTools) {
+		t.Errorf("expected %d tools, got %d", len(expectedTools), len(result.Tools))
 	}
 
 	for _, tool := range result.Tools {
@@ -421,6 +429,15 @@ func TestToolConstants(t *testing.T) {
 	if ToolReceive != "receive" {
 		t.Errorf("ToolReceive constant mismatch: got %q, want 'receive'", ToolReceive)
 	}
+	if ToolAck != "ack" {
+		t.Errorf("ToolAck constant mismatch: got %q, want 'ack'", ToolAck)
+	}
+	if ToolAsk != "ask" {
+		t.Errorf("ToolAsk constant mismatch: got %q, want 'ask'", ToolAsk)
+	}
+	if ToolWaitForMessage != "wait-for-message" {
+		t.Errorf("ToolWaitForMessage constant mismatch: got %q, want 'wait-for-message'", ToolWaitForMessage)
+	}
 	if ToolStatus != "status" {
 		t.Errorf("ToolStatus constant mismatch: got %q, want 'status'", ToolStatus)
 	}
@@ -438,7 +455,7 @@ func TestMaxMessageSize(t *testing.T) {
 
 func TestSendToolSchema_MaxLength(t *testing.T) {
 	// Verify the send tool schema includes maxLength constraint for message
-	schema := sendToolSchema()
+	schema := sendToolSchema(MaxMessageSize)
 
 	var schemaMap map[string]any
 	if err := json.Unmarshal(schema, &schemaMap); err != nil {
@@ -488,9 +505,17 @@ func TestMCPTools_NoCleanupTool(t *testing.T) {
 	// Also verify the tool constants don't include cleanup
 	allowedTools := map[string]bool{
 		ToolSend:           true,
+		ToolAsk:            true,
+		ToolAck:            true,
 		ToolReceive:        true,
+		ToolWaitForMessage: true,
 		ToolStatus:         true,
 		ToolListRecipients: true,
+		ToolInbox:          true,
+		ToolRead:           true,
+		ToolPeek:           true,
+		ToolSearch:         true,
+		ToolMessageStatus:  true,
 	}
 
 	for _, tool := range result.Tools {
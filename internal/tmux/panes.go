@@ -0,0 +1,93 @@
+package tmux
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// Pane identifies a tmux pane and where it is now.
+// The pane ID stays the same when the window or session is renamed,
+// so it identifies an agent more reliably than the window name.
+type Pane struct {
+	ID      string // Pane ID, e.g. "%12"
+	Session string // Name of the session holding the pane
+	Window  string // Name of the window holding the pane
+	Server  string // PID of the tmux server; pane IDs start over when the server restarts
+	Path    string // Working directory of the pane's foreground process
+}
+
+// Address returns the session-qualified window of the pane, e.g. "project:agent-1".
+func (p Pane) Address() string {
+	return p.Session + ":" + p.Window
+}
+
+// paneFormat prints the fields of a Pane, window name last because it may contain anything.
+const paneFormat = "#{pid}\t#{pane_id}\t#{session_name}\t#{pane_current_path}\t#{window_name}"
+
+// parsePane parses a line printed with paneFormat.
+func parsePane(line string) (Pane, bool) {
+	fields := strings.SplitN(line, "\t", 5)
+	if len(fields) != 5 || !IsPaneID(fields[1]) {
+		return Pane{}, false
+	}
+	return Pane{ID: fields[1], Session: fields[2], Window: fields[4], Server: fields[0], Path: fields[3]}, true
+}
+
+// IsPaneID reports whether s is a tmux pane ID such as "%12".
+func IsPaneID(s string) bool {
+	return validPaneIDPattern.MatchString(s)
+}
+
+// SplitAddress splits a "session:window" address. tmux doesn't allow ":" in
+// session names, so the session ends at the first colon.
+// ok is false for a bare window name or pane ID.
+func SplitAddress(address string) (session, window string, ok bool) {
+	session, window, ok = strings.Cut(address, ":")
+	if !ok || session == "" || window == "" {
+		return "", "", false
+	}
+	return session, window, true
+}
+
+// GetCurrentPane returns the pane this process runs in.
+// It executes: tmux display-message -t $TMUX_PANE -p <format>
+func GetCurrentPane() (Pane, error) {
+	paneID, err := GetCurrentPaneID()
+	if err != nil {
+		return Pane{}, err
+	}
+
+	cmd := exec.Command("tmux", "display-message", "-t", paneID, "-p", paneFormat) // #nosec G204 - paneID validated by GetCurrentPaneID
+	output, err := cmd.Output()
+	if err != nil {
+		return Pane{}, err
+	}
+
+	pane, ok := parsePane(strings.TrimRight(string(output), "\n"))
+	if !ok {
+		return Pane{}, ErrInvalidPaneID
+	}
+	return pane, nil
+}
+
+// ListPanes returns every pane of the tmux server, across all sessions.
+// It executes: tmux list-panes -a -F <format>
+func ListPanes() ([]Pane, error) {
+	if !InTmux() {
+		return nil, ErrNotInTmux
+	}
+
+	cmd := exec.Command("tmux", "list-panes", "-a", "-F", paneFormat)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var panes []Pane
+	for _, line := range strings.Split(string(output), "\n") {
+		if pane, ok := parsePane(line); ok {
+			panes = append(panes, pane)
+		}
+	}
+	return panes, nil
+}
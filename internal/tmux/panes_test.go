@@ -0,0 +1,79 @@
+package tmux
+
+import (
+	"os"
+	"testing"
+)
+
+func TestListPanes_FakeTmux(t *testing.T) {
+	log := fakeTmux(t, `printf '4242\t%%1\tweb\t/src/web\tagent-1\n4242\t%%7\tapi\t/src/api\tagent-1\n4242\t%%8\tapi\t/src/api\tlead: main\nnot a pane\n'`)
+
+	panes, err := ListPanes()
+	if err != nil {
+		t.Fatalf("ListPanes() failed: %v", err)
+	}
+	want := []Pane{
+		{ID: "%1", Session: "web", Window: "agent-1", Server: "4242", Path: "/src/web"},
+		{ID: "%7", Session: "api", Window: "agent-1", Server: "4242", Path: "/src/api"},
+		{ID: "%8", Session: "api", Window: "lead: main", Server: "4242", Path: "/src/api"},
+	}
+	if len(panes) != len(want) {
+		t.Fatalf("Expected %d panes, got %+v", len(want), panes)
+	}
+	for i := range want {
+		if panes[i] != want[i] {
+			t.Errorf("Pane %d: expected %+v, got %+v", i, want[i], panes[i])
+		}
+	}
+
+	calls, _ := os.ReadFile(log)
+	if string(calls) != "list-panes -a -F "+paneFormat+"\n" {
+		t.Errorf("Unexpected tmux call: %q", calls)
+	}
+}
+
+func TestGetCurrentPane_FakeTmux(t *testing.T) {
+	fakeTmux(t, `printf '4242\t%%3\tweb\t/src/web\treviewer\n'`)
+	t.Setenv("TMUX_PANE", "%3")
+
+	pane, err := GetCurrentPane()
+	if err != nil {
+		t.Fatalf("GetCurrentPane() failed: %v", err)
+	}
+	if pane != (Pane{ID: "%3", Session: "web", Window: "reviewer", Server: "4242", Path: "/src/web"}) {
+		t.Errorf("Unexpected pane: %+v", pane)
+	}
+	if pane.Address() != "web:reviewer" {
+		t.Errorf("Expected address web:reviewer, got %q", pane.Address())
+	}
+}
+
+func TestListPanes_NotInTmux(t *testing.T) {
+	t.Setenv("TMUX", "")
+	if _, err := ListPanes(); err != ErrNotInTmux {
+		t.Errorf("ListPanes() should return ErrNotInTmux, got: %v", err)
+	}
+	if _, err := GetCurrentPane(); err != ErrNotInTmux {
+		t.Errorf("GetCurrentPane() should return ErrNotInTmux, got: %v", err)
+	}
+}
+
+func TestSplitAddress(t *testing.T) {
+	tests := []struct {
+		address, session, window string
+		ok                       bool
+	}{
+		{"web:agent-1", "web", "agent-1", true},
+		{"web:lead: main", "web", "lead: main", true},
+		{"agent-1", "", "", false},
+		{"%12", "", "", false},
+		{":agent-1", "", "", false},
+		{"web:", "", "", false},
+	}
+	for _, tt := range tests {
+		session, window, ok := SplitAddress(tt.address)
+		if session != tt.session || window != tt.window || ok != tt.ok {
+			t.Errorf("SplitAddress(%q) = %q, %q, %v; want %q, %q, %v", tt.address, session, window, ok, tt.session, tt.window, tt.ok)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package tmux
 
 import (
+	"os"
 	"os/exec"
+	"strings"
 )
 
 // SendKeys sends text to the specified tmux window.
@@ -25,3 +27,44 @@ func SendEnter(window string) error {
 	cmd := exec.Command("tmux", "send-keys", "-t", window, "Enter")
 	return cmd.Run()
 }
+
+// DisplayMessage shows text in the status line of the client viewing the window,
+// without touching what is typed in the pane.
+// It executes: tmux display-message -t <window> "<text>"
+// "#" is doubled so that tmux doesn't expand the text as a format.
+func DisplayMessage(window, text string) error {
+	if !InTmux() {
+		return ErrNotInTmux
+	}
+
+	cmd := exec.Command("tmux", "display-message", "-t", window, strings.ReplaceAll(text, "#", "##"))
+	return cmd.Run()
+}
+
+// Bell rings the terminal bell in the specified window by writing BEL to its
+// pane's terminal, which sets the window's bell flag in the status line.
+// It executes: tmux display-message -t <window> -p '#{pane_tty}'
+func Bell(window string) error {
+	if !InTmux() {
+		return ErrNotInTmux
+	}
+
+	output, err := exec.Command("tmux", "display-message", "-t", window, "-p", "#{pane_tty}").Output()
+	if err != nil {
+		return err
+	}
+	tty := strings.TrimSpace(string(output))
+	if !strings.HasPrefix(tty, "/dev/") {
+		return &os.PathError{Op: "bell", Path: tty, Err: os.ErrInvalid}
+	}
+
+	file, err := os.OpenFile(tty, os.O_WRONLY, 0) // #nosec G304 - path is the pane's terminal reported by tmux
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString("\a"); err != nil {
+		_ = file.Close() // G104: the write error is more useful
+		return err
+	}
+	return file.Close()
+}
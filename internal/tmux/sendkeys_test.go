@@ -31,3 +31,19 @@ func TestSendEnter_NotInTmux(t *testing.T) {
 		t.Errorf("SendEnter() should return ErrNotInTmux, got: %v", err)
 	}
 }
+
+func TestDisplayMessage_NotInTmux(t *testing.T) {
+	t.Setenv("TMUX", "")
+
+	if err := DisplayMessage("agent-1", "New mail"); err != ErrNotInTmux {
+		t.Errorf("DisplayMessage() should return ErrNotInTmux, got: %v", err)
+	}
+}
+
+func TestBell_NotInTmux(t *testing.T) {
+	t.Setenv("TMUX", "")
+
+	if err := Bell("agent-1"); err != ErrNotInTmux {
+		t.Errorf("Bell() should return ErrNotInTmux, got: %v", err)
+	}
+}
@@ -108,3 +108,20 @@ func WindowExists(name string) (bool, error) {
 
 	return false, nil
 }
+
+// CapturePane returns the visible content of the active pane of a window.
+// Trailing blank lines are dropped, so rows below the cursor don't affect comparisons.
+// It executes: tmux capture-pane -p -t <window>
+func CapturePane(window string) (string, error) {
+	if !InTmux() {
+		return "", ErrNotInTmux
+	}
+
+	cmd := exec.Command("tmux", "capture-pane", "-p", "-t", window)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(output), " \t\n"), nil
+}
@@ -1,6 +1,8 @@
 package tmux
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -111,3 +113,47 @@ func TestGetCurrentWindow_NoPaneID(t *testing.T) {
 		t.Errorf("GetCurrentWindow() should return ErrNoPaneID when TMUX_PANE is empty, got: %v", err)
 	}
 }
+
+// fakeTmux puts a tmux script running body first in PATH and pretends to run inside tmux.
+// The script's arguments are appended to the returned log file, one call per line.
+func fakeTmux(t *testing.T, body string) string {
+	t.Helper()
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls.log")
+	script := "#!/bin/sh\necho \"$@\" >> \"" + log + "\"\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "tmux"), []byte(script), 0700); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TMUX", "/tmp/tmux-fake/default,1,0")
+	return log
+}
+
+func TestCapturePane_FakeTmux(t *testing.T) {
+	log := fakeTmux(t, `printf 'agent output\n> \n\n\n'`)
+
+	content, err := CapturePane("agent-1")
+	if err != nil {
+		t.Fatalf("CapturePane() failed: %v", err)
+	}
+	if content != "agent output\n>" {
+		t.Errorf("Expected trailing blank lines trimmed, got %q", content)
+	}
+
+	calls, _ := os.ReadFile(log)
+	if string(calls) != "capture-pane -p -t agent-1\n" {
+		t.Errorf("Unexpected tmux call: %q", calls)
+	}
+}
+
+func TestCapturePane_Errors(t *testing.T) {
+	t.Setenv("TMUX", "")
+	if _, err := CapturePane("agent-1"); err != ErrNotInTmux {
+		t.Errorf("CapturePane() should return ErrNotInTmux, got: %v", err)
+	}
+
+	fakeTmux(t, `echo "can't find window: agent-9" >&2; exit 1`)
+	if _, err := CapturePane("agent-9"); err == nil {
+		t.Error("CapturePane() should fail when tmux fails")
+	}
+}